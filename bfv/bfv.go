@@ -23,6 +23,9 @@ type BfvContext struct {
 	deltaMont []uint64
 	delta     []uint64
 
+	// floor(Q_l/T) mod each Qi of the level l of the moduli chain
+	deltaLevels [][]uint64
+
 	// Ternary and Gaussian samplers
 	sigma           float64
 	gaussianSampler *ring.KYSampler
@@ -37,6 +40,9 @@ type BfvContext struct {
 	contextP  *ring.Context
 	contextQP *ring.Context
 
+	// Contexts of the levels of the moduli chain, the level l being the modulus q_0 * ... * q_l
+	contextQLevels []*ring.Context
+
 	// Galois elements used to permute the batched plaintext in the encrypted domain
 	gen    uint64
 	genInv uint64
@@ -162,6 +168,30 @@ func (bfvContext *BfvContext) SetParameters(params *Parameters) (err error) {
 	bfvContext.contextP = contextP
 	bfvContext.contextQP = contextQP
 
+	bfvContext.contextQLevels = make([]*ring.Context, len(ModuliQ))
+	bfvContext.contextQLevels[len(ModuliQ)-1] = contextQ
+	for level := 0; level < len(ModuliQ)-1; level++ {
+
+		bfvContext.contextQLevels[level] = ring.NewContext()
+
+		if err := bfvContext.contextQLevels[level].SetParameters(N, ModuliQ[:level+1]); err != nil {
+			return err
+		}
+
+		if err := bfvContext.contextQLevels[level].GenNTTParams(); err != nil {
+			return err
+		}
+	}
+
+	bfvContext.deltaLevels = make([][]uint64, len(ModuliQ))
+	for level, context := range bfvContext.contextQLevels {
+		deltaLevel := ring.NewUint(1).Div(context.ModulusBigint, ring.NewUint(t))
+		bfvContext.deltaLevels[level] = make([]uint64, level+1)
+		for i, Qi := range context.Modulus {
+			bfvContext.deltaLevels[level][i] = tmpBig.Mod(deltaLevel, ring.NewUint(Qi)).Uint64()
+		}
+	}
+
 	bfvContext.gen = 5
 	bfvContext.genInv = ring.ModExp(bfvContext.gen, (N<<1)-1, N<<1)
 
@@ -184,6 +214,26 @@ func (bfvContext *BfvContext) SetParameters(params *Parameters) (err error) {
 	return nil
 }
 
+// ShallowCopy returns a new BfvContext with the same parameters as the target bfvcontext, sharing its polynomial contexts and their NTT
+// tables, which are read-only once the parameters are set, but with its own ternary and Gaussian samplers. The returned bfvcontext
+// is safe to use concurrently with the target bfvcontext and its other copies, e.g. one copy per goroutine, as long as the parameters
+// of none of them are set again (see SetParameters). The scratch memory of the homomorphic operations is held by the objects created
+// from a bfvcontext (Evaluator, Encryptor, Decryptor, KeyGenerator, encoders) rather than by the bfvcontext : each goroutine must
+// create its own from its copy. Copying is much cheaper than creating a new BfvContext with the same parameters, since the NTT tables
+// are not recomputed.
+func (bfvContext *BfvContext) ShallowCopy() *BfvContext {
+
+	bfvContextCopy := new(BfvContext)
+	*bfvContextCopy = *bfvContext
+
+	if bfvContext.contextQ != nil {
+		bfvContextCopy.gaussianSampler = bfvContext.contextQ.NewKYSampler(bfvContext.sigma, int(6*bfvContext.sigma))
+		bfvContextCopy.ternarySampler = bfvContext.contextQ.NewTernarySampler()
+	}
+
+	return bfvContextCopy
+}
+
 // N returns N which is the degree of the ring, of the target bfvcontext.
 func (bfvContext *BfvContext) N() uint64 {
 	return bfvContext.n
@@ -83,6 +83,33 @@ func Benchmark_BFVScheme(b *testing.B) {
 			_ = ptp
 		})
 
+		// Decryption of a batch of 100 ciphertexts, with DecryptNew in a loop, DecryptManyNew and DecryptMany (see -benchmem)
+		batch := make([]*Ciphertext, 100)
+		for i := range batch {
+			batch[i] = ctd1
+		}
+
+		b.Run(fmt.Sprintf("params=%d/batch=%d/DecryptNewLoop", params.N, len(batch)), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				for _, ct := range batch {
+					decryptor.DecryptNew(ct)
+				}
+			}
+		})
+
+		b.Run(fmt.Sprintf("params=%d/batch=%d/DecryptManyNew", params.N, len(batch)), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				decryptor.DecryptManyNew(batch)
+			}
+		})
+
+		batchPlaintexts := decryptor.DecryptManyNew(batch)
+		b.Run(fmt.Sprintf("params=%d/batch=%d/DecryptMany", params.N, len(batch)), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				decryptor.DecryptMany(batch, batchPlaintexts)
+			}
+		})
+
 		evaluator := bfvContext.NewEvaluator()
 
 		ct1, err := encryptorSk.EncryptNew(pt)
@@ -121,6 +148,69 @@ func Benchmark_BFVScheme(b *testing.B) {
 			}
 		})
 
+		// Multiplication at each level of the moduli chain (see DropLevel)
+		for level := len(bfvContext.contextQ.Modulus) - 1; level >= 0; level-- {
+
+			ct1Level := bfvContext.NewCiphertextAtLevel(1, level)
+			ct2Level := bfvContext.NewCiphertextAtLevel(1, level)
+			ctd2Level := bfvContext.NewCiphertextAtLevel(2, level)
+
+			levels := ct1.Level() - level
+			if err := evaluator.DropLevel(ct1, levels, ct1Level); err != nil {
+				b.Error(err)
+			}
+			if err := evaluator.DropLevel(ct2, levels, ct2Level); err != nil {
+				b.Error(err)
+			}
+
+			b.Run(fmt.Sprintf("params=%d/level=%d/MultiplyAtLevel", params.N, level), func(b *testing.B) {
+				for i := 0; i < b.N; i++ {
+					_ = evaluator.Mul(ct1Level, ct2Level, ctd2Level)
+				}
+			})
+		}
+
+		// Multiplication by a scalar, against the multiplications by a constant plaintext (encoded then multiplied, which
+		// takes the MulScalar path) and by an arbitrary plaintext (tensoring and rescaling)
+		batchencoder, err := bfvContext.NewBatchEncoder()
+		if err != nil {
+			b.Error(err)
+		}
+
+		scalar := ptcoeffs[0]
+		constant := make([]uint64, bfvContext.N())
+		for i := range constant {
+			constant[i] = scalar
+		}
+		ptConstant := bfvContext.NewPlaintext()
+
+		b.Run(fmt.Sprintf("params=%d/MulScalar", params.N), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				if err := evaluator.MulScalar(ct1, scalar, ctd1); err != nil {
+					b.Error(err)
+				}
+			}
+		})
+
+		b.Run(fmt.Sprintf("params=%d/MulConstantPlaintext", params.N), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				if err := batchencoder.EncodeUint(constant, ptConstant); err != nil {
+					b.Error(err)
+				}
+				if err := evaluator.Mul(ct1, ptConstant, ctd1); err != nil {
+					b.Error(err)
+				}
+			}
+		})
+
+		b.Run(fmt.Sprintf("params=%d/MulPlaintext", params.N), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				if err := evaluator.Mul(ct1, pt, ctd1); err != nil {
+					b.Error(err)
+				}
+			}
+		})
+
 		// Square is Mul(ct, ct) for now
 		b.Run(fmt.Sprintf("params=%d/Square", params.N), func(b *testing.B) {
 			for i := 0; i < b.N; i++ {
@@ -142,6 +232,22 @@ func Benchmark_BFVScheme(b *testing.B) {
 				}
 			})
 
+			// Chain of operations with a new evaluator per chain, with and without a shared pool
+			b.Run(fmt.Sprintf("params=%d/decomp=%d/ChainNoPool", params.N, bitDecomp), func(b *testing.B) {
+				b.ReportAllocs()
+				for i := 0; i < b.N; i++ {
+					benchmarkChain(bfvContext.NewEvaluator(), ct1, ct2, rlk, ctd2, b)
+				}
+			})
+
+			pool := bfvContext.NewEvaluatorPool()
+			b.Run(fmt.Sprintf("params=%d/decomp=%d/ChainPool", params.N, bitDecomp), func(b *testing.B) {
+				b.ReportAllocs()
+				for i := 0; i < b.N; i++ {
+					benchmarkChain(bfvContext.NewEvaluatorWithPool(pool), ct1, ct2, rlk, ctd2, b)
+				}
+			})
+
 			// Rotation Key Generation not benchmarked (no inplace gen)
 			rtk := kgen.NewRotationKeysPow2(sk, bitDecomp, true)
 
@@ -165,3 +271,17 @@ func Benchmark_BFVScheme(b *testing.B) {
 		}
 	}
 }
+
+// benchmarkChain evaluates (ct0 * ct1) + ct1 followed by a relinearization on ctOut.
+func benchmarkChain(evaluator *Evaluator, ct0, ct1 *Ciphertext, rlk *EvaluationKey, ctOut *Ciphertext, b *testing.B) {
+	if err := evaluator.Mul(ct0, ct1, ctOut); err != nil {
+		b.Error(err)
+	}
+	if err := evaluator.Add(ctOut, ct1, ctOut); err != nil {
+		b.Error(err)
+	}
+	if err := evaluator.Relinearize(ctOut, rlk, ctOut); err != nil {
+		b.Error(err)
+	}
+	ctOut.Resize(evaluator.bfvcontext, 2)
+}
@@ -3,6 +3,9 @@ package bfv
 import (
 	"fmt"
 	"github.com/ldsec/lattigo/ring"
+	"math"
+	"strings"
+	"sync"
 	"testing"
 )
 
@@ -70,6 +73,24 @@ func Test_BFV(t *testing.T) {
 		test_KeySwitching(bfvTest, bitDecomps, t)
 		test_GaloisEnd(bfvTest, bitDecomps, t)
 		test_Marshaler(bfvTest, t)
+		test_EvaluatorPool(bfvTest, bitDecomps, t)
+		test_Components(bfvTest, t)
+		test_LinearTransform(bfvTest, bitDecomps, t)
+		test_CompressForTransport(bfvTest, t)
+		test_RelinearizeLeveled(bfvTest, t)
+		test_AddNoise(bfvTest, t)
+		test_CiphertextLevel(bfvTest, t)
+		test_NoiseWatcher(bfvTest, t)
+		test_NoiseBudget(bfvTest, t)
+		test_LikelyUnderKey(bfvTest, t)
+		test_Power(bfvTest, t)
+		test_EvaluatePoly(bfvTest, t)
+		test_DropLevel(bfvTest, t)
+		test_ShallowCopy(bfvTest, t)
+
+		test_EncryptWithSeed(bfvTest, t)
+
+		test_DecryptMany(bfvTest, t)
 
 	}
 }
@@ -193,6 +214,70 @@ func test_Marshaler(bfvTest *BFVTESTPARAMS, t *testing.T) {
 			}
 		}
 
+		for _, short := range [][]byte{nil, CtxBytes[:2], CtxBytes[:3], CtxBytes[:len(CtxBytes)-16]} {
+			if CtxTest.UnMarshalBinary(short) == nil {
+				t.Errorf("error : truncated ciphertext of %d bytes was unmarshaled", len(short))
+			}
+		}
+
+		CtxBytesWrongN := make([]byte, len(CtxBytes))
+		copy(CtxBytesWrongN, CtxBytes)
+		for _, logN := range []byte{CtxBytes[0] - 1, CtxBytes[0] + 1, 64, 0xFF} {
+			CtxBytesWrongN[0] = logN
+			if CtxTest.UnMarshalBinary(CtxBytesWrongN) == nil {
+				t.Errorf("error : ciphertext with ring degree 2^%d was unmarshaled", logN)
+			}
+		}
+
+		Ctx.Value()[1].Coeffs[0][0] += bfvContext.contextQ.Modulus[0]
+
+		if CtxBytes, err = Ctx.MarshalBinary(); err != nil {
+			t.Error(err)
+		}
+
+		if CtxTest.UnMarshalBinary(CtxBytes) == nil {
+			t.Errorf("error : unreduced ciphertext was unmarshaled")
+		}
+
+		// The rejected data must leave the receiver unchanged
+		Ctx.Value()[1].Coeffs[0][0] -= bfvContext.contextQ.Modulus[0]
+		for i := range Ctx.Value() {
+			if bfvContext.contextQ.Equal(CtxTest.Value()[i], Ctx.Value()[i]) != true {
+				t.Errorf("error : rejected ciphertext encoding modified the receiver")
+				break
+			}
+		}
+	})
+
+	t.Run(fmt.Sprintf("N=%d/T=%d/Qi=%dlimbs/StringCiphertext", bfvTest.bfvcontext.n,
+		bfvTest.bfvcontext.t,
+		len(bfvTest.bfvcontext.contextQ.Modulus)), func(t *testing.T) {
+
+		coeffs, _, ciphertext, _ := newTestVectors(bfvTest)
+
+		CtxTest, err := bfvContext.ParseCiphertext(ciphertext.String())
+		if err != nil {
+			t.Error(err)
+		}
+
+		for i := range ciphertext.Value() {
+			if bfvContext.contextQ.Equal(CtxTest.Value()[i], ciphertext.Value()[i]) != true {
+				t.Errorf("error : string ciphertext")
+				break
+			}
+		}
+
+		verifyTestVectors(bfvTest, coeffs, CtxTest, t)
+
+		if _, err := bfvContext.ParseCiphertext("not a ciphertext"); err == nil {
+			t.Errorf("error : invalid string ciphertext was parsed")
+		}
+
+		ciphertext.Value()[1].Coeffs[0][0] += bfvContext.contextQ.Modulus[0]
+
+		if _, err := bfvContext.ParseCiphertext(ciphertext.String()); err == nil {
+			t.Errorf("error : unreduced string ciphertext was parsed")
+		}
 	})
 
 	t.Run(fmt.Sprintf("N=%d/T=%d/Qi=%dlimbs/bitDecomp=%d/Marshalrlk", bfvTest.bfvcontext.n,
@@ -344,6 +429,36 @@ func test_PlaintextBatchEncodeDecode(bfvTest *BFVTESTPARAMS, t *testing.T) {
 
 	})
 
+	t.Run(fmt.Sprintf("N=%d/T=%d/logQ=%d/logP=%d/DecodeStream", bfvTest.bfvcontext.N(),
+		bfvTest.bfvcontext.T(),
+		bfvTest.bfvcontext.LogQ(),
+		bfvTest.bfvcontext.LogP()), func(t *testing.T) {
+
+		coeffs, _, ciphertext, _ := newTestVectors(bfvTest)
+
+		plaintext := bfvTest.decryptor.DecryptNew(ciphertext)
+
+		coeffsBatch := bfvTest.batchencoder.DecodeUint(plaintext)
+
+		out := make(chan uint64, 64)
+		go bfvTest.batchencoder.DecodeStream(plaintext, out)
+
+		coeffsStream := []uint64{<-out}
+
+		// The encoder is reused while the coefficients are consumed
+		coeffsOther, _, ciphertextOther, _ := newTestVectors(bfvTest)
+		if equalslice(coeffsOther.Coeffs[0], bfvTest.batchencoder.DecodeUint(bfvTest.decryptor.DecryptNew(ciphertextOther))) != true {
+			t.Errorf("error : DecodeUint during DecodeStream")
+		}
+
+		for c := range out {
+			coeffsStream = append(coeffsStream, c)
+		}
+
+		if equalslice(coeffsBatch, coeffsStream) != true || equalslice(coeffs.Coeffs[0], coeffsStream) != true {
+			t.Errorf("error : DecodeStream")
+		}
+	})
 }
 
 func newTestVectors(bfvTest *BFVTESTPARAMS) (coeffs *ring.Poly, plaintext *Plaintext, ciphertext *Ciphertext, err error) {
@@ -418,6 +533,92 @@ func test_EncryptDecrypt(bfvTest *BFVTESTPARAMS, t *testing.T) {
 
 		verifyTestVectors(bfvTest, coeffs, ciphertext, t)
 	})
+
+	t.Run(fmt.Sprintf("N=%d/T=%d/logQ=%d/logP=%d/EncryptChunked", bfvTest.bfvcontext.N(),
+		bfvTest.bfvcontext.T(),
+		bfvTest.bfvcontext.LogQ(),
+		bfvTest.bfvcontext.LogP()), func(t *testing.T) {
+
+		slots := bfvTest.bfvcontext.N()
+
+		values := make([]uint64, 0, 3*slots+slots/3)
+		for uint64(len(values)) < 3*slots {
+			values = append(values, bfvTest.bfvcontext.contextT.NewUniformPoly().Coeffs[0]...)
+		}
+		values = append(values, bfvTest.bfvcontext.contextT.NewUniformPoly().Coeffs[0][:slots/3]...)
+
+		ciphertexts, err := bfvTest.encryptorPk.EncryptChunked(values)
+		if err != nil {
+			t.Error(err)
+		}
+
+		if len(ciphertexts) != 4 {
+			t.Errorf("error : EncryptChunked, want 4 ciphertexts have %d", len(ciphertexts))
+		}
+
+		valuesTest, err := bfvTest.decryptor.DecryptChunked(ciphertexts, uint64(len(values)))
+		if err != nil {
+			t.Error(err)
+		}
+
+		if equalslice(values, valuesTest) != true {
+			t.Errorf("error : EncryptChunked/DecryptChunked")
+		}
+
+		if _, err := bfvTest.decryptor.DecryptChunked(ciphertexts, 4*slots+1); err == nil {
+			t.Errorf("error : DecryptChunked with length exceeding the number of slots")
+		}
+	})
+
+	t.Run(fmt.Sprintf("N=%d/T=%d/logQ=%d/logP=%d/EncryptFromCRP", bfvTest.bfvcontext.N(),
+		bfvTest.bfvcontext.T(),
+		bfvTest.bfvcontext.LogQ(),
+		bfvTest.bfvcontext.LogP()), func(t *testing.T) {
+
+		var err error
+		var ciphertext0, ciphertext1 *Ciphertext
+
+		contextQ := bfvTest.bfvcontext.contextQ
+
+		coeffs := bfvTest.bfvcontext.contextT.NewUniformPoly()
+
+		plaintext := bfvTest.bfvcontext.NewPlaintext()
+
+		if err = bfvTest.batchencoder.EncodeUint(coeffs.Coeffs[0], plaintext); err != nil {
+			t.Error(err)
+		}
+
+		crp := contextQ.NewUniformPoly()
+		crpCopy := crp.CopyNew()
+
+		if ciphertext0, err = bfvTest.encryptorSk.EncryptFromCRPNew(plaintext, crp); err != nil {
+			t.Error(err)
+		}
+
+		ciphertext1 = bfvTest.bfvcontext.NewCiphertext(1)
+		if err = bfvTest.encryptorSk.EncryptFromCRP(plaintext, crp, ciphertext1); err != nil {
+			t.Error(err)
+		}
+
+		if !contextQ.Equal(crp, crpCopy) {
+			t.Errorf("error : EncryptFromCRP modified the crp")
+		}
+
+		if !contextQ.Equal(ciphertext0.Value()[1], ciphertext1.Value()[1]) {
+			t.Errorf("error : EncryptFromCRP, second components differ under the same crp")
+		}
+
+		if contextQ.Equal(ciphertext0.Value()[0], ciphertext1.Value()[0]) {
+			t.Errorf("error : EncryptFromCRP, first components are equal despite fresh errors")
+		}
+
+		verifyTestVectors(bfvTest, coeffs, ciphertext0, t)
+		verifyTestVectors(bfvTest, coeffs, ciphertext1, t)
+
+		if _, err = bfvTest.encryptorPk.EncryptFromCRPNew(plaintext, crp); err == nil {
+			t.Errorf("error : EncryptFromCRP without secret-key should fail")
+		}
+	})
 }
 
 func test_HomomorphicAddition(bfvTest *BFVTESTPARAMS, t *testing.T) {
@@ -851,6 +1052,56 @@ func test_MulWithPlaintext(bfvTest *BFVTESTPARAMS, t *testing.T) {
 		verifyTestVectors(bfvTest, coeffs0, receiverCiphertext, t)
 
 	})
+
+	t.Run(fmt.Sprintf("N=%d/T=%d/logQ=%d/logP=%d/MulScalar", bfvTest.bfvcontext.N(),
+		bfvTest.bfvcontext.T(),
+		bfvTest.bfvcontext.LogQ(),
+		bfvTest.bfvcontext.LogP()), func(t *testing.T) {
+
+		coeffs0, _, ciphertext0, _ := newTestVectors(bfvTest)
+
+		contextQ := bfvContext.contextQ
+
+		plaintext := bfvContext.NewPlaintext()
+		constant := make([]uint64, bfvContext.N())
+		coeffsWant := bfvContext.contextT.NewPoly()
+
+		receiverCiphertext := bfvContext.NewCiphertext(1)
+		receiverCiphertextMul := bfvContext.NewCiphertext(1)
+
+		// Random scalars, the last one exceeding t
+		scalars := []uint64{0, 1, bfvContext.t - 1, ring.RandUniform(bfvContext.t, 0xFFFFFFFF), bfvContext.t + 2}
+
+		for _, scalar := range scalars {
+
+			if err := evaluator.MulScalar(ciphertext0, scalar, receiverCiphertext); err != nil {
+				t.Fatal(err)
+			}
+
+			bfvContext.contextT.MulScalar(coeffs0, scalar%bfvContext.t, coeffsWant)
+
+			verifyTestVectors(bfvTest, coeffsWant, receiverCiphertext, t)
+
+			// The multiplication by a plaintext encoding the constant takes the same path
+			for i := range constant {
+				constant[i] = scalar % bfvContext.t
+			}
+
+			if err := bfvTest.batchencoder.EncodeUint(constant, plaintext); err != nil {
+				t.Fatal(err)
+			}
+
+			if err := evaluator.Mul(ciphertext0, plaintext, receiverCiphertextMul); err != nil {
+				t.Fatal(err)
+			}
+
+			for i := range receiverCiphertext.Value() {
+				if !contextQ.Equal(receiverCiphertext.Value()[i], receiverCiphertextMul.Value()[i]) {
+					t.Errorf("error : Mul by a constant plaintext %d does not take the MulScalar path", scalar)
+				}
+			}
+		}
+	})
 }
 
 func test_Relinearization(bfvTest *BFVTESTPARAMS, bitDecomps []uint64, t *testing.T) {
@@ -917,121 +1168,547 @@ func test_Relinearization(bfvTest *BFVTESTPARAMS, bitDecomps []uint64, t *testin
 			verifyTestVectors(bfvTest, coeffs0, ciphertext0, t)
 
 		})
-	}
-}
 
-func test_KeySwitching(bfvTest *BFVTESTPARAMS, bitDecomps []uint64, t *testing.T) {
+		t.Run(fmt.Sprintf("N=%d/T=%d/logQ=%d/logP=%d/bitDecomp=%d/RelinearizeOneStep", bfvTest.bfvcontext.N(),
+			bfvTest.bfvcontext.T(),
+			bfvTest.bfvcontext.LogQ(),
+			bfvTest.bfvcontext.LogP(),
+			bitDecomp), func(t *testing.T) {
 
-	bfvContext := bfvTest.bfvcontext
-	encoder := bfvTest.batchencoder
-	kgen := bfvTest.kgen
-	Sk := bfvTest.sk
-	evaluator := bfvTest.evaluator
+			coeffs0, _, ciphertext0, _ := newTestVectors(bfvTest)
+			coeffs1, _, ciphertext1, _ := newTestVectors(bfvTest)
 
-	SkNew := kgen.NewSecretKey()
+			ciphertext0, _ = evaluator.MulNew(ciphertext0, ciphertext1)
+			bfvContext.contextT.MulCoeffs(coeffs0, coeffs1, coeffs0)
 
-	decryptor_SkNew, err := bfvContext.NewDecryptor(SkNew)
-	if err != nil {
-		t.Error(err)
-	}
+			ciphertext0, _ = evaluator.MulNew(ciphertext0, ciphertext1)
+			bfvContext.contextT.MulCoeffs(coeffs0, coeffs1, coeffs0)
 
-	for _, bitDecomp := range bitDecomps {
+			receiver := bfvContext.NewCiphertext(2)
 
-		switching_key := kgen.NewSwitchingKey(Sk, SkNew, bitDecomp)
+			if err := evaluator.RelinearizeOneStep(ciphertext0, rlk, receiver); err != nil {
+				t.Error(err)
+			}
 
-		t.Run(fmt.Sprintf("N=%d/T=%d/logQ=%d/logP=%d/bitDecomp=%d/SwitchKeys", bfvTest.bfvcontext.N(),
+			if receiver.Degree() != 2 {
+				t.Errorf("error : RelinearizeOneStep output is of degree %d", receiver.Degree())
+			}
+
+			verifyTestVectors(bfvTest, coeffs0, receiver, t)
+
+			// In place, down to degree 1
+			for ciphertext0.Degree() > 1 {
+				if err := evaluator.RelinearizeOneStep(ciphertext0, rlk, ciphertext0); err != nil {
+					t.Error(err)
+				}
+			}
+
+			verifyTestVectors(bfvTest, coeffs0, ciphertext0, t)
+
+			if err := evaluator.RelinearizeOneStep(ciphertext0, rlk, ciphertext0); err == nil {
+				t.Errorf("error : RelinearizeOneStep accepted a ciphertext of degree 1")
+			}
+		})
+
+		t.Run(fmt.Sprintf("N=%d/T=%d/logQ=%d/logP=%d/bitDecomp=%d/Element", bfvTest.bfvcontext.N(),
 			bfvTest.bfvcontext.T(),
 			bfvTest.bfvcontext.LogQ(),
 			bfvTest.bfvcontext.LogP(),
 			bitDecomp), func(t *testing.T) {
 
-			coeffs0, _, ciphertext0, _ := newTestVectors(bfvTest)
+			switchkey := rlk.Get()[0].evakey
 
-			if err := evaluator.SwitchKeys(ciphertext0, switching_key, ciphertext0); err != nil {
-				t.Error(err)
+			for limb := range switchkey {
+				for digit := range switchkey[limb] {
+					for part := 0; part < 2; part++ {
+
+						pol, err := rlk.Element(digit, limb, part)
+						if err != nil {
+							t.Error(err)
+						}
+
+						if pol != switchkey[limb][digit][part] {
+							t.Errorf("error : Element(%d, %d, %d) does not return the expected polynomial", digit, limb, part)
+						}
+					}
+				}
 			}
 
-			if equalslice(coeffs0.Coeffs[0], encoder.DecodeUint(decryptor_SkNew.DecryptNew(ciphertext0))) != true {
-				t.Errorf("error : switchingKey encrypt/decrypt")
+			for _, index := range [][3]int{{-1, 0, 0}, {len(switchkey[0]), 0, 0}, {0, -1, 0}, {0, len(switchkey), 0}, {0, 0, -1}, {0, 0, 2}} {
+				if _, err := rlk.Element(index[0], index[1], index[2]); err == nil {
+					t.Errorf("error : Element(%d, %d, %d) out of range did not return an error", index[0], index[1], index[2])
+				}
 			}
 
+			if _, err := new(EvaluationKey).Element(0, 0, 0); err == nil {
+				t.Errorf("error : Element on an empty evaluation-key did not return an error")
+			}
 		})
 
-		t.Run(fmt.Sprintf("N=%d/T=%d/logQ=%d/logP=%d/bitDecomp=%d/SwitchKeysNew", bfvTest.bfvcontext.N(),
+		t.Run(fmt.Sprintf("N=%d/T=%d/logQ=%d/logP=%d/bitDecomp=%d/Square", bfvTest.bfvcontext.N(),
 			bfvTest.bfvcontext.T(),
 			bfvTest.bfvcontext.LogQ(),
 			bfvTest.bfvcontext.LogP(),
 			bitDecomp), func(t *testing.T) {
 
-			coeffs0, _, ciphertext0, _ := newTestVectors(bfvTest)
+			coeffs, _, ciphertext, _ := newTestVectors(bfvTest)
+			bfvContext.contextT.MulCoeffs(coeffs, coeffs, coeffs)
 
-			ciphertextTest, err := evaluator.SwitchKeysNew(ciphertext0, switching_key)
-			if err != nil {
+			ciphertextWant, _ := evaluator.MulNew(ciphertext, ciphertext)
+			if err := evaluator.Relinearize(ciphertextWant, rlk, ciphertextWant); err != nil {
 				t.Error(err)
 			}
 
-			if equalslice(coeffs0.Coeffs[0], encoder.DecodeUint(decryptor_SkNew.DecryptNew(ciphertextTest))) != true {
-				t.Errorf("error : switchingKeyNew encrypt/decrypt")
+			ciphertextTest := bfvContext.NewCiphertext(1)
+			if err := evaluator.Square(ciphertext, rlk, ciphertextTest); err != nil {
+				t.Error(err)
 			}
-		})
-	}
-}
 
-func test_GaloisEnd(bfvTest *BFVTESTPARAMS, bitDecomps []uint64, t *testing.T) {
+			verifyTestVectors(bfvTest, coeffs, ciphertextTest, t)
 
-	bfvContext := bfvTest.bfvcontext
-	kgen := bfvTest.kgen
-	Sk := bfvTest.sk
-	evaluator := bfvTest.evaluator
+			// Same computation as Mul followed by Relinearize
+			for i := range ciphertextWant.Value() {
+				if bfvContext.contextQ.Equal(ciphertextWant.Value()[i], ciphertextTest.Value()[i]) != true {
+					t.Errorf("error : Square differs from Mul followed by Relinearize")
+				}
+			}
 
-	coeffs, _, ciphertext, _ := newTestVectors(bfvTest)
+			allocsSquare := testing.AllocsPerRun(10, func() {
+				evaluator.Square(ciphertext, rlk, ciphertextTest)
+			})
 
-	receiverCiphertext := bfvContext.NewCiphertext(1)
+			allocsMulRelin := testing.AllocsPerRun(10, func() {
+				ciphertextWant, _ := evaluator.MulNew(ciphertext, ciphertext)
+				evaluator.Relinearize(ciphertextWant, rlk, ciphertextTest)
+			})
 
-	slots := bfvContext.n >> 1
-	mask := slots - 1
+			if allocsSquare >= allocsMulRelin {
+				t.Errorf("error : Square does not allocate less than Mul followed by Relinearize (%.0f >= %.0f)", allocsSquare, allocsMulRelin)
+			}
 
-	coeffsWantRotateCol := bfvContext.contextT.NewPoly()
-	coeffsWantRotateRow := bfvContext.contextT.NewPoly()
-	coeffsWantRotateRow.Coeffs[0] = append(coeffs.Coeffs[0][bfvContext.n>>1:], coeffs.Coeffs[0][:bfvContext.n>>1]...)
+			// In place
+			if err := evaluator.Square(ciphertext, rlk, ciphertext); err != nil {
+				t.Error(err)
+			}
 
-	for _, bitDecomp := range bitDecomps {
+			verifyTestVectors(bfvTest, coeffs, ciphertext, t)
+		})
 
-		rotation_key := kgen.NewRotationKeysPow2(Sk, bitDecomp, true)
+		t.Run(fmt.Sprintf("N=%d/T=%d/logQ=%d/logP=%d/bitDecomp=%d/RelationError", bfvTest.bfvcontext.N(),
+			bfvTest.bfvcontext.T(),
+			bfvTest.bfvcontext.LogQ(),
+			bfvTest.bfvcontext.LogP(),
+			bitDecomp), func(t *testing.T) {
 
-		for n := uint64(1); n < bfvContext.n>>1; n <<= 1 {
+			// The error of the key is sampled with a bound of 6*sigma
+			bound := math.Log2(6 * bfvContext.sigma)
 
-			for i := uint64(0); i < slots; i++ {
-				coeffsWantRotateCol.Coeffs[0][i] = coeffs.Coeffs[0][(i+n)&mask]
-				coeffsWantRotateCol.Coeffs[0][i+slots] = coeffs.Coeffs[0][((i+n)&mask)+slots]
+			relationError, err := rlk.RelationError(bfvTest.sk, bfvContext)
+			if err != nil {
+				t.Fatal(err)
 			}
 
-			t.Run(fmt.Sprintf("N=%d/T=%d/logQ=%d/logP=%d/bitDecomp=%d/RotateColumns/%d", bfvTest.bfvcontext.N(),
-				bfvTest.bfvcontext.T(),
-				bfvTest.bfvcontext.LogQ(),
-				bfvTest.bfvcontext.LogP(),
-				bitDecomp, n), func(t *testing.T) {
+			for limb := range relationError {
+				if len(relationError[limb]) != len(rlk.Get()[0].evakey[limb]) {
+					t.Errorf("error : RelationError returned %d digits for the limb %d", len(relationError[limb]), limb)
+				}
+				for digit, deviation := range relationError[limb] {
+					if deviation > bound {
+						t.Errorf("error : RelationError(%d, %d) = %f exceeds the noise bound %f", limb, digit, deviation, bound)
+					}
+				}
+			}
 
-				if err := evaluator.RotateColumns(ciphertext, n, rotation_key, receiverCiphertext); err != nil {
-					t.Error(err)
+			// With the wrong secret-key, the deviation is of the size of the moduli
+			relationError, err = rlk.RelationError(kgen.NewSecretKey(), bfvContext)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			for limb, deviations := range relationError {
+				for digit, deviation := range deviations {
+					if deviation <= bound {
+						t.Errorf("error : RelationError(%d, %d) = %f within the noise bound with the wrong secret-key", limb, digit, deviation)
+					}
 				}
+			}
 
-				verifyTestVectors(bfvTest, coeffsWantRotateCol, receiverCiphertext, t)
-			})
-		}
+			// An empty evaluation-key has no relation to check
+			if _, err := new(EvaluationKey).RelationError(bfvTest.sk, bfvContext); err == nil {
+				t.Errorf("error : RelationError accepted an empty evaluation-key")
+			}
+		})
 
-		for n := uint64(1); n < bfvContext.n>>1; n <<= 2 {
+		t.Run(fmt.Sprintf("N=%d/T=%d/logQ=%d/logP=%d/bitDecomp=%d/NoiseWithinPolicy", bfvTest.bfvcontext.N(),
+			bfvTest.bfvcontext.T(),
+			bfvTest.bfvcontext.LogQ(),
+			bfvTest.bfvcontext.LogP(),
+			bitDecomp), func(t *testing.T) {
 
-			rand := ring.RandUniform(mask+1, mask)
+			// The error of the key is sampled with a bound of 6*sigma
+			maxBits := math.Log2(6 * bfvContext.sigma)
 
-			for i := uint64(0); i < slots; i++ {
-				coeffsWantRotateCol.Coeffs[0][i] = coeffs.Coeffs[0][(i+rand)&mask]
-				coeffsWantRotateCol.Coeffs[0][i+slots] = coeffs.Coeffs[0][((i+rand)&mask)+slots]
+			if err := rlk.NoiseWithinPolicy(bfvTest.sk, maxBits, bfvContext); err != nil {
+				t.Errorf("error : fresh key rejected by the noise policy : %s", err)
 			}
 
-			t.Run(fmt.Sprintf("N=%d/T=%d/logQ=%d/logP=%d/bitDecomp=%d/RotateColumns/%d", bfvTest.bfvcontext.N(),
-				bfvTest.bfvcontext.T(),
-				bfvTest.bfvcontext.LogQ(),
+			// Copy of the key whose last digit of the first limb carries an additional noise
+			switchkey := rlk.Get()[0].evakey
+			noisy := make([][][2]*ring.Poly, len(switchkey))
+			for limb := range switchkey {
+				noisy[limb] = make([][2]*ring.Poly, len(switchkey[limb]))
+				copy(noisy[limb], switchkey[limb])
+			}
+
+			digit := len(noisy[0]) - 1
+			noise := bfvContext.contextQ.NewPoly()
+			for i := range noise.Coeffs {
+				noise.Coeffs[i][0] = 1 << 20
+			}
+			bfvContext.contextQ.NTT(noise, noise)
+			bfvContext.contextQ.MForm(noise, noise)
+
+			noisy[0][digit][0] = noisy[0][digit][0].CopyNew()
+			bfvContext.contextQ.Add(noisy[0][digit][0], noise, noisy[0][digit][0])
+
+			rlkNoisy := new(EvaluationKey)
+			rlkNoisy.SetRelinKeys([][][][2]*ring.Poly{noisy}, bitDecomp)
+
+			err := rlkNoisy.NoiseWithinPolicy(bfvTest.sk, maxBits, bfvContext)
+			if err == nil {
+				t.Fatalf("error : key with a noisy digit accepted by the noise policy")
+			}
+
+			if !strings.Contains(err.Error(), fmt.Sprintf("limb 0 digit %d ", digit)) {
+				t.Errorf("error : the noise policy error does not name the noisy element : %s", err)
+			}
+
+			if strings.Count(err.Error(), "limb ") != 1 {
+				t.Errorf("error : the noise policy error names compliant elements : %s", err)
+			}
+		})
+
+		t.Run(fmt.Sprintf("N=%d/T=%d/logQ=%d/logP=%d/bitDecomp=%d/NonzeroDigits", bfvTest.bfvcontext.N(),
+			bfvTest.bfvcontext.T(),
+			bfvTest.bfvcontext.LogQ(),
+			bfvTest.bfvcontext.LogP(),
+			bitDecomp), func(t *testing.T) {
+
+			for limb, digits := range rlk.NonzeroDigits() {
+				for digit, nonzero := range digits {
+					if !nonzero {
+						t.Errorf("error : digit %d of limb %d of a fresh key reported as zero", digit, limb)
+					}
+				}
+			}
+
+			// Truncated copy of a key with several digits per limb, keeping only the first digit of each limb
+			switchkey := kgen.NewRelinKey(bfvTest.sk, 1, 20).Get()[0].evakey
+			truncated := make([][][2]*ring.Poly, len(switchkey))
+			for limb := range switchkey {
+				truncated[limb] = make([][2]*ring.Poly, len(switchkey[limb]))
+				for digit := range switchkey[limb] {
+					truncated[limb][digit] = [2]*ring.Poly{bfvContext.contextQ.NewPoly(), bfvContext.contextQ.NewPoly()}
+				}
+				truncated[limb][0] = switchkey[limb][0]
+			}
+
+			rlkTruncated := new(EvaluationKey)
+			rlkTruncated.SetRelinKeys([][][][2]*ring.Poly{truncated}, 20)
+
+			nonzeroDigits := rlkTruncated.NonzeroDigits()
+
+			if len(nonzeroDigits) != len(switchkey) {
+				t.Fatalf("error : NonzeroDigits returned %d limbs, want %d", len(nonzeroDigits), len(switchkey))
+			}
+
+			for limb, digits := range nonzeroDigits {
+				for digit, nonzero := range digits {
+					if nonzero != (digit == 0) {
+						t.Errorf("error : digit %d of limb %d of the truncated key reported as nonzero=%t", digit, limb, nonzero)
+					}
+				}
+			}
+
+			if new(EvaluationKey).NonzeroDigits() != nil {
+				t.Errorf("error : NonzeroDigits of an empty key is not nil")
+			}
+		})
+
+		t.Run(fmt.Sprintf("N=%d/T=%d/logQ=%d/logP=%d/bitDecomp=%d/FromComponents", bfvTest.bfvcontext.N(),
+			bfvTest.bfvcontext.T(),
+			bfvTest.bfvcontext.LogQ(),
+			bfvTest.bfvcontext.LogP(),
+			bitDecomp), func(t *testing.T) {
+
+			contextQ := bfvContext.contextQ
+
+			rlk2 := kgen.NewRelinKey(bfvTest.sk, 1, bitDecomp)
+			switchkey := rlk2.Get()[0].evakey
+
+			// The components as computed by an external system, in standard form
+			components := make([][][2]*ring.Poly, len(switchkey))
+			for i := range switchkey {
+				components[i] = make([][2]*ring.Poly, len(switchkey[i]))
+				for j := range switchkey[i] {
+					for u := 0; u < 2; u++ {
+						components[i][j][u] = contextQ.NewPoly()
+						contextQ.InvMForm(switchkey[i][j][u], components[i][j][u])
+					}
+				}
+			}
+
+			rlkAssembled, err := bfvContext.NewEvaluationKeyFromComponents(components, rlk2.Get()[0].bitDecomp)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			for i := range switchkey {
+				for j := range switchkey[i] {
+					for u := 0; u < 2; u++ {
+						if !contextQ.Equal(rlkAssembled.Get()[0].evakey[i][j][u], switchkey[i][j][u]) {
+							t.Errorf("error : NewEvaluationKeyFromComponents, limb %d digit %d part %d differs", i, j, u)
+						}
+					}
+				}
+			}
+
+			coeffs0, _, ciphertext0, _ := newTestVectors(bfvTest)
+			coeffs1, _, ciphertext1, _ := newTestVectors(bfvTest)
+
+			ciphertext0, _ = evaluator.MulNew(ciphertext0, ciphertext1)
+			bfvContext.contextT.MulCoeffs(coeffs0, coeffs1, coeffs0)
+
+			ciphertextRef, _ := evaluator.RelinearizeNew(ciphertext0, rlk2)
+
+			ciphertextTest, err := evaluator.RelinearizeNew(ciphertext0, rlkAssembled)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			for i := range ciphertextRef.Value() {
+				if !contextQ.Equal(ciphertextRef.Value()[i], ciphertextTest.Value()[i]) {
+					t.Errorf("error : NewEvaluationKeyFromComponents, relinearization differs from the original key")
+				}
+			}
+
+			verifyTestVectors(bfvTest, coeffs0, ciphertextTest, t)
+
+			if _, err := bfvContext.NewEvaluationKeyFromComponents(components[1:], rlk2.Get()[0].bitDecomp); err == nil {
+				t.Errorf("error : NewEvaluationKeyFromComponents accepted a missing limb")
+			}
+
+			components[0] = components[0][:len(components[0])-1]
+			if _, err := bfvContext.NewEvaluationKeyFromComponents(components, rlk2.Get()[0].bitDecomp); err == nil {
+				t.Errorf("error : NewEvaluationKeyFromComponents accepted a missing digit")
+			}
+
+			if _, err := bfvContext.NewEvaluationKeyFromComponents(components, 0); err == nil {
+				t.Errorf("error : NewEvaluationKeyFromComponents accepted a zero bitDecomp")
+			}
+		})
+
+		t.Run(fmt.Sprintf("N=%d/T=%d/logQ=%d/logP=%d/bitDecomp=%d/CompareNoiseBudget", bfvTest.bfvcontext.N(),
+			bfvTest.bfvcontext.T(),
+			bfvTest.bfvcontext.LogQ(),
+			bfvTest.bfvcontext.LogP(),
+			bitDecomp), func(t *testing.T) {
+
+			coeffs0, _, ciphertext0, _ := newTestVectors(bfvTest)
+			coeffs1, _, ciphertext1, _ := newTestVectors(bfvTest)
+			coeffs2, _, ciphertext2, _ := newTestVectors(bfvTest)
+
+			bfvContext.contextT.MulCoeffs(coeffs0, coeffs1, coeffs0)
+			bfvContext.contextT.MulCoeffs(coeffs0, coeffs2, coeffs0)
+
+			// ct0 * ct1 * ct2, relinearized after each multiplication
+			eager := func(evaluator *Evaluator) *Ciphertext {
+				ciphertext, _ := evaluator.MulNew(ciphertext0, ciphertext1)
+				evaluator.Relinearize(ciphertext, rlk, ciphertext)
+				ciphertext, _ = evaluator.MulNew(ciphertext, ciphertext2)
+				evaluator.Relinearize(ciphertext, rlk, ciphertext)
+				verifyTestVectors(bfvTest, coeffs0, ciphertext, t)
+				return ciphertext
+			}
+
+			// ct0 * ct1 * ct2, relinearized once from degree 3
+			lazy := func(evaluator *Evaluator) *Ciphertext {
+				ciphertext, _ := evaluator.MulNew(ciphertext0, ciphertext1)
+				ciphertext, _ = evaluator.MulNew(ciphertext, ciphertext2)
+				evaluator.Relinearize(ciphertext, rlk, ciphertext)
+				verifyTestVectors(bfvTest, coeffs0, ciphertext, t)
+				return ciphertext
+			}
+
+			budgetEager, budgetLazy := bfvContext.CompareNoiseBudget(eager, lazy, bfvTest.sk)
+			t.Logf("noise budget : fresh %d, eager %d, lazy %d", bfvTest.decryptor.NoiseBudget(ciphertext0), budgetEager, budgetLazy)
+
+			if budgetEager <= 0 || budgetLazy <= 0 {
+				t.Errorf("error : CompareNoiseBudget, exhausted budget (eager %d, lazy %d)", budgetEager, budgetLazy)
+			}
+
+			// The relinearization noise of the first multiplication is amplified by the second multiplication in the eager strategy
+			if budgetLazy < budgetEager {
+				t.Errorf("error : CompareNoiseBudget, lazy relinearization consumed more budget than eager (%d < %d)", budgetLazy, budgetEager)
+			}
+
+			if budgetEager >= bfvTest.decryptor.NoiseBudget(ciphertext0) {
+				t.Errorf("error : NoiseBudget, the multiplications did not consume budget")
+			}
+		})
+	}
+}
+
+func test_KeySwitching(bfvTest *BFVTESTPARAMS, bitDecomps []uint64, t *testing.T) {
+
+	bfvContext := bfvTest.bfvcontext
+	encoder := bfvTest.batchencoder
+	kgen := bfvTest.kgen
+	Sk := bfvTest.sk
+	evaluator := bfvTest.evaluator
+
+	SkNew := kgen.NewSecretKey()
+
+	decryptor_SkNew, err := bfvContext.NewDecryptor(SkNew)
+	if err != nil {
+		t.Error(err)
+	}
+
+	for _, bitDecomp := range bitDecomps {
+
+		switching_key := kgen.NewSwitchingKey(Sk, SkNew, bitDecomp)
+
+		t.Run(fmt.Sprintf("N=%d/T=%d/logQ=%d/logP=%d/bitDecomp=%d/SwitchKeys", bfvTest.bfvcontext.N(),
+			bfvTest.bfvcontext.T(),
+			bfvTest.bfvcontext.LogQ(),
+			bfvTest.bfvcontext.LogP(),
+			bitDecomp), func(t *testing.T) {
+
+			coeffs0, _, ciphertext0, _ := newTestVectors(bfvTest)
+
+			if err := evaluator.SwitchKeys(ciphertext0, switching_key, ciphertext0); err != nil {
+				t.Error(err)
+			}
+
+			if equalslice(coeffs0.Coeffs[0], encoder.DecodeUint(decryptor_SkNew.DecryptNew(ciphertext0))) != true {
+				t.Errorf("error : switchingKey encrypt/decrypt")
+			}
+
+		})
+
+		t.Run(fmt.Sprintf("N=%d/T=%d/logQ=%d/logP=%d/bitDecomp=%d/EvaluationKeyAsSwitchingKey", bfvTest.bfvcontext.N(),
+			bfvTest.bfvcontext.T(),
+			bfvTest.bfvcontext.LogQ(),
+			bfvTest.bfvcontext.LogP(),
+			bitDecomp), func(t *testing.T) {
+
+			rlk := kgen.NewRelinKey(Sk, 1, bitDecomp)
+
+			// The relinearization key encrypts sk^2 = (sk^2 + sk) - sk, it switches from sk^2 + sk to sk
+			SkInput := kgen.NewSecretKeyEmpty()
+			bfvContext.contextQ.MulCoeffsMontgomery(Sk.Get(), Sk.Get(), SkInput.Get())
+			bfvContext.contextQ.Add(SkInput.Get(), Sk.Get(), SkInput.Get())
+
+			encryptor_SkInput, err := bfvContext.NewEncryptorFromSk(SkInput)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			coeffs0, plaintext0, _, _ := newTestVectors(bfvTest)
+
+			ciphertext0, err := encryptor_SkInput.EncryptNew(plaintext0)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if err := evaluator.SwitchKeys(ciphertext0, rlk.AsSwitchingKey(), ciphertext0); err != nil {
+				t.Error(err)
+			}
+
+			if equalslice(coeffs0.Coeffs[0], bfvTest.batchencoder.DecodeUint(bfvTest.decryptor.DecryptNew(ciphertext0))) != true {
+				t.Errorf("error : switchingKey extracted from the evaluation key encrypt/decrypt")
+			}
+
+			if new(EvaluationKey).AsSwitchingKey() != nil {
+				t.Errorf("error : switchingKey extracted from an empty evaluation key")
+			}
+		})
+
+		t.Run(fmt.Sprintf("N=%d/T=%d/logQ=%d/logP=%d/bitDecomp=%d/SwitchKeysNew", bfvTest.bfvcontext.N(),
+			bfvTest.bfvcontext.T(),
+			bfvTest.bfvcontext.LogQ(),
+			bfvTest.bfvcontext.LogP(),
+			bitDecomp), func(t *testing.T) {
+
+			coeffs0, _, ciphertext0, _ := newTestVectors(bfvTest)
+
+			ciphertextTest, err := evaluator.SwitchKeysNew(ciphertext0, switching_key)
+			if err != nil {
+				t.Error(err)
+			}
+
+			if equalslice(coeffs0.Coeffs[0], encoder.DecodeUint(decryptor_SkNew.DecryptNew(ciphertextTest))) != true {
+				t.Errorf("error : switchingKeyNew encrypt/decrypt")
+			}
+		})
+	}
+}
+
+func test_GaloisEnd(bfvTest *BFVTESTPARAMS, bitDecomps []uint64, t *testing.T) {
+
+	bfvContext := bfvTest.bfvcontext
+	kgen := bfvTest.kgen
+	Sk := bfvTest.sk
+	evaluator := bfvTest.evaluator
+
+	coeffs, _, ciphertext, _ := newTestVectors(bfvTest)
+
+	receiverCiphertext := bfvContext.NewCiphertext(1)
+
+	slots := bfvContext.n >> 1
+	mask := slots - 1
+
+	coeffsWantRotateCol := bfvContext.contextT.NewPoly()
+	coeffsWantRotateRow := bfvContext.contextT.NewPoly()
+	coeffsWantRotateRow.Coeffs[0] = append(coeffs.Coeffs[0][bfvContext.n>>1:], coeffs.Coeffs[0][:bfvContext.n>>1]...)
+
+	for _, bitDecomp := range bitDecomps {
+
+		rotation_key := kgen.NewRotationKeysPow2(Sk, bitDecomp, true)
+
+		for n := uint64(1); n < bfvContext.n>>1; n <<= 1 {
+
+			for i := uint64(0); i < slots; i++ {
+				coeffsWantRotateCol.Coeffs[0][i] = coeffs.Coeffs[0][(i+n)&mask]
+				coeffsWantRotateCol.Coeffs[0][i+slots] = coeffs.Coeffs[0][((i+n)&mask)+slots]
+			}
+
+			t.Run(fmt.Sprintf("N=%d/T=%d/logQ=%d/logP=%d/bitDecomp=%d/RotateColumns/%d", bfvTest.bfvcontext.N(),
+				bfvTest.bfvcontext.T(),
+				bfvTest.bfvcontext.LogQ(),
+				bfvTest.bfvcontext.LogP(),
+				bitDecomp, n), func(t *testing.T) {
+
+				if err := evaluator.RotateColumns(ciphertext, n, rotation_key, receiverCiphertext); err != nil {
+					t.Error(err)
+				}
+
+				verifyTestVectors(bfvTest, coeffsWantRotateCol, receiverCiphertext, t)
+			})
+		}
+
+		for n := uint64(1); n < bfvContext.n>>1; n <<= 2 {
+
+			rand := ring.RandUniform(mask+1, mask)
+
+			for i := uint64(0); i < slots; i++ {
+				coeffsWantRotateCol.Coeffs[0][i] = coeffs.Coeffs[0][(i+rand)&mask]
+				coeffsWantRotateCol.Coeffs[0][i+slots] = coeffs.Coeffs[0][((i+rand)&mask)+slots]
+			}
+
+			t.Run(fmt.Sprintf("N=%d/T=%d/logQ=%d/logP=%d/bitDecomp=%d/RotateColumns/%d", bfvTest.bfvcontext.N(),
+				bfvTest.bfvcontext.T(),
+				bfvTest.bfvcontext.LogQ(),
 				bfvTest.bfvcontext.LogP(),
 				bitDecomp, rand), func(t *testing.T) {
 
@@ -1043,17 +1720,1435 @@ func test_GaloisEnd(bfvTest *BFVTESTPARAMS, bitDecomps []uint64, t *testing.T) {
 			})
 		}
 
-		t.Run(fmt.Sprintf("N=%d/T=%d/logQ=%d/logP=%d/bitDecomp=%d/RotateRows", bfvTest.bfvcontext.N(),
+		t.Run(fmt.Sprintf("N=%d/T=%d/logQ=%d/logP=%d/bitDecomp=%d/RotateColumnsPow2VsSpecific", bfvTest.bfvcontext.N(),
 			bfvTest.bfvcontext.T(),
 			bfvTest.bfvcontext.LogQ(),
 			bfvTest.bfvcontext.LogP(),
 			bitDecomp), func(t *testing.T) {
 
-			if err := evaluator.RotateRows(ciphertext, rotation_key, receiverCiphertext); err != nil {
-				t.Error(err)
+			// A rotation composed of power of two rotations decrypts to the same plaintext as the rotation with its specific key
+			k := ring.RandUniform(mask+1, mask) | 3
+
+			specificKey := kgen.NewRotationKeys(Sk, bitDecomp, []uint64{k}, nil, false)
+
+			receiverSpecific := bfvContext.NewCiphertext(1)
+
+			if err := evaluator.RotateColumns(ciphertext, k, rotation_key, receiverCiphertext); err != nil {
+				t.Fatal(err)
 			}
 
-			verifyTestVectors(bfvTest, coeffsWantRotateRow, receiverCiphertext, t)
+			if err := evaluator.RotateColumns(ciphertext, k, specificKey, receiverSpecific); err != nil {
+				t.Fatal(err)
+			}
+
+			havePow2 := bfvTest.batchencoder.DecodeUint(bfvTest.decryptor.DecryptNew(receiverCiphertext))
+			haveSpecific := bfvTest.batchencoder.DecodeUint(bfvTest.decryptor.DecryptNew(receiverSpecific))
+
+			if equalslice(havePow2, haveSpecific) != true {
+				t.Errorf("error : the rotation by %d composed of power of two rotations does not match the rotation with its specific key", k)
+			}
 		})
-	}
+
+		t.Run(fmt.Sprintf("N=%d/T=%d/logQ=%d/logP=%d/bitDecomp=%d/CompactRotationKeys", bfvTest.bfvcontext.N(),
+			bfvTest.bfvcontext.T(),
+			bfvTest.bfvcontext.LogQ(),
+			bfvTest.bfvcontext.LogP(),
+			bitDecomp), func(t *testing.T) {
+
+			// The left and right rotations by N/4 share the same Galois element
+			rotKey := kgen.NewRotationKeysPow2(Sk, bitDecomp, true)
+
+			before := distinctSwitchingKeys(rotKey)
+			rotKey.Compact()
+			after := distinctSwitchingKeys(rotKey)
+
+			if after != before-1 {
+				t.Errorf("error : Compact, want %d distinct switching-keys have %d", before-1, after)
+			}
+
+			// Rotations by N/4 to the left and by N/4 + 1 to the right, which use the shared switching-key, and a random rotation
+			for _, k := range []uint64{slots >> 1, (slots >> 1) - 1, ring.RandUniform(mask+1, mask)} {
+
+				for i := uint64(0); i < slots; i++ {
+					coeffsWantRotateCol.Coeffs[0][i] = coeffs.Coeffs[0][(i+k)&mask]
+					coeffsWantRotateCol.Coeffs[0][i+slots] = coeffs.Coeffs[0][((i+k)&mask)+slots]
+				}
+
+				if err := evaluator.RotateColumns(ciphertext, k, rotKey, receiverCiphertext); err != nil {
+					t.Error(err)
+				}
+
+				verifyTestVectors(bfvTest, coeffsWantRotateCol, receiverCiphertext, t)
+			}
+
+			if err := evaluator.RotateRows(ciphertext, rotKey, receiverCiphertext); err != nil {
+				t.Error(err)
+			}
+
+			verifyTestVectors(bfvTest, coeffsWantRotateRow, receiverCiphertext, t)
+		})
+
+		t.Run(fmt.Sprintf("N=%d/T=%d/logQ=%d/logP=%d/bitDecomp=%d/InnerSumBatch", bfvTest.bfvcontext.N(),
+			bfvTest.bfvcontext.T(),
+			bfvTest.bfvcontext.LogQ(),
+			bfvTest.bfvcontext.LogP(),
+			bitDecomp), func(t *testing.T) {
+
+			plaintext := bfvContext.NewPlaintext()
+
+			// [1, 2, ..., 8, 0, ..., 0] sums to 36
+			if err := bfvTest.batchencoder.EncodeUint([]uint64{1, 2, 3, 4, 5, 6, 7, 8}, plaintext); err != nil {
+				t.Fatal(err)
+			}
+
+			ciphertextSum, err := bfvTest.encryptorSk.EncryptNew(plaintext)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if err := evaluator.InnerSumBatch(ciphertextSum, 1, 8, rotation_key, ciphertextSum); err != nil {
+				t.Fatal(err)
+			}
+
+			if sum := bfvTest.batchencoder.DecodeUint(bfvTest.decryptor.DecryptNew(ciphertextSum))[0]; sum != 36 {
+				t.Errorf("error : InnerSumBatch, first slot is %d, want 36", sum)
+			}
+
+			// Strided sums of random values, n not being a power of two
+			for _, batchN := range [][2]int{{2, 5}, {3, 7}} {
+
+				batch, n := uint64(batchN[0]), uint64(batchN[1])
+
+				coeffsWant := bfvContext.contextT.NewPoly()
+				for row := uint64(0); row < 2; row++ {
+					for i := uint64(0); i < slots; i++ {
+						for j := uint64(0); j < n; j++ {
+							coeffsWant.Coeffs[0][row*slots+i] += coeffs.Coeffs[0][row*slots+((i+j*batch)&mask)]
+						}
+						coeffsWant.Coeffs[0][row*slots+i] %= bfvContext.t
+					}
+				}
+
+				if err := evaluator.InnerSumBatch(ciphertext, batchN[0], batchN[1], rotation_key, receiverCiphertext); err != nil {
+					t.Fatal(err)
+				}
+
+				verifyTestVectors(bfvTest, coeffsWant, receiverCiphertext, t)
+			}
+
+			if err := evaluator.InnerSumBatch(ciphertext, 1, int(slots)+1, rotation_key, receiverCiphertext); err == nil {
+				t.Errorf("error : InnerSumBatch accepted n*batch > N/2")
+			}
+		})
+
+		t.Run(fmt.Sprintf("N=%d/T=%d/logQ=%d/logP=%d/bitDecomp=%d/RelinearizeAndRotateColumns", bfvTest.bfvcontext.N(),
+			bfvTest.bfvcontext.T(),
+			bfvTest.bfvcontext.LogQ(),
+			bfvTest.bfvcontext.LogP(),
+			bitDecomp), func(t *testing.T) {
+
+			k := ring.RandUniform(mask+1, mask)
+
+			rlk := kgen.NewRelinKey(Sk, 1, bitDecomp)
+			relinRotKey := kgen.NewRelinRotationKey(Sk, k, bitDecomp)
+
+			coeffs1, _, ciphertext1, _ := newTestVectors(bfvTest)
+
+			ciphertextMul, _ := evaluator.MulNew(ciphertext, ciphertext1)
+
+			coeffsMul := bfvContext.contextT.NewPoly()
+			bfvContext.contextT.MulCoeffs(coeffs, coeffs1, coeffsMul)
+
+			for i := uint64(0); i < slots; i++ {
+				coeffsWantRotateCol.Coeffs[0][i] = coeffsMul.Coeffs[0][(i+k)&mask]
+				coeffsWantRotateCol.Coeffs[0][i+slots] = coeffsMul.Coeffs[0][((i+k)&mask)+slots]
+			}
+
+			// Separate operations
+			ciphertextSeparate, _ := evaluator.RelinearizeNew(ciphertextMul, rlk)
+			if err := evaluator.RotateColumns(ciphertextSeparate, k, rotation_key, ciphertextSeparate); err != nil {
+				t.Error(err)
+			}
+
+			// Fused operation
+			if err := evaluator.RelinearizeAndRotateColumns(ciphertextMul, relinRotKey, receiverCiphertext); err != nil {
+				t.Error(err)
+			}
+
+			verifyTestVectors(bfvTest, coeffsWantRotateCol, ciphertextSeparate, t)
+			verifyTestVectors(bfvTest, coeffsWantRotateCol, receiverCiphertext, t)
+
+			if len(bfvContext.contextQ.Modulus) < 2 {
+				return
+			}
+
+			// Fused operation on a lower level, with a receiver of the top level
+			ciphertextLow := ciphertext.CopyNew().Ciphertext()
+			if err := evaluator.DropLevel(ciphertextLow, 1, ciphertextLow); err != nil {
+				t.Fatal(err)
+			}
+
+			if err := evaluator.DropLevel(ciphertext1, 1, ciphertext1); err != nil {
+				t.Fatal(err)
+			}
+
+			ciphertextMulLow, err := evaluator.MulNew(ciphertextLow, ciphertext1)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			receiverLow := bfvContext.NewCiphertext(1)
+
+			if err := evaluator.RelinearizeAndRotateColumns(ciphertextMulLow, relinRotKey, receiverLow); err != nil {
+				t.Fatal(err)
+			}
+
+			if receiverLow.Level() != ciphertextMulLow.Level() {
+				t.Errorf("error : RelinearizeAndRotateColumns output is at level %d, want %d", receiverLow.Level(), ciphertextMulLow.Level())
+			}
+
+			verifyTestVectors(bfvTest, coeffsWantRotateCol, receiverLow, t)
+		})
+
+		t.Run(fmt.Sprintf("N=%d/T=%d/logQ=%d/logP=%d/bitDecomp=%d/RotateRows", bfvTest.bfvcontext.N(),
+			bfvTest.bfvcontext.T(),
+			bfvTest.bfvcontext.LogQ(),
+			bfvTest.bfvcontext.LogP(),
+			bitDecomp), func(t *testing.T) {
+
+			if err := evaluator.RotateRows(ciphertext, rotation_key, receiverCiphertext); err != nil {
+				t.Error(err)
+			}
+
+			verifyTestVectors(bfvTest, coeffsWantRotateRow, receiverCiphertext, t)
+		})
+	}
+}
+
+// distinctSwitchingKeys returns the number of distinct switching-keys stored by the rotation-keys.
+func distinctSwitchingKeys(rotKey *RotationKeys) int {
+
+	keys := make(map[*SwitchingKey]bool)
+
+	for _, switchkey := range rotKey.evakey_rot_col_L {
+		keys[switchkey] = true
+	}
+
+	for _, switchkey := range rotKey.evakey_rot_col_R {
+		keys[switchkey] = true
+	}
+
+	if rotKey.evakey_rot_row != nil {
+		keys[rotKey.evakey_rot_row] = true
+	}
+
+	return len(keys)
+}
+
+func test_EvaluatorPool(bfvTest *BFVTESTPARAMS, bitDecomps []uint64, t *testing.T) {
+
+	bfvContext := bfvTest.bfvcontext
+	kgen := bfvTest.kgen
+	evaluator := bfvTest.evaluator
+
+	pool := bfvContext.NewEvaluatorPool()
+	evaluatorPooled0 := bfvContext.NewEvaluatorWithPool(pool)
+	evaluatorPooled1 := bfvContext.NewEvaluatorWithPool(pool)
+
+	for _, bitDecomp := range bitDecomps {
+
+		rlk := kgen.NewRelinKey(bfvTest.sk, 1, bitDecomp)
+
+		t.Run(fmt.Sprintf("N=%d/T=%d/logQ=%d/logP=%d/bitDecomp=%d/EvaluatorPool", bfvTest.bfvcontext.N(),
+			bfvTest.bfvcontext.T(),
+			bfvTest.bfvcontext.LogQ(),
+			bfvTest.bfvcontext.LogP(),
+			bitDecomp), func(t *testing.T) {
+
+			coeffs0, _, ciphertext0, _ := newTestVectors(bfvTest)
+			coeffs1, _, ciphertext1, _ := newTestVectors(bfvTest)
+
+			want := bfvContext.NewCiphertext(2)
+			have := bfvContext.NewCiphertext(2)
+
+			// Reference chain with an evaluator owning its pool
+			if err := evaluator.Mul(ciphertext0, ciphertext1, want); err != nil {
+				t.Error(err)
+			}
+			if err := evaluator.Relinearize(want, rlk, want); err != nil {
+				t.Error(err)
+			}
+			if err := evaluator.Add(want, ciphertext1, want); err != nil {
+				t.Error(err)
+			}
+
+			// Same chain, alternating between two evaluators sharing the same pool
+			if err := evaluatorPooled0.Mul(ciphertext0, ciphertext1, have); err != nil {
+				t.Error(err)
+			}
+			if err := evaluatorPooled1.Relinearize(have, rlk, have); err != nil {
+				t.Error(err)
+			}
+			if err := evaluatorPooled0.Add(have, ciphertext1, have); err != nil {
+				t.Error(err)
+			}
+
+			for i := range want.Value() {
+				if bfvContext.contextQ.Equal(want.Value()[i], have.Value()[i]) != true {
+					t.Errorf("error : pooled and non-pooled evaluation do not match")
+					break
+				}
+			}
+
+			bfvContext.contextT.MulCoeffs(coeffs0, coeffs1, coeffs0)
+			bfvContext.contextT.Add(coeffs0, coeffs1, coeffs0)
+
+			verifyTestVectors(bfvTest, coeffs0, have, t)
+		})
+	}
+}
+
+func test_CompressForTransport(bfvTest *BFVTESTPARAMS, t *testing.T) {
+
+	bfvContext := bfvTest.bfvcontext
+	evaluator := bfvTest.evaluator
+
+	t.Run(fmt.Sprintf("N=%d/T=%d/logQ=%d/logP=%d/CompressForTransport", bfvTest.bfvcontext.N(),
+		bfvTest.bfvcontext.T(),
+		bfvTest.bfvcontext.LogQ(),
+		bfvTest.bfvcontext.LogP()), func(t *testing.T) {
+
+		coeffs0, _, ciphertext0, _ := newTestVectors(bfvTest)
+		coeffs1, _, ciphertext1, _ := newTestVectors(bfvTest)
+
+		// A fresh ciphertext and the product of two ciphertexts, which has less budget left
+		product, _ := evaluator.MulNew(ciphertext0, ciphertext1)
+		coeffsProduct := bfvContext.contextT.NewPoly()
+		bfvContext.contextT.MulCoeffs(coeffs0, coeffs1, coeffsProduct)
+
+		limbs := make([]int, 2)
+
+		for k, test := range []struct {
+			coeffs     *ring.Poly
+			ciphertext *Ciphertext
+		}{{coeffs0, ciphertext0}, {coeffsProduct, product}} {
+
+			compressed := evaluator.CompressForTransport(test.ciphertext, bfvTest.sk)
+
+			limbs[k] = len(compressed.Value()[0].Coeffs)
+
+			if limbs[k] >= len(bfvContext.contextQ.Modulus) {
+				t.Errorf("error : CompressForTransport did not drop any modulus")
+			}
+
+			data, err := test.ciphertext.MarshalBinary()
+			if err != nil {
+				t.Error(err)
+			}
+
+			dataCompressed, err := compressed.MarshalBinary()
+			if err != nil {
+				t.Error(err)
+			}
+
+			if len(dataCompressed) >= len(data) {
+				t.Errorf("error : CompressForTransport, the compressed ciphertext is not smaller (%d >= %d bytes)", len(dataCompressed), len(data))
+			}
+
+			decompressed, err := evaluator.DecompressFromTransport(compressed)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			verifyTestVectors(bfvTest, test.coeffs, decompressed, t)
+
+			// The input ciphertext must not be modified
+			verifyTestVectors(bfvTest, test.coeffs, test.ciphertext, t)
+		}
+
+		if limbs[1] < limbs[0] {
+			t.Errorf("error : CompressForTransport, the product was compressed more than the fresh ciphertext")
+		}
+
+		if _, err := evaluator.DecompressFromTransport(bfvContext.NewCiphertextBig(1)); err == nil {
+			t.Errorf("error : DecompressFromTransport accepted a ciphertext with too many limbs")
+		}
+	})
+}
+
+func test_RelinearizeLeveled(bfvTest *BFVTESTPARAMS, t *testing.T) {
+
+	bfvContext := bfvTest.bfvcontext
+	evaluator := bfvTest.evaluator
+
+	// A small bit-decomposition keeps the key-switching noise below the scaling factor of the lowest level
+	bitDecomp := uint64(16)
+
+	rlk := bfvTest.kgen.NewLeveledRelinKey(bfvTest.sk, 1, bitDecomp)
+
+	t.Run(fmt.Sprintf("N=%d/T=%d/logQ=%d/logP=%d/bitDecomp=%d/RelinearizeLeveled", bfvTest.bfvcontext.N(),
+		bfvTest.bfvcontext.T(),
+		bfvTest.bfvcontext.LogQ(),
+		bfvTest.bfvcontext.LogP(),
+		bitDecomp), func(t *testing.T) {
+
+		if rlk.Levels() != len(bfvContext.contextQ.Modulus) {
+			t.Errorf("error : leveled relin key has %d levels, want %d", rlk.Levels(), len(bfvContext.contextQ.Modulus))
+		}
+
+		coeffs0, _, ciphertext0, _ := newTestVectors(bfvTest)
+		coeffs1, _, ciphertext1, _ := newTestVectors(bfvTest)
+
+		bfvContext.contextT.MulCoeffs(coeffs0, coeffs1, coeffs0)
+
+		for level := len(bfvContext.contextQ.Modulus) - 1; level >= 0; level-- {
+
+			product, _ := evaluator.MulNew(ciphertext0, ciphertext1)
+
+			for len(product.Value()[0].Coeffs) > level+1 {
+				product = evaluator.dropLastModulus(product)
+			}
+
+			if err := evaluator.RelinearizeLeveled(product, rlk, product); err != nil {
+				t.Fatal(err)
+			}
+
+			if product.Degree() != 1 {
+				t.Errorf("error : RelinearizeLeveled at level %d, the result is of degree %d", level, product.Degree())
+			}
+
+			decompressed, err := evaluator.DecompressFromTransport(product)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			verifyTestVectors(bfvTest, coeffs0, decompressed, t)
+		}
+
+		if err := evaluator.RelinearizeLeveled(bfvContext.NewCiphertextBig(2), rlk, bfvContext.NewCiphertextBig(1)); err == nil {
+			t.Errorf("error : RelinearizeLeveled accepted a ciphertext with more limbs than the leveled key has levels")
+		}
+	})
+}
+
+func test_AddNoise(bfvTest *BFVTESTPARAMS, t *testing.T) {
+
+	bfvContext := bfvTest.bfvcontext
+
+	t.Run(fmt.Sprintf("N=%d/T=%d/logQ=%d/logP=%d/AddNoise", bfvTest.bfvcontext.N(),
+		bfvTest.bfvcontext.T(),
+		bfvTest.bfvcontext.LogQ(),
+		bfvTest.bfvcontext.LogP()), func(t *testing.T) {
+
+		coeffs, _, ciphertext, _ := newTestVectors(bfvTest)
+
+		for _, bits := range []int{10, 40, 100} {
+
+			budget := bfvTest.decryptor.NoiseBudget(ciphertext)
+
+			bfvContext.AddNoise(ciphertext, bits, bfvTest.sk)
+
+			drop := budget - bfvTest.decryptor.NoiseBudget(ciphertext)
+
+			if drop < bits-2 || drop > bits+2 {
+				t.Errorf("error : AddNoise of %d bits decreased the noise budget by %d bits", bits, drop)
+			}
+
+			verifyTestVectors(bfvTest, coeffs, ciphertext, t)
+		}
+
+		// More noise than the remaining budget
+		bfvContext.AddNoise(ciphertext, int(bfvContext.LogQ()), bfvTest.sk)
+
+		if bfvTest.decryptor.NoiseBudget(ciphertext) != 0 {
+			t.Errorf("error : AddNoise beyond the noise budget, the budget is not exhausted")
+		}
+	})
+}
+
+func test_CiphertextLevel(bfvTest *BFVTESTPARAMS, t *testing.T) {
+
+	bfvContext := bfvTest.bfvcontext
+	evaluator := bfvTest.evaluator
+
+	t.Run(fmt.Sprintf("N=%d/T=%d/logQ=%d/logP=%d/CiphertextLevel", bfvTest.bfvcontext.N(),
+		bfvTest.bfvcontext.T(),
+		bfvTest.bfvcontext.LogQ(),
+		bfvTest.bfvcontext.LogP()), func(t *testing.T) {
+
+		_, _, ciphertext, _ := newTestVectors(bfvTest)
+
+		moduli := bfvContext.contextQ.Modulus
+
+		for level := len(moduli) - 1; level >= 0; level-- {
+
+			if ciphertext.Level() != level {
+				t.Errorf("error : ciphertext level is %d, want %d", ciphertext.Level(), level)
+			}
+
+			if len(ciphertext.Moduli()) != level+1 {
+				t.Fatalf("error : ciphertext at level %d has %d moduli", level, len(ciphertext.Moduli()))
+			}
+
+			for i, qi := range ciphertext.Moduli() {
+				if qi != moduli[i] {
+					t.Errorf("error : modulus %d of the ciphertext at level %d is %d, want %d", i, level, qi, moduli[i])
+				}
+			}
+
+			if level > 0 {
+				ciphertext = evaluator.dropLastModulus(ciphertext)
+			}
+		}
+
+		if copied := ciphertext.CopyNew().Ciphertext(); copied.Level() != 0 || len(copied.Moduli()) != 1 {
+			t.Errorf("error : the copy of a ciphertext does not keep its level")
+		}
+	})
+}
+
+func test_Components(bfvTest *BFVTESTPARAMS, t *testing.T) {
+
+	bfvContext := bfvTest.bfvcontext
+
+	for _, degree := range []uint64{1, 2, 3} {
+
+		t.Run(fmt.Sprintf("N=%d/T=%d/logQ=%d/logP=%d/degree=%d/Components", bfvTest.bfvcontext.N(),
+			bfvTest.bfvcontext.T(),
+			bfvTest.bfvcontext.LogQ(),
+			bfvTest.bfvcontext.LogP(),
+			degree), func(t *testing.T) {
+
+			ciphertext := bfvContext.NewRandomCiphertext(degree)
+
+			components := ciphertext.Components()
+
+			if uint64(len(components)) != degree+1 {
+				t.Errorf("error : Components, want %d components have %d", degree+1, len(components))
+			}
+
+			for i := range components {
+				if components[i] != ciphertext.Value()[i] {
+					t.Errorf("error : Components does not return the polynomials of the ciphertext")
+				}
+			}
+
+			// In place operations on the components must modify the ciphertext
+			for _, pol := range components {
+				pol.Zero()
+			}
+
+			for i := range ciphertext.Value() {
+				if bfvContext.contextQ.Equal(ciphertext.Value()[i], bfvContext.contextQ.NewPoly()) != true {
+					t.Errorf("error : Components in place operation")
+				}
+			}
+
+			// Appending to the components must not write in the ciphertext storage
+			if cap(components) != len(components) {
+				t.Errorf("error : Components append can modify the ciphertext")
+			}
+		})
+	}
+}
+
+func test_LinearTransform(bfvTest *BFVTESTPARAMS, bitDecomps []uint64, t *testing.T) {
+
+	bfvContext := bfvTest.bfvcontext
+	contextT := bfvContext.contextT
+	evaluator := bfvTest.evaluator
+
+	slots := bfvContext.n >> 1
+	mask := slots - 1
+
+	for _, bitDecomp := range bitDecomps {
+
+		rotkey := bfvTest.kgen.NewRotationKeysPow2(bfvTest.sk, bitDecomp, true)
+		rlk := bfvTest.kgen.NewRelinKey(bfvTest.sk, 1, bitDecomp)
+
+		t.Run(fmt.Sprintf("N=%d/T=%d/logQ=%d/logP=%d/bitDecomp=%d/LinearTransform", bfvTest.bfvcontext.N(),
+			bfvTest.bfvcontext.T(),
+			bfvTest.bfvcontext.LogQ(),
+			bfvTest.bfvcontext.LogP(),
+			bitDecomp), func(t *testing.T) {
+
+			coeffs, _, ciphertext, _ := newTestVectors(bfvTest)
+
+			// Sparse matrix whose diagonals span several baby and giant steps
+			diagonals := make(map[int][]uint64)
+			for _, d := range []uint64{0, 1, 3, slots >> 1, (slots >> 1) + 5, slots - 1} {
+				diagonals[int(d)] = contextT.NewUniformPoly().Coeffs[0]
+			}
+
+			// Cleartext matrix-vector product
+			applyMatrix := func(coeffs *ring.Poly) (coeffsWant *ring.Poly) {
+				coeffsWant = contextT.NewPoly()
+				for d, diag := range diagonals {
+					for j := uint64(0); j < bfvContext.n; j++ {
+						row := j &^ mask
+						coeffsWant.Coeffs[0][j] += (diag[j] * coeffs.Coeffs[0][row|((j+uint64(d))&mask)]) % bfvContext.t
+						coeffsWant.Coeffs[0][j] %= bfvContext.t
+					}
+				}
+				return
+			}
+
+			receiverCiphertext, err := evaluator.LinearTransformNew(ciphertext, diagonals, rotkey, nil)
+			if err != nil {
+				t.Error(err)
+			}
+
+			verifyTestVectors(bfvTest, applyMatrix(coeffs), receiverCiphertext, t)
+
+			// Ciphertexts of degree 2 are relinearized before the transform
+			coeffs1, plaintext1, _, _ := newTestVectors(bfvTest)
+			ciphertext2, _ := evaluator.MulNew(ciphertext, plaintext1)
+			ciphertext2, _ = evaluator.MulNew(ciphertext2, ciphertext2)
+
+			for j := range coeffs.Coeffs[0] {
+				coeffs.Coeffs[0][j] = (coeffs.Coeffs[0][j] * coeffs1.Coeffs[0][j]) % bfvContext.t
+				coeffs.Coeffs[0][j] = (coeffs.Coeffs[0][j] * coeffs.Coeffs[0][j]) % bfvContext.t
+			}
+
+			if _, err := evaluator.LinearTransformNew(ciphertext2, diagonals, rotkey, nil); err == nil {
+				t.Errorf("error : LinearTransform of a degree 2 ciphertext without evaluation-key")
+			}
+
+			if err := evaluator.LinearTransform(ciphertext2, diagonals, rotkey, rlk, receiverCiphertext); err != nil {
+				t.Error(err)
+			}
+
+			verifyTestVectors(bfvTest, applyMatrix(coeffs), receiverCiphertext, t)
+
+			if _, err := evaluator.LinearTransformNew(ciphertext, map[int][]uint64{int(slots): diagonals[0]}, rotkey, nil); err == nil {
+				t.Errorf("error : LinearTransform with an out of range diagonal")
+			}
+		})
+	}
+}
+
+func test_NoiseWatcher(bfvTest *BFVTESTPARAMS, t *testing.T) {
+
+	t.Run(fmt.Sprintf("N=%d/T=%d/logQ=%d/logP=%d/NoiseWatcher", bfvTest.bfvcontext.N(),
+		bfvTest.bfvcontext.T(),
+		bfvTest.bfvcontext.LogQ(),
+		bfvTest.bfvcontext.LogP()), func(t *testing.T) {
+
+		bfvContext := bfvTest.bfvcontext
+
+		rlk := bfvTest.kgen.NewRelinKey(bfvTest.sk, 1, 60)
+
+		_, _, ciphertext, _ := newTestVectors(bfvTest)
+
+		// Squares and relinearizes the ciphertext, calling step after each operation
+		stream := func(evaluator *Evaluator, step func(ct *Ciphertext)) {
+			ct := ciphertext.CopyNew().Ciphertext()
+			ct2 := bfvContext.NewCiphertext(2)
+			for i := 0; i < 3; i++ {
+				evaluator.Mul(ct, ct, ct2)
+				step(ct2)
+				evaluator.Relinearize(ct2, rlk, ct)
+				step(ct)
+			}
+		}
+
+		// Noise budget after each operation, without watcher
+		var budgets []int
+		stream(bfvContext.NewEvaluator(), func(ct *Ciphertext) {
+			budgets = append(budgets, bfvTest.decryptor.NoiseBudget(ct))
+		})
+
+		t.Logf("noise budgets : %v", budgets)
+
+		// The watcher must fire from the third operation (the second square) on
+		threshold := budgets[1]
+
+		evaluator := bfvContext.NewEvaluator()
+
+		var fired []int
+		var budgetsFired []int
+		if err := evaluator.SetNoiseWatcher(bfvTest.sk, threshold, func(ct *Ciphertext) {
+			budgetsFired = append(budgetsFired, bfvTest.decryptor.NoiseBudget(ct))
+		}); err != nil {
+			t.Fatal(err)
+		}
+
+		operation := 0
+		stream(evaluator, func(ct *Ciphertext) {
+			if len(budgetsFired) > len(fired) {
+				fired = append(fired, operation)
+			}
+			operation++
+		})
+
+		var want []int
+		for i, budget := range budgets {
+			if budget < threshold {
+				want = append(want, i)
+			}
+		}
+
+		if len(want) == 0 || want[0] < 2 {
+			t.Fatalf("error : the noise budgets %v do not decrease as expected", budgets)
+		}
+
+		if len(fired) != len(want) {
+			t.Fatalf("error : NoiseWatcher fired after the operations %v, want %v", fired, want)
+		}
+
+		for i := range want {
+			if fired[i] != want[i] || budgetsFired[i] != budgets[want[i]] {
+				t.Errorf("error : NoiseWatcher fired after the operations %v (budgets %v), want %v", fired, budgetsFired, want)
+				break
+			}
+		}
+
+		// Removing the watcher
+		evaluator.SetNoiseWatcher(nil, threshold, nil)
+		budgetsFired = nil
+		stream(evaluator, func(ct *Ciphertext) {})
+		if budgetsFired != nil {
+			t.Errorf("error : NoiseWatcher fired once removed")
+		}
+	})
+
+	t.Run(fmt.Sprintf("N=%d/T=%d/logQ=%d/logP=%d/NoiseWatcherRelinearizeVariants", bfvTest.bfvcontext.N(),
+		bfvTest.bfvcontext.T(),
+		bfvTest.bfvcontext.LogQ(),
+		bfvTest.bfvcontext.LogP()), func(t *testing.T) {
+
+		bfvContext := bfvTest.bfvcontext
+
+		_, _, ciphertext, _ := newTestVectors(bfvTest)
+
+		evaluator := bfvContext.NewEvaluator()
+
+		product, err := evaluator.MulNew(ciphertext, ciphertext)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		// With a threshold above any budget, the watcher fires after each operation
+		var fired int
+		if err := evaluator.SetNoiseWatcher(bfvTest.sk, int(bfvContext.LogQ())+1, func(ct *Ciphertext) {
+			fired++
+		}); err != nil {
+			t.Fatal(err)
+		}
+
+		if err := evaluator.RelinearizeAndRotateColumns(product, bfvTest.kgen.NewRelinRotationKey(bfvTest.sk, 1, 60), bfvContext.NewCiphertext(1)); err != nil {
+			t.Fatal(err)
+		}
+
+		if fired != 1 {
+			t.Errorf("error : NoiseWatcher fired %d times after RelinearizeAndRotateColumns, want 1", fired)
+		}
+
+		fired = 0
+
+		if err := evaluator.RelinearizeLeveled(product, bfvTest.kgen.NewLeveledRelinKey(bfvTest.sk, 1, 60), bfvContext.NewCiphertext(1)); err != nil {
+			t.Fatal(err)
+		}
+
+		if fired != 1 {
+			t.Errorf("error : NoiseWatcher fired %d times after RelinearizeLeveled, want 1", fired)
+		}
+	})
+}
+
+func test_NoiseBudget(bfvTest *BFVTESTPARAMS, t *testing.T) {
+
+	t.Run(fmt.Sprintf("N=%d/T=%d/logQ=%d/logP=%d/NoiseBudget", bfvTest.bfvcontext.N(),
+		bfvTest.bfvcontext.T(),
+		bfvTest.bfvcontext.LogQ(),
+		bfvTest.bfvcontext.LogP()), func(t *testing.T) {
+
+		evaluator := bfvTest.evaluator
+
+		rlk := bfvTest.kgen.NewRelinKey(bfvTest.sk, 1, 60)
+
+		_, _, ciphertext, _ := newTestVectors(bfvTest)
+
+		budget := bfvTest.decryptor.NoiseBudget(ciphertext)
+		if budget <= 0 {
+			t.Fatalf("error : NoiseBudget of a fresh encryption is %d", budget)
+		}
+
+		budgets := []int{budget}
+
+		// Squares the ciphertext until its budget is exhausted, the budget must strictly decrease at each multiplication
+		for budget > 0 {
+
+			if err := evaluator.Square(ciphertext, rlk, ciphertext); err != nil {
+				t.Fatal(err)
+			}
+
+			next := bfvTest.decryptor.NoiseBudget(ciphertext)
+			budgets = append(budgets, next)
+
+			if next >= budget {
+				t.Fatalf("error : NoiseBudget does not decrease with the multiplications %v", budgets)
+			}
+
+			budget = next
+		}
+
+		t.Logf("noise budgets : %v", budgets)
+	})
+}
+
+func test_LikelyUnderKey(bfvTest *BFVTESTPARAMS, t *testing.T) {
+
+	t.Run(fmt.Sprintf("N=%d/T=%d/logQ=%d/logP=%d/LikelyUnderKey", bfvTest.bfvcontext.N(),
+		bfvTest.bfvcontext.T(),
+		bfvTest.bfvcontext.LogQ(),
+		bfvTest.bfvcontext.LogP()), func(t *testing.T) {
+
+		_, plaintext, ciphertext, err := newTestVectors(bfvTest)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if ciphertext.LikelyUnderKey(bfvTest.pk, bfvTest.sk, bfvTest.bfvcontext) != true {
+			t.Errorf("error : ciphertext under the expected key rejected")
+		}
+
+		// A ciphertext encrypted under a foreign key-pair
+		skForeign, pkForeign := bfvTest.kgen.NewKeyPair()
+
+		encryptorForeign, err := bfvTest.bfvcontext.NewEncryptorFromPk(pkForeign)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		ciphertextForeign, err := encryptorForeign.EncryptNew(plaintext)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if ciphertextForeign.LikelyUnderKey(bfvTest.pk, bfvTest.sk, bfvTest.bfvcontext) != false {
+			t.Errorf("error : ciphertext under a foreign key accepted")
+		}
+
+		if ciphertextForeign.LikelyUnderKey(pkForeign, skForeign, bfvTest.bfvcontext) != true {
+			t.Errorf("error : ciphertext under the foreign key rejected with the foreign key-pair")
+		}
+
+		// The public-key must match the secret-key
+		if ciphertext.LikelyUnderKey(pkForeign, bfvTest.sk, bfvTest.bfvcontext) != false {
+			t.Errorf("error : public-key of another secret-key accepted")
+		}
+	})
+}
+
+func test_Power(bfvTest *BFVTESTPARAMS, t *testing.T) {
+
+	t.Run(fmt.Sprintf("N=%d/T=%d/logQ=%d/logP=%d/Power", bfvTest.bfvcontext.N(),
+		bfvTest.bfvcontext.T(),
+		bfvTest.bfvcontext.LogQ(),
+		bfvTest.bfvcontext.LogP()), func(t *testing.T) {
+
+		rlk := bfvTest.kgen.NewRelinKey(bfvTest.sk, 1, 60)
+
+		coeffs, _, ciphertext, err := newTestVectors(bfvTest)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		for _, exponent := range []uint64{0, 1, 5} {
+
+			receiver := bfvTest.bfvcontext.NewCiphertext(1)
+
+			if err := bfvTest.evaluator.Power(ciphertext, exponent, rlk, receiver); err != nil {
+				t.Fatal(err)
+			}
+
+			coeffsWant := bfvTest.bfvcontext.contextT.NewPoly()
+			for i := range coeffs.Coeffs[0] {
+				coeffsWant.Coeffs[0][i] = ring.ModExp(coeffs.Coeffs[0][i], exponent, bfvTest.bfvcontext.t)
+			}
+
+			verifyTestVectors(bfvTest, coeffsWant, receiver, t)
+		}
+
+		// In place
+		if err := bfvTest.evaluator.Power(ciphertext, 5, rlk, ciphertext); err != nil {
+			t.Fatal(err)
+		}
+
+		for i := range coeffs.Coeffs[0] {
+			coeffs.Coeffs[0][i] = ring.ModExp(coeffs.Coeffs[0][i], 5, bfvTest.bfvcontext.t)
+		}
+
+		verifyTestVectors(bfvTest, coeffs, ciphertext, t)
+	})
+}
+
+func test_EvaluatePoly(bfvTest *BFVTESTPARAMS, t *testing.T) {
+
+	t.Run(fmt.Sprintf("N=%d/T=%d/logQ=%d/logP=%d/EvaluatePoly", bfvTest.bfvcontext.N(),
+		bfvTest.bfvcontext.T(),
+		bfvTest.bfvcontext.LogQ(),
+		bfvTest.bfvcontext.LogP()), func(t *testing.T) {
+
+		rlk := bfvTest.kgen.NewRelinKey(bfvTest.sk, 1, 60)
+
+		coeffs, _, ciphertext, err := newTestVectors(bfvTest)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		tmod := bfvTest.bfvcontext.t
+
+		// 3 + 2x + x^2
+		poly := []uint64{3, 2, 1}
+
+		for i, x := range coeffs.Coeffs[0] {
+			coeffs.Coeffs[0][i] = (3 + 2*x + (x*x)%tmod) % tmod
+		}
+
+		if err := bfvTest.evaluator.EvaluatePoly(ciphertext, poly, rlk, ciphertext); err != nil {
+			t.Fatal(err)
+		}
+
+		verifyTestVectors(bfvTest, coeffs, ciphertext, t)
+
+		// Constant polynomial, the trailing zero coefficients being ignored
+		if err := bfvTest.evaluator.EvaluatePoly(ciphertext, []uint64{tmod + 7, 0, tmod}, nil, ciphertext); err != nil {
+			t.Fatal(err)
+		}
+
+		for i := range coeffs.Coeffs[0] {
+			coeffs.Coeffs[0][i] = 7
+		}
+
+		verifyTestVectors(bfvTest, coeffs, ciphertext, t)
+	})
+}
+
+func test_DropLevel(bfvTest *BFVTESTPARAMS, t *testing.T) {
+
+	bfvContext := bfvTest.bfvcontext
+	evaluator := bfvTest.evaluator
+
+	t.Run(fmt.Sprintf("N=%d/T=%d/logQ=%d/logP=%d/DropLevel", bfvTest.bfvcontext.N(),
+		bfvTest.bfvcontext.T(),
+		bfvTest.bfvcontext.LogQ(),
+		bfvTest.bfvcontext.LogP()), func(t *testing.T) {
+
+		if len(bfvContext.contextQ.Modulus) < 2 {
+			t.Skip("the moduli chain has a single level")
+		}
+
+		level := len(bfvContext.contextQ.Modulus) - 2
+
+		coeffs0, _, ciphertext0, _ := newTestVectors(bfvTest)
+		coeffs1, _, ciphertext1, _ := newTestVectors(bfvTest)
+
+		receiver := bfvContext.NewCiphertext(1)
+
+		if err := evaluator.DropLevel(ciphertext0, 1, receiver); err != nil {
+			t.Fatal(err)
+		}
+
+		if receiver.Level() != level {
+			t.Errorf("error : DropLevel output is at level %d, want %d", receiver.Level(), level)
+		}
+
+		verifyTestVectors(bfvTest, coeffs0, receiver, t)
+
+		// In place
+		if err := evaluator.DropLevel(ciphertext1, 1, ciphertext1); err != nil {
+			t.Fatal(err)
+		}
+
+		verifyTestVectors(bfvTest, coeffs1, ciphertext1, t)
+
+		// Down to the lowest level, on a receiver already at that level
+		receiverLowest := bfvContext.NewCiphertextAtLevel(1, 0)
+
+		if err := evaluator.DropLevel(ciphertext0, ciphertext0.Level(), receiverLowest); err != nil {
+			t.Fatal(err)
+		}
+
+		verifyTestVectors(bfvTest, coeffs0, receiverLowest, t)
+
+		// Operations at the lower level
+		sum, err := evaluator.AddNew(receiver, ciphertext1)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		coeffsSum := bfvContext.contextT.NewPoly()
+		bfvContext.contextT.Add(coeffs0, coeffs1, coeffsSum)
+
+		if sum.Level() != level {
+			t.Errorf("error : Add output is at level %d, want %d", sum.Level(), level)
+		}
+
+		verifyTestVectors(bfvTest, coeffsSum, sum, t)
+
+		rlk := bfvTest.kgen.NewLeveledRelinKey(bfvTest.sk, 1, 16)
+
+		product := bfvContext.NewCiphertextAtLevel(2, level)
+
+		if err := evaluator.Mul(receiver, ciphertext1, product); err != nil {
+			t.Fatal(err)
+		}
+
+		if err := evaluator.RelinearizeLeveled(product, rlk, product); err != nil {
+			t.Fatal(err)
+		}
+
+		bfvContext.contextT.MulCoeffs(coeffs0, coeffs1, coeffs0)
+
+		if product.Level() != level {
+			t.Errorf("error : Mul output is at level %d, want %d", product.Level(), level)
+		}
+
+		verifyTestVectors(bfvTest, coeffs0, product, t)
+
+		// Mixed levels
+		_, _, ciphertextTop, _ := newTestVectors(bfvTest)
+
+		if _, err := evaluator.MulNew(ciphertextTop, ciphertext1); err == nil {
+			t.Errorf("error : Mul accepted operands at different levels")
+		}
+
+		if err := evaluator.DropLevel(ciphertextTop, ciphertextTop.Level()+1, ciphertextTop); err == nil {
+			t.Errorf("error : DropLevel accepted to drop more levels than the ciphertext has")
+		}
+	})
+
+	t.Run(fmt.Sprintf("N=%d/T=%d/logQ=%d/logP=%d/DropLevelThenEvaluate", bfvTest.bfvcontext.N(),
+		bfvTest.bfvcontext.T(),
+		bfvTest.bfvcontext.LogQ(),
+		bfvTest.bfvcontext.LogP()), func(t *testing.T) {
+
+		if len(bfvContext.contextQ.Modulus) < 2 {
+			t.Skip("the moduli chain has a single level")
+		}
+
+		level := len(bfvContext.contextQ.Modulus) - 2
+
+		rlk := bfvTest.kgen.NewRelinKey(bfvTest.sk, 1, 60)
+
+		coeffs0, plaintext0, ciphertext0, err := newTestVectors(bfvTest)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		coeffs1, _, ciphertext1, err := newTestVectors(bfvTest)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if err := evaluator.DropLevel(ciphertext0, 1, ciphertext0); err != nil {
+			t.Fatal(err)
+		}
+
+		if err := evaluator.DropLevel(ciphertext1, 1, ciphertext1); err != nil {
+			t.Fatal(err)
+		}
+
+		// Mul then Relinearize with the evaluation key of the top level
+		product, err := evaluator.MulNew(ciphertext0, ciphertext1)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		relinearized, err := evaluator.RelinearizeNew(product, rlk)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		coeffsWant := bfvContext.contextT.NewPoly()
+		bfvContext.contextT.MulCoeffs(coeffs0, coeffs1, coeffsWant)
+
+		if relinearized.Level() != level || relinearized.Degree() != 1 {
+			t.Errorf("error : Relinearize output is at level %d and degree %d, want %d and 1", relinearized.Level(), relinearized.Degree(), level)
+		}
+
+		verifyTestVectors(bfvTest, coeffsWant, relinearized, t)
+
+		// Square and Power on a receiver of the top level
+		receiver := bfvContext.NewCiphertext(1)
+
+		if err := evaluator.Square(ciphertext0, rlk, receiver); err != nil {
+			t.Fatal(err)
+		}
+
+		bfvContext.contextT.MulCoeffs(coeffs0, coeffs0, coeffsWant)
+
+		verifyTestVectors(bfvTest, coeffsWant, receiver, t)
+
+		if err := evaluator.Power(ciphertext0, 3, rlk, receiver); err != nil {
+			t.Fatal(err)
+		}
+
+		bfvContext.contextT.MulCoeffs(coeffsWant, coeffs0, coeffsWant)
+
+		verifyTestVectors(bfvTest, coeffsWant, receiver, t)
+
+		// Plaintext of the top level
+		sum, err := evaluator.AddNew(ciphertext0, plaintext0)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		bfvContext.contextT.Add(coeffs0, coeffs0, coeffsWant)
+
+		verifyTestVectors(bfvTest, coeffsWant, sum, t)
+
+		product, err = evaluator.MulNew(ciphertext1, plaintext0)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		bfvContext.contextT.MulCoeffs(coeffs0, coeffs1, coeffsWant)
+
+		verifyTestVectors(bfvTest, coeffsWant, product, t)
+
+		// Evaluation key of a lower level
+		rlkLowest := bfvTest.kgen.NewLeveledRelinKey(bfvTest.sk, 1, 60).Level(0)
+
+		product, err = evaluator.MulNew(ciphertext0, ciphertext1)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if err := evaluator.Relinearize(product, rlkLowest, product); err == nil {
+			t.Errorf("error : Relinearize accepted an evaluation key below the level of the ciphertext")
+		}
+	})
+
+	t.Run(fmt.Sprintf("N=%d/T=%d/logQ=%d/logP=%d/DropLevelThenRotate", bfvTest.bfvcontext.N(),
+		bfvTest.bfvcontext.T(),
+		bfvTest.bfvcontext.LogQ(),
+		bfvTest.bfvcontext.LogP()), func(t *testing.T) {
+
+		if len(bfvContext.contextQ.Modulus) < 2 {
+			t.Skip("the moduli chain has a single level")
+		}
+
+		level := len(bfvContext.contextQ.Modulus) - 2
+
+		slots := bfvContext.n >> 1
+		mask := slots - 1
+		t0 := bfvContext.contextT.Modulus[0]
+
+		coeffs, _, ciphertext, err := newTestVectors(bfvTest)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if err := evaluator.DropLevel(ciphertext, 1, ciphertext); err != nil {
+			t.Fatal(err)
+		}
+
+		rotkey := bfvTest.kgen.NewRotationKeysPow2(bfvTest.sk, 60, true)
+
+		coeffsWant := bfvContext.contextT.NewPoly()
+
+		rotated := func(k uint64) *ring.Poly {
+			for i := uint64(0); i < slots; i++ {
+				coeffsWant.Coeffs[0][i] = coeffs.Coeffs[0][(i+k)&mask]
+				coeffsWant.Coeffs[0][i+slots] = coeffs.Coeffs[0][((i+k)&mask)+slots]
+			}
+			return coeffsWant
+		}
+
+		// Receivers of the top level are truncated to the level of the input
+		receiver := bfvContext.NewCiphertext(1)
+
+		for _, k := range []uint64{1, 3} {
+
+			if err := evaluator.RotateColumns(ciphertext, k, rotkey, receiver); err != nil {
+				t.Fatal(err)
+			}
+
+			if receiver.Level() != level {
+				t.Errorf("error : RotateColumns output is at level %d, want %d", receiver.Level(), level)
+			}
+
+			verifyTestVectors(bfvTest, rotated(k), receiver, t)
+		}
+
+		specificKey := bfvTest.kgen.NewRotationKeys(bfvTest.sk, 60, []uint64{5}, nil, false)
+
+		if err := evaluator.RotateColumns(ciphertext, 5, specificKey, receiver); err != nil {
+			t.Fatal(err)
+		}
+
+		verifyTestVectors(bfvTest, rotated(5), receiver, t)
+
+		receiver = bfvContext.NewCiphertext(1)
+
+		if err := evaluator.RotateRows(ciphertext, rotkey, receiver); err != nil {
+			t.Fatal(err)
+		}
+
+		coeffsWant.Coeffs[0] = append(append([]uint64{}, coeffs.Coeffs[0][slots:]...), coeffs.Coeffs[0][:slots]...)
+
+		verifyTestVectors(bfvTest, coeffsWant, receiver, t)
+
+		// InnerSum and InnerSumBatch go through the column and row rotations
+		receiver = bfvContext.NewCiphertext(1)
+
+		if err := evaluator.InnerSum(ciphertext, rotkey, receiver); err != nil {
+			t.Fatal(err)
+		}
+
+		var sum uint64
+		for _, c := range coeffs.Coeffs[0] {
+			sum = (sum + c) % t0
+		}
+
+		for i := range coeffsWant.Coeffs[0] {
+			coeffsWant.Coeffs[0][i] = sum
+		}
+
+		verifyTestVectors(bfvTest, coeffsWant, receiver, t)
+
+		receiver = bfvContext.NewCiphertext(1)
+
+		if err := evaluator.InnerSumBatch(ciphertext, 1, 2, rotkey, receiver); err != nil {
+			t.Fatal(err)
+		}
+
+		for i := uint64(0); i < slots; i++ {
+			coeffsWant.Coeffs[0][i] = (coeffs.Coeffs[0][i] + coeffs.Coeffs[0][(i+1)&mask]) % t0
+			coeffsWant.Coeffs[0][i+slots] = (coeffs.Coeffs[0][i+slots] + coeffs.Coeffs[0][((i+1)&mask)+slots]) % t0
+		}
+
+		verifyTestVectors(bfvTest, coeffsWant, receiver, t)
+
+		// LinearTransform with the single diagonal of ones at index 1 rotates the columns by one position
+		ones := make([]uint64, bfvContext.n)
+		for i := range ones {
+			ones[i] = 1
+		}
+
+		transformed, err := evaluator.LinearTransformNew(ciphertext, map[int][]uint64{1: ones}, rotkey, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		verifyTestVectors(bfvTest, rotated(1), transformed, t)
+
+		// SwitchKeys to a new secret-key
+		skNew := bfvTest.kgen.NewSecretKey()
+
+		decryptorSkNew, err := bfvContext.NewDecryptor(skNew)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		switched, err := evaluator.SwitchKeysNew(ciphertext, bfvTest.kgen.NewSwitchingKey(bfvTest.sk, skNew, 60))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if equalslice(coeffs.Coeffs[0], bfvTest.batchencoder.DecodeUint(decryptorSkNew.DecryptNew(switched))) != true {
+			t.Errorf("error : SwitchKeys of a dropped level ciphertext")
+		}
+
+		// A receiver below the level of the input is rejected
+		if err := evaluator.RotateRows(ciphertext, rotkey, bfvContext.NewCiphertextAtLevel(1, level-1)); level > 0 && err == nil {
+			t.Errorf("error : RotateRows accepted a receiver below the level of the ciphertext")
+		}
+	})
+}
+
+func test_ShallowCopy(bfvTest *BFVTESTPARAMS, t *testing.T) {
+
+	t.Run(fmt.Sprintf("N=%d/T=%d/logQ=%d/logP=%d/ShallowCopy", bfvTest.bfvcontext.N(),
+		bfvTest.bfvcontext.T(),
+		bfvTest.bfvcontext.LogQ(),
+		bfvTest.bfvcontext.LogP()), func(t *testing.T) {
+
+		goroutines := 4
+
+		errs := make(chan error, goroutines)
+
+		var wg sync.WaitGroup
+
+		for g := 0; g < goroutines; g++ {
+
+			wg.Add(1)
+
+			// Each goroutine owns a copy of the bfvcontext and the objects created from it
+			go func(bfvContext *BfvContext) {
+
+				defer wg.Done()
+
+				batchencoder, err := bfvContext.NewBatchEncoder()
+				if err != nil {
+					errs <- err
+					return
+				}
+
+				encryptor, err := bfvContext.NewEncryptorFromPk(bfvTest.pk)
+				if err != nil {
+					errs <- err
+					return
+				}
+
+				decryptor, err := bfvContext.NewDecryptor(bfvTest.sk)
+				if err != nil {
+					errs <- err
+					return
+				}
+
+				evaluator := bfvContext.NewEvaluator()
+
+				for i := 0; i < 4; i++ {
+
+					coeffs := bfvContext.contextT.NewUniformPoly()
+
+					plaintext := bfvContext.NewPlaintext()
+					if err := batchencoder.EncodeUint(coeffs.Coeffs[0], plaintext); err != nil {
+						errs <- err
+						return
+					}
+
+					ciphertext, err := encryptor.EncryptNew(plaintext)
+					if err != nil {
+						errs <- err
+						return
+					}
+
+					if err := evaluator.Add(ciphertext, ciphertext, ciphertext); err != nil {
+						errs <- err
+						return
+					}
+
+					bfvContext.contextT.Add(coeffs, coeffs, coeffs)
+
+					if !equalslice(coeffs.Coeffs[0], batchencoder.DecodeUint(decryptor.DecryptNew(ciphertext))) {
+						errs <- fmt.Errorf("decryption error on a copy of the bfvcontext")
+						return
+					}
+				}
+			}(bfvTest.bfvcontext.ShallowCopy())
+		}
+
+		wg.Wait()
+		close(errs)
+
+		for err := range errs {
+			t.Error(err)
+		}
+	})
+}
+
+func test_EncryptWithSeed(bfvTest *BFVTESTPARAMS, t *testing.T) {
+
+	bfvContext := bfvTest.bfvcontext
+
+	coeffs, plaintext, _, _ := newTestVectors(bfvTest)
+
+	equal := func(ct0, ct1 *Ciphertext) bool {
+		for i := range ct0.value {
+			if bfvContext.contextQ.Equal(ct0.value[i], ct1.value[i]) != true {
+				return false
+			}
+		}
+		return true
+	}
+
+	for _, encryptor := range []struct {
+		name      string
+		encryptor *Encryptor
+	}{{"Pk", bfvTest.encryptorPk}, {"Sk", bfvTest.encryptorSk}} {
+
+		t.Run(fmt.Sprintf("N=%d/T=%d/logQ=%d/logP=%d/EncryptWithSeed/%s", bfvTest.bfvcontext.N(),
+			bfvTest.bfvcontext.T(),
+			bfvTest.bfvcontext.LogQ(),
+			bfvTest.bfvcontext.LogP(),
+			encryptor.name), func(t *testing.T) {
+
+			ciphertext0 := bfvContext.NewCiphertext(1)
+			ciphertext1 := bfvContext.NewCiphertext(1)
+			ciphertext2 := bfvContext.NewCiphertext(1)
+
+			if err := encryptor.encryptor.EncryptWithSeed(plaintext, []byte("seed"), ciphertext0); err != nil {
+				t.Fatal(err)
+			}
+
+			if err := encryptor.encryptor.EncryptWithSeed(plaintext, []byte("seed"), ciphertext1); err != nil {
+				t.Fatal(err)
+			}
+
+			if err := encryptor.encryptor.EncryptWithSeed(plaintext, []byte("another seed"), ciphertext2); err != nil {
+				t.Fatal(err)
+			}
+
+			if equal(ciphertext0, ciphertext1) != true {
+				t.Errorf("error : the same seed produces different ciphertexts")
+			}
+
+			if equal(ciphertext0, ciphertext2) {
+				t.Errorf("error : different seeds produce the same ciphertext")
+			}
+
+			verifyTestVectors(bfvTest, coeffs, ciphertext0, t)
+			verifyTestVectors(bfvTest, coeffs, ciphertext2, t)
+		})
+	}
+}
+
+func test_DecryptMany(bfvTest *BFVTESTPARAMS, t *testing.T) {
+
+	bfvContext := bfvTest.bfvcontext
+
+	t.Run(fmt.Sprintf("N=%d/T=%d/logQ=%d/logP=%d/DecryptMany", bfvTest.bfvcontext.N(),
+		bfvTest.bfvcontext.T(),
+		bfvTest.bfvcontext.LogQ(),
+		bfvTest.bfvcontext.LogP()), func(t *testing.T) {
+
+		count := 8
+
+		coeffs := make([]*ring.Poly, count)
+		ciphertexts := make([]*Ciphertext, count)
+
+		for i := range ciphertexts {
+
+			var err error
+			if coeffs[i], _, ciphertexts[i], err = newTestVectors(bfvTest); err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		// A ciphertext at a lower level
+		if err := bfvTest.evaluator.DropLevel(ciphertexts[count-1], 1, ciphertexts[count-1]); err != nil {
+			t.Fatal(err)
+		}
+
+		plaintexts := make([]*Plaintext, count)
+		for i := range plaintexts {
+			plaintexts[i] = bfvContext.NewPlaintext()
+		}
+
+		if err := bfvTest.decryptor.DecryptMany(ciphertexts, plaintexts); err != nil {
+			t.Fatal(err)
+		}
+
+		plaintextsNew := bfvTest.decryptor.DecryptManyNew(ciphertexts)
+
+		for i := range ciphertexts {
+
+			want := bfvTest.decryptor.DecryptNew(ciphertexts[i])
+
+			if bfvContext.contextQ.Equal(want.value, plaintexts[i].value) != true || bfvContext.contextQ.Equal(want.value, plaintextsNew[i].value) != true {
+				t.Errorf("error : DecryptMany does not match Decrypt for the ciphertext %d", i)
+			}
+
+			verifyTestVectors(bfvTest, coeffs[i], plaintextsNew[i], t)
+		}
+
+		if bfvTest.decryptor.DecryptMany(ciphertexts, plaintexts[1:]) == nil {
+			t.Errorf("error : DecryptMany accepted fewer plaintexts than ciphertexts")
+		}
+	})
 }
@@ -1,6 +1,7 @@
 package bfv
 
 import (
+	"encoding/base64"
 	"errors"
 	"github.com/ldsec/lattigo/ring"
 	"math/bits"
@@ -19,10 +20,32 @@ func (bfvcontext *BfvContext) NewCiphertext(degree uint64) *Ciphertext {
 		ciphertext.value[i] = bfvcontext.contextQ.NewPoly()
 	}
 	ciphertext.isNTT = false
+	ciphertext.moduli = bfvcontext.contextQ.Modulus
 
 	return ciphertext
 }
 
+// Components returns the polynomials of the target ciphertext, one per component, regardless of its degree.
+// The polynomials are not copied, but appending to the returned slice does not modify the ciphertext.
+func (ciphertext *Ciphertext) Components() []*ring.Poly {
+	return ciphertext.value[:len(ciphertext.value):len(ciphertext.value)]
+}
+
+// Level returns the level of the target ciphertext, i.e. its number of active moduli minus one. A ciphertext of the
+// bfvcontext is at the level len(Q)-1, and each modulus dropped (see DropLevel and CompressForTransport) decreases its level by one.
+func (ciphertext *Ciphertext) Level() int {
+	return len(ciphertext.value[0].Coeffs) - 1
+}
+
+// Moduli returns the active moduli q_0, ..., q_l of the target ciphertext, l being its level. The returned slice must not
+// be modified. Returns nil if the ciphertext was not created by a bfvcontext and its moduli chain is thus unknown.
+func (ciphertext *Ciphertext) Moduli() []uint64 {
+	if ciphertext.moduli == nil {
+		return nil
+	}
+	return ciphertext.moduli[:ciphertext.Level()+1]
+}
+
 // NewCiphertextBig creates a new empty ciphertext of degree degree in the extended ciphertext context (Q + P).
 func (bfvcontext *BfvContext) NewCiphertextBig(degree uint64) *Ciphertext {
 	ciphertext := &Ciphertext{&bfvElement{}}
@@ -31,6 +54,7 @@ func (bfvcontext *BfvContext) NewCiphertextBig(degree uint64) *Ciphertext {
 		ciphertext.value[i] = bfvcontext.contextQP.NewPoly()
 	}
 	ciphertext.isNTT = false
+	ciphertext.moduli = bfvcontext.contextQP.Modulus
 
 	return ciphertext
 }
@@ -43,6 +67,7 @@ func (bfvcontext *BfvContext) NewRandomCiphertext(degree uint64) *Ciphertext {
 		ciphertext.value[i] = bfvcontext.contextQ.NewUniformPoly()
 	}
 	ciphertext.isNTT = false
+	ciphertext.moduli = bfvcontext.contextQ.Modulus
 
 	return ciphertext
 }
@@ -88,15 +113,25 @@ func (ciphertext *Ciphertext) MarshalBinary() ([]byte, error) {
 
 // UnMarshalBinary decodes a previously marshaled ciphertext on the target ciphertext.
 // The target ciphertext must be of the appropriate format and size, it can be created with the
-// methode NewCiphertext(uint64).
+// methode NewCiphertext(uint64). Returns an error if the data does not match the ring degree, the degree or
+// the number of moduli of the target ciphertext, or if a coefficient is not reduced modulo its modulus, in which
+// case the target ciphertext is left unchanged.
 func (ciphertext *Ciphertext) UnMarshalBinary(data []byte) error {
 
-	N := uint64(1 << data[0])
+	if len(data) < 3 {
+		return errors.New("cannot unmarshal ciphertext -> invalid ciphertext encoding (data too short)")
+	}
+
+	N := uint64(len(ciphertext.Value()[0].Coeffs[0]))
 	level := uint64(data[1])
 	degree := uint64(data[2])
 
 	pointer := uint64(3)
 
+	if data[0] >= 64 || uint64(1)<<data[0] != N {
+		return errors.New("cannot unmarshal ciphertext -> ring degree does not match ciphertext ring degree")
+	}
+
 	if ciphertext.Degree() != degree {
 		return errors.New("cannot unmarshal ciphertext -> invalid ciphertext encoding (unexpected degree)")
 	}
@@ -109,9 +144,119 @@ func (ciphertext *Ciphertext) UnMarshalBinary(data []byte) error {
 		return errors.New("cannot unmarshal ciphertext -> invalid ciphertext encoding (unexpected data length)")
 	}
 
-	for x := uint64(0); x < degree+1; x++ {
-		pointer, _ = ring.DecodeCoeffs(pointer, N, level, ciphertext.Value()[x].Coeffs, data)
+	moduli := ciphertext.Moduli()
+	if moduli == nil {
+		return errors.New("cannot unmarshal ciphertext -> unknown moduli chain")
+	}
+
+	// Decodes on scratch coefficients first, so that the receiver is not corrupted if the data is rejected
+	coeffs := make([][][]uint64, degree+1)
+	for x := range coeffs {
+		coeffs[x] = make([][]uint64, level)
+		pointer, _ = ring.DecodeCoeffsNew(pointer, N, level, coeffs[x], data)
+	}
+
+	for x := range coeffs {
+		for i, qi := range moduli {
+			for j := uint64(0); j < N; j++ {
+				if coeffs[x][i][j] >= qi {
+					return errors.New("cannot unmarshal ciphertext -> coefficients are not reduced")
+				}
+			}
+		}
+	}
+
+	for x := range coeffs {
+		for i := range coeffs[x] {
+			copy(ciphertext.Value()[x].Coeffs[i], coeffs[x][i])
+		}
 	}
 
 	return nil
 }
+
+// String returns the base64 encoding of the binary representation of the target ciphertext (see MarshalBinary).
+// It is intended for debugging and logging purposes. Returns an empty string if the ciphertext cannot be marshaled
+// (for example if it is in the NTT domain).
+func (ciphertext *Ciphertext) String() string {
+
+	data, err := ciphertext.MarshalBinary()
+	if err != nil {
+		return ""
+	}
+
+	return base64.StdEncoding.EncodeToString(data)
+}
+
+// ParseCiphertext decodes a ciphertext previously encoded with the method String and returns it on a newly created
+// ciphertext of the target bfvcontext. Returns an error if a coefficient is not reduced modulo its modulus.
+func (bfvcontext *BfvContext) ParseCiphertext(s string) (ciphertext *Ciphertext, err error) {
+
+	var data []byte
+
+	if data, err = base64.StdEncoding.DecodeString(s); err != nil {
+		return nil, err
+	}
+
+	if len(data) < 3 {
+		return nil, errors.New("cannot parse ciphertext -> invalid ciphertext encoding (data too short)")
+	}
+
+	if uint64(1<<data[0]) != bfvcontext.n {
+		return nil, errors.New("cannot parse ciphertext -> ring degree does not match bfvcontext ring degree")
+	}
+
+	ciphertext = bfvcontext.NewCiphertext(uint64(data[2]))
+
+	if err = ciphertext.UnMarshalBinary(data); err != nil {
+		return nil, err
+	}
+
+	return ciphertext, nil
+}
+
+// LikelyUnderKey is a heuristic check that the target ciphertext was encrypted under the public-key pk, whose secret-key sk is
+// known, e.g. to detect ciphertexts mixed up between several key-pairs. It returns true if pk is a public-key of sk, i.e. if
+// pk[0] + pk[1]*sk is small, and if the ciphertext decrypts under sk with a positive noise budget (see Decryptor.NoiseBudget).
+// The phase of a ciphertext encrypted under another key is uniform, and thus leaves no noise budget, except with negligible
+// probability. A false negative is returned for a ciphertext whose noise budget is exhausted, and a false positive for a
+// ciphertext encrypted under another public-key of the same secret-key. Returns false if the ciphertext is in the NTT domain
+// or is not at the level of the bfvcontext.
+func (ciphertext *Ciphertext) LikelyUnderKey(pk *PublicKey, sk *SecretKey, bfvcontext *BfvContext) bool {
+
+	context := bfvcontext.contextQ
+
+	if ciphertext.IsNTT() || ciphertext.Level() != len(context.Modulus)-1 {
+		return false
+	}
+
+	for _, pol := range []*ring.Poly{pk.pk[0], pk.pk[1], sk.sk} {
+		if pol.GetLenModuli() != len(context.Modulus) || pol.GetDegree() != int(context.N) {
+			return false
+		}
+	}
+
+	decryptor, err := bfvcontext.NewDecryptor(sk)
+	if err != nil {
+		return false
+	}
+
+	// pk[0] + pk[1]*sk = -e
+	e := pk.pk[0].CopyNew()
+	context.MulCoeffsMontgomeryAndAdd(pk.pk[1], sk.sk, e)
+	context.InvNTT(e, e)
+
+	coeffsBigint := make([]*ring.Int, context.N)
+
+	context.PolyToBigint(e, coeffsBigint)
+
+	// A public-key of sk has an error of a few bits, a public-key of another secret-key a uniform one
+	for i := range coeffsBigint {
+		coeffsBigint[i].Center(context.ModulusBigint)
+		if coeffsBigint[i].Value.BitLen() > context.ModulusBigint.Value.BitLen()>>1 {
+			return false
+		}
+	}
+
+	return decryptor.NoiseBudget(ciphertext) > 0
+}
@@ -7,9 +7,10 @@ import (
 
 // Decryptor is a structure used to decrypt ciphertext. It stores the secret-key.
 type Decryptor struct {
-	bfvcontext *BfvContext
-	sk         *SecretKey
-	polypool   *ring.Poly
+	bfvcontext   *BfvContext
+	sk           *SecretKey
+	polypool     *ring.Poly
+	batchencoder *BatchEncoder
 }
 
 // NewDecryptor creates a new Decryptor from the target bfvcontext with the secret-key given as input.
@@ -40,24 +41,225 @@ func (decryptor *Decryptor) DecryptNew(ciphertext *Ciphertext) (plaintext *Plain
 	return
 }
 
-// Decrypt decrypts the input ciphertext and returns the result on the provided receiver plaintext.
+// Decrypt decrypts the input ciphertext and returns the result on the provided receiver plaintext. A ciphertext at a lower level
+// (see Evaluator.DropLevel) is decrypted modulo q_0 * ... * q_l, l being its level, and the result is lifted to the modulus Q of
+// the bfvcontext, so that the plaintext is decoded as usual.
 func (decryptor *Decryptor) Decrypt(ciphertext *Ciphertext, plaintext *Plaintext) {
 
-	decryptor.bfvcontext.contextQ.NTT(ciphertext.value[ciphertext.Degree()], plaintext.value)
+	level := ciphertext.Level()
+	if level > len(decryptor.bfvcontext.contextQLevels)-1 {
+		level = len(decryptor.bfvcontext.contextQLevels) - 1
+	}
+
+	context := decryptor.bfvcontext.contextQLevels[level]
+
+	ptLevel, skLevel, pool := plaintext.value, decryptor.sk.sk, decryptor.polypool
+
+	if level < len(decryptor.bfvcontext.contextQ.Modulus)-1 {
+		ptLevel = &ring.Poly{Coeffs: plaintext.value.Coeffs[:level+1]}
+		skLevel = &ring.Poly{Coeffs: decryptor.sk.sk.Coeffs[:level+1]}
+		pool = &ring.Poly{Coeffs: decryptor.polypool.Coeffs[:level+1]}
+	}
+
+	context.NTT(ciphertext.value[ciphertext.Degree()], ptLevel)
 
 	for i := uint64(ciphertext.Degree()); i > 0; i-- {
-		decryptor.bfvcontext.contextQ.MulCoeffsMontgomery(plaintext.value, decryptor.sk.sk, plaintext.value)
-		decryptor.bfvcontext.contextQ.NTT(ciphertext.value[i-1], decryptor.polypool)
-		decryptor.bfvcontext.contextQ.Add(plaintext.value, decryptor.polypool, plaintext.value)
+		context.MulCoeffsMontgomery(ptLevel, skLevel, ptLevel)
+		context.NTT(ciphertext.value[i-1], pool)
+		context.Add(ptLevel, pool, ptLevel)
 
 		if i&7 == 7 {
-			decryptor.bfvcontext.contextQ.Reduce(plaintext.value, plaintext.value)
+			context.Reduce(ptLevel, ptLevel)
 		}
 	}
 
 	if (ciphertext.Degree())&7 != 7 {
-		decryptor.bfvcontext.contextQ.Reduce(plaintext.value, plaintext.value)
+		context.Reduce(ptLevel, ptLevel)
+	}
+
+	context.InvNTT(ptLevel, ptLevel)
+
+	if level < len(decryptor.bfvcontext.contextQ.Modulus)-1 {
+		decryptor.bfvcontext.liftToQ(plaintext.value, level)
+	}
+}
+
+// DecryptManyNew decrypts the input ciphertexts and returns the results on new plaintexts, identical to the ones returned by
+// DecryptNew. The plaintexts are allocated at once and the scratch memory of the decryptor is reused across the batch.
+func (decryptor *Decryptor) DecryptManyNew(ciphertexts []*Ciphertext) (plaintexts []*Plaintext) {
+
+	n := decryptor.bfvcontext.n
+	limbs := uint64(len(decryptor.bfvcontext.contextQ.Modulus))
+
+	count := uint64(len(ciphertexts))
+
+	coeffs := make([]uint64, count*limbs*n)
+	rows := make([][]uint64, count*limbs)
+	polys := make([]ring.Poly, count)
+	values := make([]*ring.Poly, count)
+	elements := make([]bfvElement, count)
+	structs := make([]Plaintext, count)
+
+	plaintexts = make([]*Plaintext, count)
+
+	for i := uint64(0); i < count; i++ {
+
+		for j := uint64(0); j < limbs; j++ {
+			rows[i*limbs+j] = coeffs[(i*limbs+j)*n : (i*limbs+j+1)*n : (i*limbs+j+1)*n]
+		}
+
+		polys[i].Coeffs = rows[i*limbs : (i+1)*limbs : (i+1)*limbs]
+
+		values[i] = &polys[i]
+		elements[i].value = values[i : i+1 : i+1]
+
+		structs[i] = Plaintext{&elements[i], values[i]}
+		plaintexts[i] = &structs[i]
+	}
+
+	decryptor.DecryptMany(ciphertexts, plaintexts)
+
+	return plaintexts
+}
+
+// DecryptMany decrypts the input ciphertexts and returns the results on the provided receiver plaintexts, the i-th ciphertext
+// on the i-th plaintext, identical to the ones of Decrypt. The scratch memory of the decryptor is reused across the batch.
+// Returns an error if the number of ciphertexts and of plaintexts differ.
+func (decryptor *Decryptor) DecryptMany(ciphertexts []*Ciphertext, plaintexts []*Plaintext) error {
+
+	if len(ciphertexts) != len(plaintexts) {
+		return errors.New("cannot decrypt many -> the number of ciphertexts and of plaintexts differ")
+	}
+
+	for i := range ciphertexts {
+		decryptor.Decrypt(ciphertexts[i], plaintexts[i])
+	}
+
+	return nil
+}
+
+// NoiseBudget returns the invariant noise budget of the input ciphertext, in bits, i.e. the number of bits by which its noise can
+// still grow before the decryption fails. It is computed as log2(Q) - log2(||[t * (ct[0] + ct[1]*s + ... + ct[d]*s^d)]_Q||) - 1,
+// and a budget of 0 means that the ciphertext cannot be correctly decrypted anymore.
+func (decryptor *Decryptor) NoiseBudget(ciphertext *Ciphertext) int {
+
+	context := decryptor.bfvcontext.contextQ
+
+	phase := decryptor.bfvcontext.NewPlaintext()
+
+	decryptor.Decrypt(ciphertext, phase)
+
+	context.MulScalar(phase.value, decryptor.bfvcontext.t, phase.value)
+
+	coeffsBigint := make([]*ring.Int, context.N)
+
+	context.PolyToBigint(phase.value, coeffsBigint)
+
+	var normBitLen int
+	for i := range coeffsBigint {
+		coeffsBigint[i].Center(context.ModulusBigint)
+		if bitLen := coeffsBigint[i].Value.BitLen(); bitLen > normBitLen {
+			normBitLen = bitLen
+		}
+	}
+
+	if budget := context.ModulusBigint.Value.BitLen() - normBitLen - 1; budget > 0 {
+		return budget
+	}
+
+	return 0
+}
+
+// CompareNoiseBudget evaluates the two strategies, each with its own new evaluator, and returns the noise budget (see
+// Decryptor.NoiseBudget) remaining in their respective output ciphertext under the secret-key sk. It is a support function to
+// quantify the noise difference between two evaluations of the same circuit, for example a relinearization after each
+// multiplication against a single relinearization at the end. Returns -1, -1 if the secret-key does not match the bfvcontext.
+func (bfvcontext *BfvContext) CompareNoiseBudget(strategyA, strategyB func(*Evaluator) *Ciphertext, sk *SecretKey) (int, int) {
+
+	decryptor, err := bfvcontext.NewDecryptor(sk)
+	if err != nil {
+		return -1, -1
+	}
+
+	return decryptor.NoiseBudget(strategyA(bfvcontext.NewEvaluator())), decryptor.NoiseBudget(strategyB(bfvcontext.NewEvaluator()))
+}
+
+// AddNoise injects noise in the ciphertext ct so that its noise budget under the secret-key sk (see Decryptor.NoiseBudget) decreases by
+// approximately the given number of bits. The injected noise is uniform in [-B, B] with B = 2^(log2(Q) - budget + bits - 1) / t, added on the
+// first element of ct. It is a test-only function, meant to put ciphertexts in near-failure conditions to validate the handling of a
+// decreasing noise budget. It does nothing if bits is not positive or if the secret-key does not match the bfvcontext.
+func (bfvcontext *BfvContext) AddNoise(ct *Ciphertext, bits int, sk *SecretKey) {
+
+	decryptor, err := bfvcontext.NewDecryptor(sk)
+	if err != nil || bits <= 0 {
+		return
+	}
+
+	context := bfvcontext.contextQ
+
+	// log2 of the norm of t * the noise of ct
+	noiseBitLen := context.ModulusBigint.Value.BitLen() - decryptor.NoiseBudget(ct) - 1
+
+	bound := ring.NewUint(1)
+	bound.Lsh(bound, uint64(noiseBitLen+bits))
+	bound.Div(bound, ring.NewUint(bfvcontext.t))
+
+	interval := ring.NewUint(1)
+	interval.Add(interval, bound)
+	interval.Add(interval, bound)
+
+	coeffs := make([]*ring.Int, context.N)
+	for i := range coeffs {
+		coeffs[i] = ring.RandInt(interval)
+		coeffs[i].Sub(coeffs[i], bound)
+	}
+
+	noise := context.NewPoly()
+	context.SetCoefficientsBigint(coeffs, noise)
+
+	if ct.IsNTT() {
+		context.NTT(noise, noise)
+	}
+
+	context.Add(ct.value[0], noise, ct.value[0])
+}
+
+// DecryptChunked decrypts and decodes the ciphertexts returned by Encryptor.EncryptChunked and returns the first
+// length values of their concatenated slots, removing the zero padding of the last chunk.
+func (decryptor *Decryptor) DecryptChunked(ciphertexts []*Ciphertext, length uint64) (values []uint64, err error) {
+
+	slots := decryptor.bfvcontext.n
+
+	if length > uint64(len(ciphertexts))*slots {
+		return nil, errors.New("cannot decrypt chunked -> length exceeds the number of slots of the ciphertexts")
+	}
+
+	if decryptor.batchencoder == nil {
+		if decryptor.batchencoder, err = decryptor.bfvcontext.NewBatchEncoder(); err != nil {
+			return nil, err
+		}
+	}
+
+	plaintext := decryptor.bfvcontext.NewPlaintext()
+
+	values = make([]uint64, 0, length)
+
+	for _, ciphertext := range ciphertexts {
+
+		if uint64(len(values)) == length {
+			break
+		}
+
+		decryptor.Decrypt(ciphertext, plaintext)
+
+		coeffs := decryptor.batchencoder.DecodeUint(plaintext)
+
+		if remaining := length - uint64(len(values)); remaining < slots {
+			coeffs = coeffs[:remaining]
+		}
+
+		values = append(values, coeffs...)
 	}
 
-	decryptor.bfvcontext.contextQ.InvNTT(plaintext.value, plaintext.value)
+	return values, nil
 }
@@ -135,6 +135,22 @@ func (batchencoder *BatchEncoder) DecodeUint(plaintext *Plaintext) (coeffs []uin
 
 }
 
+// DecodeStream decodes a batched plaintext and sends its coefficients one by one, in the same order as DecodeUint, on the
+// provided channel, which is closed once all the coefficients have been sent. The plaintext is decoded with DecodeUint into a
+// private slice before the first coefficient is sent, so that the memory used is the same as DecodeUint's, and the encoder
+// can be reused while the coefficients are consumed. As the other methods of the encoder, the decoding itself must not run
+// concurrently with another use of the encoder, e.g. when DecodeStream is started in a new goroutine.
+func (batchencoder *BatchEncoder) DecodeStream(plaintext *Plaintext, out chan<- uint64) {
+
+	coeffs := batchencoder.DecodeUint(plaintext)
+
+	for _, c := range coeffs {
+		out <- c
+	}
+
+	close(out)
+}
+
 // DecodeInt decodes a batched plaintext and returns the coefficients in an int64 slice. Also decodes the sign (by centering the values around the plaintext
 // modulus).
 func (batchencoder *BatchEncoder) DecodeInt(plaintext *Plaintext) (coeffs []int64) {
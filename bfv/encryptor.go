@@ -3,14 +3,17 @@ package bfv
 import (
 	"errors"
 	"github.com/ldsec/lattigo/ring"
+	"golang.org/x/crypto/blake2b"
+	"io"
 )
 
 // Encryptor is a structure holding the parameters needed to encrypt plaintexts.
 type Encryptor struct {
-	bfvcontext *BfvContext
-	pk         *PublicKey
-	sk         *SecretKey
-	polypool   *ring.Poly
+	bfvcontext   *BfvContext
+	pk           *PublicKey
+	sk           *SecretKey
+	polypool     *ring.Poly
+	batchencoder *BatchEncoder
 }
 
 // NewEncryptorFromPk creates a new Encryptor with the provided public-key.
@@ -67,11 +70,11 @@ func (encryptor *Encryptor) Encrypt(plaintext *Plaintext, ciphertext *Ciphertext
 
 	if encryptor.sk != nil {
 
-		encryptfromsk(encryptor, plaintext, ciphertext)
+		encryptfromsk(encryptor, nil, plaintext, ciphertext)
 
 	} else if encryptor.pk != nil {
 
-		encryptfrompk(encryptor, plaintext, ciphertext)
+		encryptfrompk(encryptor, nil, plaintext, ciphertext)
 
 	} else {
 
@@ -81,12 +84,130 @@ func (encryptor *Encryptor) Encrypt(plaintext *Plaintext, ciphertext *Ciphertext
 	return nil
 }
 
-func encryptfrompk(encryptor *Encryptor, plaintext *Plaintext, ciphertext *Ciphertext) {
+// EncryptWithSeed encrypts the input plaintext as Encrypt, but derives all the randomness of the encryption (the uniform polynomial
+// or the ternary polynomial u, and the errors) deterministically from the seed instead of sampling it from crypto/rand, and returns
+// the result on the receiver ciphertext. The same seed, key and plaintext always give the same ciphertext, e.g. to create reproducible
+// test vectors. The randomness is read from a blake2b XOF seeded with the seed : a seed must never be reused outside of tests.
+func (encryptor *Encryptor) EncryptWithSeed(plaintext *Plaintext, seed []byte, ciphertext *Ciphertext) (err error) {
+
+	xof, err := blake2b.NewXOF(blake2b.OutputLengthUnknown, nil)
+	if err != nil {
+		return err
+	}
+
+	xof.Write(seed)
+
+	if encryptor.sk != nil {
+
+		encryptfromsk(encryptor, xof, plaintext, ciphertext)
+
+	} else if encryptor.pk != nil {
+
+		encryptfrompk(encryptor, xof, plaintext, ciphertext)
+
+	} else {
+
+		return errors.New("cannot encrypt -> public-key and/or secret-key has not been set")
+	}
+
+	return nil
+}
+
+// EncryptChunked encodes and encrypts a slice of values of arbitrary length, splitting it in chunks of N values
+// each batch encoded and encrypted on a newly created ciphertext. The last chunk is padded with zeros.
+// The values can be recovered with Decryptor.DecryptChunked.
+func (encryptor *Encryptor) EncryptChunked(values []uint64) (ciphertexts []*Ciphertext, err error) {
+
+	if encryptor.batchencoder == nil {
+		if encryptor.batchencoder, err = encryptor.bfvcontext.NewBatchEncoder(); err != nil {
+			return nil, err
+		}
+	}
+
+	slots := encryptor.bfvcontext.n
+
+	plaintext := encryptor.bfvcontext.NewPlaintext()
+
+	ciphertexts = make([]*Ciphertext, (uint64(len(values))+slots-1)/slots)
+
+	for i := range ciphertexts {
+
+		start := uint64(i) * slots
+		end := start + slots
+		if end > uint64(len(values)) {
+			end = uint64(len(values))
+		}
+
+		if err = encryptor.batchencoder.EncodeUint(values[start:end], plaintext); err != nil {
+			return nil, err
+		}
+
+		if ciphertexts[i], err = encryptor.EncryptNew(plaintext); err != nil {
+			return nil, err
+		}
+	}
+
+	return ciphertexts, nil
+}
+
+// EncryptFromCRPNew encrypts the input plaintext using the stored secret-key and the provided common reference polynomial crp
+// instead of a freshly sampled uniform polynomial, and returns the result on a newly created ciphertext. crp is interpreted
+// in the NTT domain (as a uniform polynomial sampled by Encrypt) and is not modified. A fresh error is sampled for each call.
+//
+// encrypt with sk : ciphertext = [-crp*sk + m + e, crp]
+func (encryptor *Encryptor) EncryptFromCRPNew(plaintext *Plaintext, crp *ring.Poly) (ciphertext *Ciphertext, err error) {
+
+	ciphertext = encryptor.bfvcontext.NewCiphertext(1)
+
+	return ciphertext, encryptor.EncryptFromCRP(plaintext, crp, ciphertext)
+}
+
+// EncryptFromCRP encrypts the input plaintext using the stored secret-key and the provided common reference polynomial crp
+// instead of a freshly sampled uniform polynomial, and returns the result on the receiver ciphertext. crp is interpreted
+// in the NTT domain (as a uniform polynomial sampled by Encrypt) and is not modified. A fresh error is sampled for each call.
+//
+// encrypt with sk : ciphertext = [-crp*sk + m + e, crp]
+func (encryptor *Encryptor) EncryptFromCRP(plaintext *Plaintext, crp *ring.Poly, ciphertext *Ciphertext) (err error) {
+
+	if encryptor.sk == nil {
+		return errors.New("cannot encrypt from crp -> secret-key has not been set")
+	}
+
+	if crp == nil || len(crp.Coeffs) != len(encryptor.bfvcontext.contextQ.Modulus) || uint64(crp.GetDegree()) != encryptor.bfvcontext.n {
+		return errors.New("cannot encrypt from crp -> crp does not match the bfvcontext")
+	}
+
+	encryptor.bfvcontext.contextQ.Copy(crp, ciphertext.value[1])
+
+	encryptfromskwithcrp(encryptor, nil, plaintext, ciphertext)
+
+	return nil
+}
+
+// samplers returns the samplers of the bfvcontext if source is nil, else new samplers reading their randomness from source.
+func (encryptor *Encryptor) samplers(source io.Reader) (*ring.TernarySampler, *ring.KYSampler) {
+
+	if source == nil {
+		return encryptor.bfvcontext.ternarySampler, encryptor.bfvcontext.gaussianSampler
+	}
+
+	ternarySampler := encryptor.bfvcontext.contextQ.NewTernarySampler()
+	ternarySampler.SetSource(source)
+
+	gaussianSampler := encryptor.bfvcontext.contextQ.NewKYSampler(encryptor.bfvcontext.sigma, int(6*encryptor.bfvcontext.sigma))
+	gaussianSampler.SetSource(source)
+
+	return ternarySampler, gaussianSampler
+}
+
+func encryptfrompk(encryptor *Encryptor, source io.Reader, plaintext *Plaintext, ciphertext *Ciphertext) {
 
 	context := encryptor.bfvcontext.contextQ
 
+	ternarySampler, gaussianSampler := encryptor.samplers(source)
+
 	// u
-	encryptor.bfvcontext.ternarySampler.SampleMontgomeryNTT(0.5, encryptor.polypool)
+	ternarySampler.SampleMontgomeryNTT(0.5, encryptor.polypool)
 
 	// ct[0] = pk[0]*u
 	// ct[1] = pk[1]*u
@@ -97,11 +218,11 @@ func encryptfrompk(encryptor *Encryptor, plaintext *Plaintext, ciphertext *Ciphe
 	context.InvNTT(ciphertext.value[1], ciphertext.value[1])
 
 	// ct[0] = pk[0]*u + e0
-	encryptor.bfvcontext.gaussianSampler.Sample(encryptor.polypool)
+	gaussianSampler.Sample(encryptor.polypool)
 	context.Add(ciphertext.value[0], encryptor.polypool, ciphertext.value[0])
 
 	// ct[1] = pk[1]*u + e1
-	encryptor.bfvcontext.gaussianSampler.Sample(encryptor.polypool)
+	gaussianSampler.Sample(encryptor.polypool)
 	context.Add(ciphertext.value[1], encryptor.polypool, ciphertext.value[1])
 
 	// ct[0] = pk[0]*u + e0 + m
@@ -111,19 +232,31 @@ func encryptfrompk(encryptor *Encryptor, plaintext *Plaintext, ciphertext *Ciphe
 	encryptor.polypool.Zero()
 }
 
-func encryptfromsk(encryptor *Encryptor, plaintext *Plaintext, ciphertext *Ciphertext) {
+func encryptfromsk(encryptor *Encryptor, source io.Reader, plaintext *Plaintext, ciphertext *Ciphertext) {
 
 	context := encryptor.bfvcontext.contextQ
 
+	ciphertext.value[1] = context.NewUniformPolyFromSource(source)
+
+	encryptfromskwithcrp(encryptor, source, plaintext, ciphertext)
+}
+
+// encryptfromskwithcrp encrypts the plaintext with the secret-key using the uniform polynomial a stored, in the NTT domain,
+// on the second component of the ciphertext. The error is sampled from source, or from the sampler of the bfvcontext if source is nil.
+func encryptfromskwithcrp(encryptor *Encryptor, source io.Reader, plaintext *Plaintext, ciphertext *Ciphertext) {
+
+	context := encryptor.bfvcontext.contextQ
+
+	_, gaussianSampler := encryptor.samplers(source)
+
 	// ct = [-a*s , a]
-	ciphertext.value[1] = context.NewUniformPoly()
 	context.MulCoeffsMontgomery(ciphertext.value[1], encryptor.sk.sk, ciphertext.value[0])
 	context.Neg(ciphertext.value[0], ciphertext.value[0])
 	context.InvNTT(ciphertext.value[0], ciphertext.value[0])
 	context.InvNTT(ciphertext.value[1], ciphertext.value[1])
 
 	// ct = [-a*s + e, a]
-	encryptor.bfvcontext.gaussianSampler.Sample(encryptor.polypool)
+	gaussianSampler.Sample(encryptor.polypool)
 	context.Add(ciphertext.value[0], encryptor.polypool, ciphertext.value[0])
 
 	// ct = [-a*s + m + e , a]
@@ -3,37 +3,74 @@ package bfv
 import (
 	"errors"
 	"github.com/ldsec/lattigo/ring"
+	"math/bits"
 )
 
 // Evaluator is a struct holding the necessary elements to operates the homomorphic operations between ciphertext and/or plaintexts.
 // It also holds a small memory pool used to store intermediate computations.
 type Evaluator struct {
-	bfvcontext    *BfvContext
-	basisextender *ring.BasisExtender
-	complexscaler *ring.ComplexScaler
-	polypool      [4]*ring.Poly
-	ctxpool       [3]*Ciphertext
+	bfvcontext     *BfvContext
+	tensorContexts []*tensorContext
+	polypool       [4]*ring.Poly
+	ctxpool        [3]*Ciphertext
+	batchencoder   *BatchEncoder
+	noiseWatcher   *noiseWatcher
+}
+
+// EvaluatorPool is a scratch memory pool storing the intermediate polynomials and ciphertexts used by the Evaluator
+// during the Mul, Relinearize, Add and key-switching operations. A single pool can be shared among several evaluators
+// to avoid re-allocating the temporary elements for each of them.
+//
+// The pool is not thread safe : all the evaluators sharing the same pool must be used from a single goroutine.
+type EvaluatorPool struct {
+	polypool [4]*ring.Poly
+	ctxpool  [3]*Ciphertext
+}
+
+// NewEvaluatorPool allocates a new EvaluatorPool for the target bfvcontext.
+func (bfvcontext *BfvContext) NewEvaluatorPool() (pool *EvaluatorPool) {
+
+	pool = new(EvaluatorPool)
+
+	for i := 0; i < 4; i++ {
+		pool.polypool[i] = bfvcontext.contextQP.NewPoly()
+	}
+
+	pool.ctxpool[0] = bfvcontext.NewCiphertextBig(5)
+	pool.ctxpool[1] = bfvcontext.NewCiphertextBig(5)
+	pool.ctxpool[2] = bfvcontext.NewCiphertextBig(5)
+
+	return
 }
 
 // NewEvaluator creates a new Evaluator, that can be used to do homomorphic
 // operations on the ciphertexts and/or plaintexts. It stores a small pool of polynomials
 // and ciphertexts that will be used for intermediate values.
 func (bfvcontext *BfvContext) NewEvaluator() (evaluator *Evaluator) {
+	return bfvcontext.NewEvaluatorWithPool(bfvcontext.NewEvaluatorPool())
+}
+
+// NewEvaluatorWithPool creates a new Evaluator that will use the provided EvaluatorPool for its intermediate values
+// instead of allocating its own. The pool must have been created from the same bfvcontext. Since the pool is reused
+// across operations, evaluators sharing a pool must not be used concurrently.
+func (bfvcontext *BfvContext) NewEvaluatorWithPool(pool *EvaluatorPool) (evaluator *Evaluator) {
 
 	evaluator = new(Evaluator)
 	evaluator.bfvcontext = bfvcontext
 
-	evaluator.basisextender = ring.NewBasisExtender(bfvcontext.contextQ, bfvcontext.contextP)
-	evaluator.complexscaler = ring.NewComplexScaler(bfvcontext.t, bfvcontext.contextQ, bfvcontext.contextP)
-
-	for i := 0; i < 4; i++ {
-		evaluator.polypool[i] = bfvcontext.contextQP.NewPoly()
+	evaluator.polypool = pool.polypool
+	evaluator.ctxpool = pool.ctxpool
+
+	// The tensorContext of the top level uses the pool, the ones of the lower levels are created on first use
+	evaluator.tensorContexts = make([]*tensorContext, len(bfvcontext.contextQLevels))
+	evaluator.tensorContexts[len(bfvcontext.contextQLevels)-1] = &tensorContext{
+		contextQP:     bfvcontext.contextQP,
+		basisextender: ring.NewBasisExtender(bfvcontext.contextQ, bfvcontext.contextP),
+		complexscaler: ring.NewComplexScaler(bfvcontext.t, bfvcontext.contextQ, bfvcontext.contextP),
+		polypool:      [2]*ring.Poly{pool.polypool[0], pool.polypool[1]},
+		ctxpool:       pool.ctxpool,
 	}
 
-	evaluator.ctxpool[0] = bfvcontext.NewCiphertextBig(5)
-	evaluator.ctxpool[1] = bfvcontext.NewCiphertextBig(5)
-	evaluator.ctxpool[2] = bfvcontext.NewCiphertextBig(5)
-
 	return evaluator
 }
 
@@ -51,6 +88,27 @@ func (evaluator *Evaluator) getElemAndCheckBinary(op0, op1, opOut Operand, opOut
 	}
 
 	el0, el1, elOut = op0.Element(), op1.Element(), opOut.Element()
+
+	// A plaintext above the level of the ciphertext is brought down to the level of the ciphertext
+	level0, level1 := evaluator.levelOf(el0), evaluator.levelOf(el1)
+	if level0 > level1 && el0.Degree() == 0 {
+		if el0, err = evaluator.plaintextAtLevel(el0, level1); err != nil {
+			return nil, nil, nil, err
+		}
+	} else if level1 > level0 && el1.Degree() == 0 {
+		if el1, err = evaluator.plaintextAtLevel(el1, level0); err != nil {
+			return nil, nil, nil, err
+		}
+	}
+
+	if evaluator.levelOf(el0) != evaluator.levelOf(el1) {
+		return nil, nil, nil, errors.New("operands are not at the same level")
+	}
+
+	if err = evaluator.checkAndTruncateReceiver(el0, elOut); err != nil {
+		return nil, nil, nil, err
+	}
+
 	return // TODO: more checks on elements
 }
 
@@ -67,9 +125,59 @@ func (evaluator *Evaluator) getElemAndCheckUnary(op0, opOut Operand, opOutMinDeg
 		return nil, nil, errors.New("receiver operand degree is too small")
 	}
 	el0, elOut = op0.Element(), opOut.Element()
+
+	if err = evaluator.checkAndTruncateReceiver(el0, elOut); err != nil {
+		return nil, nil, err
+	}
 	return // TODO: more checks on elements
 }
 
+// checkAndTruncateReceiver checks that the receiver elOut is at least at the level of the input el, and truncates it to that
+// level if it is below the top level, so that the result of the operation is at the level of its inputs.
+func (evaluator *Evaluator) checkAndTruncateReceiver(el, elOut *bfvElement) error {
+
+	level := evaluator.levelOf(el)
+
+	if evaluator.levelOf(elOut) < level {
+		return errors.New("receiver operand level is too small")
+	}
+
+	if level < len(evaluator.bfvcontext.contextQLevels)-1 {
+		elOut.truncate(level)
+	}
+
+	return nil
+}
+
+// plaintextAtLevel returns a copy of the plaintext element el brought down to the given level by dividing and rounding it by its last
+// moduli, as done by DropLevel on the ciphertexts, so that it encodes the same message with the scaling factor of that level.
+func (evaluator *Evaluator) plaintextAtLevel(el *bfvElement, level int) (*bfvElement, error) {
+
+	if el.IsNTT() {
+		return nil, errors.New("cannot bring the plaintext to the level of the ciphertext -> plaintext must not be in the NTT domain")
+	}
+
+	pt := el.CopyNew().Ciphertext()
+	for pt.Level() > level {
+		evaluator.dropLastModulusTo(pt, pt)
+	}
+
+	return pt.Element(), nil
+}
+
+// levelOf returns the level of the element, the elements of the extended context QP (see NewCiphertextBig) being at the top level.
+func (evaluator *Evaluator) levelOf(el *bfvElement) int {
+	if level := el.level(); level < len(evaluator.bfvcontext.contextQLevels) {
+		return level
+	}
+	return len(evaluator.bfvcontext.contextQLevels) - 1
+}
+
+// contextQAtLevel returns the context of the moduli q_0, ..., q_l, l being the level of the element.
+func (evaluator *Evaluator) contextQAtLevel(el *bfvElement) *ring.Context {
+	return evaluator.bfvcontext.contextQLevels[evaluator.levelOf(el)]
+}
+
 // evaluateInPlaceBinary applies the provided function in place on el0 and el1 and returns the result in elOut.
 func evaluateInPlaceBinary(el0, el1, elOut *bfvElement, evaluate func(*ring.Poly, *ring.Poly, *ring.Poly)) {
 
@@ -107,13 +215,14 @@ func (evaluator *Evaluator) Add(op0, op1 Operand, ctOut *Ciphertext) (err error)
 	if err != nil {
 		return err
 	}
-	evaluateInPlaceBinary(el0, el1, elOut, evaluator.bfvcontext.contextQ.Add)
+	evaluateInPlaceBinary(el0, el1, elOut, evaluator.contextQAtLevel(el0).Add)
+	evaluator.watchNoise(ctOut)
 	return
 }
 
 // AddNew adds op0 to op1 and creates a new element ctOut to store the result.
 func (evaluator *Evaluator) AddNew(op0, op1 Operand) (ctOut *Ciphertext, err error) {
-	ctOut = evaluator.bfvcontext.NewCiphertext(max(op0.Degree(), op1.Degree()))
+	ctOut = evaluator.bfvcontext.NewCiphertextAtLevel(max(op0.Degree(), op1.Degree()), evaluator.levelOf(op0.Element()))
 	return ctOut, evaluator.Add(op0, op1, ctOut)
 }
 
@@ -123,13 +232,13 @@ func (evaluator *Evaluator) AddNoMod(op0, op1 Operand, ctOut *Ciphertext) (err e
 	if err != nil {
 		return err
 	}
-	evaluateInPlaceBinary(el0, el1, elOut, evaluator.bfvcontext.contextQ.AddNoMod)
+	evaluateInPlaceBinary(el0, el1, elOut, evaluator.contextQAtLevel(el0).AddNoMod)
 	return nil
 }
 
 // AddNoModNew adds op0 to op1 without modular reduction and creates a new element ctOut to store the result.
 func (evaluator *Evaluator) AddNoModNew(op0, op1 Operand) (ctOut *Ciphertext, err error) {
-	ctOut = evaluator.bfvcontext.NewCiphertext(max(op0.Degree(), op1.Degree()))
+	ctOut = evaluator.bfvcontext.NewCiphertextAtLevel(max(op0.Degree(), op1.Degree()), evaluator.levelOf(op0.Element()))
 	return ctOut, evaluator.AddNoMod(op0, op1, ctOut)
 }
 
@@ -139,13 +248,14 @@ func (evaluator *Evaluator) Sub(op0, op1 Operand, ctOut *Ciphertext) (err error)
 	if err != nil {
 		return err
 	}
-	evaluateInPlaceBinary(el0, el1, elOut, evaluator.bfvcontext.contextQ.Sub)
+	evaluateInPlaceBinary(el0, el1, elOut, evaluator.contextQAtLevel(el0).Sub)
+	evaluator.watchNoise(ctOut)
 	return nil
 }
 
 // SubNew subtracts op0 to op1 and creates a new element ctOut to store the result.
 func (evaluator *Evaluator) SubNew(op0, op1 Operand) (ctOut *Ciphertext, err error) {
-	ctOut = evaluator.bfvcontext.NewCiphertext(max(op0.Degree(), op1.Degree()))
+	ctOut = evaluator.bfvcontext.NewCiphertextAtLevel(max(op0.Degree(), op1.Degree()), evaluator.levelOf(op0.Element()))
 	return ctOut, evaluator.Sub(op0, op1, ctOut)
 }
 
@@ -155,13 +265,13 @@ func (evaluator *Evaluator) SubNoMod(op0, op1 Operand, ctOut *Ciphertext) (err e
 	if err != nil {
 		return err
 	}
-	evaluateInPlaceBinary(el0, el1, elOut, evaluator.bfvcontext.contextQ.SubNoMod)
+	evaluateInPlaceBinary(el0, el1, elOut, evaluator.contextQAtLevel(el0).SubNoMod)
 	return nil
 }
 
 // SubNoModNew subtracts op0 to op1 without modular reduction and creates a new element ctOut to store the result.
 func (evaluator *Evaluator) SubNoModNew(op0, op1 Operand) (ctOut *Ciphertext, err error) {
-	ctOut = evaluator.bfvcontext.NewCiphertext(max(op0.Degree(), op1.Degree()))
+	ctOut = evaluator.bfvcontext.NewCiphertextAtLevel(max(op0.Degree(), op1.Degree()), evaluator.levelOf(op0.Element()))
 	return ctOut, evaluator.SubNoMod(op0, op1, ctOut)
 }
 
@@ -171,13 +281,14 @@ func (evaluator *Evaluator) Neg(op Operand, ctOut *Ciphertext) error {
 	if err != nil {
 		return err
 	}
-	evaluateInPlaceUnary(el0, elOut, evaluator.bfvcontext.contextQ.Neg)
+	evaluateInPlaceUnary(el0, elOut, evaluator.contextQAtLevel(el0).Neg)
+	evaluator.watchNoise(ctOut)
 	return nil
 }
 
 // Neg negates op and creates a new element to store the result.
 func (evaluator *Evaluator) NegNew(op Operand) (ctOut *Ciphertext, err error) {
-	ctOut = evaluator.bfvcontext.NewCiphertext(op.Degree())
+	ctOut = evaluator.bfvcontext.NewCiphertextAtLevel(op.Degree(), evaluator.levelOf(op.Element()))
 	return ctOut, evaluator.Neg(op, ctOut)
 }
 
@@ -187,60 +298,71 @@ func (evaluator *Evaluator) Reduce(op Operand, ctOut *Ciphertext) error {
 	if err != nil {
 		return err
 	}
-	evaluateInPlaceUnary(el0, elOut, evaluator.bfvcontext.contextQ.Reduce)
+	evaluateInPlaceUnary(el0, elOut, evaluator.contextQAtLevel(el0).Reduce)
 	return nil
 }
 
 // Reduce applies a modular reduction on op and creates a new element ctOut to store the result.
 func (evaluator *Evaluator) ReduceNew(op Operand) (ctOut *Ciphertext, err error) {
-	ctOut = evaluator.bfvcontext.NewCiphertext(op.Degree())
+	ctOut = evaluator.bfvcontext.NewCiphertextAtLevel(op.Degree(), evaluator.levelOf(op.Element()))
 	return ctOut, evaluator.Reduce(op, ctOut)
 }
 
-// MulScalar multiplies op by an uint64 scalar and returns the result on ctOut.
+// MulScalar multiplies op by an uint64 scalar and returns the result on ctOut. The scalar is first reduced modulo t, and each
+// coefficient of op is multiplied by it modulo each qi, without the encoding, the NTT and the rescaling of a multiplication by a
+// plaintext. It is the path taken by Mul for a plaintext encoding a constant.
 func (evaluator *Evaluator) MulScalar(op Operand, scalar uint64, ctOut *Ciphertext) error {
 
 	el0, elOut, err := evaluator.getElemAndCheckUnary(op, ctOut, op.Degree())
 	if err != nil {
 		return err
 	}
-	fun := func(el, elOut *ring.Poly) { evaluator.bfvcontext.contextQ.MulScalar(el, scalar, elOut) }
+	scalar %= evaluator.bfvcontext.t
+	context := evaluator.contextQAtLevel(el0)
+	fun := func(el, elOut *ring.Poly) { context.MulScalar(el, scalar, elOut) }
 	evaluateInPlaceUnary(el0, elOut, fun)
+	evaluator.watchNoise(ctOut)
 	return nil
 }
 
 // MulScalarNew multiplies op by an uint64 scalar and creates a new element ctOut to store the result.
 func (evaluator *Evaluator) MulScalarNew(op Operand, scalar uint64) (ctOut *Ciphertext, err error) {
-	ctOut = evaluator.bfvcontext.NewCiphertext(op.Degree())
+	ctOut = evaluator.bfvcontext.NewCiphertextAtLevel(op.Degree(), evaluator.levelOf(op.Element()))
 	return ctOut, evaluator.MulScalar(op, scalar, ctOut)
 }
 
 // tensorAndRescales computes (ct0 x ct1) * (t/Q) and stores the result on ctOut.
-func (evaluator *Evaluator) tensorAndRescale(ct0, ct1, ctOut *bfvElement) {
+func (evaluator *Evaluator) tensorAndRescale(ct0, ct1, ctOut *bfvElement) error {
 
 	// Prepares the ciphertexts for the Tensoring by extending their
 	// basis from Q to QP and transforming them in NTT form
-	c0 := evaluator.ctxpool[0]
-	c1 := evaluator.ctxpool[1]
-	tmpCtOut := evaluator.ctxpool[2]
+	tensorer, err := evaluator.tensorContextAtLevel(evaluator.levelOf(ct0))
+	if err != nil {
+		return err
+	}
+	contextQP := tensorer.contextQP
+
+	c0 := tensorer.ctxpool[0]
+	c1 := tensorer.ctxpool[1]
+	tmpCtOut := tensorer.ctxpool[2]
 
 	if ct0 == ct1 {
 
 		for i := range ct0.value {
-			evaluator.basisextender.ExtendBasis(ct0.value[i], c0.value[i])
-			evaluator.bfvcontext.contextQP.NTT(c0.value[i], c0.value[i])
+			tensorer.basisextender.ExtendBasis(ct0.value[i], c0.value[i])
+			contextQP.NTT(c0.value[i], c0.value[i])
 		}
 
 	} else {
 
 		for i := range ct0.value {
-			evaluator.basisextender.ExtendBasis(ct0.value[i], c0.value[i])
-			evaluator.bfvcontext.contextQP.NTT(c0.value[i], c0.value[i])
+			tensorer.basisextender.ExtendBasis(ct0.value[i], c0.value[i])
+			contextQP.NTT(c0.value[i], c0.value[i])
 		}
 
 		for i := range ct1.value {
-			evaluator.basisextender.ExtendBasis(ct1.value[i], c1.value[i])
-			evaluator.bfvcontext.contextQP.NTT(c1.value[i], c1.value[i])
+			tensorer.basisextender.ExtendBasis(ct1.value[i], c1.value[i])
+			contextQP.NTT(c1.value[i], c1.value[i])
 		}
 	}
 
@@ -251,31 +373,31 @@ func (evaluator *Evaluator) tensorAndRescale(ct0, ct1, ctOut *bfvElement) {
 	// Case where both BfvElements are of degree 1
 	if ct0.Degree() == 1 && ct1.Degree() == 1 {
 
-		c_00 := evaluator.polypool[0]
-		c_01 := evaluator.polypool[1]
+		c_00 := tensorer.polypool[0]
+		c_01 := tensorer.polypool[1]
 
 		d0 := tmpCtOut.value[0]
 		d1 := tmpCtOut.value[1]
 		d2 := tmpCtOut.value[2]
 
-		evaluator.bfvcontext.contextQP.MForm(c0.value[0], c_00)
-		evaluator.bfvcontext.contextQP.MForm(c0.value[1], c_01)
+		contextQP.MForm(c0.value[0], c_00)
+		contextQP.MForm(c0.value[1], c_01)
 
 		// Squaring case
 		if ct0 == ct1 {
 
-			evaluator.bfvcontext.contextQP.MulCoeffsMontgomery(c_00, c0.value[0], d0) // c0 = c0[0]*c0[0]
-			evaluator.bfvcontext.contextQP.MulCoeffsMontgomery(c_00, c0.value[1], d1) // c1 = 2*c0[0]*0[1]
-			evaluator.bfvcontext.contextQP.Add(d1, d1, d1)
-			evaluator.bfvcontext.contextQP.MulCoeffsMontgomery(c_01, c0.value[1], d2) // c2 = c0[1]*c0[1]
+			contextQP.MulCoeffsMontgomery(c_00, c0.value[0], d0) // c0 = c0[0]*c0[0]
+			contextQP.MulCoeffsMontgomery(c_00, c0.value[1], d1) // c1 = 2*c0[0]*0[1]
+			contextQP.Add(d1, d1, d1)
+			contextQP.MulCoeffsMontgomery(c_01, c0.value[1], d2) // c2 = c0[1]*c0[1]
 
 			// Normal case
 		} else {
 
-			evaluator.bfvcontext.contextQP.MulCoeffsMontgomery(c_00, c1.value[0], d0) // c0 = c0[0]*c0[0]
-			evaluator.bfvcontext.contextQP.MulCoeffsMontgomery(c_00, c1.value[1], d1)
-			evaluator.bfvcontext.contextQP.MulCoeffsMontgomeryAndAddNoMod(c_01, c1.value[0], d1) // c1 = c0[0]*c1[1] + c0[1]*c1[0]
-			evaluator.bfvcontext.contextQP.MulCoeffsMontgomery(c_01, c1.value[1], d2)            // c2 = c0[1]*c1[1]
+			contextQP.MulCoeffsMontgomery(c_00, c1.value[0], d0) // c0 = c0[0]*c0[0]
+			contextQP.MulCoeffsMontgomery(c_00, c1.value[1], d1)
+			contextQP.MulCoeffsMontgomeryAndAddNoMod(c_01, c1.value[0], d1) // c1 = c0[0]*c1[1] + c0[1]*c1[0]
+			contextQP.MulCoeffsMontgomery(c_01, c1.value[1], d2)            // c2 = c0[1]*c1[1]
 		}
 
 		// Case where both BfvElements are not of degree 1
@@ -288,29 +410,29 @@ func (evaluator *Evaluator) tensorAndRescale(ct0, ct1, ctOut *bfvElement) {
 		// Squaring case
 		if ct0 == ct1 {
 
-			c_00 := evaluator.ctxpool[1]
+			c_00 := tensorer.ctxpool[1]
 
 			for i := range ct0.value {
-				evaluator.bfvcontext.contextQP.MForm(c0.value[i], c_00.value[i])
+				contextQP.MForm(c0.value[i], c_00.value[i])
 			}
 
 			for i := uint64(0); i < ct0.Degree()+1; i++ {
 				for j := i + 1; j < ct0.Degree()+1; j++ {
-					evaluator.bfvcontext.contextQP.MulCoeffsMontgomery(c_00.value[i], c0.value[j], tmpCtOut.value[i+j])
-					evaluator.bfvcontext.contextQP.Add(tmpCtOut.value[i+j], tmpCtOut.value[i+j], tmpCtOut.value[i+j])
+					contextQP.MulCoeffsMontgomery(c_00.value[i], c0.value[j], tmpCtOut.value[i+j])
+					contextQP.Add(tmpCtOut.value[i+j], tmpCtOut.value[i+j], tmpCtOut.value[i+j])
 				}
 			}
 
 			for i := uint64(0); i < ct0.Degree()+1; i++ {
-				evaluator.bfvcontext.contextQP.MulCoeffsMontgomeryAndAdd(c_00.value[i], c0.value[i], tmpCtOut.value[i<<1])
+				contextQP.MulCoeffsMontgomeryAndAdd(c_00.value[i], c0.value[i], tmpCtOut.value[i<<1])
 			}
 
 			// Normal case
 		} else {
 			for i := range ct0.value {
-				evaluator.bfvcontext.contextQP.MForm(c0.value[i], c0.value[i])
+				contextQP.MForm(c0.value[i], c0.value[i])
 				for j := range ct1.value {
-					evaluator.bfvcontext.contextQP.MulCoeffsMontgomeryAndAdd(c0.value[i], c1.value[j], tmpCtOut.value[i+j])
+					contextQP.MulCoeffsMontgomeryAndAdd(c0.value[i], c1.value[j], tmpCtOut.value[i+j])
 				}
 			}
 		}
@@ -319,43 +441,76 @@ func (evaluator *Evaluator) tensorAndRescale(ct0, ct1, ctOut *bfvElement) {
 	// Applies the inverse NTT to the ciphertext, scales the down ciphertext
 	// by t/q and reduces its basis from QP to Q
 	for i := range ctOut.value {
-		evaluator.bfvcontext.contextQP.InvNTT(tmpCtOut.value[i], tmpCtOut.value[i])
-		evaluator.complexscaler.Scale(tmpCtOut.value[i], ctOut.value[i])
+		contextQP.InvNTT(tmpCtOut.value[i], tmpCtOut.value[i])
+		tensorer.complexscaler.Scale(tmpCtOut.value[i], ctOut.value[i])
 	}
+
+	return nil
 }
 
-// Mul multiplies op0 by op1 and returns the result on ctOut.
+// Mul multiplies op0 by op1 and returns the result on ctOut. If op1 is a plaintext encoding a constant, the multiplication
+// is done by MulScalar.
 func (evaluator *Evaluator) Mul(op0 *Ciphertext, op1 Operand, ctOut *Ciphertext) (err error) {
 
 	el0, el1, elOut, err := evaluator.getElemAndCheckBinary(op0, op1, ctOut, op0.Degree()+op1.Degree())
 	if err != nil {
 		return err
 	}
-	evaluator.tensorAndRescale(el0, el1, elOut)
+
+	if plaintext, isPlaintext := op1.(*Plaintext); isPlaintext {
+		if scalar, isConstant := plaintext.constant(evaluator.bfvcontext); isConstant {
+			return evaluator.MulScalar(op0, scalar, ctOut)
+		}
+	}
+	if err = evaluator.tensorAndRescale(el0, el1, elOut); err != nil {
+		return err
+	}
+	evaluator.watchNoise(ctOut)
 	return nil
 }
 
 // MulNew multiplies op0 by op1 and creates a new element ctOut to store the result.
 func (evaluator *Evaluator) MulNew(op0 *Ciphertext, op1 Operand) (ctOut *Ciphertext, err error) {
 
-	ctOut = evaluator.bfvcontext.NewCiphertext(op0.Degree() + op1.Degree())
+	ctOut = evaluator.bfvcontext.NewCiphertextAtLevel(op0.Degree()+op1.Degree(), evaluator.levelOf(op0.Element()))
 	return ctOut, evaluator.Mul(op0, op1, ctOut)
 }
 
 // relinearize is a methode common to Relinearize and RelinearizeNew. It switches ct0 out in the NTT domain, applies the keyswitch, and returns the result out of the NTT domain.
+// It operates at the level of ct0, with the limbs of the evaluation key of that level.
 func (evaluator *Evaluator) relinearize(ct0 *Ciphertext, evakey *EvaluationKey, ctOut *Ciphertext) {
 
-	evaluator.bfvcontext.contextQ.NTT(ct0.value[0], ctOut.value[0])
-	evaluator.bfvcontext.contextQ.NTT(ct0.value[1], ctOut.value[1])
+	context := evaluator.contextQAtLevel(ct0.Element())
+
+	context.NTT(ct0.value[0], ctOut.value[0])
+	context.NTT(ct0.value[1], ctOut.value[1])
 
 	for deg := uint64(ct0.Degree()); deg > 1; deg-- {
-		evaluator.switchKeys(ct0.value[deg], evakey.evakey[deg-2], ctOut)
+		evaluator.switchKeysInContext(context, ct0.value[deg], evakey.evakey[deg-2], ctOut)
 	}
 
 	ctOut.SetValue(ctOut.value[:2])
 
-	evaluator.bfvcontext.contextQ.InvNTT(ctOut.value[0], ctOut.value[0])
-	evaluator.bfvcontext.contextQ.InvNTT(ctOut.value[1], ctOut.value[1])
+	context.InvNTT(ctOut.value[0], ctOut.value[0])
+	context.InvNTT(ctOut.value[1], ctOut.value[1])
+}
+
+// checkRelinearizationKeyLevel checks that the evaluation key spans the moduli of the level of the element el, i.e. that it was generated
+// for a level at least the level of el. The switching-keys of the levels below its own level are given by its first limbs.
+func (evaluator *Evaluator) checkRelinearizationKeyLevel(el *bfvElement, evakey *EvaluationKey) error {
+	if len(evakey.evakey) > 0 && len(evakey.evakey[0].evakey) < evaluator.levelOf(el)+1 {
+		return errors.New("evaluation key level is smaller than the level of the input ciphertext")
+	}
+	return nil
+}
+
+// checkSwitchingKeyLevel checks that the switching-key spans the moduli of the level of the element el. As for the evaluation keys, the
+// switching-keys of the levels below its own level are given by its first limbs.
+func (evaluator *Evaluator) checkSwitchingKeyLevel(el *bfvElement, switchkey *SwitchingKey) error {
+	if len(switchkey.evakey) < evaluator.levelOf(el)+1 {
+		return errors.New("switching key level is smaller than the level of the input ciphertext")
+	}
+	return nil
 }
 
 // Relinearize relinearize the ciphertext ct0 of degree > 1 until it is of degree 1 and returns the result on cOut.
@@ -365,13 +520,21 @@ func (evaluator *Evaluator) relinearize(ct0 *Ciphertext, evakey *EvaluationKey,
 // - it must match the secret-key that was used to create the public key under which the current ct0 is encrypted.
 //
 // - it must be of degree high enough to relinearize the input ciphertext to degree 1 (ex. a ciphertext
-//of degree 3 will require that the evaluation key stores the keys for both degree 3 and 2 ciphertexts).
+// of degree 3 will require that the evaluation key stores the keys for both degree 3 and 2 ciphertexts).
 func (evaluator *Evaluator) Relinearize(ct0 *Ciphertext, evakey *EvaluationKey, ctOut *Ciphertext) error {
 
 	if int(ct0.Degree()-1) > len(evakey.evakey) {
 		return errors.New("cannot relinearize -> input ciphertext degree too large to allow relinearization")
 	}
 
+	if err := evaluator.checkRelinearizationKeyLevel(ct0.Element(), evakey); err != nil {
+		return err
+	}
+
+	if err := evaluator.checkAndTruncateReceiver(ct0.Element(), ctOut.Element()); err != nil {
+		return err
+	}
+
 	if ct0.Degree() < 2 {
 		if ct0 != ctOut {
 			ctOut.Copy(ct0.Element())
@@ -380,6 +543,7 @@ func (evaluator *Evaluator) Relinearize(ct0 *Ciphertext, evakey *EvaluationKey,
 	}
 
 	evaluator.relinearize(ct0, evakey, ctOut)
+	evaluator.watchNoise(ctOut)
 	return nil
 }
 
@@ -393,11 +557,259 @@ func (evaluator *Evaluator) Relinearize(ct0 *Ciphertext, evakey *EvaluationKey,
 // of degree 3 will require that the evaluation key stores the keys for both degree 3 and 2 ciphertexts).
 func (evaluator *Evaluator) RelinearizeNew(ct0 *Ciphertext, evakey *EvaluationKey) (ctOut *Ciphertext, err error) {
 
-	ctOut = evaluator.bfvcontext.NewCiphertext(1)
+	ctOut = evaluator.bfvcontext.NewCiphertextAtLevel(1, evaluator.levelOf(ct0.Element()))
 
 	return ctOut, evaluator.Relinearize(ct0, evakey, ctOut)
 }
 
+// RelinearizeOneStep relinearizes the ciphertext ct0 of degree d > 1 by a single step, i.e. switches its degree d element only,
+// and returns the resulting ciphertext of degree d-1 on ctOut, e.g. to bring a degree 3 ciphertext back to degree 2 before a further
+// multiplication. The evaluation key must store the key for degree d ciphertexts (see Relinearize), and ctOut must be of degree
+// at least d-1, in which case it is resized to degree d-1. ctOut can be ct0.
+func (evaluator *Evaluator) RelinearizeOneStep(ct0 *Ciphertext, evakey *EvaluationKey, ctOut *Ciphertext) error {
+
+	degree := ct0.Degree()
+
+	if degree < 2 {
+		return errors.New("cannot relinearize one step -> input ciphertext must be of degree 2 or more")
+	}
+
+	if int(degree-1) > len(evakey.evakey) {
+		return errors.New("cannot relinearize one step -> input ciphertext degree too large to allow relinearization")
+	}
+
+	if ctOut.Degree() < degree-1 {
+		return errors.New("cannot relinearize one step -> receiver operand degree is too small")
+	}
+
+	if err := evaluator.checkRelinearizationKeyLevel(ct0.Element(), evakey); err != nil {
+		return err
+	}
+
+	if err := evaluator.checkAndTruncateReceiver(ct0.Element(), ctOut.Element()); err != nil {
+		return err
+	}
+
+	context := evaluator.contextQAtLevel(ct0.Element())
+
+	context.NTT(ct0.value[0], ctOut.value[0])
+	context.NTT(ct0.value[1], ctOut.value[1])
+
+	evaluator.switchKeysInContext(context, ct0.value[degree], evakey.evakey[degree-2], ctOut)
+
+	context.InvNTT(ctOut.value[0], ctOut.value[0])
+	context.InvNTT(ctOut.value[1], ctOut.value[1])
+
+	if ct0 != ctOut {
+		for i := uint64(2); i < degree; i++ {
+			context.Copy(ct0.value[i], ctOut.value[i])
+		}
+	}
+
+	ctOut.SetValue(ctOut.value[:degree])
+
+	evaluator.watchNoise(ctOut)
+
+	return nil
+}
+
+// Square computes the square of ct0 and relinearizes it in a single call, returning the result on ctOut. Unlike Mul followed by
+// Relinearize, the degree 2 element of the square is kept in the memory pool of the evaluator, so that no intermediate ciphertext
+// of degree 2 is needed. ct0 and ctOut must be of degree 1, and the evaluation key must match the secret-key under which ct0 is encrypted.
+func (evaluator *Evaluator) Square(ct0 *Ciphertext, evakey *EvaluationKey, ctOut *Ciphertext) error {
+
+	if ct0.Degree() != 1 || ctOut.Degree() != 1 {
+		return errors.New("cannot square -> input and output must be of degree 1")
+	}
+
+	if len(evakey.evakey) < 1 {
+		return errors.New("cannot square -> evaluation key does not allow the relinearization of a degree 2 ciphertext")
+	}
+
+	el0 := ct0.Element()
+
+	if err := evaluator.checkRelinearizationKeyLevel(el0, evakey); err != nil {
+		return err
+	}
+
+	if err := evaluator.checkAndTruncateReceiver(el0, ctOut.Element()); err != nil {
+		return err
+	}
+
+	context := evaluator.contextQAtLevel(el0)
+
+	c2 := &ring.Poly{Coeffs: evaluator.polypool[2].Coeffs[:len(context.Modulus)]}
+
+	if err := evaluator.tensorAndRescale(el0, el0, &bfvElement{value: []*ring.Poly{ctOut.value[0], ctOut.value[1], c2}}); err != nil {
+		return err
+	}
+
+	context.NTT(ctOut.value[0], ctOut.value[0])
+	context.NTT(ctOut.value[1], ctOut.value[1])
+
+	evaluator.switchKeysInContext(context, c2, evakey.evakey[0], ctOut)
+
+	context.InvNTT(ctOut.value[0], ctOut.value[0])
+	context.InvNTT(ctOut.value[1], ctOut.value[1])
+
+	evaluator.watchNoise(ctOut)
+
+	return nil
+}
+
+// Power computes ct0^exponent by exponentiation by squaring and returns the result on ctOut. Each multiplication is followed by
+// a relinearization, so that the intermediate ciphertexts never exceed degree 2, and the noise grows with log2(exponent)
+// multiplications. An exponent of 0 returns the constant one (a trivial encryption of the plaintext 1, i.e. all slots
+// set to 1) and an exponent of 1 a copy of ct0. ct0 and ctOut must be of degree 1, and can be the same ciphertext.
+func (evaluator *Evaluator) Power(ct0 *Ciphertext, exponent uint64, evakey *EvaluationKey, ctOut *Ciphertext) error {
+
+	if ct0.Degree() != 1 || ctOut.Degree() != 1 {
+		return errors.New("cannot power -> input and output must be of degree 1")
+	}
+
+	if err := evaluator.checkAndTruncateReceiver(ct0.Element(), ctOut.Element()); err != nil {
+		return err
+	}
+
+	level := evaluator.levelOf(ct0.Element())
+
+	if exponent == 0 {
+		ctOut.value[0].Zero()
+		ctOut.value[1].Zero()
+		ctOut.SetIsNTT(false)
+		evaluator.addConstant(ctOut, 1)
+		return nil
+	}
+
+	if exponent == 1 {
+		if ct0 != ctOut {
+			ctOut.Copy(ct0.Element())
+		}
+		return nil
+	}
+
+	if len(evakey.evakey) < 1 {
+		return errors.New("cannot power -> evaluation key does not allow the relinearization of a degree 2 ciphertext")
+	}
+
+	// ct0 is overwritten along the exponentiation if ctOut is ct0
+	base := ct0
+	if ct0 == ctOut {
+		base = evaluator.bfvcontext.NewCiphertextAtLevel(1, level)
+		base.Copy(ct0.Element())
+	}
+
+	tmp := evaluator.bfvcontext.NewCiphertextAtLevel(2, level)
+
+	ctOut.Copy(base.Element())
+
+	// Left-to-right square-and-multiply over the bits of the exponent following the most significant one
+	for i := bits.Len64(exponent) - 2; i >= 0; i-- {
+
+		if err := evaluator.Square(ctOut, evakey, ctOut); err != nil {
+			return err
+		}
+
+		if (exponent>>uint(i))&1 == 1 {
+
+			if err := evaluator.Mul(ctOut, base, tmp); err != nil {
+				return err
+			}
+
+			if err := evaluator.Relinearize(tmp, evakey, ctOut); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// EvaluatePoly evaluates the polynomial coeffs[0] + coeffs[1] * x + ... + coeffs[d] * x^d on the ciphertext ct0 with Horner's method
+// and returns the result on ctOut. The coefficients are given in increasing degree and are reduced modulo the plaintext modulus.
+// Each of the d-1 ciphertext multiplications is followed by a relinearization, so that the intermediate ciphertexts never exceed
+// degree 2, the first multiplication by x being a scalar multiplication by coeffs[d]. A polynomial of degree 0 returns a trivial
+// encryption of its constant, and the evaluation key is only required for polynomials of degree 2 or more. ct0 and ctOut must be
+// of degree 1, and can be the same ciphertext.
+func (evaluator *Evaluator) EvaluatePoly(ct0 *Ciphertext, coeffs []uint64, evakey *EvaluationKey, ctOut *Ciphertext) error {
+
+	if ct0.Degree() != 1 || ctOut.Degree() != 1 {
+		return errors.New("cannot evaluate polynomial -> input and output must be of degree 1")
+	}
+
+	if err := evaluator.checkAndTruncateReceiver(ct0.Element(), ctOut.Element()); err != nil {
+		return err
+	}
+
+	level := evaluator.levelOf(ct0.Element())
+
+	t := evaluator.bfvcontext.t
+
+	// Degree of the polynomial, ignoring the leading coefficients equal to zero modulo t
+	degree := len(coeffs) - 1
+	for degree > 0 && coeffs[degree]%t == 0 {
+		degree--
+	}
+
+	if degree < 1 {
+		ctOut.value[0].Zero()
+		ctOut.value[1].Zero()
+		ctOut.SetIsNTT(false)
+		if degree == 0 {
+			evaluator.addConstant(ctOut, coeffs[0]%t)
+		}
+		return nil
+	}
+
+	if degree > 1 && (evakey == nil || len(evakey.evakey) < 1) {
+		return errors.New("cannot evaluate polynomial -> evaluation key does not allow the relinearization of a degree 2 ciphertext")
+	}
+
+	// ct0 is overwritten along the evaluation if ctOut is ct0
+	x := ct0
+	if ct0 == ctOut {
+		x = evaluator.bfvcontext.NewCiphertextAtLevel(1, level)
+		x.Copy(ct0.Element())
+	}
+
+	// ctOut = coeffs[d] * x + coeffs[d-1]
+	if err := evaluator.MulScalar(x, coeffs[degree], ctOut); err != nil {
+		return err
+	}
+	evaluator.addConstant(ctOut, coeffs[degree-1]%t)
+
+	// ctOut = ctOut * x + coeffs[i]
+	tmp := evaluator.bfvcontext.NewCiphertextAtLevel(2, level)
+
+	for i := degree - 2; i >= 0; i-- {
+
+		if err := evaluator.Mul(ctOut, x, tmp); err != nil {
+			return err
+		}
+
+		if err := evaluator.Relinearize(tmp, evakey, ctOut); err != nil {
+			return err
+		}
+
+		evaluator.addConstant(ctOut, coeffs[i]%t)
+	}
+
+	return nil
+}
+
+// addConstant adds the constant plaintext polynomial m < t to the ciphertext ct, not in the NTT domain, i.e. adds Delta * m to
+// the constant coefficient of its degree 0 element, Delta being the scaling factor of the level of ct.
+func (evaluator *Evaluator) addConstant(ct *Ciphertext, m uint64) {
+
+	level := evaluator.levelOf(ct.Element())
+	context := evaluator.bfvcontext.contextQLevels[level]
+	delta := evaluator.bfvcontext.deltaLevels[level]
+
+	for i, qi := range context.Modulus {
+		ct.value[0].Coeffs[i][0] = ring.CRed(ct.value[0].Coeffs[i][0]+ring.BRed(m, delta[i], qi, context.GetBredParams()[i]), qi)
+	}
+}
+
 // SwitchKeys applies the key-switching procedure to the ciphertext ct0 and returns the result on ctOut. It requires as an additional input a valide switching-key :
 // it must encrypt the target key under the public key under which ct0 is currently encrypted.
 func (evaluator *Evaluator) SwitchKeys(ct0 *Ciphertext, switchkey *SwitchingKey, ctOut *Ciphertext) (err error) {
@@ -406,7 +818,17 @@ func (evaluator *Evaluator) SwitchKeys(ct0 *Ciphertext, switchkey *SwitchingKey,
 		return errors.New("cannot switchkeys -> input and output must be of degree 1 to allow key switching")
 	}
 
-	evaluator.switchKeysOutOfNTTDomain(ct0.value[0], ct0.value[1], switchkey, ctOut)
+	if err = evaluator.checkSwitchingKeyLevel(ct0.Element(), switchkey); err != nil {
+		return err
+	}
+
+	if err = evaluator.checkAndTruncateReceiver(ct0.Element(), ctOut.Element()); err != nil {
+		return err
+	}
+
+	evaluator.switchKeysOutOfNTTDomain(evaluator.contextQAtLevel(ct0.Element()), ct0.value[0], ct0.value[1], switchkey, ctOut)
+
+	evaluator.watchNoise(ctOut)
 
 	return nil
 }
@@ -415,7 +837,7 @@ func (evaluator *Evaluator) SwitchKeys(ct0 *Ciphertext, switchkey *SwitchingKey,
 // it must encrypt the target key under the public key under which ct0 is currently encrypted.
 func (evaluator *Evaluator) SwitchKeysNew(ct0 *Ciphertext, switchkey *SwitchingKey) (ctOut *Ciphertext, err error) {
 
-	ctOut = evaluator.bfvcontext.NewCiphertext(1)
+	ctOut = evaluator.bfvcontext.NewCiphertextAtLevel(1, evaluator.levelOf(ct0.Element()))
 	return ctOut, evaluator.SwitchKeys(ct0, switchkey, ctOut)
 }
 
@@ -427,8 +849,18 @@ func (evaluator *Evaluator) SwitchKeysNew(ct0 *Ciphertext, switchkey *SwitchingK
 // hamming weight will be chosen, then the specific rotation will be computed as a sum of powers of two rotations.
 func (evaluator *Evaluator) RotateColumns(ct0 *Ciphertext, k uint64, evakey *RotationKeys, ctOut *Ciphertext) (err error) {
 
+	defer func() {
+		if err == nil {
+			evaluator.watchNoise(ctOut)
+		}
+	}()
+
 	k &= ((evaluator.bfvcontext.n >> 1) - 1)
 
+	if err = evaluator.checkAndTruncateReceiver(ct0.Element(), ctOut.Element()); err != nil {
+		return err
+	}
+
 	if k == 0 {
 		ctOut.Copy(ct0.Element())
 		return nil
@@ -438,10 +870,16 @@ func (evaluator *Evaluator) RotateColumns(ct0 *Ciphertext, k uint64, evakey *Rot
 		return errors.New("cannot rotate -> input and or output must be of degree 1")
 	}
 
+	context := evaluator.contextQAtLevel(ct0.Element())
+
 	// Looks in the rotationkey if the corresponding rotation has been generated or if the input is a plaintext
 	if evakey.evakey_rot_col_L[k] != nil {
 
-		evaluator.permute(ct0, ct0.IsNTT(), evaluator.bfvcontext.galElRotColLeft[k], evakey.evakey_rot_col_L[k], ctOut)
+		if err = evaluator.checkSwitchingKeyLevel(ct0.Element(), evakey.evakey_rot_col_L[k]); err != nil {
+			return err
+		}
+
+		evaluator.permute(context, ct0, ct0.IsNTT(), evaluator.bfvcontext.galElRotColLeft[k], evakey.evakey_rot_col_L[k], ctOut)
 
 		return nil
 
@@ -459,10 +897,19 @@ func (evaluator *Evaluator) RotateColumns(ct0 *Ciphertext, k uint64, evakey *Rot
 		// If yes, computes the least amount of rotation between k to the left and n/2 -k to the right required to apply the demanded rotation
 		if has_pow2_rotations {
 
+			for i := uint64(1); i < evaluator.bfvcontext.n>>1; i <<= 1 {
+				if err = evaluator.checkSwitchingKeyLevel(ct0.Element(), evakey.evakey_rot_col_L[i]); err != nil {
+					return err
+				}
+				if err = evaluator.checkSwitchingKeyLevel(ct0.Element(), evakey.evakey_rot_col_R[i]); err != nil {
+					return err
+				}
+			}
+
 			if hammingWeight64(k) <= hammingWeight64((evaluator.bfvcontext.n>>1)-k) {
-				evaluator.rotateColumnsLPow2(ct0, k, evakey, ctOut)
+				evaluator.rotateColumnsLPow2(context, ct0, k, evakey, ctOut)
 			} else {
-				evaluator.rotateColumnsRPow2(ct0, (evaluator.bfvcontext.n>>1)-k, evakey, ctOut)
+				evaluator.rotateColumnsRPow2(context, ct0, (evaluator.bfvcontext.n>>1)-k, evakey, ctOut)
 			}
 
 			return nil
@@ -475,22 +922,21 @@ func (evaluator *Evaluator) RotateColumns(ct0 *Ciphertext, k uint64, evakey *Rot
 }
 
 // rotateColumnsLPow2 applies the Galois Automorphism on the element, rotating the element by k positions to the left, returns the result on ctOut.
-func (evaluator *Evaluator) rotateColumnsLPow2(ct0 *Ciphertext, k uint64, evakey *RotationKeys, ctOut *Ciphertext) {
-	evaluator.rotateColumnsPow2(ct0, evaluator.bfvcontext.gen, k, evakey.evakey_rot_col_L, ctOut)
+func (evaluator *Evaluator) rotateColumnsLPow2(context *ring.Context, ct0 *Ciphertext, k uint64, evakey *RotationKeys, ctOut *Ciphertext) {
+	evaluator.rotateColumnsPow2(context, ct0, evaluator.bfvcontext.gen, k, evakey.evakey_rot_col_L, ctOut)
 }
 
 // rotateColumnsRPow2 applies the Galois Endomorphism on the element, rotating the element by k positions to the right, returns the result on ctOut.
-func (evaluator *Evaluator) rotateColumnsRPow2(ct0 *Ciphertext, k uint64, evakey *RotationKeys, ctOut *Ciphertext) {
-	evaluator.rotateColumnsPow2(ct0, evaluator.bfvcontext.genInv, k, evakey.evakey_rot_col_R, ctOut)
+func (evaluator *Evaluator) rotateColumnsRPow2(context *ring.Context, ct0 *Ciphertext, k uint64, evakey *RotationKeys, ctOut *Ciphertext) {
+	evaluator.rotateColumnsPow2(context, ct0, evaluator.bfvcontext.genInv, k, evakey.evakey_rot_col_R, ctOut)
 }
 
 // rotateColumnsPow2 rotates ct0 by k position (left or right depending on the input), decomposing k as a sum of power of 2 rotations, and returns the result on ctOut.
-func (evaluator *Evaluator) rotateColumnsPow2(ct0 *Ciphertext, generator, k uint64, evakey_rot_col map[uint64]*SwitchingKey, ctOut *Ciphertext) {
+// The context is the context of the level of ct0.
+func (evaluator *Evaluator) rotateColumnsPow2(context *ring.Context, ct0 *Ciphertext, generator, k uint64, evakey_rot_col map[uint64]*SwitchingKey, ctOut *Ciphertext) {
 
 	var mask, evakey_index uint64
 
-	context := evaluator.bfvcontext.contextQ
-
 	mask = (evaluator.bfvcontext.n << 1) - 1
 
 	evakey_index = 1
@@ -507,7 +953,7 @@ func (evaluator *Evaluator) rotateColumnsPow2(ct0 *Ciphertext, generator, k uint
 
 		if k&1 == 1 {
 
-			evaluator.permute(ctOut, true, generator, evakey_rot_col[evakey_index], ctOut)
+			evaluator.permute(context, ctOut, true, generator, evakey_rot_col[evakey_index], ctOut)
 		}
 
 		generator *= generator
@@ -534,7 +980,65 @@ func (evaluator *Evaluator) RotateRows(ct0 *Ciphertext, evakey *RotationKeys, ct
 		return errors.New("cannot rotate -> rotation key not generated")
 	}
 
-	evaluator.permute(ct0, ct0.IsNTT(), evaluator.bfvcontext.galElRotRow, evakey.evakey_rot_row, ctOut)
+	if err := evaluator.checkSwitchingKeyLevel(ct0.Element(), evakey.evakey_rot_row); err != nil {
+		return err
+	}
+
+	if err := evaluator.checkAndTruncateReceiver(ct0.Element(), ctOut.Element()); err != nil {
+		return err
+	}
+
+	evaluator.permute(evaluator.contextQAtLevel(ct0.Element()), ct0, ct0.IsNTT(), evaluator.bfvcontext.galElRotRow, evakey.evakey_rot_row, ctOut)
+
+	evaluator.watchNoise(ctOut)
+
+	return nil
+}
+
+// RelinearizeAndRotateColumns relinearizes the ciphertext ct0 of degree 2 and rotates its columns by the number of positions to the left of the
+// provided key, and returns the result on ctOut. Both operations are fused in a single key-switching pass: the Galois automorphism is applied
+// on each element of ct0, and the keys for GaloisEnd(sk) and GaloisEnd(sk)^2 are used to switch back to sk. The operation is done at the
+// level of ct0.
+func (evaluator *Evaluator) RelinearizeAndRotateColumns(ct0 *Ciphertext, key *RelinRotationKey, ctOut *Ciphertext) error {
+
+	if ct0.Degree() != 2 {
+		return errors.New("cannot relinearize and rotate -> input must be of degree 2")
+	}
+
+	if ctOut.Degree() < 1 {
+		return errors.New("cannot relinearize and rotate -> output must be at least of degree 1")
+	}
+
+	for _, switchkey := range key.evakey {
+		if err := evaluator.checkSwitchingKeyLevel(ct0.Element(), switchkey); err != nil {
+			return err
+		}
+	}
+
+	if err := evaluator.checkAndTruncateReceiver(ct0.Element(), ctOut.Element()); err != nil {
+		return err
+	}
+
+	context := evaluator.contextQAtLevel(ct0.Element())
+
+	el0, el1, el2 := evaluator.polypool[0], evaluator.polypool[1], evaluator.polypool[2]
+
+	context.Permute(ct0.value[0], key.galEl, el0)
+	context.Permute(ct0.value[1], key.galEl, el1)
+	context.Permute(ct0.value[2], key.galEl, el2)
+
+	context.NTT(el0, ctOut.value[0])
+	context.NTT(el1, ctOut.value[1])
+
+	evaluator.switchKeysInContext(context, el1, key.evakey[0], ctOut)
+	evaluator.switchKeysInContext(context, el2, key.evakey[1], ctOut)
+
+	ctOut.SetValue(ctOut.value[:2])
+
+	context.InvNTT(ctOut.value[0], ctOut.value[0])
+	context.InvNTT(ctOut.value[1], ctOut.value[1])
+
+	evaluator.watchNoise(ctOut)
 
 	return nil
 }
@@ -547,7 +1051,11 @@ func (evaluator *Evaluator) InnerSum(ct0 *Ciphertext, evakey *RotationKeys, ctOu
 		return errors.New("cannot inner sum -> input and output must be of degree 1")
 	}
 
-	cTmp := evaluator.bfvcontext.NewCiphertext(1)
+	if err := evaluator.checkAndTruncateReceiver(ct0.Element(), ctOut.Element()); err != nil {
+		return err
+	}
+
+	cTmp := evaluator.bfvcontext.NewCiphertextAtLevel(1, evaluator.levelOf(ct0.Element()))
 
 	ctOut.Copy(ct0.Element())
 
@@ -567,10 +1075,68 @@ func (evaluator *Evaluator) InnerSum(ct0 *Ciphertext, evakey *RotationKeys, ctOu
 
 }
 
-// permute operates a column rotation on ct0 and returns the result on ctOut
-func (evaluator *Evaluator) permute(ct0 *Ciphertext, isNTT bool, generator uint64, evakey *SwitchingKey, ctOut *Ciphertext) {
+// InnerSumBatch sums, in each row of the slots, n slots of ct0 spaced by batch and returns the result on ctOut, i.e. the slot i of
+// ctOut is the sum of the slots i, i + batch, ..., i + (n-1)*batch of the same row of ct0 (the indexes being taken modulo N/2). With a
+// batch of 1, the first slot of ctOut is the sum of the first n slots of ct0. It uses O(log(n)) rotations and additions, and requires
+// the left column rotations by batch*2^i for 2^(i+1) <= n and by batch*(n mod 2^i) for each bit i set in n, or all the left and
+// right power of two column rotations (see NewRotationKeysPow2). n*batch must not exceed N/2.
+func (evaluator *Evaluator) InnerSumBatch(ct0 *Ciphertext, batch, n int, evakey *RotationKeys, ctOut *Ciphertext) error {
+
+	if ct0.Degree() != 1 || ctOut.Degree() != 1 {
+		return errors.New("cannot inner sum -> input and output must be of degree 1")
+	}
 
-	context := evaluator.bfvcontext.contextQ
+	if batch < 1 || n < 1 || uint64(batch*n) > evaluator.bfvcontext.n>>1 {
+		return errors.New("cannot inner sum -> batch and n must be positive and n*batch must not exceed N/2")
+	}
+
+	if err := evaluator.checkAndTruncateReceiver(ct0.Element(), ctOut.Element()); err != nil {
+		return err
+	}
+
+	level := evaluator.levelOf(ct0.Element())
+
+	// cTmp stores the sum of the 2^i first rotations of ct0 by batch
+	cTmp := evaluator.bfvcontext.NewCiphertextAtLevel(1, level)
+	cRot := evaluator.bfvcontext.NewCiphertextAtLevel(1, level)
+
+	cTmp.Copy(ct0.Element())
+
+	var offset int
+
+	for i := uint(0); n>>i > 0; i++ {
+
+		// Adds the sum of the rotations offset to offset + 2^i - 1 by batch
+		if (n>>i)&1 == 1 {
+
+			if err := evaluator.RotateColumns(cTmp, uint64(offset*batch), evakey, cRot); err != nil {
+				return err
+			}
+
+			if offset == 0 {
+				ctOut.Copy(cRot.Element())
+			} else {
+				evaluator.Add(ctOut, cRot, ctOut)
+			}
+
+			offset += 1 << i
+		}
+
+		if n>>(i+1) > 0 {
+
+			if err := evaluator.RotateColumns(cTmp, uint64((1<<i)*batch), evakey, cRot); err != nil {
+				return err
+			}
+
+			evaluator.Add(cTmp, cRot, cTmp)
+		}
+	}
+
+	return nil
+}
+
+// permute operates a column rotation on ct0 and returns the result on ctOut, the context being the context of the level of ct0.
+func (evaluator *Evaluator) permute(context *ring.Context, ct0 *Ciphertext, isNTT bool, generator uint64, evakey *SwitchingKey, ctOut *Ciphertext) {
 
 	var el0, el1 *ring.Poly
 
@@ -583,74 +1149,71 @@ func (evaluator *Evaluator) permute(ct0 *Ciphertext, isNTT bool, generator uint6
 	if isNTT {
 		ring.PermuteNTT(ct0.value[0], generator, el0)
 		ring.PermuteNTT(ct0.value[1], generator, el1)
-		evaluator.switchKeysInNTTDomain(el0, el1, evakey, ctOut)
+		evaluator.switchKeysInNTTDomain(context, el0, el1, evakey, ctOut)
 	} else {
 		context.Permute(ct0.value[0], generator, el0)
 		context.Permute(ct0.value[1], generator, el1)
-		evaluator.switchKeysOutOfNTTDomain(el0, el1, evakey, ctOut)
+		evaluator.switchKeysOutOfNTTDomain(context, el0, el1, evakey, ctOut)
 	}
 }
 
 // switchKeysInNTTDomain operates a keyswitching assuming el0 and el1 are in the NTT domain
-func (evaluator *Evaluator) switchKeysInNTTDomain(el0, el1 *ring.Poly, switchkey *SwitchingKey, ctOut *Ciphertext) {
-
-	context := evaluator.bfvcontext.contextQ
+func (evaluator *Evaluator) switchKeysInNTTDomain(context *ring.Context, el0, el1 *ring.Poly, switchkey *SwitchingKey, ctOut *Ciphertext) {
 
 	context.Copy(el0, ctOut.value[0])
 	context.Copy(el1, ctOut.value[1])
 
 	context.InvNTT(el1, evaluator.polypool[1])
-	evaluator.switchKeys(evaluator.polypool[1], switchkey, ctOut)
+	evaluator.switchKeysInContext(context, evaluator.polypool[1], switchkey, ctOut)
 
 }
 
 // switchKeysOutOfNTTDomain operates a keyswitching assuming el0, el1 are not in the NTT domain
-func (evaluator *Evaluator) switchKeysOutOfNTTDomain(el0, el1 *ring.Poly, switchKey *SwitchingKey, ctOut *Ciphertext) {
-
-	context := evaluator.bfvcontext.contextQ
+func (evaluator *Evaluator) switchKeysOutOfNTTDomain(context *ring.Context, el0, el1 *ring.Poly, switchKey *SwitchingKey, ctOut *Ciphertext) {
 
 	context.Copy(el1, evaluator.polypool[1])
 
 	context.NTT(el0, ctOut.value[0])
 	context.NTT(el1, ctOut.value[1])
 
-	evaluator.switchKeys(evaluator.polypool[1], switchKey, ctOut)
+	evaluator.switchKeysInContext(context, evaluator.polypool[1], switchKey, ctOut)
 
 	context.InvNTT(ctOut.value[0], ctOut.value[0])
 	context.InvNTT(ctOut.value[1], ctOut.value[1])
 }
 
-// switchKeys compute ctOut = [ctOut[0] + c2*evakey[0], ctOut[1] + c2*evakey[1]], for c2 not in NTT and ctOut in NTT.
-func (evaluator *Evaluator) switchKeys(c2 *ring.Poly, evakey *SwitchingKey, ctOut *Ciphertext) {
+// switchKeysInContext compute ctOut = [ctOut[0] + c2*evakey[0], ctOut[1] + c2*evakey[1]], for c2 not in NTT and ctOut in NTT, in the given
+// context, which can be the context of a level of the moduli chain.
+func (evaluator *Evaluator) switchKeysInContext(context *ring.Context, c2 *ring.Poly, evakey *SwitchingKey, ctOut *Ciphertext) {
 
 	var mask, reduce, bitLog uint64
 
-	c2_qi_w := evaluator.polypool[3]
+	c2_qi_w := &ring.Poly{Coeffs: evaluator.polypool[3].Coeffs[:len(context.Modulus)]}
 
 	mask = uint64((1 << evakey.bitDecomp) - 1)
 
 	reduce = 0
 
-	for i := range evaluator.bfvcontext.contextQ.Modulus {
+	for i := range context.Modulus {
 
 		bitLog = uint64(len(evakey.evakey[i]))
 
 		for j := uint64(0); j < bitLog; j++ {
 			//c2_qi_w = (c2_qi_w >> (w*z)) & (w-1)
 			for u := uint64(0); u < evaluator.bfvcontext.n; u++ {
-				for v := range evaluator.bfvcontext.contextQ.Modulus {
+				for v := range context.Modulus {
 					c2_qi_w.Coeffs[v][u] = (c2.Coeffs[i][u] >> (j * evakey.bitDecomp)) & mask
 				}
 			}
 
-			evaluator.bfvcontext.contextQ.NTT(c2_qi_w, c2_qi_w)
+			context.NTT(c2_qi_w, c2_qi_w)
 
-			evaluator.bfvcontext.contextQ.MulCoeffsMontgomeryAndAddNoMod(evakey.evakey[i][j][0], c2_qi_w, ctOut.value[0])
-			evaluator.bfvcontext.contextQ.MulCoeffsMontgomeryAndAddNoMod(evakey.evakey[i][j][1], c2_qi_w, ctOut.value[1])
+			context.MulCoeffsMontgomeryAndAddNoMod(evakey.evakey[i][j][0], c2_qi_w, ctOut.value[0])
+			context.MulCoeffsMontgomeryAndAddNoMod(evakey.evakey[i][j][1], c2_qi_w, ctOut.value[1])
 
 			if reduce&7 == 7 {
-				evaluator.bfvcontext.contextQ.Reduce(ctOut.value[0], ctOut.value[0])
-				evaluator.bfvcontext.contextQ.Reduce(ctOut.value[1], ctOut.value[1])
+				context.Reduce(ctOut.value[0], ctOut.value[0])
+				context.Reduce(ctOut.value[1], ctOut.value[1])
 			}
 
 			reduce += 1
@@ -658,7 +1221,7 @@ func (evaluator *Evaluator) switchKeys(c2 *ring.Poly, evakey *SwitchingKey, ctOu
 	}
 
 	if (reduce-1)&7 != 7 {
-		evaluator.bfvcontext.contextQ.Reduce(ctOut.value[0], ctOut.value[0])
-		evaluator.bfvcontext.contextQ.Reduce(ctOut.value[1], ctOut.value[1])
+		context.Reduce(ctOut.value[0], ctOut.value[0])
+		context.Reduce(ctOut.value[1], ctOut.value[1])
 	}
 }
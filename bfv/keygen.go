@@ -3,9 +3,11 @@ package bfv
 import (
 	"encoding/binary"
 	"errors"
+	"fmt"
 	"github.com/ldsec/lattigo/ring"
 	"math"
 	"math/bits"
+	"strings"
 )
 
 // KeyGenerator is a structure that stores the elements required to create new keys,
@@ -40,6 +42,14 @@ type EvaluationKey struct {
 	evakey []*SwitchingKey
 }
 
+// RelinRotationKey is a structure that stores the switching-keys required to relinearize a ciphertext of degree 2
+// and rotate its columns in a single key-switching pass.
+type RelinRotationKey struct {
+	k      uint64
+	galEl  uint64
+	evakey [2]*SwitchingKey
+}
+
 // Switchingkey is a structure that stores the switching-keys required during the key-switching.
 type SwitchingKey struct {
 	bitDecomp uint64
@@ -284,6 +294,172 @@ func (evk *EvaluationKey) Get() []*SwitchingKey {
 	return evk.evakey
 }
 
+// AsSwitchingKey returns the switching-key of the evaluation-key relinearizing the degree 2 of the ciphertexts, which can be used as any
+// other switching-key with Evaluator.SwitchKeys. As a switching-key from sk_input to sk_output encrypts sk_input - sk_output, and the
+// evaluation-key encrypts sk^2, it is the switching-key from sk^2 + sk to sk. The returned switching-key shares its polynomials with the
+// evaluation-key. Returns nil if the evaluation-key is empty.
+func (evk *EvaluationKey) AsSwitchingKey() *SwitchingKey {
+	if len(evk.evakey) == 0 {
+		return nil
+	}
+	return evk.evakey[0]
+}
+
+// Element returns the polynomial of the given part (0 or 1) of the given digit of the decomposition of the given limb (modulus) of
+// the switching-key relinearizing the degree 2 of the ciphertexts, i.e. the polynomial evk.Get()[0].evakey[limb][digit][part].
+// Returns an error if any of the indexes is out of range.
+func (evk *EvaluationKey) Element(digit, limb, part int) (*ring.Poly, error) {
+
+	if len(evk.evakey) == 0 || evk.evakey[0] == nil {
+		return nil, errors.New("cannot get element -> evaluation-key is empty")
+	}
+
+	switchkey := evk.evakey[0].evakey
+
+	if limb < 0 || limb >= len(switchkey) {
+		return nil, fmt.Errorf("cannot get element -> limb %d out of range [0, %d)", limb, len(switchkey))
+	}
+
+	if digit < 0 || digit >= len(switchkey[limb]) {
+		return nil, fmt.Errorf("cannot get element -> digit %d out of range [0, %d)", digit, len(switchkey[limb]))
+	}
+
+	if part < 0 || part > 1 {
+		return nil, fmt.Errorf("cannot get element -> part %d out of range [0, 2)", part)
+	}
+
+	return switchkey[limb][digit][part], nil
+}
+
+// RelationError returns, for each limb (modulus) and digit of the decomposition of the switching-key relinearizing the
+// degree 2 of the ciphertexts, the log2 of the infinity norm of the deviation of the key from the ideal relation
+// evk[limb][digit][0] + evk[limb][digit][1] * sk = sk^2 * 2^(bitDecomp * digit) (on the limb only). For a valid key, the deviation is
+// the error sampled during the key generation (or the sum of the errors of the parties for a collective key), and an entry of
+// -Inf means that the relation holds exactly. It is meant as a debugging tool to locate the faulty parts of a key. Returns an error
+// if the evaluation-key is empty or if its number of limbs does not match the bfvcontext.
+func (evk *EvaluationKey) RelationError(sk *SecretKey, bfvcontext *BfvContext) ([][]float64, error) {
+
+	context := bfvcontext.contextQ
+
+	if len(evk.evakey) == 0 || evk.evakey[0] == nil {
+		return nil, errors.New("cannot compute relation error -> evaluation-key is empty")
+	}
+
+	switchkey := evk.evakey[0]
+
+	if len(switchkey.evakey) != len(context.Modulus) {
+		return nil, fmt.Errorf("cannot compute relation error -> evaluation-key has %d limbs, want %d", len(switchkey.evakey), len(context.Modulus))
+	}
+
+	mredParams := context.GetMredParams()
+
+	skSquare := context.NewPoly()
+	context.MulCoeffsMontgomery(sk.Get(), sk.Get(), skSquare)
+
+	deviation := context.NewPoly()
+
+	relationError := make([][]float64, len(switchkey.evakey))
+
+	for i, qi := range context.Modulus {
+
+		relationError[i] = make([]float64, len(switchkey.evakey[i]))
+
+		for j := range switchkey.evakey[i] {
+
+			// evk[0] + evk[1] * sk
+			deviation.Copy(switchkey.evakey[i][j][0])
+			context.MulCoeffsMontgomeryAndAdd(switchkey.evakey[i][j][1], sk.Get(), deviation)
+			context.InvMForm(deviation, deviation)
+
+			// - sk^2 * 2^(bitDecomp * digit), only on the limb i
+			for w := uint64(0); w < context.N; w++ {
+				deviation.Coeffs[i][w] = ring.CRed(deviation.Coeffs[i][w]+qi-ring.PowerOf2(skSquare.Coeffs[i][w], switchkey.bitDecomp*uint64(j), qi, mredParams[i]), qi)
+			}
+
+			context.InvNTT(deviation, deviation)
+
+			var norm uint64
+			for k, qk := range context.Modulus {
+				for _, coeff := range deviation.Coeffs[k] {
+					if coeff > qk>>1 {
+						coeff = qk - coeff
+					}
+					norm = max(norm, coeff)
+				}
+			}
+
+			relationError[i][j] = math.Log2(float64(norm))
+		}
+	}
+
+	return relationError, nil
+}
+
+// NoiseWithinPolicy checks that the noise of the switching-key relinearizing the degree 2 of the ciphertexts, as measured by
+// RelationError under sk, does not exceed maxBits bits for any limb and digit, so that a key can be validated against a maximum
+// key noise policy before being used. It returns nil if the key is compliant, else an error listing the (limb, digit) elements
+// exceeding maxBits along with their noise.
+func (evk *EvaluationKey) NoiseWithinPolicy(sk *SecretKey, maxBits float64, bfvcontext *BfvContext) error {
+
+	relationError, err := evk.RelationError(sk, bfvcontext)
+	if err != nil {
+		return err
+	}
+
+	var exceeding []string
+
+	for limb, deviations := range relationError {
+		for digit, deviation := range deviations {
+			if deviation > maxBits {
+				exceeding = append(exceeding, fmt.Sprintf("limb %d digit %d (%.2f bits)", limb, digit, deviation))
+			}
+		}
+	}
+
+	if len(exceeding) != 0 {
+		return fmt.Errorf("evaluation-key noise exceeds the policy of %.2f bits -> %s", maxBits, strings.Join(exceeding, ", "))
+	}
+
+	return nil
+}
+
+// NonzeroDigits returns, for each limb (modulus) of the switching-key relinearizing the degree 2 of the ciphertexts, which digits of
+// its decomposition carry information, i.e. have at least one nonzero coefficient in either of their two parts. The digits reported as
+// false, e.g. the trailing digits of a truncated key, contribute nothing to the key-switching and can be skipped by the evaluator.
+func (evk *EvaluationKey) NonzeroDigits() [][]bool {
+
+	if len(evk.evakey) == 0 || evk.evakey[0] == nil {
+		return nil
+	}
+
+	switchkey := evk.evakey[0].evakey
+
+	nonzero := make([][]bool, len(switchkey))
+
+	for i := range switchkey {
+
+		nonzero[i] = make([]bool, len(switchkey[i]))
+
+		for j := range switchkey[i] {
+			nonzero[i][j] = !isZeroPoly(switchkey[i][j][0]) || !isZeroPoly(switchkey[i][j][1])
+		}
+	}
+
+	return nonzero
+}
+
+// isZeroPoly returns true if all the coefficients of the polynomial are zero.
+func isZeroPoly(pol *ring.Poly) bool {
+	for i := range pol.Coeffs {
+		for _, coeff := range pol.Coeffs[i] {
+			if coeff != 0 {
+				return false
+			}
+		}
+	}
+	return true
+}
+
 // SetRelinKeys sets the polynomial of the target evaluation-key as the input polynomials.
 func (newevakey *EvaluationKey) SetRelinKeys(rlk [][][][2]*ring.Poly, bitDecomp uint64) {
 
@@ -302,6 +478,58 @@ func (newevakey *EvaluationKey) SetRelinKeys(rlk [][][][2]*ring.Poly, bitDecomp
 	}
 }
 
+// NewEvaluationKeyFromComponents assembles an evaluation key relinearizing the ciphertexts of degree 2 from externally computed components,
+// components[limb][digit] being the two polynomials of the given digit of the decomposition of the given limb (modulus) of the key. The
+// components must be in the NTT domain and in standard form; they are copied and converted to the Montgomery form used by the evaluator.
+// Returns an error if bitDecomp is not in [1, maxBit] or if the dimensions of the components do not match the bfvcontext and bitDecomp.
+func (bfvcontext *BfvContext) NewEvaluationKeyFromComponents(components [][][2]*ring.Poly, bitDecomp uint64) (*EvaluationKey, error) {
+
+	if bitDecomp == 0 || bitDecomp > bfvcontext.maxBit {
+		return nil, fmt.Errorf("cannot assemble evaluation-key -> bitDecomp %d out of range [1, %d]", bitDecomp, bfvcontext.maxBit)
+	}
+
+	context := bfvcontext.contextQ
+
+	if len(components) != len(context.Modulus) {
+		return nil, fmt.Errorf("cannot assemble evaluation-key -> %d limbs, want %d", len(components), len(context.Modulus))
+	}
+
+	switchkey := new(SwitchingKey)
+	switchkey.bitDecomp = bitDecomp
+	switchkey.evakey = make([][][2]*ring.Poly, len(context.Modulus))
+
+	for i, qi := range context.Modulus {
+
+		bitLog := int(math.Ceil(float64(bits.Len64(qi)) / float64(bitDecomp)))
+
+		if len(components[i]) != bitLog {
+			return nil, fmt.Errorf("cannot assemble evaluation-key -> limb %d has %d digits, want %d", i, len(components[i]), bitLog)
+		}
+
+		switchkey.evakey[i] = make([][2]*ring.Poly, bitLog)
+
+		for j := range components[i] {
+			for u := 0; u < 2; u++ {
+
+				pol := components[i][j][u]
+
+				if pol == nil || len(pol.Coeffs) != len(context.Modulus) || uint64(pol.GetDegree()) != context.N {
+					return nil, fmt.Errorf("cannot assemble evaluation-key -> part %d of limb %d digit %d does not match the bfvcontext", u, i, j)
+				}
+
+				if !context.IsReduced(pol) {
+					return nil, fmt.Errorf("cannot assemble evaluation-key -> part %d of limb %d digit %d is not reduced", u, i, j)
+				}
+
+				switchkey.evakey[i][j][u] = context.NewPoly()
+				context.MForm(pol, switchkey.evakey[i][j][u])
+			}
+		}
+	}
+
+	return &EvaluationKey{evakey: []*SwitchingKey{switchkey}}, nil
+}
+
 // Newswitchintkey generates a new key-switching key, that will allow to re-encrypt under the output-key a ciphertext encrypted under the input-key. Bitdecomp
 // is the power of two binary decomposition of the key. A higher bigdecomp will induce smaller keys, faster key-switching, but at the cost of more noise.
 func (keygen *KeyGenerator) NewSwitchingKey(sk_input, sk_output *SecretKey, bitDecomp uint64) (newevakey *SwitchingKey) {
@@ -390,6 +618,10 @@ func (keygen *KeyGenerator) NewRotationKeysEmpty() (rotKey *RotationKeys) {
 // Newrotationkeys generates a new struct of rotationkeys storing the keys of all the left and right powers of two rotations. The provided secret-key must be the secret-key used to generate the public-key under
 // which the ciphertexts to rotate are encrypted under. rows is a boolean value indicatig if the keys for the row rotation have to be generated. Bitdecomp is the power of two binary decomposition of the key.
 // A higher bigdecomp will induce smaller keys, faster key-switching, but at the cost of more noise.
+//
+// These 2*log(N/2) keys (plus the key of the row rotation) are enough for all the rotations of the Evaluator : RotateColumns by any k,
+// which is then computed as at most log(N/2) power of two rotations, RotateRows (if row is set), InnerSum (if row is set) and InnerSumBatch.
+// They are much smaller and faster to generate than the keys of all the N/2 column rotations, at the cost of more key-switchings per rotation.
 func (keygen *KeyGenerator) NewRotationKeysPow2(sk *SecretKey, bitDecomp uint64, row bool) (rotKey *RotationKeys) {
 
 	rotKey = new(RotationKeys)
@@ -412,6 +644,54 @@ func (keygen *KeyGenerator) NewRotationKeysPow2(sk *SecretKey, bitDecomp uint64,
 	return
 }
 
+// Compact deduplicates the switching-keys of the target rotation-keys that are associated to the same Galois element, which then
+// share the same memory. This is the case of the keys of the left rotation by k and of the right rotation by N/2 - k, for example
+// of the keys of the left and right rotations by N/4 in the power of two rotation-keys. The keys are accessed as before.
+func (rotKey *RotationKeys) Compact() {
+
+	keys := make(map[uint64]*SwitchingKey)
+
+	compact := func(evakey_rot_col map[uint64]*SwitchingKey, galEl []uint64) {
+		for n, switchkey := range evakey_rot_col {
+			if key, ok := keys[galEl[n]]; ok {
+				evakey_rot_col[n] = key
+			} else {
+				keys[galEl[n]] = switchkey
+			}
+		}
+	}
+
+	compact(rotKey.evakey_rot_col_L, rotKey.bfvcontext.galElRotColLeft)
+	compact(rotKey.evakey_rot_col_R, rotKey.bfvcontext.galElRotColRight)
+}
+
+// NewRelinRotationKey generates a new key that allows to relinearize a ciphertext of degree 2 and to rotate its columns by k positions to the left
+// in a single evaluation step. The provided secret-key must be the secret-key used to generate the public-key under which the ciphertexts
+// to relinearize and rotate are encrypted under. Bitdecomp is the power of two binary decomposition of the key.
+func (keygen *KeyGenerator) NewRelinRotationKey(sk *SecretKey, k, bitDecomp uint64) (key *RelinRotationKey) {
+
+	key = new(RelinRotationKey)
+	key.k = k & ((keygen.bfvcontext.n >> 1) - 1)
+	key.galEl = keygen.bfvcontext.galElRotColLeft[key.k]
+
+	// GaloisEnd(sk) - sk, switches GaloisEnd(c1) from GaloisEnd(sk) to sk
+	key.evakey[0] = genrotkey(keygen, sk.Get(), key.galEl, bitDecomp)
+
+	// GaloisEnd(sk^2), switches GaloisEnd(c2) from GaloisEnd(sk)^2 to sk
+	skSquared := keygen.context.NewPoly()
+	keygen.context.MulCoeffsMontgomery(sk.Get(), sk.Get(), skSquared)
+	ring.PermuteNTT(skSquared, key.galEl, keygen.polypool)
+	key.evakey[1] = newswitchintkey(keygen.bfvcontext, keygen.polypool, sk.Get(), bitDecomp)
+	keygen.polypool.Zero()
+
+	return
+}
+
+// K returns the number of positions to the left by which the key rotates the columns.
+func (key *RelinRotationKey) K() uint64 {
+	return key.k
+}
+
 // genrotkey is a methode used in the rotation-keys generation.
 func genrotkey(keygen *KeyGenerator, sk *ring.Poly, gen, bitDecomp uint64) (switchkey *SwitchingKey) {
 
@@ -425,6 +705,11 @@ func genrotkey(keygen *KeyGenerator, sk *ring.Poly, gen, bitDecomp uint64) (swit
 
 // newswitchintkey is a generic methode to generate key-switching keys used in the evaluation, key-switching and rotation-keys generation.
 func newswitchintkey(bfvcontext *BfvContext, sk_in, sk_out *ring.Poly, bitDecomp uint64) (switchkey *SwitchingKey) {
+	return newswitchintkeyInContext(bfvcontext, bfvcontext.contextQ, bfvcontext.gaussianSampler, sk_in, sk_out, bitDecomp)
+}
+
+// newswitchintkeyInContext generates a key-switching key in the given context, which can be the context of a level of the moduli chain.
+func newswitchintkeyInContext(bfvcontext *BfvContext, context *ring.Context, gaussianSampler *ring.KYSampler, sk_in, sk_out *ring.Poly, bitDecomp uint64) (switchkey *SwitchingKey) {
 
 	if bitDecomp > bfvcontext.maxBit || bitDecomp == 0 {
 		bitDecomp = bfvcontext.maxBit
@@ -432,8 +717,6 @@ func newswitchintkey(bfvcontext *BfvContext, sk_in, sk_out *ring.Poly, bitDecomp
 
 	switchkey = new(SwitchingKey)
 
-	context := bfvcontext.contextQ
-
 	switchkey.bitDecomp = uint64(bitDecomp)
 
 	mredParams := context.GetMredParams()
@@ -453,7 +736,7 @@ func newswitchintkey(bfvcontext *BfvContext, sk_in, sk_out *ring.Poly, bitDecomp
 		for j := uint64(0); j < bitLog; j++ {
 
 			// e
-			switchkey.evakey[i][j][0] = bfvcontext.gaussianSampler.SampleNTTNew()
+			switchkey.evakey[i][j][0] = gaussianSampler.SampleNTTNew()
 			// a
 			switchkey.evakey[i][j][1] = context.NewUniformPoly()
 
@@ -0,0 +1,219 @@
+package bfv
+
+import (
+	"errors"
+	"github.com/ldsec/lattigo/ring"
+)
+
+// LeveledEvaluationKey is a structure that stores, for each level of the moduli chain, the switching-keys required during the
+// relinearization of a ciphertext at that level, the level l being the modulus q_0 * ... * q_l (i.e. a ciphertext of l+1 limbs,
+// as obtained by dropping the last moduli of a ciphertext, see CompressForTransport).
+type LeveledEvaluationKey struct {
+	evakey []*EvaluationKey
+}
+
+// NewLeveledRelinKey generates a new leveled evaluation key from the provided secret-key. For each level of the moduli chain, it stores
+// an evaluation key generated modulo q_0 * ... * q_l with its own randomness. Max degree is the maximum degree of the ciphertext allowed
+// to relinearize and bitdecomp is the power of two binary decomposition of the key (see NewRelinKey).
+func (keygen *KeyGenerator) NewLeveledRelinKey(sk *SecretKey, maxDegree, bitDecomp uint64) (newEvakey *LeveledEvaluationKey) {
+
+	newEvakey = new(LeveledEvaluationKey)
+	newEvakey.evakey = make([]*EvaluationKey, len(keygen.bfvcontext.contextQLevels))
+
+	for level, context := range keygen.bfvcontext.contextQLevels {
+
+		gaussianSampler := context.NewKYSampler(keygen.bfvcontext.sigma, int(6*keygen.bfvcontext.sigma))
+
+		skLevel := &ring.Poly{Coeffs: sk.Get().Coeffs[:level+1]}
+		skPow := skLevel.CopyNew()
+
+		newEvakey.evakey[level] = new(EvaluationKey)
+		newEvakey.evakey[level].evakey = make([]*SwitchingKey, maxDegree)
+
+		for i := uint64(0); i < maxDegree; i++ {
+			context.MulCoeffsMontgomery(skPow, skLevel, skPow)
+			newEvakey.evakey[level].evakey[i] = newswitchintkeyInContext(keygen.bfvcontext, context, gaussianSampler, skPow, skLevel, bitDecomp)
+		}
+
+		skPow.Zero()
+	}
+
+	return
+}
+
+// Levels returns the number of levels of the leveled evaluation key.
+func (evk *LeveledEvaluationKey) Levels() int {
+	return len(evk.evakey)
+}
+
+// Level returns the evaluation key of the given level, or nil if the leveled evaluation key has no such level.
+func (evk *LeveledEvaluationKey) Level(level int) *EvaluationKey {
+	if level < 0 || level >= len(evk.evakey) {
+		return nil
+	}
+	return evk.evakey[level]
+}
+
+// RelinearizeLeveled relinearizes the ciphertext ct0 of degree > 1 until it is of degree 1 and returns the result on ctOut, selecting the
+// evaluation key of the level of ct0 (given by its number of limbs) from the leveled evaluation key. ctOut must be of the same level as ct0.
+func (evaluator *Evaluator) RelinearizeLeveled(ct0 *Ciphertext, evakey *LeveledEvaluationKey, ctOut *Ciphertext) error {
+
+	level := ct0.Level()
+
+	levelKey := evakey.Level(level)
+	if levelKey == nil {
+		return errors.New("cannot relinearize -> no evaluation key for the level of the input ciphertext")
+	}
+
+	if ctOut.Level() != level {
+		return errors.New("cannot relinearize -> input and output ciphertexts are not at the same level")
+	}
+
+	if int(ct0.Degree()-1) > len(levelKey.evakey) {
+		return errors.New("cannot relinearize -> input ciphertext degree too large to allow relinearization")
+	}
+
+	if ct0.Degree() < 2 {
+		if ct0 != ctOut {
+			ctOut.Copy(ct0.Element())
+		}
+		return nil
+	}
+
+	context := evaluator.bfvcontext.contextQLevels[level]
+
+	context.NTT(ct0.value[0], ctOut.value[0])
+	context.NTT(ct0.value[1], ctOut.value[1])
+
+	for deg := uint64(ct0.Degree()); deg > 1; deg-- {
+		evaluator.switchKeysInContext(context, ct0.value[deg], levelKey.evakey[deg-2], ctOut)
+	}
+
+	ctOut.SetValue(ctOut.value[:2])
+
+	context.InvNTT(ctOut.value[0], ctOut.value[0])
+	context.InvNTT(ctOut.value[1], ctOut.value[1])
+
+	evaluator.watchNoise(ctOut)
+
+	return nil
+}
+
+// NewCiphertextAtLevel creates a new empty ciphertext of degree degree at the given level, i.e. modulo q_0 * ... * q_level,
+// e.g. to receive the result of an operation on ciphertexts whose level was decreased with DropLevel. The level must be
+// in [0, len(Q)-1].
+func (bfvcontext *BfvContext) NewCiphertextAtLevel(degree uint64, level int) *Ciphertext {
+
+	context := bfvcontext.contextQLevels[level]
+
+	ciphertext := &Ciphertext{&bfvElement{}}
+	ciphertext.value = make([]*ring.Poly, degree+1)
+	for i := uint64(0); i < degree+1; i++ {
+		ciphertext.value[i] = context.NewPoly()
+	}
+	ciphertext.isNTT = false
+	ciphertext.moduli = bfvcontext.contextQ.Modulus
+
+	return ciphertext
+}
+
+// DropLevel decreases the level of the ciphertext ct0 by the given number of levels and returns the result on ctOut : its last moduli
+// are dropped one by one by dividing and rounding the ciphertext by each of them, which preserves the plaintext and scales its noise
+// down along with the modulus (see CompressForTransport), so that the subsequent operations run on fewer limbs. The noise budget
+// (see Decryptor.NoiseBudget) decreases by about log2(q_l) minus the log2 of the noise for each dropped modulus q_l once the noise
+// is smaller than it. Add, Sub, Mul and the decryption operate at the level of their inputs, which must all be at the same level, a plaintext
+// being brought down to the level of the ciphertext. Relinearize, Square and Power operate at the level of their input with the first limbs
+// of the evaluation key, and RelinearizeLeveled with the keys generated for each level. SwitchKeys, the rotations, InnerSum and LinearTransform
+// as well as RelinearizeAndRotateColumns also operate at the level of their input with the first limbs of the keys. ctOut must be of degree at least the degree
+// of ct0 and at level at least the target level, in which case its limbs are truncated to the target level. ctOut can be ct0.
+func (evaluator *Evaluator) DropLevel(ct0 *Ciphertext, levels int, ctOut *Ciphertext) error {
+
+	level := ct0.Level() - levels
+
+	if levels < 0 || level < 0 {
+		return errors.New("cannot drop level -> the number of levels to drop must be in [0, level of the input ciphertext]")
+	}
+
+	if ct0.IsNTT() {
+		return errors.New("cannot drop level -> input ciphertext must not be in the NTT domain")
+	}
+
+	if ctOut.Degree() < ct0.Degree() || ctOut.Level() < level {
+		return errors.New("cannot drop level -> receiver ciphertext degree or level is too small")
+	}
+
+	ctOut.SetValue(ctOut.value[:ct0.Degree()+1])
+
+	if levels == 0 {
+		for k := range ctOut.value {
+			ctOut.value[k].Coeffs = ctOut.value[k].Coeffs[:level+1]
+		}
+		ctOut.Copy(ct0.Element())
+		return nil
+	}
+
+	// The moduli are dropped on ctOut, or on a copy of ct0 until the last one if ctOut has not enough limbs for the intermediate levels
+	src := ct0
+	if ct0 != ctOut && ctOut.Level() < ct0.Level()-1 {
+		src = ct0.CopyNew().Ciphertext()
+		for src.Level() > level+1 {
+			evaluator.dropLastModulusTo(src, src)
+		}
+	}
+
+	evaluator.dropLastModulusTo(src, ctOut)
+
+	for ctOut.Level() > level {
+		evaluator.dropLastModulusTo(ctOut, ctOut)
+	}
+
+	evaluator.watchNoise(ctOut)
+
+	return nil
+}
+
+// tensorContext stores the contexts and the memory pool used by tensorAndRescale for the ciphertexts of a given level.
+type tensorContext struct {
+	contextQP     *ring.Context
+	basisextender *ring.BasisExtender
+	complexscaler *ring.ComplexScaler
+	polypool      [2]*ring.Poly
+	ctxpool       [3]*Ciphertext
+}
+
+// tensorContextAtLevel returns the tensorContext of the given level, creating it on first use for the levels below the top level.
+func (evaluator *Evaluator) tensorContextAtLevel(level int) (*tensorContext, error) {
+
+	if evaluator.tensorContexts[level] != nil {
+		return evaluator.tensorContexts[level], nil
+	}
+
+	contextQ := evaluator.bfvcontext.contextQLevels[level]
+	contextP := evaluator.bfvcontext.contextP
+
+	tensorer := new(tensorContext)
+
+	tensorer.contextQP = ring.NewContext()
+	if err := tensorer.contextQP.Merge(contextQ, contextP); err != nil {
+		return nil, err
+	}
+
+	tensorer.basisextender = ring.NewBasisExtender(contextQ, contextP)
+	tensorer.complexscaler = ring.NewComplexScaler(evaluator.bfvcontext.t, contextQ, contextP)
+
+	for i := range tensorer.polypool {
+		tensorer.polypool[i] = tensorer.contextQP.NewPoly()
+	}
+
+	for i := range tensorer.ctxpool {
+		tensorer.ctxpool[i] = &Ciphertext{&bfvElement{}}
+		tensorer.ctxpool[i].value = make([]*ring.Poly, 6)
+		for j := range tensorer.ctxpool[i].value {
+			tensorer.ctxpool[i].value[j] = tensorer.contextQP.NewPoly()
+		}
+	}
+
+	evaluator.tensorContexts[level] = tensorer
+
+	return tensorer, nil
+}
@@ -0,0 +1,152 @@
+package bfv
+
+import (
+	"errors"
+	"math/bits"
+	"sort"
+)
+
+// LinearTransformNew applies the linear transform of the given diagonals on the slots of ct0 and returns the result on a newly created element.
+// See LinearTransform.
+func (evaluator *Evaluator) LinearTransformNew(ct0 *Ciphertext, diagonals map[int][]uint64, rotkey *RotationKeys, evakey *EvaluationKey) (ctOut *Ciphertext, err error) {
+
+	ctOut = evaluator.bfvcontext.NewCiphertextAtLevel(1, evaluator.levelOf(ct0.Element()))
+
+	return ctOut, evaluator.LinearTransform(ct0, diagonals, rotkey, evakey, ctOut)
+}
+
+// LinearTransform applies the (N/2)x(N/2) matrix M given by its non-zero diagonals on each of the two rows of slots of ct0, and returns
+// the result on ctOut. The diagonal d, for 0 <= d < N/2, is a slice of N values such that diagonals[d][j] = M_r[j][(j+d) mod N/2] for the
+// slot j of the row r, i.e. :
+//
+// ctOut[j] = sum_d diagonals[d][j] * ct0[(j+d) mod N/2]
+//
+// The product is evaluated with the baby-step giant-step diagonal method, which requires a rotation-key storing either all the power
+// of two column rotations or all the column rotations used by the method. If ct0 is of degree larger than 1, it is first relinearized
+// with the provided evaluation-key, which can otherwise be nil.
+func (evaluator *Evaluator) LinearTransform(ct0 *Ciphertext, diagonals map[int][]uint64, rotkey *RotationKeys, evakey *EvaluationKey, ctOut *Ciphertext) (err error) {
+
+	bfvcontext := evaluator.bfvcontext
+
+	slots := bfvcontext.n >> 1
+
+	if len(diagonals) == 0 {
+		return errors.New("cannot apply linear transform -> no diagonals")
+	}
+
+	if ctOut.Degree() != 1 {
+		return errors.New("cannot apply linear transform -> output must be of degree 1")
+	}
+
+	// Groups the diagonals by giant step, with n1*n2 = N/2 baby and giant steps
+	n1 := uint64(1) << ((uint64(bits.Len64(slots)-1) + 1) >> 1)
+
+	giantSteps := make(map[uint64][]uint64)
+	for d, diag := range diagonals {
+
+		if d < 0 || uint64(d) >= slots {
+			return errors.New("cannot apply linear transform -> diagonal index must be in [0, N/2)")
+		}
+
+		if uint64(len(diag)) != bfvcontext.n {
+			return errors.New("cannot apply linear transform -> diagonals must be of size N")
+		}
+
+		giantSteps[uint64(d)/n1] = append(giantSteps[uint64(d)/n1], uint64(d))
+	}
+
+	ct := ct0
+	if ct0.Degree() > 1 {
+
+		if evakey == nil {
+			return errors.New("cannot apply linear transform -> input ciphertext of degree > 1 requires an evaluation-key")
+		}
+
+		if ct, err = evaluator.RelinearizeNew(ct0, evakey); err != nil {
+			return err
+		}
+
+	} else if ct0.Degree() != 1 {
+		return errors.New("cannot apply linear transform -> input must be a ciphertext")
+	}
+
+	if err = evaluator.checkAndTruncateReceiver(ct.Element(), ctOut.Element()); err != nil {
+		return err
+	}
+
+	level := evaluator.levelOf(ct.Element())
+
+	if evaluator.batchencoder == nil {
+		if evaluator.batchencoder, err = bfvcontext.NewBatchEncoder(); err != nil {
+			return err
+		}
+	}
+
+	// Iterates over the giant steps in a deterministic order
+	giants := make([]uint64, 0, len(giantSteps))
+	for g := range giantSteps {
+		giants = append(giants, g)
+	}
+	sort.Slice(giants, func(i, j int) bool { return giants[i] < giants[j] })
+
+	// Baby steps : rot_b(ct0), computed on demand
+	babySteps := map[uint64]*Ciphertext{0: ct}
+
+	plaintext := bfvcontext.NewPlaintext()
+	diag := make([]uint64, bfvcontext.n)
+
+	acc := bfvcontext.NewCiphertextAtLevel(1, level)
+	inner := bfvcontext.NewCiphertextAtLevel(1, level)
+	tmp := bfvcontext.NewCiphertextAtLevel(1, level)
+
+	// Giant steps : sum_g rot_(g*n1)(sum_b rot_-(g*n1)(diag_(g*n1+b)) * rot_b(ct0))
+	for _, g := range giants {
+
+		sort.Slice(giantSteps[g], func(i, j int) bool { return giantSteps[g][i] < giantSteps[g][j] })
+
+		inner.Value()[0].Zero()
+		inner.Value()[1].Zero()
+
+		for _, d := range giantSteps[g] {
+
+			b := d - g*n1
+
+			if babySteps[b] == nil {
+				babySteps[b] = bfvcontext.NewCiphertextAtLevel(1, level)
+				if err = evaluator.RotateColumns(ct, b, rotkey, babySteps[b]); err != nil {
+					return err
+				}
+			}
+
+			// rot_-(g*n1)(diag_d), on each row
+			for j := uint64(0); j < bfvcontext.n; j++ {
+				row := j &^ (slots - 1)
+				diag[j] = diagonals[int(d)][row|((j-g*n1)&(slots-1))]
+			}
+
+			if err = evaluator.batchencoder.EncodeUint(diag, plaintext); err != nil {
+				return err
+			}
+
+			if err = evaluator.Mul(babySteps[b], plaintext, tmp); err != nil {
+				return err
+			}
+
+			if err = evaluator.Add(inner, tmp, inner); err != nil {
+				return err
+			}
+		}
+
+		if err = evaluator.RotateColumns(inner, g*n1, rotkey, tmp); err != nil {
+			return err
+		}
+
+		if err = evaluator.Add(acc, tmp, acc); err != nil {
+			return err
+		}
+	}
+
+	ctOut.Copy(acc.Element())
+
+	return nil
+}
@@ -0,0 +1,41 @@
+package bfv
+
+// noiseWatcher stores the state of the noise watcher of an Evaluator (see Evaluator.SetNoiseWatcher).
+type noiseWatcher struct {
+	decryptor *Decryptor
+	threshold int
+	callback  func(ct *Ciphertext)
+}
+
+// SetNoiseWatcher sets a noise watcher on the evaluator : after each Add, Sub, Neg, MulScalar, Mul, Relinearize, RelinearizeLeveled,
+// RelinearizeAndRotateColumns, Square, SwitchKeys, RotateColumns and RotateRows, the noise budget of the output ciphertext under the secret-key sk is measured (see Decryptor.NoiseBudget),
+// and the callback is called with the output ciphertext if the budget is below threshold, e.g. to trigger a refresh of a streaming
+// computation. The operations composed of several of them (e.g. InnerSum) report each of their steps. Measuring the budget costs a
+// decryption per operation, and requires the secret-key : it is meant for debugging and for the parties holding it. A nil sk or
+// callback removes the watcher. Returns an error if sk does not match the bfvcontext of the evaluator.
+func (evaluator *Evaluator) SetNoiseWatcher(sk *SecretKey, threshold int, callback func(ct *Ciphertext)) error {
+
+	if sk == nil || callback == nil {
+		evaluator.noiseWatcher = nil
+		return nil
+	}
+
+	decryptor, err := evaluator.bfvcontext.NewDecryptor(sk)
+	if err != nil {
+		return err
+	}
+
+	evaluator.noiseWatcher = &noiseWatcher{decryptor: decryptor, threshold: threshold, callback: callback}
+
+	return nil
+}
+
+// watchNoise calls the callback of the noise watcher of the evaluator, if any, if the noise budget of ct is below its threshold.
+func (evaluator *Evaluator) watchNoise(ct *Ciphertext) {
+
+	watcher := evaluator.noiseWatcher
+
+	if watcher != nil && watcher.decryptor.NoiseBudget(ct) < watcher.threshold {
+		watcher.callback(ct)
+	}
+}
@@ -11,10 +11,12 @@ type Operand interface {
 }
 
 // bfvElement is a common struct between plaintexts and ciphertexts. It stores a value
-// as a slice of polynomials, and an isNTT flag indicatig if the element is in the NTT domain.
+// as a slice of polynomials, an isNTT flag indicatig if the element is in the NTT domain
+// and the moduli chain of the context in which it was created.
 type bfvElement struct {
-	value []*ring.Poly
-	isNTT bool
+	value  []*ring.Poly
+	isNTT  bool
+	moduli []uint64
 }
 
 // NewCiphertext creates a new empty ciphertext of degree degree.
@@ -25,6 +27,7 @@ func (bfvcontext *BfvContext) NewBfvElement(degree uint64) *bfvElement {
 		el.value[i] = bfvcontext.contextQ.NewPoly()
 	}
 	el.isNTT = false
+	el.moduli = bfvcontext.contextQ.Modulus
 
 	return el
 }
@@ -57,6 +60,18 @@ func (el *bfvElement) Resize(bfvcontext *BfvContext, degree uint64) {
 	}
 }
 
+// level returns the level of the target element, i.e. its number of limbs minus one (see Ciphertext.Level).
+func (el *bfvElement) level() int {
+	return len(el.value[0].Coeffs) - 1
+}
+
+// truncate truncates the limbs of the target element above the given level.
+func (el *bfvElement) truncate(level int) {
+	for i := range el.value {
+		el.value[i].Coeffs = el.value[i].Coeffs[:level+1]
+	}
+}
+
 // IsNTT returns true if the target ciphertext is in the NTT domain, else false.
 func (el *bfvElement) IsNTT() bool {
 	return el.isNTT
@@ -78,6 +93,7 @@ func (el *bfvElement) CopyNew() *bfvElement {
 		ctxCopy.value[i] = el.value[i].CopyNew()
 	}
 	ctxCopy.isNTT = el.isNTT
+	ctxCopy.moduli = el.moduli
 
 	return ctxCopy
 }
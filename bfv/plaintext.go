@@ -68,6 +68,47 @@ func (P *Plaintext) Lift(bfvcontext *BfvContext) {
 	}
 }
 
+// constant returns the value m < t and true if the plaintext is the lift of the constant polynomial m (see Lift), e.g. the
+// batch encoding of a vector whose slots all equal m, or false otherwise.
+func (P *Plaintext) constant(bfvcontext *BfvContext) (uint64, bool) {
+
+	if P.isNTT {
+		return 0, false
+	}
+
+	context := bfvcontext.contextQ
+
+	for i := range context.Modulus {
+		for j := uint64(1); j < bfvcontext.n; j++ {
+			if P.value.Coeffs[i][j] != 0 {
+				return 0, false
+			}
+		}
+	}
+
+	bredParams := context.GetBredParams()
+
+	q0 := context.Modulus[0]
+	if bfvcontext.delta[0] == 0 {
+		return 0, false
+	}
+
+	// m = (delta * m) * delta^-1 mod q0, which must match the other moduli
+	m := ring.BRed(P.value.Coeffs[0][0], ring.ModExp(bfvcontext.delta[0], q0-2, q0), q0, bredParams[0])
+
+	if m >= bfvcontext.t {
+		return 0, false
+	}
+
+	for i, qi := range context.Modulus[1:] {
+		if ring.BRed(m, bfvcontext.delta[i+1], qi, bredParams[i+1]) != P.value.Coeffs[i+1][0] {
+			return 0, false
+		}
+	}
+
+	return m, true
+}
+
 // EMBInv applies the InvNTT on a plaintext within the plaintext modulus.
 func (P *Plaintext) EMBInv(bfvcontext *BfvContext) error {
 
@@ -0,0 +1,133 @@
+package bfv
+
+import (
+	"errors"
+	"github.com/ldsec/lattigo/ring"
+)
+
+// CompressForTransport returns a copy of ct0 whose modulus is reduced to the smallest product of the first moduli of the chain
+// for which it still decrypts correctly, i.e. for which its noise budget (see Decryptor.NoiseBudget) under sk remains positive.
+// Each modulus is dropped by dividing and rounding the ciphertext by it, so that the compressed ciphertext has fewer limbs and a
+// smaller binary encoding. It must be brought back to the modulus of the bfvcontext with DecompressFromTransport before being
+// decrypted or evaluated. Returns an uncompressed copy of ct0 if sk does not match the bfvcontext.
+func (evaluator *Evaluator) CompressForTransport(ct0 *Ciphertext, sk *SecretKey) *Ciphertext {
+
+	compressed := ct0.CopyNew().Ciphertext()
+
+	decryptor, err := evaluator.bfvcontext.NewDecryptor(sk)
+	if err != nil {
+		return compressed
+	}
+
+	for len(compressed.value[0].Coeffs) > 1 {
+
+		candidate := evaluator.dropLastModulus(compressed)
+
+		decompressed, _ := evaluator.DecompressFromTransport(candidate)
+
+		if decryptor.NoiseBudget(decompressed) == 0 {
+			break
+		}
+
+		compressed = candidate
+	}
+
+	return compressed
+}
+
+// DecompressFromTransport brings a ciphertext compressed by CompressForTransport back to the modulus Q of the bfvcontext and returns
+// the result on a new ciphertext. A ciphertext modulo Q' = q_0 * ... * q_l is lifted to Q by multiplying it by Q/Q', which is exact,
+// and its limbs modulo the dropped moduli are thus zero.
+func (evaluator *Evaluator) DecompressFromTransport(ct0 *Ciphertext) (ctOut *Ciphertext, err error) {
+
+	context := evaluator.bfvcontext.contextQ
+
+	levels := len(ct0.value[0].Coeffs)
+
+	if levels == 0 || levels > len(context.Modulus) {
+		return nil, errors.New("cannot decompress -> the ciphertext has more limbs than the bfvcontext has moduli")
+	}
+
+	ctOut = evaluator.bfvcontext.NewCiphertext(ct0.Degree())
+
+	for k := range ct0.value {
+		ctOut.value[k].Copy(ct0.value[k])
+		evaluator.bfvcontext.liftToQ(ctOut.value[k], levels-1)
+	}
+
+	return ctOut, nil
+}
+
+// liftToQ multiplies the polynomial p, whose limbs 0 to level store a polynomial modulo Q' = q_0 * ... * q_level, by Q/Q', which
+// lifts it exactly to the modulus Q of the bfvcontext : its limbs modulo the moduli above the level are thus set to zero.
+func (bfvcontext *BfvContext) liftToQ(p *ring.Poly, level int) {
+
+	context := bfvcontext.contextQ
+
+	bredParams := context.GetBredParams()
+
+	for i := 0; i <= level; i++ {
+
+		qi := context.Modulus[i]
+
+		// Q/Q' mod qi
+		droppedModQi := uint64(1)
+		for _, qj := range context.Modulus[level+1:] {
+			droppedModQi = ring.BRed(droppedModQi, qj%qi, qi, bredParams[i])
+		}
+
+		for j := uint64(0); j < context.N; j++ {
+			p.Coeffs[i][j] = ring.BRed(p.Coeffs[i][j], droppedModQi, qi, bredParams[i])
+		}
+	}
+
+	for i := level + 1; i < len(context.Modulus); i++ {
+		for j := uint64(0); j < context.N; j++ {
+			p.Coeffs[i][j] = 0
+		}
+	}
+}
+
+// dropLastModulus returns a new ciphertext equal to round(ct0 / q_l), with q_l the last modulus of ct0, on one limb less.
+func (evaluator *Evaluator) dropLastModulus(ct0 *Ciphertext) (ctOut *Ciphertext) {
+
+	ctOut = evaluator.bfvcontext.NewCiphertextAtLevel(ct0.Degree(), ct0.Level()-1)
+
+	evaluator.dropLastModulusTo(ct0, ctOut)
+
+	return
+}
+
+// dropLastModulusTo computes round(ct0 / q_l), with q_l the last modulus of ct0, and returns the result on ctOut, whose limbs are
+// truncated to the level of ct0 minus one. ctOut must have at least as many limbs, and can be ct0.
+func (evaluator *Evaluator) dropLastModulusTo(ct0, ctOut *Ciphertext) {
+
+	context := evaluator.bfvcontext.contextQ
+
+	bredParams := context.GetBredParams()
+
+	level := ct0.Level()
+
+	ql := context.Modulus[level]
+	qlHalf := ql >> 1
+
+	for i := 0; i < level; i++ {
+
+		qi := context.Modulus[i]
+		qlInv := ring.ModExp(ql%qi, qi-2, qi)
+		qlHalfModQi := qlHalf % qi
+
+		for k := range ct0.value {
+			for j := uint64(0); j < context.N; j++ {
+				// (c + q_l/2 - [c + q_l/2]_q_l) / q_l = round(c / q_l)
+				r := (ct0.value[k].Coeffs[level][j] + qlHalf) % ql
+				coeff := ring.CRed(ring.CRed(ct0.value[k].Coeffs[i][j]+qlHalfModQi, qi)+qi-r%qi, qi)
+				ctOut.value[k].Coeffs[i][j] = ring.BRed(coeff, qlInv, qi, bredParams[i])
+			}
+		}
+	}
+
+	for k := range ct0.value {
+		ctOut.value[k].Coeffs = ctOut.value[k].Coeffs[:level]
+	}
+}
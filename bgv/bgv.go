@@ -0,0 +1,197 @@
+// Package bgv implements a RNS-accelerated version of the Brakerski-Gentry-Vaikuntanathan leveled homomorphic encryption scheme.
+// It provides modular arithmetic over the integers. Unlike bfv, the message is stored in the least significant bits of the
+// ciphertexts (the noise being a multiple of the plaintext modulus), and the noise growth is managed by modulus switching.
+package bgv
+
+import (
+	"errors"
+	"github.com/ldsec/lattigo/ring"
+	"math/bits"
+)
+
+// BgvContext is a struct which contains all the elements required to instantiate the BGV Scheme. This includes the parameters
+// (N, plaintext modulus, modulus chain, sampling, polynomial contexts and other parameters required for the homomorphic operations).
+type BgvContext struct {
+
+	// Polynomial degree
+	n uint64
+
+	// Plaintext Modulus
+	t uint64
+
+	// Uperbound in bits of the moduli
+	maxBit uint64
+
+	// Number of avaliable levels
+	levels uint64
+
+	// moduli chain
+	moduli []uint64
+
+	// Contexts chain
+	contextT     *ring.Context
+	contextLevel []*ring.Context
+
+	// Keys' context
+	contextKeys *ring.Context
+
+	// Pre-computed values for the modulus switching
+	// modSwitchParams[l-1][i] = q_l^-1 * (q_l mod t) mod q_i in Montgomery form
+	// qlModQi[l-1][i] = q_l mod q_i
+	// qlInvModT[l-1] = -q_l^-1 mod t
+	modSwitchParams [][]uint64
+	qlModQi         [][]uint64
+	qlInvModT       []uint64
+
+	// Sampling variance
+	sigma float64
+
+	// Samplers
+	gaussianSampler *ring.KYSampler
+	ternarySampler  *ring.TernarySampler
+
+	// Galois generator for the encoding
+	gen uint64
+}
+
+// NewBgvContextWithParam creates a new BgvContext with the given parameters. Returns an error if one of the parameters would not ensure the
+// correctness of the scheme (however it doesn't check for security).
+//
+// Parameters :
+//
+// - N     : the ring degree (must be a power of 2).
+//
+// - T     : the plaintext modulus (must be a prime, congruent to 1 mod 2N to enable batching).
+//
+// - Qi    : the modulus chain, composed of primes congruent to 1 mod 2N.
+//
+// - Sigma : the variance of the gaussian sampling.
+func NewBgvContextWithParam(params *Parameters) (bgvcontext *BgvContext, err error) {
+
+	if !ring.IsPrime(params.T) {
+		return nil, errors.New("cannot create bgvcontext -> plaintext modulus is not a prime")
+	}
+
+	if len(params.Qi) == 0 {
+		return nil, errors.New("cannot create bgvcontext -> empty modulus chain")
+	}
+
+	bgvcontext = new(BgvContext)
+
+	bgvcontext.n = params.N
+	bgvcontext.t = params.T
+	bgvcontext.sigma = params.Sigma
+	bgvcontext.levels = uint64(len(params.Qi))
+	bgvcontext.moduli = append([]uint64{}, params.Qi...)
+
+	for _, qi := range bgvcontext.moduli {
+
+		if qi <= params.T {
+			return nil, errors.New("cannot create bgvcontext -> moduli must be larger than the plaintext modulus")
+		}
+
+		if uint64(bits.Len64(qi)) > bgvcontext.maxBit {
+			bgvcontext.maxBit = uint64(bits.Len64(qi))
+		}
+	}
+
+	// Plaintext NTT Parameters
+	// We do not check for an error since the plaintext NTT is optional
+	// it will still compute the other relevant parameters
+	bgvcontext.contextT = ring.NewContext()
+	bgvcontext.contextT.SetParameters(params.N, []uint64{params.T})
+	bgvcontext.contextT.GenNTTParams()
+
+	// ========== START < CONTEXTS CHAIN > START ===============
+	bgvcontext.contextLevel = make([]*ring.Context, bgvcontext.levels)
+
+	for i := uint64(0); i < bgvcontext.levels; i++ {
+
+		bgvcontext.contextLevel[i] = ring.NewContext()
+
+		if err = bgvcontext.contextLevel[i].SetParameters(params.N, bgvcontext.moduli[:i+1]); err != nil {
+			return nil, err
+		}
+
+		if err = bgvcontext.contextLevel[i].GenNTTParams(); err != nil {
+			return nil, err
+		}
+	}
+	// ========== END < CONTEXTS CHAIN > END ===============
+
+	// Context used for the generation of the keys
+	bgvcontext.contextKeys = bgvcontext.contextLevel[bgvcontext.levels-1]
+
+	// ========== START < MODULUS SWITCHING PRE-COMPUATION PARAMETERS > START ===============
+	bgvcontext.modSwitchParams = make([][]uint64, bgvcontext.levels-1)
+	bgvcontext.qlModQi = make([][]uint64, bgvcontext.levels-1)
+	bgvcontext.qlInvModT = make([]uint64, bgvcontext.levels-1)
+
+	for l := uint64(1); l < bgvcontext.levels; l++ {
+
+		ql := bgvcontext.moduli[l]
+		qlModT := ql % params.T
+
+		// -q_l^-1 mod t
+		bgvcontext.qlInvModT[l-1] = params.T - ring.ModExp(qlModT, params.T-2, params.T)
+
+		bgvcontext.modSwitchParams[l-1] = make([]uint64, l)
+		bgvcontext.qlModQi[l-1] = make([]uint64, l)
+
+		bredParams := bgvcontext.contextLevel[l-1].GetBredParams()
+
+		for i := uint64(0); i < l; i++ {
+
+			qi := bgvcontext.moduli[i]
+
+			bgvcontext.qlModQi[l-1][i] = ql % qi
+
+			// q_l^-1 * (q_l mod t) mod q_i
+			bgvcontext.modSwitchParams[l-1][i] = ring.BRed(ring.ModExp(ql%qi, qi-2, qi), qlModT, qi, bredParams[i])
+			bgvcontext.modSwitchParams[l-1][i] = ring.MForm(bgvcontext.modSwitchParams[l-1][i], qi, bredParams[i])
+		}
+	}
+	// ========== END < MODULUS SWITCHING PRE-COMPUATION PARAMETERS > END ===============
+
+	bgvcontext.gaussianSampler = bgvcontext.contextKeys.NewKYSampler(params.Sigma, int(6*params.Sigma))
+	bgvcontext.ternarySampler = bgvcontext.contextKeys.NewTernarySampler()
+
+	bgvcontext.gen = 5
+
+	return bgvcontext, nil
+}
+
+// N returns N which is the degree of the ring, of the target bgvcontext.
+func (bgvcontext *BgvContext) N() uint64 {
+	return bgvcontext.n
+}
+
+// T returns the plaintext modulus of the target bgvcontext.
+func (bgvcontext *BgvContext) T() uint64 {
+	return bgvcontext.t
+}
+
+// Levels returns the number of levels of the target bgvcontext, i.e. the number of moduli of the modulus chain.
+func (bgvcontext *BgvContext) Levels() uint64 {
+	return bgvcontext.levels
+}
+
+// Moduli returns the modulus chain of the target bgvcontext.
+func (bgvcontext *BgvContext) Moduli() []uint64 {
+	return bgvcontext.moduli
+}
+
+// Sigma returns sigma, which is the variance used for the gaussian sampling of the target bgvcontext.
+func (bgvcontext *BgvContext) Sigma() float64 {
+	return bgvcontext.sigma
+}
+
+// ContextT returns the polynomial (ring) context of the plaintext modulus, of the target bgvcontext.
+func (bgvcontext *BgvContext) ContextT() *ring.Context {
+	return bgvcontext.contextT
+}
+
+// ContextKeys returns the polynomial (ring) context of the full modulus chain, in which the keys are generated.
+func (bgvcontext *BgvContext) ContextKeys() *ring.Context {
+	return bgvcontext.contextKeys
+}
@@ -0,0 +1,265 @@
+package bgv
+
+import (
+	"fmt"
+	"github.com/ldsec/lattigo/ring"
+	"testing"
+)
+
+type BGVTESTPARAMS struct {
+	bgvcontext   *BgvContext
+	batchencoder *BatchEncoder
+	kgen         *KeyGenerator
+	sk           *SecretKey
+	pk           *PublicKey
+	encryptorSk  *Encryptor
+	encryptorPk  *Encryptor
+	decryptor    *Decryptor
+	evaluator    *Evaluator
+}
+
+func Test_BGV(t *testing.T) {
+
+	var err error
+
+	paramSets := DefaultParams[1:2]
+
+	bitDecomps := []uint64{16, 60}
+
+	for _, params := range paramSets {
+
+		bgvTest := new(BGVTESTPARAMS)
+
+		if bgvTest.bgvcontext, err = NewBgvContextWithParam(&params); err != nil {
+			t.Fatal(err)
+		}
+
+		bgvTest.kgen = bgvTest.bgvcontext.NewKeyGenerator()
+
+		if bgvTest.batchencoder, err = bgvTest.bgvcontext.NewBatchEncoder(); err != nil {
+			t.Error(err)
+		}
+
+		bgvTest.sk, bgvTest.pk = bgvTest.kgen.NewKeyPair()
+
+		if bgvTest.decryptor, err = bgvTest.bgvcontext.NewDecryptor(bgvTest.sk); err != nil {
+			t.Error(err)
+		}
+
+		if bgvTest.encryptorPk, err = bgvTest.bgvcontext.NewEncryptorFromPk(bgvTest.pk); err != nil {
+			t.Error(err)
+		}
+
+		if bgvTest.encryptorSk, err = bgvTest.bgvcontext.NewEncryptorFromSk(bgvTest.sk); err != nil {
+			t.Error(err)
+		}
+
+		bgvTest.evaluator = bgvTest.bgvcontext.NewEvaluator()
+
+		test_EncryptDecrypt(bgvTest, t)
+		test_Evaluation(bgvTest, t)
+		test_Relinearization(bgvTest, bitDecomps, t)
+		test_ModSwitch(bgvTest, bitDecomps, t)
+	}
+}
+
+func newTestVectors(bgvTest *BGVTESTPARAMS) (coeffs *ring.Poly, plaintext *Plaintext, ciphertext *Ciphertext, err error) {
+
+	coeffs = bgvTest.bgvcontext.contextT.NewUniformPoly()
+
+	plaintext = bgvTest.bgvcontext.NewPlaintext()
+
+	if err = bgvTest.batchencoder.EncodeUint(coeffs.Coeffs[0], plaintext); err != nil {
+		return nil, nil, nil, err
+	}
+
+	if ciphertext, err = bgvTest.encryptorPk.EncryptNew(plaintext); err != nil {
+		return nil, nil, nil, err
+	}
+
+	return coeffs, plaintext, ciphertext, nil
+}
+
+func verifyTestVectors(bgvTest *BGVTESTPARAMS, coeffs *ring.Poly, ciphertext *Ciphertext, t *testing.T) {
+
+	coeffsTest := bgvTest.batchencoder.DecodeUint(bgvTest.decryptor.DecryptNew(ciphertext))
+
+	if equalslice(coeffs.Coeffs[0], coeffsTest) != true {
+		t.Errorf("decryption error")
+	}
+}
+
+func test_EncryptDecrypt(bgvTest *BGVTESTPARAMS, t *testing.T) {
+
+	bgvcontext := bgvTest.bgvcontext
+
+	t.Run(fmt.Sprintf("N=%d/T=%d/levels=%d/EncryptPk", bgvcontext.N(), bgvcontext.T(), bgvcontext.Levels()), func(t *testing.T) {
+
+		coeffs, _, ciphertext, _ := newTestVectors(bgvTest)
+		verifyTestVectors(bgvTest, coeffs, ciphertext, t)
+	})
+
+	t.Run(fmt.Sprintf("N=%d/T=%d/levels=%d/EncryptSk", bgvcontext.N(), bgvcontext.T(), bgvcontext.Levels()), func(t *testing.T) {
+
+		coeffs, plaintext, _, _ := newTestVectors(bgvTest)
+
+		ciphertext, err := bgvTest.encryptorSk.EncryptNew(plaintext)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		verifyTestVectors(bgvTest, coeffs, ciphertext, t)
+	})
+}
+
+func test_Evaluation(bgvTest *BGVTESTPARAMS, t *testing.T) {
+
+	bgvcontext := bgvTest.bgvcontext
+	evaluator := bgvTest.evaluator
+	contextT := bgvcontext.contextT
+
+	t.Run(fmt.Sprintf("N=%d/T=%d/levels=%d/AddSubNeg", bgvcontext.N(), bgvcontext.T(), bgvcontext.Levels()), func(t *testing.T) {
+
+		coeffs0, _, ciphertext0, _ := newTestVectors(bgvTest)
+		coeffs1, _, ciphertext1, _ := newTestVectors(bgvTest)
+
+		sum, err := evaluator.AddNew(ciphertext0, ciphertext1)
+		if err != nil {
+			t.Fatal(err)
+		}
+		want := contextT.NewPoly()
+		contextT.Add(coeffs0, coeffs1, want)
+		verifyTestVectors(bgvTest, want, sum, t)
+
+		diff, err := evaluator.SubNew(ciphertext0, ciphertext1)
+		if err != nil {
+			t.Fatal(err)
+		}
+		contextT.Sub(coeffs0, coeffs1, want)
+		verifyTestVectors(bgvTest, want, diff, t)
+
+		if err := evaluator.Neg(ciphertext0, ciphertext0); err != nil {
+			t.Fatal(err)
+		}
+		contextT.Neg(coeffs0, want)
+		verifyTestVectors(bgvTest, want, ciphertext0, t)
+	})
+
+	t.Run(fmt.Sprintf("N=%d/T=%d/levels=%d/MulPlain", bgvcontext.N(), bgvcontext.T(), bgvcontext.Levels()), func(t *testing.T) {
+
+		coeffs0, _, ciphertext0, _ := newTestVectors(bgvTest)
+		coeffs1, plaintext1, _, _ := newTestVectors(bgvTest)
+
+		if err := evaluator.MulPlain(ciphertext0, plaintext1, ciphertext0); err != nil {
+			t.Fatal(err)
+		}
+
+		contextT.MulCoeffs(coeffs0, coeffs1, coeffs0)
+		verifyTestVectors(bgvTest, coeffs0, ciphertext0, t)
+	})
+
+	t.Run(fmt.Sprintf("N=%d/T=%d/levels=%d/Mul", bgvcontext.N(), bgvcontext.T(), bgvcontext.Levels()), func(t *testing.T) {
+
+		coeffs0, _, ciphertext0, _ := newTestVectors(bgvTest)
+		coeffs1, _, ciphertext1, _ := newTestVectors(bgvTest)
+
+		receiver, err := evaluator.MulNew(ciphertext0, ciphertext1)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if receiver.Degree() != 2 {
+			t.Errorf("error : Mul returned a ciphertext of degree %d", receiver.Degree())
+		}
+
+		contextT.MulCoeffs(coeffs0, coeffs1, coeffs0)
+		verifyTestVectors(bgvTest, coeffs0, receiver, t)
+
+		if _, err := evaluator.MulNew(receiver, ciphertext1); err == nil {
+			t.Errorf("error : Mul with a ciphertext of degree 2 did not return an error")
+		}
+	})
+}
+
+func test_Relinearization(bgvTest *BGVTESTPARAMS, bitDecomps []uint64, t *testing.T) {
+
+	bgvcontext := bgvTest.bgvcontext
+	evaluator := bgvTest.evaluator
+
+	for _, bitDecomp := range bitDecomps {
+
+		rlk := bgvTest.kgen.NewRelinKey(bgvTest.sk, bitDecomp)
+
+		t.Run(fmt.Sprintf("N=%d/T=%d/levels=%d/bitDecomp=%d/MulRelin", bgvcontext.N(), bgvcontext.T(), bgvcontext.Levels(), bitDecomp), func(t *testing.T) {
+
+			coeffs0, _, ciphertext0, _ := newTestVectors(bgvTest)
+			coeffs1, _, ciphertext1, _ := newTestVectors(bgvTest)
+
+			receiver, err := evaluator.MulNew(ciphertext0, ciphertext1)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if err := evaluator.Relinearize(receiver, rlk, receiver); err != nil {
+				t.Fatal(err)
+			}
+
+			if receiver.Degree() != 1 {
+				t.Errorf("error : Relinearize returned a ciphertext of degree %d", receiver.Degree())
+			}
+
+			bgvcontext.contextT.MulCoeffs(coeffs0, coeffs1, coeffs0)
+			verifyTestVectors(bgvTest, coeffs0, receiver, t)
+		})
+	}
+}
+
+func test_ModSwitch(bgvTest *BGVTESTPARAMS, bitDecomps []uint64, t *testing.T) {
+
+	bgvcontext := bgvTest.bgvcontext
+	evaluator := bgvTest.evaluator
+
+	for _, bitDecomp := range bitDecomps {
+
+		rlk := bgvTest.kgen.NewRelinKey(bgvTest.sk, bitDecomp)
+
+		t.Run(fmt.Sprintf("N=%d/T=%d/levels=%d/bitDecomp=%d/ModSwitch", bgvcontext.N(), bgvcontext.T(), bgvcontext.Levels(), bitDecomp), func(t *testing.T) {
+
+			coeffs, _, ciphertext, _ := newTestVectors(bgvTest)
+
+			// Squares and switches the modulus down at each level, the last level being only switched
+			for ciphertext.Level() > 0 {
+
+				if ciphertext.Level() > 1 {
+
+					receiver, err := evaluator.MulNew(ciphertext, ciphertext)
+					if err != nil {
+						t.Fatal(err)
+					}
+
+					if ciphertext, err = evaluator.RelinearizeNew(receiver, rlk); err != nil {
+						t.Fatal(err)
+					}
+
+					bgvcontext.contextT.MulCoeffs(coeffs, coeffs, coeffs)
+				}
+
+				level := ciphertext.Level()
+
+				if err := evaluator.ModSwitch(ciphertext, ciphertext); err != nil {
+					t.Fatal(err)
+				}
+
+				if ciphertext.Level() != level-1 {
+					t.Errorf("error : ModSwitch returned a ciphertext at level %d instead of %d", ciphertext.Level(), level-1)
+				}
+
+				verifyTestVectors(bgvTest, coeffs, ciphertext, t)
+			}
+
+			if err := evaluator.ModSwitch(ciphertext, ciphertext); err == nil {
+				t.Errorf("error : ModSwitch at level 0 did not return an error")
+			}
+		})
+	}
+}
@@ -0,0 +1,61 @@
+package bgv
+
+import (
+	"github.com/ldsec/lattigo/ring"
+)
+
+// Ciphertext is a *ring.Poly array representing a polynomial of degree > 0 where coefficients are in R_Ql, Ql being the
+// product of the moduli of the modulus chain up to the level of the ciphertext. The polynomials are always in the NTT domain.
+type Ciphertext struct {
+	value []*ring.Poly
+}
+
+// NewCiphertext creates a new empty ciphertext of the given degree at the given level.
+func (bgvcontext *BgvContext) NewCiphertext(degree, level uint64) *Ciphertext {
+	ciphertext := new(Ciphertext)
+	ciphertext.value = make([]*ring.Poly, degree+1)
+	for i := uint64(0); i < degree+1; i++ {
+		ciphertext.value[i] = bgvcontext.contextLevel[level].NewPoly()
+	}
+	return ciphertext
+}
+
+// Value returns the value of the target ciphertext (as a slice of polynomials in CRT form).
+func (ciphertext *Ciphertext) Value() []*ring.Poly {
+	return ciphertext.value
+}
+
+// Degree returns the degree of the target ciphertext.
+func (ciphertext *Ciphertext) Degree() uint64 {
+	return uint64(len(ciphertext.value) - 1)
+}
+
+// Level returns the level of the target ciphertext, i.e. the number of moduli of its modulus minus one.
+func (ciphertext *Ciphertext) Level() uint64 {
+	return uint64(len(ciphertext.value[0].Coeffs) - 1)
+}
+
+// CopyNew creates a new ciphertext which is a copy of the target ciphertext.
+func (ciphertext *Ciphertext) CopyNew() *Ciphertext {
+
+	ctxCopy := new(Ciphertext)
+
+	ctxCopy.value = make([]*ring.Poly, ciphertext.Degree()+1)
+	for i := range ciphertext.value {
+		ctxCopy.value[i] = ciphertext.value[i].CopyNew()
+	}
+
+	return ctxCopy
+}
+
+// resize sets the degree of the target ciphertext to the given degree, appending new polynomials at its level or
+// deleting the last polynomials.
+func (ciphertext *Ciphertext) resize(bgvcontext *BgvContext, degree uint64) {
+	level := ciphertext.Level()
+	if ciphertext.Degree() > degree {
+		ciphertext.value = ciphertext.value[:degree+1]
+	}
+	for ciphertext.Degree() < degree {
+		ciphertext.value = append(ciphertext.value, bgvcontext.contextLevel[level].NewPoly())
+	}
+}
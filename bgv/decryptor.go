@@ -0,0 +1,73 @@
+package bgv
+
+import (
+	"errors"
+	"github.com/ldsec/lattigo/ring"
+)
+
+// Decryptor is a structure used to decrypt ciphertext. It stores the secret-key.
+type Decryptor struct {
+	bgvcontext *BgvContext
+	sk         *SecretKey
+	polypool   *ring.Poly
+}
+
+// NewDecryptor creates a new Decryptor from the target bgvcontext with the secret-key given as input.
+func (bgvcontext *BgvContext) NewDecryptor(sk *SecretKey) (decryptor *Decryptor, err error) {
+
+	if sk.sk.GetDegree() != int(bgvcontext.n) {
+		return nil, errors.New("error : secret_key degree must match context degree")
+	}
+
+	decryptor = new(Decryptor)
+
+	decryptor.bgvcontext = bgvcontext
+
+	decryptor.sk = sk
+
+	decryptor.polypool = bgvcontext.contextKeys.NewPoly()
+
+	return decryptor, nil
+}
+
+// DecryptNew decrypts the input ciphertext and returns the result on a new plaintext.
+func (decryptor *Decryptor) DecryptNew(ciphertext *Ciphertext) (plaintext *Plaintext) {
+
+	plaintext = decryptor.bgvcontext.NewPlaintext()
+
+	decryptor.Decrypt(ciphertext, plaintext)
+
+	return
+}
+
+// Decrypt decrypts the input ciphertext and returns the result on the provided receiver plaintext. The phase
+// [ct[0] + ct[1]*s + ... + ct[d]*s^d] is reconstructed modulo the modulus of the ciphertext, centered, and
+// reduced modulo the plaintext modulus t.
+func (decryptor *Decryptor) Decrypt(ciphertext *Ciphertext, plaintext *Plaintext) {
+
+	context := decryptor.bgvcontext.contextLevel[ciphertext.Level()]
+
+	phase := decryptor.polypool
+
+	context.Copy(ciphertext.value[ciphertext.Degree()], phase)
+
+	for i := ciphertext.Degree(); i > 0; i-- {
+		context.MulCoeffsMontgomery(phase, decryptor.sk.sk, phase)
+		context.Add(phase, ciphertext.value[i-1], phase)
+	}
+
+	context.InvNTT(phase, phase)
+
+	coeffsBigint := make([]*ring.Int, context.N)
+
+	context.PolyToBigint(phase, coeffsBigint)
+
+	tBigint := ring.NewUint(decryptor.bgvcontext.t)
+
+	for i := range coeffsBigint {
+		coeffsBigint[i].Center(context.ModulusBigint)
+		plaintext.value.Coeffs[0][i] = coeffsBigint[i].Mod(coeffsBigint[i], tBigint).Uint64()
+	}
+
+	phase.Zero()
+}
@@ -0,0 +1,84 @@
+package bgv
+
+import (
+	"errors"
+	"math/bits"
+)
+
+// BatchEncoder is a structure storing the parameters encode values on a plaintext in a SIMD fashion.
+type BatchEncoder struct {
+	indexMatrix []uint64
+	bgvcontext  *BgvContext
+}
+
+// NewBatchEncoder creates a new BatchEncoder from the target bgvcontext.
+func (bgvcontext *BgvContext) NewBatchEncoder() (batchencoder *BatchEncoder, err error) {
+
+	if bgvcontext.contextT.AllowsNTT() != true {
+		return nil, errors.New("cannot create batch encoder : plaintext modulus does not allow NTT")
+	}
+
+	var m, pos, index1, index2 uint64
+
+	batchencoder = new(BatchEncoder)
+
+	batchencoder.bgvcontext = bgvcontext
+
+	batchencoder.indexMatrix = make([]uint64, bgvcontext.n)
+
+	logN := uint64(bits.Len64(bgvcontext.n) - 1)
+
+	row_size := bgvcontext.n >> 1
+	m = (bgvcontext.n << 1)
+	pos = 1
+
+	for i := uint64(0); i < row_size; i++ {
+
+		index1 = (pos - 1) >> 1
+		index2 = (m - pos - 1) >> 1
+
+		batchencoder.indexMatrix[i] = bitReverse64(index1, logN)
+		batchencoder.indexMatrix[i|row_size] = bitReverse64(index2, logN)
+
+		pos *= bgvcontext.gen
+		pos &= (m - 1)
+	}
+
+	return batchencoder, nil
+}
+
+// EncodeUint encodes an uint64 slice of size at most N on a plaintext.
+func (batchencoder *BatchEncoder) EncodeUint(coeffs []uint64, plaintext *Plaintext) error {
+
+	if len(coeffs) > len(batchencoder.indexMatrix) {
+		return errors.New("invalid input to encode (number of coefficients must be smaller or equal to the context)")
+	}
+
+	for i := 0; i < len(coeffs); i++ {
+		plaintext.value.Coeffs[0][batchencoder.indexMatrix[i]] = coeffs[i] % batchencoder.bgvcontext.t
+	}
+
+	for i := len(coeffs); i < len(batchencoder.indexMatrix); i++ {
+		plaintext.value.Coeffs[0][batchencoder.indexMatrix[i]] = 0
+	}
+
+	batchencoder.bgvcontext.contextT.InvNTT(plaintext.value, plaintext.value)
+
+	return nil
+}
+
+// DecodeUint decodes a batched plaintext and returns the coefficients in a uint64 slice.
+func (batchencoder *BatchEncoder) DecodeUint(plaintext *Plaintext) (coeffs []uint64) {
+
+	pol := plaintext.value.CopyNew()
+
+	batchencoder.bgvcontext.contextT.NTT(pol, pol)
+
+	coeffs = make([]uint64, batchencoder.bgvcontext.n)
+
+	for i := range coeffs {
+		coeffs[i] = pol.Coeffs[0][batchencoder.indexMatrix[i]]
+	}
+
+	return
+}
@@ -0,0 +1,138 @@
+package bgv
+
+import (
+	"errors"
+	"github.com/ldsec/lattigo/ring"
+)
+
+// Encryptor is a structure holding the parameters needed to encrypt plaintexts.
+type Encryptor struct {
+	bgvcontext *BgvContext
+	pk         *PublicKey
+	sk         *SecretKey
+	polypool   [2]*ring.Poly
+}
+
+// NewEncryptorFromPk creates a new Encryptor with the provided public-key.
+// This encryptor can be used to encrypt plaintexts, using the stored key.
+func (bgvcontext *BgvContext) NewEncryptorFromPk(pk *PublicKey) (*Encryptor, error) {
+	return bgvcontext.newEncryptor(pk, nil)
+}
+
+// NewEncryptorFromSk creates a new Encryptor with the provided secret-key.
+// This encryptor can be used to encrypt plaintexts, using the stored key.
+func (bgvcontext *BgvContext) NewEncryptorFromSk(sk *SecretKey) (*Encryptor, error) {
+	return bgvcontext.newEncryptor(nil, sk)
+}
+
+func (bgvcontext *BgvContext) newEncryptor(pk *PublicKey, sk *SecretKey) (encryptor *Encryptor, err error) {
+
+	if pk != nil && (uint64(pk.pk[0].GetDegree()) != bgvcontext.n || uint64(pk.pk[1].GetDegree()) != bgvcontext.n) {
+		return nil, errors.New("error : pk ring degree doesn't match bgvcontext ring degree")
+	}
+
+	if sk != nil && uint64(sk.sk.GetDegree()) != bgvcontext.n {
+		return nil, errors.New("error : sk ring degree doesn't match bgvcontext ring degree")
+	}
+
+	encryptor = new(Encryptor)
+	encryptor.bgvcontext = bgvcontext
+	encryptor.pk = pk
+	encryptor.sk = sk
+	encryptor.polypool[0] = bgvcontext.contextKeys.NewPoly()
+	encryptor.polypool[1] = bgvcontext.contextKeys.NewPoly()
+
+	return encryptor, nil
+}
+
+// EncryptNew encrypts the input plaintext using the stored key and returns the result on a newly created ciphertext
+// at the maximum level.
+//
+// encrypt with pk : ciphertext = [pk[0]*u + t*e_0 + m, pk[1]*u + t*e_1]
+// encrypt with sk : ciphertext = [-a*sk + t*e + m, a]
+func (encryptor *Encryptor) EncryptNew(plaintext *Plaintext) (ciphertext *Ciphertext, err error) {
+
+	ciphertext = encryptor.bgvcontext.NewCiphertext(1, encryptor.bgvcontext.levels-1)
+
+	return ciphertext, encryptor.Encrypt(plaintext, ciphertext)
+}
+
+// Encrypt encrypts the input plaintext using the stored key, and returns the result on the receiver ciphertext,
+// which must be of degree 1 and at the maximum level.
+//
+// encrypt with pk : ciphertext = [pk[0]*u + t*e_0 + m, pk[1]*u + t*e_1]
+// encrypt with sk : ciphertext = [-a*sk + t*e + m, a]
+func (encryptor *Encryptor) Encrypt(plaintext *Plaintext, ciphertext *Ciphertext) (err error) {
+
+	if ciphertext.Degree() != 1 || ciphertext.Level() != encryptor.bgvcontext.levels-1 {
+		return errors.New("cannot encrypt -> receiver ciphertext must be of degree 1 and at the maximum level")
+	}
+
+	if encryptor.sk != nil {
+
+		encryptfromsk(encryptor, plaintext, ciphertext)
+
+	} else if encryptor.pk != nil {
+
+		encryptfrompk(encryptor, plaintext, ciphertext)
+
+	} else {
+
+		return errors.New("cannot encrypt -> public-key and/or secret-key has not been set")
+	}
+
+	return nil
+}
+
+func encryptfrompk(encryptor *Encryptor, plaintext *Plaintext, ciphertext *Ciphertext) {
+
+	context := encryptor.bgvcontext.contextKeys
+	t := encryptor.bgvcontext.t
+
+	// u
+	encryptor.bgvcontext.ternarySampler.SampleMontgomeryNTT(0.5, encryptor.polypool[0])
+
+	// ct[0] = pk[0]*u
+	// ct[1] = pk[1]*u
+	context.MulCoeffsMontgomery(encryptor.polypool[0], encryptor.pk.pk[0], ciphertext.value[0])
+	context.MulCoeffsMontgomery(encryptor.polypool[0], encryptor.pk.pk[1], ciphertext.value[1])
+
+	// ct[0] = pk[0]*u + t*e0
+	encryptor.bgvcontext.gaussianSampler.SampleNTT(encryptor.polypool[0])
+	context.MulScalar(encryptor.polypool[0], t, encryptor.polypool[0])
+	context.Add(ciphertext.value[0], encryptor.polypool[0], ciphertext.value[0])
+
+	// ct[1] = pk[1]*u + t*e1
+	encryptor.bgvcontext.gaussianSampler.SampleNTT(encryptor.polypool[0])
+	context.MulScalar(encryptor.polypool[0], t, encryptor.polypool[0])
+	context.Add(ciphertext.value[1], encryptor.polypool[0], ciphertext.value[1])
+
+	// ct[0] = pk[0]*u + t*e0 + m
+	plaintext.lift(context, encryptor.polypool[1])
+	context.Add(ciphertext.value[0], encryptor.polypool[1], ciphertext.value[0])
+
+	encryptor.polypool[0].Zero()
+	encryptor.polypool[1].Zero()
+}
+
+func encryptfromsk(encryptor *Encryptor, plaintext *Plaintext, ciphertext *Ciphertext) {
+
+	context := encryptor.bgvcontext.contextKeys
+
+	// ct = [-a*s , a]
+	context.Copy(context.NewUniformPoly(), ciphertext.value[1])
+	context.MulCoeffsMontgomery(ciphertext.value[1], encryptor.sk.sk, ciphertext.value[0])
+	context.Neg(ciphertext.value[0], ciphertext.value[0])
+
+	// ct = [-a*s + t*e, a]
+	encryptor.bgvcontext.gaussianSampler.SampleNTT(encryptor.polypool[0])
+	context.MulScalar(encryptor.polypool[0], encryptor.bgvcontext.t, encryptor.polypool[0])
+	context.Add(ciphertext.value[0], encryptor.polypool[0], ciphertext.value[0])
+
+	// ct = [-a*s + t*e + m, a]
+	plaintext.lift(context, encryptor.polypool[1])
+	context.Add(ciphertext.value[0], encryptor.polypool[1], ciphertext.value[0])
+
+	encryptor.polypool[0].Zero()
+	encryptor.polypool[1].Zero()
+}
@@ -0,0 +1,346 @@
+package bgv
+
+import (
+	"errors"
+	"github.com/ldsec/lattigo/ring"
+)
+
+// Evaluator is a struct holding the necessary elements to operates the homomorphic operations between ciphertext and/or plaintexts.
+// It also holds a small memory pool used to store intermediate computations.
+type Evaluator struct {
+	bgvcontext *BgvContext
+	ringpool   [5]*ring.Poly
+}
+
+// NewEvaluator creates a new Evaluator, that can be used to do homomorphic
+// operations on the ciphertexts and/or plaintexts. It stores a small pool of polynomials
+// that will be used for intermediate values.
+func (bgvcontext *BgvContext) NewEvaluator() (evaluator *Evaluator) {
+
+	evaluator = new(Evaluator)
+	evaluator.bgvcontext = bgvcontext
+
+	for i := range evaluator.ringpool {
+		evaluator.ringpool[i] = bgvcontext.contextKeys.NewPoly()
+	}
+
+	return evaluator
+}
+
+// checkLevels returns the context of the common level of the input ciphertexts, or an error if their levels differ.
+func (evaluator *Evaluator) checkLevels(ciphertexts ...*Ciphertext) (context *ring.Context, err error) {
+
+	level := ciphertexts[0].Level()
+
+	for _, ciphertext := range ciphertexts[1:] {
+		if ciphertext.Level() != level {
+			return nil, errors.New("ciphertexts are not at the same level")
+		}
+	}
+
+	return evaluator.bgvcontext.contextLevel[level], nil
+}
+
+// Add adds ct0 to ct1 and returns the result on ctOut. The ciphertexts must be at the same level.
+func (evaluator *Evaluator) Add(ct0, ct1, ctOut *Ciphertext) (err error) {
+
+	context, err := evaluator.checkLevels(ct0, ct1, ctOut)
+	if err != nil {
+		return errors.New("cannot add -> " + err.Error())
+	}
+
+	evaluator.evaluate(context, ct0, ct1, ctOut, context.Add)
+
+	return nil
+}
+
+// AddNew adds ct0 to ct1 and returns the result on a newly created ciphertext.
+func (evaluator *Evaluator) AddNew(ct0, ct1 *Ciphertext) (ctOut *Ciphertext, err error) {
+
+	ctOut = evaluator.bgvcontext.NewCiphertext(max(ct0.Degree(), ct1.Degree()), ct0.Level())
+
+	return ctOut, evaluator.Add(ct0, ct1, ctOut)
+}
+
+// Sub subtracts ct1 to ct0 and returns the result on ctOut. The ciphertexts must be at the same level.
+func (evaluator *Evaluator) Sub(ct0, ct1, ctOut *Ciphertext) (err error) {
+
+	context, err := evaluator.checkLevels(ct0, ct1, ctOut)
+	if err != nil {
+		return errors.New("cannot sub -> " + err.Error())
+	}
+
+	evaluator.evaluate(context, ct0, ct1, ctOut, context.Sub)
+
+	return nil
+}
+
+// SubNew subtracts ct1 to ct0 and returns the result on a newly created ciphertext.
+func (evaluator *Evaluator) SubNew(ct0, ct1 *Ciphertext) (ctOut *Ciphertext, err error) {
+
+	ctOut = evaluator.bgvcontext.NewCiphertext(max(ct0.Degree(), ct1.Degree()), ct0.Level())
+
+	return ctOut, evaluator.Sub(ct0, ct1, ctOut)
+}
+
+// evaluate applies the given coefficient-wise operation on the components of ct0 and ct1, considering
+// the missing components of the lowest degree ciphertext as zero.
+func (evaluator *Evaluator) evaluate(context *ring.Context, ct0, ct1, ctOut *Ciphertext, operation func(p0, p1, pOut *ring.Poly)) {
+
+	maxDegree := max(ct0.Degree(), ct1.Degree())
+	minDegree := min(ct0.Degree(), ct1.Degree())
+
+	ctOut.resize(evaluator.bgvcontext, maxDegree)
+
+	for i := uint64(0); i < minDegree+1; i++ {
+		operation(ct0.value[i], ct1.value[i], ctOut.value[i])
+	}
+
+	zero := evaluator.ringpool[0]
+	zero.Zero()
+
+	for i := minDegree + 1; i < maxDegree+1; i++ {
+		if ct0.Degree() > ct1.Degree() {
+			operation(ct0.value[i], zero, ctOut.value[i])
+		} else {
+			operation(zero, ct1.value[i], ctOut.value[i])
+		}
+	}
+}
+
+// Neg negates ct0 and returns the result on ctOut. The ciphertexts must be at the same level.
+func (evaluator *Evaluator) Neg(ct0, ctOut *Ciphertext) (err error) {
+
+	context, err := evaluator.checkLevels(ct0, ctOut)
+	if err != nil {
+		return errors.New("cannot neg -> " + err.Error())
+	}
+
+	ctOut.resize(evaluator.bgvcontext, ct0.Degree())
+
+	for i := range ct0.value {
+		context.Neg(ct0.value[i], ctOut.value[i])
+	}
+
+	return nil
+}
+
+// Mul multiplies ct0 by ct1 and returns the result on ctOut, which is of degree 2. The ciphertexts must be of degree 1 and
+// at the same level. Since the message is stored in the least significant bits of the ciphertexts, the tensoring is done directly
+// modulo the modulus of the ciphertexts, without scaling.
+func (evaluator *Evaluator) Mul(ct0, ct1, ctOut *Ciphertext) (err error) {
+
+	if ct0.Degree() != 1 || ct1.Degree() != 1 {
+		return errors.New("cannot mul -> input ciphertexts must be of degree 1")
+	}
+
+	context, err := evaluator.checkLevels(ct0, ct1, ctOut)
+	if err != nil {
+		return errors.New("cannot mul -> " + err.Error())
+	}
+
+	a0, a1 := evaluator.ringpool[0], evaluator.ringpool[1]
+	c0, c1, c2 := evaluator.ringpool[2], evaluator.ringpool[3], evaluator.ringpool[4]
+
+	context.MForm(ct0.value[0], a0)
+	context.MForm(ct0.value[1], a1)
+
+	// c0 = a0*b0
+	context.MulCoeffsMontgomery(a0, ct1.value[0], c0)
+
+	// c1 = a0*b1 + a1*b0
+	context.MulCoeffsMontgomery(a0, ct1.value[1], c1)
+	context.MulCoeffsMontgomeryAndAdd(a1, ct1.value[0], c1)
+
+	// c2 = a1*b1
+	context.MulCoeffsMontgomery(a1, ct1.value[1], c2)
+
+	ctOut.resize(evaluator.bgvcontext, 2)
+
+	context.Copy(c0, ctOut.value[0])
+	context.Copy(c1, ctOut.value[1])
+	context.Copy(c2, ctOut.value[2])
+
+	return nil
+}
+
+// MulNew multiplies ct0 by ct1 and returns the result on a newly created ciphertext of degree 2.
+func (evaluator *Evaluator) MulNew(ct0, ct1 *Ciphertext) (ctOut *Ciphertext, err error) {
+
+	ctOut = evaluator.bgvcontext.NewCiphertext(2, ct0.Level())
+
+	return ctOut, evaluator.Mul(ct0, ct1, ctOut)
+}
+
+// MulPlain multiplies ct0 by the plaintext pt and returns the result on ctOut. The ciphertexts must be at the same level.
+func (evaluator *Evaluator) MulPlain(ct0 *Ciphertext, pt *Plaintext, ctOut *Ciphertext) (err error) {
+
+	context, err := evaluator.checkLevels(ct0, ctOut)
+	if err != nil {
+		return errors.New("cannot mul plain -> " + err.Error())
+	}
+
+	pt.lift(context, evaluator.ringpool[0])
+	context.MForm(evaluator.ringpool[0], evaluator.ringpool[0])
+
+	ctOut.resize(evaluator.bgvcontext, ct0.Degree())
+
+	for i := range ct0.value {
+		context.MulCoeffsMontgomery(ct0.value[i], evaluator.ringpool[0], ctOut.value[i])
+	}
+
+	return nil
+}
+
+// Relinearize relinearizes the ciphertext ct0 of degree 2 and returns the result on ctOut, of degree 1. The ciphertexts must be at the same level.
+//
+// The last component of ct0 is decomposed modulo each modulus qi of its level and in base 2^bitDecomp, and each digit is
+// multiplied with the corresponding element of the evaluation-key, whose noise is a multiple of the plaintext modulus.
+func (evaluator *Evaluator) Relinearize(ct0 *Ciphertext, evakey *EvaluationKey, ctOut *Ciphertext) (err error) {
+
+	if ct0.Degree() != 2 {
+		return errors.New("cannot relinearize -> input ciphertext must be of degree 2")
+	}
+
+	context, err := evaluator.checkLevels(ct0, ctOut)
+	if err != nil {
+		return errors.New("cannot relinearize -> " + err.Error())
+	}
+
+	if len(evakey.evakey) < len(context.Modulus) {
+		return errors.New("cannot relinearize -> evaluation-key has less moduli than the ciphertext level")
+	}
+
+	c2, digit := evaluator.ringpool[0], evaluator.ringpool[1]
+	c0, c1 := evaluator.ringpool[2], evaluator.ringpool[3]
+
+	context.Copy(ct0.value[0], c0)
+	context.Copy(ct0.value[1], c1)
+
+	context.InvNTT(ct0.value[2], c2)
+
+	mask := uint64(1<<evakey.bitDecomp) - 1
+
+	bredParams := context.GetBredParams()
+
+	for i := range context.Modulus {
+
+		for j := range evakey.evakey[i] {
+
+			// digit = ([c2]_qi >> (bitDecomp*j)) & (2^bitDecomp - 1)
+			shift := evakey.bitDecomp * uint64(j)
+
+			for w := uint64(0); w < context.N; w++ {
+
+				coeff := (c2.Coeffs[i][w] >> shift) & mask
+
+				for k, qk := range context.Modulus {
+					digit.Coeffs[k][w] = ring.BRedAdd(coeff, qk, bredParams[k])
+				}
+			}
+
+			context.NTT(digit, digit)
+
+			context.MulCoeffsMontgomeryAndAdd(evakey.evakey[i][j][0], digit, c0)
+			context.MulCoeffsMontgomeryAndAdd(evakey.evakey[i][j][1], digit, c1)
+		}
+	}
+
+	ctOut.resize(evaluator.bgvcontext, 1)
+
+	context.Copy(c0, ctOut.value[0])
+	context.Copy(c1, ctOut.value[1])
+
+	return nil
+}
+
+// RelinearizeNew relinearizes the ciphertext ct0 of degree 2 and returns the result on a newly created ciphertext of degree 1.
+func (evaluator *Evaluator) RelinearizeNew(ct0 *Ciphertext, evakey *EvaluationKey) (ctOut *Ciphertext, err error) {
+
+	ctOut = evaluator.bgvcontext.NewCiphertext(1, ct0.Level())
+
+	return ctOut, evaluator.Relinearize(ct0, evakey, ctOut)
+}
+
+// ModSwitch switches the modulus of ct0 from Ql = q0*...*ql to Ql-1 = q0*...*ql-1 and returns the result on ctOut, which is
+// then at the level l-1. The noise is divided by approximately ql, while the message is preserved.
+//
+// Each component c is replaced by (c - delta)/ql * [ql]_t, where delta is the smallest correction such that
+// delta = c mod ql and delta = 0 mod t : the division is exact and only changes the message by a factor ql^-1 mod t,
+// which is cancelled by the multiplication by [ql]_t.
+func (evaluator *Evaluator) ModSwitch(ct0, ctOut *Ciphertext) (err error) {
+
+	if ct0.Level() == 0 {
+		return errors.New("cannot mod switch -> input ciphertext already at level 0")
+	}
+
+	if _, err = evaluator.checkLevels(ct0, ctOut); err != nil {
+		return errors.New("cannot mod switch -> " + err.Error())
+	}
+
+	ctOut.resize(evaluator.bgvcontext, ct0.Degree())
+
+	for i := range ct0.value {
+		modswitch(evaluator, ct0.value[i], ctOut.value[i])
+	}
+
+	return nil
+}
+
+// ModSwitchNew switches the modulus of ct0 from Ql to Ql-1 and returns the result on a newly created ciphertext at the level l-1.
+func (evaluator *Evaluator) ModSwitchNew(ct0 *Ciphertext) (ctOut *Ciphertext, err error) {
+
+	ctOut = ct0.CopyNew()
+
+	return ctOut, evaluator.ModSwitch(ctOut, ctOut)
+}
+
+// modswitch performs the modulus switching of a polynomial in the NTT domain : starts with a base Q = {q0, q1, ..., ql},
+// ends up with a base Q = {q0, q1, ..., ql-1}.
+func modswitch(evaluator *Evaluator, p0, p1 *ring.Poly) {
+
+	bgvcontext := evaluator.bgvcontext
+
+	level := len(p0.Coeffs) - 1
+
+	context := bgvcontext.contextLevel[level]
+	mredParams := context.GetMredParams()
+	bredParams := context.GetBredParams()
+
+	t := bgvcontext.t
+	bredParamsT := bgvcontext.contextT.GetBredParams()[0]
+
+	ql := context.Modulus[level]
+	qlInvModT := bgvcontext.qlInvModT[level-1]
+
+	a, k, delta := evaluator.ringpool[0].Coeffs[0], evaluator.ringpool[1].Coeffs[0], evaluator.ringpool[2].Coeffs[0]
+
+	// a = [c]_ql
+	ring.InvNTT(p0.Coeffs[level], a, context.N, context.GetNttPsiInv()[level], context.GetNttNInv()[level], ql, mredParams[level])
+
+	// k = -a * ql^-1 mod t, such that delta = a + ql*k = 0 mod t
+	for j := uint64(0); j < context.N; j++ {
+		k[j] = ring.BRed(a[j]%t, qlInvModT, t, bredParamsT)
+	}
+
+	for i := 0; i < level; i++ {
+
+		qi := context.Modulus[i]
+		qlModQi := bgvcontext.qlModQi[level-1][i]
+		params := bgvcontext.modSwitchParams[level-1][i]
+
+		// delta mod qi
+		for j := uint64(0); j < context.N; j++ {
+			delta[j] = ring.CRed(ring.BRedAdd(a[j], qi, bredParams[i])+ring.BRed(k[j], qlModQi, qi, bredParams[i]), qi)
+		}
+
+		ring.NTT(delta, delta, context.N, context.GetNttPsi()[i], qi, mredParams[i], bredParams[i])
+
+		// (c - delta) * ql^-1 * [ql]_t
+		for j := uint64(0); j < context.N; j++ {
+			p1.Coeffs[i][j] = ring.MRed(ring.CRed(p0.Coeffs[i][j]+qi-delta[j], qi), params, qi, mredParams[i])
+		}
+	}
+
+	p1.Coeffs = p1.Coeffs[:level]
+}
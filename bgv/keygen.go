@@ -0,0 +1,165 @@
+package bgv
+
+import (
+	"github.com/ldsec/lattigo/ring"
+	"math"
+	"math/bits"
+)
+
+// KeyGenerator is a structure that stores the elements required to create new keys,
+// as well as a small memory pool for intermediate values.
+type KeyGenerator struct {
+	bgvcontext *BgvContext
+	context    *ring.Context
+	polypool   *ring.Poly
+}
+
+// SecretKey is a structure that stores the secret-key.
+type SecretKey struct {
+	sk *ring.Poly
+}
+
+// PublicKey is a structure that stores the public-key.
+type PublicKey struct {
+	pk [2]*ring.Poly
+}
+
+// EvaluationKey is a structure that stores the switching-key required during the relinearization.
+type EvaluationKey struct {
+	bitDecomp uint64
+	evakey    [][][2]*ring.Poly
+}
+
+// NewKeyGenerator creates a new KeyGenerator, from which the secret, public and evaluation keys can be generated.
+func (bgvcontext *BgvContext) NewKeyGenerator() (keygen *KeyGenerator) {
+	keygen = new(KeyGenerator)
+	keygen.bgvcontext = bgvcontext
+	keygen.context = bgvcontext.contextKeys
+	keygen.polypool = keygen.context.NewPoly()
+	return
+}
+
+// NewSecretKey creates a new SecretKey with the distribution [1/3, 1/3, 1/3].
+func (keygen *KeyGenerator) NewSecretKey() (sk *SecretKey) {
+	sk = new(SecretKey)
+	sk.sk, _ = keygen.bgvcontext.ternarySampler.SampleMontgomeryNTTNew(1.0 / 3)
+	return sk
+}
+
+// Get returns the polynomial of the target secret-key.
+func (sk *SecretKey) Get() *ring.Poly {
+	return sk.sk
+}
+
+// Set sets the polynomial of the target secret key as the input polynomial.
+func (sk *SecretKey) Set(poly *ring.Poly) {
+	sk.sk = poly.CopyNew()
+}
+
+// NewPublicKey generates a new public-key from the provided secret-key.
+func (keygen *KeyGenerator) NewPublicKey(sk *SecretKey) (pk *PublicKey) {
+
+	pk = new(PublicKey)
+
+	//pk[0] = [-a*s + t*e]
+	//pk[1] = [a]
+	pk.pk[0] = keygen.bgvcontext.gaussianSampler.SampleNTTNew()
+	keygen.context.MulScalar(pk.pk[0], keygen.bgvcontext.t, pk.pk[0])
+	pk.pk[1] = keygen.context.NewUniformPoly()
+
+	keygen.context.MulCoeffsMontgomeryAndSub(sk.sk, pk.pk[1], pk.pk[0])
+
+	return pk
+}
+
+// Get returns the polynomials of the public-key.
+func (pk *PublicKey) Get() [2]*ring.Poly {
+	return pk.pk
+}
+
+// NewKeyPair generates a new secret-key with distribution [1/3, 1/3, 1/3] and a corresponding public-key.
+func (keygen *KeyGenerator) NewKeyPair() (sk *SecretKey, pk *PublicKey) {
+	sk = keygen.NewSecretKey()
+	return sk, keygen.NewPublicKey(sk)
+}
+
+// NewRelinKey generates a new evaluation-key from the provided secret-key, used to relinearize the ciphertexts of degree 2
+// to ciphertexts of degree 1. Bitdecomp is the power of two binary decomposition of the key. A higher bitDecomp will induce
+// smaller keys, faster relinearization, but at the cost of more noise. The key is generated on the full modulus chain and can
+// be used to relinearize ciphertexts at any level.
+//
+// For each modulus qi and each digit j of its decomposition, the key is [-a*s + t*e + s^2 * (qiBarre*qiStar) * 2^(bitDecomp*j), a].
+func (keygen *KeyGenerator) NewRelinKey(sk *SecretKey, bitDecomp uint64) (evakey *EvaluationKey) {
+
+	if bitDecomp > keygen.bgvcontext.maxBit || bitDecomp == 0 {
+		bitDecomp = keygen.bgvcontext.maxBit
+	}
+
+	context := keygen.context
+	mredParams := context.GetMredParams()
+
+	evakey = new(EvaluationKey)
+	evakey.bitDecomp = bitDecomp
+	evakey.evakey = make([][][2]*ring.Poly, len(context.Modulus))
+
+	// s^2
+	context.MulCoeffsMontgomery(sk.sk, sk.sk, keygen.polypool)
+
+	for i, qi := range context.Modulus {
+
+		bitLog := uint64(math.Ceil(float64(bits.Len64(qi)) / float64(bitDecomp)))
+
+		evakey.evakey[i] = make([][2]*ring.Poly, bitLog)
+
+		for j := uint64(0); j < bitLog; j++ {
+
+			// t*e
+			evakey.evakey[i][j][0] = keygen.bgvcontext.gaussianSampler.SampleNTTNew()
+			context.MulScalar(evakey.evakey[i][j][0], keygen.bgvcontext.t, evakey.evakey[i][j][0])
+			// a
+			evakey.evakey[i][j][1] = context.NewUniformPoly()
+
+			// t*e + s^2 * (qiBarre*qiStar) * 2^(bitDecomp*j)
+			// (qiBarre*qiStar)%qi = 1, else 0
+			for w := uint64(0); w < context.N; w++ {
+				evakey.evakey[i][j][0].Coeffs[i][w] = ring.CRed(evakey.evakey[i][j][0].Coeffs[i][w]+ring.PowerOf2(keygen.polypool.Coeffs[i][w], bitDecomp*j, qi, mredParams[i]), qi)
+			}
+
+			// -a*s + t*e + s^2 * (qiBarre*qiStar) * 2^(bitDecomp*j)
+			context.MulCoeffsMontgomeryAndSub(evakey.evakey[i][j][1], sk.sk, evakey.evakey[i][j][0])
+
+			context.MForm(evakey.evakey[i][j][0], evakey.evakey[i][j][0])
+			context.MForm(evakey.evakey[i][j][1], evakey.evakey[i][j][1])
+		}
+	}
+
+	keygen.polypool.Zero()
+
+	return
+}
+
+// Get returns the polynomials of the evaluation-key, indexed by modulus and digit of the decomposition.
+func (evk *EvaluationKey) Get() [][][2]*ring.Poly {
+	return evk.evakey
+}
+
+// BitDecomp returns the power of two binary decomposition of the evaluation-key.
+func (evk *EvaluationKey) BitDecomp() uint64 {
+	return evk.bitDecomp
+}
+
+// SetRelinKeys sets the polynomials of the target evaluation-key as copies of the input polynomials, indexed by modulus and
+// digit of the decomposition and given in the Montgomery and NTT domain, with the given bit decomposition.
+func (evk *EvaluationKey) SetRelinKeys(rlk [][][2]*ring.Poly, bitDecomp uint64) {
+
+	evk.bitDecomp = bitDecomp
+	evk.evakey = make([][][2]*ring.Poly, len(rlk))
+
+	for i := range rlk {
+		evk.evakey[i] = make([][2]*ring.Poly, len(rlk[i]))
+		for j := range rlk[i] {
+			evk.evakey[i][j][0] = rlk[i][j][0].CopyNew()
+			evk.evakey[i][j][1] = rlk[i][j][1].CopyNew()
+		}
+	}
+}
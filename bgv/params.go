@@ -0,0 +1,22 @@
+package bgv
+
+import (
+	"github.com/ldsec/lattigo/bfv"
+)
+
+// Parameters is a struct storing the necessary parameters to instantiate a BgvContext. The moduli Qi form the modulus
+// chain : a fresh ciphertext is defined modulo their product, and each modulus switching drops the last remaining modulus.
+type Parameters struct {
+	N     uint64
+	T     uint64
+	Qi    []uint64
+	Sigma float64
+}
+
+// DefaultParams is an array default parameters with increasing homomorphic capacity. They reuse the ring degree, plaintext
+// modulus and ciphertext moduli of the corresponding default parameters of the bfv package.
+var DefaultParams = []Parameters{
+	{bfv.DefaultParams[0].N, bfv.DefaultParams[0].T, bfv.DefaultParams[0].Qi, 3.19},
+	{bfv.DefaultParams[1].N, bfv.DefaultParams[1].T, bfv.DefaultParams[1].Qi, 3.19},
+	{bfv.DefaultParams[2].N, bfv.DefaultParams[2].T, bfv.DefaultParams[2].Qi, 3.19},
+}
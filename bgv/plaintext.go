@@ -0,0 +1,29 @@
+package bgv
+
+import (
+	"github.com/ldsec/lattigo/ring"
+)
+
+// Plaintext is a polynomial with coefficients in R_t, t being the plaintext modulus.
+type Plaintext struct {
+	value *ring.Poly
+}
+
+// NewPlaintext creates a new plaintext from the target bgvcontext.
+func (bgvcontext *BgvContext) NewPlaintext() *Plaintext {
+	return &Plaintext{bgvcontext.contextT.NewPoly()}
+}
+
+// Value returns the polynomial of the target plaintext.
+func (plaintext *Plaintext) Value() *ring.Poly {
+	return plaintext.value
+}
+
+// lift switches the modulus of the plaintext from t to Ql, l being the level of the given context, and stores the result
+// in the NTT domain on pol.
+func (plaintext *Plaintext) lift(context *ring.Context, pol *ring.Poly) {
+	for i := range context.Modulus {
+		copy(pol.Coeffs[i], plaintext.value.Coeffs[0])
+	}
+	context.NTT(pol, pol)
+}
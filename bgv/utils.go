@@ -0,0 +1,43 @@
+package bgv
+
+// bitReverse64 returns the bit-reverse value of the input value, within a context of 2^bitLen.
+func bitReverse64(index, bitLen uint64) uint64 {
+	indexReverse := uint64(0)
+	for i := uint64(0); i < bitLen; i++ {
+		if (index>>i)&1 != 0 {
+			indexReverse |= 1 << (bitLen - 1 - i)
+		}
+	}
+	return indexReverse
+}
+
+// min returns the minimum of the two input values.
+func min(a, b uint64) (r uint64) {
+	if a <= b {
+		return a
+	}
+	return b
+}
+
+// max returns the maximum of the two input values.
+func max(a, b uint64) (r uint64) {
+	if a >= b {
+		return a
+	}
+	return b
+}
+
+// equalslice compares two slices of uint64 values, and return true if they are equal, else false.
+func equalslice(a, b []uint64) bool {
+
+	if len(a) != len(b) {
+		return false
+	}
+
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
@@ -96,6 +96,7 @@ func Test_CKKS(t *testing.T) {
 	test_SwitchKeys(ckksTest, t)
 	test_Conjugate(ckksTest, t)
 	test_RotColumns(ckksTest, t)
+	test_CoeffsToSlots(ckksTest, t)
 
 	test_MarshalCiphertext(ckksTest, t)
 	test_MarshalSecretKey(ckksTest, t)
@@ -1361,6 +1362,59 @@ func test_RotColumns(params *CKKSTESTPARAMS, t *testing.T) {
 	})
 }
 
+func test_CoeffsToSlots(params *CKKSTESTPARAMS, t *testing.T) {
+
+	slots := params.ckkscontext.slots
+
+	values, _, ciphertext, err := new_test_vectors(params, -1, 1)
+	if err != nil {
+		t.Error(err)
+	}
+
+	// Coefficients of the plaintext polynomial, as expected in the slots after CoeffsToSlots
+	encoder := params.ckkscontext.NewEncoder()
+	preprocessCmplx(values, encoder)
+	invfft(encoder.values, encoder.inv_roots)
+
+	coeffsWant := make([]complex128, slots)
+	for i := uint64(0); i < slots; i++ {
+		coeffsWant[i] = complex(real(encoder.values[i]), real(encoder.values[i+slots]))
+	}
+
+	ciphertextSlots := params.ckkscontext.NewCiphertext(1, ciphertext.Level(), ciphertext.Scale())
+
+	t.Run(fmt.Sprintf("logN=%d/logQ=%d/levels=%d/CoeffsToSlots", params.ckkscontext.logN,
+		params.ckkscontext.logQ,
+		params.ckkscontext.levels), func(t *testing.T) {
+
+		if err := params.evaluator.CoeffsToSlots(ciphertext, params.rotkey, ciphertextSlots); err != nil {
+			t.Error(err)
+		}
+
+		if err := verify_test_vectors(params, coeffsWant, ciphertextSlots, t); err != nil {
+			t.Error(err)
+		}
+	})
+
+	t.Run(fmt.Sprintf("logN=%d/logQ=%d/levels=%d/SlotsToCoeffs", params.ckkscontext.logN,
+		params.ckkscontext.logQ,
+		params.ckkscontext.levels), func(t *testing.T) {
+
+		ciphertextCoeffs, err := params.evaluator.SlotsToCoeffsNew(ciphertextSlots, params.rotkey)
+		if err != nil {
+			t.Error(err)
+		}
+
+		if ciphertextCoeffs.Level() != ciphertext.Level()-2 {
+			t.Errorf("error : CoeffsToSlots and SlotsToCoeffs must consume one level each")
+		}
+
+		if err := verify_test_vectors(params, values, ciphertextCoeffs, t); err != nil {
+			t.Error(err)
+		}
+	})
+}
+
 func test_MarshalCiphertext(params *CKKSTESTPARAMS, t *testing.T) {
 
 	t.Run(fmt.Sprintf("logN=%d/logQ=%d/levels=%d/MarshalCiphertext", params.ckkscontext.logN,
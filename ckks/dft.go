@@ -0,0 +1,200 @@
+package ckks
+
+import (
+	"errors"
+	"math"
+	"math/bits"
+	"math/cmplx"
+)
+
+// dftContext stores the tables from which the diagonals of the matrices of the homomorphic DFT are computed, built once per evaluator.
+// The diagonals themselves are computed on the fly by linearTransform, so that the memory is linear in the number of slots.
+type dftContext struct {
+	encoder *Encoder
+	slots   uint64
+	mask    uint64
+	// roots[t] = psi^t, psi = exp(2*pi*i/2N) being a primitive 2N-th root of unity
+	roots []complex128
+	// galEl[j] = 5^j mod 2N, so that the slot j is the evaluation on zeta_j = psi^galEl[j]
+	galEl []uint64
+}
+
+// CoeffsToSlotsNew homomorphically evaluates the inverse of the canonical embedding on ct0 and returns the result on a newly created element.
+// See CoeffsToSlots.
+func (evaluator *Evaluator) CoeffsToSlotsNew(ct0 *Ciphertext, rotkey *RotationKey) (ctOut *Ciphertext, err error) {
+
+	ctOut = evaluator.ckkscontext.NewCiphertext(1, ct0.Level(), ct0.Scale())
+
+	return ctOut, evaluator.CoeffsToSlots(ct0, rotkey, ctOut)
+}
+
+// CoeffsToSlots homomorphically evaluates the inverse of the canonical embedding on ct0 and returns the result on ctOut. If ct0 encrypts
+// the polynomial m(X) = m_0 + m_1X + ... + m_{N-1}X^{N-1}, ctOut will encrypt the vector [m_0 + i*m_{N/2}, ..., m_{N/2-1} + i*m_{N-1}]
+// (at the scale of ct0), i.e. the coefficients of the plaintext moved into the slots.
+//
+// The transform is evaluated as a linear transform using the baby-step giant-step diagonal method, consumes one level and requires a
+// rotation-key storing all the power of two rotations. The receiver must be at the same level as ct0 and will be rescaled by one level.
+// The matrix is dense : the evaluation encodes and multiplies N/2 plaintexts, so that its cost grows with N^2 log(N).
+func (evaluator *Evaluator) CoeffsToSlots(ct0 *Ciphertext, rotkey *RotationKey, ctOut *Ciphertext) (err error) {
+	return evaluator.linearTransform(ct0, evaluator.getDFTContext().coeffsToSlots, rotkey, ctOut)
+}
+
+// SlotsToCoeffsNew homomorphically evaluates the canonical embedding on ct0 and returns the result on a newly created element.
+// See SlotsToCoeffs.
+func (evaluator *Evaluator) SlotsToCoeffsNew(ct0 *Ciphertext, rotkey *RotationKey) (ctOut *Ciphertext, err error) {
+
+	ctOut = evaluator.ckkscontext.NewCiphertext(1, ct0.Level(), ct0.Scale())
+
+	return ctOut, evaluator.SlotsToCoeffs(ct0, rotkey, ctOut)
+}
+
+// SlotsToCoeffs homomorphically evaluates the canonical embedding on ct0 and returns the result on ctOut. It is the inverse of CoeffsToSlots :
+// if ct0 encrypts the vector [m_0 + i*m_{N/2}, ..., m_{N/2-1} + i*m_{N-1}], ctOut will encrypt the polynomial m(X) = m_0 + m_1X + ... + m_{N-1}X^{N-1}.
+//
+// The transform is evaluated as a linear transform using the baby-step giant-step diagonal method, consumes one level and requires a
+// rotation-key storing all the power of two rotations. The receiver must be at the same level as ct0 and will be rescaled by one level.
+// The matrix is dense : the evaluation encodes and multiplies N/2 plaintexts, so that its cost grows with N^2 log(N).
+func (evaluator *Evaluator) SlotsToCoeffs(ct0 *Ciphertext, rotkey *RotationKey, ctOut *Ciphertext) (err error) {
+	return evaluator.linearTransform(ct0, evaluator.getDFTContext().slotsToCoeffs, rotkey, ctOut)
+}
+
+// getDFTContext returns the tables of the homomorphic DFT, generating them on the first call.
+//
+// Slot j of a plaintext is the evaluation of the plaintext polynomial on zeta_j = psi^(5^j) with psi a primitive 2N-th root of unity.
+// Since zeta_j^(N/2) = i for all j, the canonical embedding restricted to real polynomials is the (N/2)x(N/2) matrix
+// V[j][k] = zeta_j^k applied on the vector m_k + i*m_{k+N/2}, and its inverse is V^-1 = (2/N) * V^H.
+func (evaluator *Evaluator) getDFTContext() *dftContext {
+
+	if evaluator.dft != nil {
+		return evaluator.dft
+	}
+
+	ckkscontext := evaluator.ckkscontext
+
+	m := ckkscontext.n << 1
+
+	dft := new(dftContext)
+	dft.encoder = ckkscontext.NewEncoder()
+	dft.slots = ckkscontext.slots
+	dft.mask = m - 1
+
+	dft.roots = make([]complex128, m)
+	for t := uint64(0); t < m; t++ {
+		angle := 2 * math.Pi * float64(t) / float64(m)
+		dft.roots[t] = complex(math.Cos(angle), math.Sin(angle))
+	}
+
+	dft.galEl = make([]uint64, dft.slots)
+	dft.galEl[0] = 1
+	for j := uint64(1); j < dft.slots; j++ {
+		dft.galEl[j] = (dft.galEl[j-1] * ckkscontext.gen) & dft.mask
+	}
+
+	evaluator.dft = dft
+
+	return dft
+}
+
+// zeta returns zeta_j^k = V[j][k].
+func (dft *dftContext) zeta(j, k uint64) complex128 {
+	return dft.roots[(dft.galEl[j]*k)&dft.mask]
+}
+
+// slotsToCoeffs returns the coefficient j of the diagonal d of V, i.e. V[j][(j+d) mod slots].
+func (dft *dftContext) slotsToCoeffs(d, j uint64) complex128 {
+	return dft.zeta(j, (j+d)&(dft.slots-1))
+}
+
+// coeffsToSlots returns the coefficient j of the diagonal d of V^-1, i.e. conj(V[(j+d) mod slots][j]) / slots.
+func (dft *dftContext) coeffsToSlots(d, j uint64) complex128 {
+	return cmplx.Conj(dft.zeta((j+d)&(dft.slots-1), j)) / complex(float64(dft.slots), 0)
+}
+
+// linearTransform evaluates the product between the matrix whose diagonal d has the coefficients diagonal(d, j) and the vector of slots of
+// ct0 using the baby-step giant-step diagonal method, and returns the rescaled result on ctOut. Each diagonal is computed when it is encoded.
+func (evaluator *Evaluator) linearTransform(ct0 *Ciphertext, diagonal func(d, j uint64) complex128, rotkey *RotationKey, ctOut *Ciphertext) (err error) {
+
+	ckkscontext := evaluator.ckkscontext
+
+	if ct0.Degree() != 1 || ctOut.Degree() != 1 {
+		return errors.New("cannot evaluate linear transform -> input and output ciphertext must be of degree 1")
+	}
+
+	if ct0.Level() == 0 {
+		return errors.New("cannot evaluate linear transform -> input ciphertext already at level 0")
+	}
+
+	if ct0.Level() != ctOut.Level() {
+		return errors.New("cannot evaluate linear transform -> reciever ciphertext does not match input ciphertext level")
+	}
+
+	encoder := evaluator.getDFTContext().encoder
+
+	slots := ckkscontext.slots
+	level := ct0.Level()
+
+	// Baby-step giant-step split : n1 baby steps and n2 giant steps with n1*n2 = slots
+	n1 := uint64(1) << ((uint64(bits.Len64(slots)-1) + 1) >> 1)
+	n2 := slots / n1
+
+	// Baby steps : rot_b(ct0) for 0 <= b < n1
+	babySteps := make([]*Ciphertext, n1)
+	babySteps[0] = ct0
+	for b := uint64(1); b < n1; b++ {
+		babySteps[b] = ckkscontext.NewCiphertext(1, level, ct0.Scale())
+		if err = evaluator.RotateColumns(ct0, b, rotkey, babySteps[b]); err != nil {
+			return err
+		}
+	}
+
+	plaintext := ckkscontext.NewPlaintext(level, ckkscontext.logScale)
+	diag := make([]complex128, slots)
+
+	var acc, inner, tmp *Ciphertext
+
+	tmp = ckkscontext.NewCiphertext(1, level, ct0.Scale()+ckkscontext.logScale)
+
+	// Giant steps : sum_g rot_(g*n1)(sum_b rot_-(g*n1)(diag_(g*n1+b)) * rot_b(ct0))
+	for g := uint64(0); g < n2; g++ {
+
+		inner = ckkscontext.NewCiphertext(1, level, ct0.Scale()+ckkscontext.logScale)
+
+		for b := uint64(0); b < n1; b++ {
+
+			for j := uint64(0); j < slots; j++ {
+				diag[j] = diagonal(g*n1+b, (j-g*n1)&(slots-1))
+			}
+
+			if err = encoder.EncodeComplex(plaintext, diag); err != nil {
+				return err
+			}
+
+			if b == 0 {
+				err = evaluator.MulRelin(babySteps[b], plaintext, nil, inner)
+			} else {
+				if err = evaluator.MulRelin(babySteps[b], plaintext, nil, tmp); err == nil {
+					err = evaluator.Add(inner, tmp, inner)
+				}
+			}
+
+			if err != nil {
+				return err
+			}
+		}
+
+		if g == 0 {
+			acc = inner
+		} else {
+
+			if err = evaluator.RotateColumns(inner, g*n1, rotkey, tmp); err != nil {
+				return err
+			}
+
+			if err = evaluator.Add(acc, tmp, acc); err != nil {
+				return err
+			}
+		}
+	}
+
+	return evaluator.Rescale(acc, ctOut)
+}
@@ -168,7 +168,7 @@ func encodeFromComplex(plaintext *Plaintext, encoder *Encoder) {
 
 	invfft(encoder.values, encoder.inv_roots)
 
-	for i, qi := range encoder.ckkscontext.moduli {
+	for i, qi := range encoder.ckkscontext.moduli[:plaintext.Level()+1] {
 
 		for j := uint64(0); j < encoder.ckkscontext.n; j++ {
 
@@ -11,6 +11,7 @@ type Evaluator struct {
 	ckkscontext *CkksContext
 	ringpool    [6]*ring.Poly
 	ctxpool     *Ciphertext
+	dft         *dftContext
 }
 
 // NewEvaluator creates a new Evaluator, that can be used to do homomorphic
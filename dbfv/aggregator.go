@@ -0,0 +1,175 @@
+package dbfv
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"reflect"
+	"sort"
+)
+
+// Aggregator folds the shares of the parties of a protocol round one by one, keeping track of the parties whose share has
+// been folded. Its state can be saved and restored (see SaveState and LoadAggregatorState), so that a long aggregation over
+// many parties can survive a restart of the party running it.
+//
+// By default, the shares are folded in their order of arrival. For reproducible logs and checkpoints, the Aggregator can instead
+// fold them in ascending order of party (see SetSortedFolding).
+type Aggregator struct {
+	aggregate Share
+	folded    map[uint32]bool
+	order     []uint32
+	sorted    bool
+	pending   map[uint32]Share
+}
+
+// NewAggregator creates a new Aggregator folding the shares on the given share, which must be an empty share of the type of
+// the shares to aggregate (e.g. EkgProtocol.NewShareRoundOneEmpty).
+func NewAggregator(empty Share) *Aggregator {
+	return &Aggregator{aggregate: empty, folded: make(map[uint32]bool), pending: make(map[uint32]Share)}
+}
+
+// SetSortedFolding sets whether the shares are folded in ascending order of party instead of their order of arrival. When set,
+// Fold only buffers the shares, which are folded by Flush, so that the same set of parties always aggregates in the same order
+// regardless of the order in which their shares arrived. It must be set before the first call to Fold.
+func (a *Aggregator) SetSortedFolding(sorted bool) {
+	a.sorted = sorted
+}
+
+// Flush folds the buffered shares in ascending order of party (see SetSortedFolding). It does nothing if no share is buffered.
+// A share failing to be folded is dropped, along with the shares of the following parties, which can be folded again.
+func (a *Aggregator) Flush() error {
+
+	parties := make([]uint32, 0, len(a.pending))
+	for party := range a.pending {
+		parties = append(parties, party)
+	}
+
+	sort.Slice(parties, func(i, j int) bool { return parties[i] < parties[j] })
+
+	defer func() {
+		a.pending = make(map[uint32]Share)
+	}()
+
+	for _, party := range parties {
+
+		if err := a.aggregate.Aggregate(a.pending[party]); err != nil {
+			return fmt.Errorf("cannot fold share of the party %d -> %s", party, err)
+		}
+
+		a.folded[party] = true
+		a.order = append(a.order, party)
+	}
+
+	return nil
+}
+
+// Fold adds the share of the given party to the running aggregate, or buffers it until the next Flush if the folding is sorted
+// (see SetSortedFolding), in which case the share must not be modified until then. Returns an error if the share of the party
+// has already been folded or buffered, or if the share is not of the type of the aggregate.
+func (a *Aggregator) Fold(party uint32, share Share) error {
+
+	if a.folded[party] || a.pending[party] != nil {
+		return fmt.Errorf("cannot fold share -> the share of the party %d has already been folded", party)
+	}
+
+	if a.sorted {
+
+		if reflect.TypeOf(share) != reflect.TypeOf(a.aggregate) {
+			return errors.New("cannot fold share -> share types do not match")
+		}
+
+		a.pending[party] = share
+
+		return nil
+	}
+
+	if err := a.aggregate.Aggregate(share); err != nil {
+		return err
+	}
+
+	a.folded[party] = true
+	a.order = append(a.order, party)
+
+	return nil
+}
+
+// Order returns the list of the parties whose share has been folded, in the order their shares were folded. For an Aggregator
+// restored by LoadAggregatorState, the parties folded before the save are listed first, in ascending order.
+func (a *Aggregator) Order() []uint32 {
+	return append([]uint32(nil), a.order...)
+}
+
+// Folded returns the sorted list of the parties whose share has been folded.
+func (a *Aggregator) Folded() (parties []uint32) {
+
+	parties = make([]uint32, 0, len(a.folded))
+	for party := range a.folded {
+		parties = append(parties, party)
+	}
+
+	sort.Slice(parties, func(i, j int) bool { return parties[i] < parties[j] })
+
+	return
+}
+
+// Aggregate returns the running aggregate of the folded shares. The buffered shares of a sorted folding are not included until
+// the next Flush.
+func (a *Aggregator) Aggregate() Share {
+	return a.aggregate
+}
+
+// SaveState encodes the running aggregate and the list of the folded parties on a byte slice. The encoding is the number of
+// folded parties on 4 bytes, followed by the parties on 4 bytes each and by the binary encoding of the aggregate. The buffered
+// shares of a sorted folding are flushed first.
+func (a *Aggregator) SaveState() ([]byte, error) {
+
+	if err := a.Flush(); err != nil {
+		return nil, err
+	}
+
+	aggregateData, err := a.aggregate.MarshalBinary()
+	if err != nil {
+		return nil, err
+	}
+
+	parties := a.Folded()
+
+	data := make([]byte, 4+4*len(parties), 4+4*len(parties)+len(aggregateData))
+
+	binary.BigEndian.PutUint32(data[:4], uint32(len(parties)))
+
+	for i, party := range parties {
+		binary.BigEndian.PutUint32(data[4+4*i:8+4*i], party)
+	}
+
+	return append(data, aggregateData...), nil
+}
+
+// LoadAggregatorState restores an Aggregator from a state previously encoded by SaveState. The running aggregate is decoded on
+// the given share, which must be an empty share of the type of the saved aggregate.
+func LoadAggregatorState(data []byte, empty Share) (*Aggregator, error) {
+
+	if len(data) < 4 {
+		return nil, errors.New("cannot load aggregator state -> invalid state encoding (data too short)")
+	}
+
+	count := uint64(binary.BigEndian.Uint32(data[:4]))
+
+	if uint64(len(data)) < 4+4*count {
+		return nil, errors.New("cannot load aggregator state -> invalid state encoding (data too short)")
+	}
+
+	a := NewAggregator(empty)
+
+	for i := uint64(0); i < count; i++ {
+		party := binary.BigEndian.Uint32(data[4+4*i : 8+4*i])
+		a.folded[party] = true
+		a.order = append(a.order, party)
+	}
+
+	if err := a.aggregate.UnMarshalBinary(data[4+4*count:]); err != nil {
+		return nil, err
+	}
+
+	return a, nil
+}
@@ -0,0 +1,110 @@
+package dbfv
+
+import (
+	"github.com/ldsec/lattigo/ring"
+	"golang.org/x/crypto/blake2b"
+	"sync"
+	"time"
+)
+
+// AuditDirection tells whether an audited share was contributed (sent) or received by the party.
+type AuditDirection int
+
+const (
+	// AuditSent marks a share computed by the party and broadcast to the other parties.
+	AuditSent AuditDirection = iota
+	// AuditReceived marks a share of another party consumed by the party.
+	AuditReceived
+)
+
+// AuditEntry is a record of a share passing through a step of a protocol. Hash is the blake2b-256 hash of the binary encoding of
+// the share (see Share.MarshalBinary), so that the logs of the sender and of the receivers of a share can be cross-checked.
+type AuditEntry struct {
+	Step      string
+	Direction AuditDirection
+	Hash      [32]byte
+	Time      time.Time
+}
+
+// AuditLogger is the interface implemented by the audit logs of the shares sent and received by a party (see EkgProtocol.SetAuditLogger).
+type AuditLogger interface {
+	// Log records the entry. It is called synchronously by the protocol.
+	Log(entry AuditEntry)
+}
+
+// MemoryAuditLogger is an AuditLogger keeping the entries in memory. It is safe for concurrent use.
+type MemoryAuditLogger struct {
+	mutex   sync.Mutex
+	entries []AuditEntry
+}
+
+// NewMemoryAuditLogger creates a new empty MemoryAuditLogger.
+func NewMemoryAuditLogger() *MemoryAuditLogger {
+	return new(MemoryAuditLogger)
+}
+
+// Log appends the entry to the log.
+func (logger *MemoryAuditLogger) Log(entry AuditEntry) {
+	logger.mutex.Lock()
+	logger.entries = append(logger.entries, entry)
+	logger.mutex.Unlock()
+}
+
+// Entries returns a copy of the entries of the log, in the order they were recorded.
+func (logger *MemoryAuditLogger) Entries() []AuditEntry {
+	logger.mutex.Lock()
+	defer logger.mutex.Unlock()
+	return append([]AuditEntry(nil), logger.entries...)
+}
+
+// HashShare returns the hash of the share recorded in the audit logs, the blake2b-256 hash of its binary encoding.
+func HashShare(share Share) (hash [32]byte, err error) {
+
+	data, err := share.MarshalBinary()
+	if err != nil {
+		return hash, err
+	}
+
+	return blake2b.Sum256(data), nil
+}
+
+// audit records the share on the audit logger of the EkgProtocol, which must be set. Shares that cannot be encoded are recorded
+// with a zero hash.
+func (ekg *EkgProtocol) audit(step string, direction AuditDirection, share Share) {
+
+	hash, _ := HashShare(share)
+
+	ekg.auditLogger.Log(AuditEntry{Step: step, Direction: direction, Hash: hash, Time: time.Now()})
+}
+
+func (ekg *EkgProtocol) auditRoundOne(step string, direction AuditDirection, h [][]*ring.Poly) {
+	if ekg.auditLogger != nil {
+		ekg.audit(step, direction, ekg.NewShareRoundOne(h))
+	}
+}
+
+func (ekg *EkgProtocol) auditRoundTwo(step string, direction AuditDirection, h [][][2]*ring.Poly) {
+	if ekg.auditLogger != nil {
+		ekg.audit(step, direction, ekg.NewShareRoundTwo(h))
+	}
+}
+
+func (ekg *EkgProtocol) auditRoundThree(step string, direction AuditDirection, h1 [][]*ring.Poly) {
+	if ekg.auditLogger != nil {
+		ekg.audit(step, direction, ekg.NewShareRoundThree(h1))
+	}
+}
+
+// AuditLogger returns the audit logger of the EkgProtocol, or nil if none is set.
+func (ekg *EkgProtocol) AuditLogger() AuditLogger {
+	return ekg.auditLogger
+}
+
+// SetAuditLogger sets the audit logger recording the shares passing through the rounds of the EkgProtocol (nil disables it, the default).
+// For each call, the shares of the other parties given as input are recorded as AuditReceived, one entry per party in the order
+// of the input, followed by the share returned by the call as AuditSent. GenSamples, GenSamplesDecomposed, Aggregate and KeySwitch
+// record their output, while Aggregate, Sum and ComputeEVK record their inputs. The rounds of an EkgPipeline are recorded per limb
+// (see EkgPipeline).
+func (ekg *EkgProtocol) SetAuditLogger(logger AuditLogger) {
+	ekg.auditLogger = logger
+}
@@ -0,0 +1,47 @@
+package dbfv
+
+import (
+	"errors"
+)
+
+// Codec is the interface implemented by the wire formats of the shares exchanged among the parties. It decouples the transport
+// of the shares from their serialization, so that different deployments can use different formats (binary, protobuf, JSON...).
+type Codec interface {
+	// Encode encodes the share on a byte slice.
+	Encode(share Share) ([]byte, error)
+	// Decode decodes a share previously encoded by the codec.
+	Decode(data []byte) (Share, error)
+}
+
+// BinaryCodec is the default Codec, encoding the shares with their binary encoding (see Share.MarshalBinary).
+type BinaryCodec struct {
+	newShare func() Share
+}
+
+// NewBinaryCodec creates a new BinaryCodec decoding the shares on the new empty shares returned by newShare, which must
+// be of the type of the encoded shares, e.g. func() Share { return ekg.NewShareRoundOneEmpty() }.
+func NewBinaryCodec(newShare func() Share) *BinaryCodec {
+	return &BinaryCodec{newShare: newShare}
+}
+
+// Encode encodes the share on a byte slice.
+func (codec *BinaryCodec) Encode(share Share) ([]byte, error) {
+
+	if share == nil {
+		return nil, errors.New("cannot encode share -> nil share")
+	}
+
+	return share.MarshalBinary()
+}
+
+// Decode decodes a share previously encoded by the BinaryCodec on a new empty share.
+func (codec *BinaryCodec) Decode(data []byte) (Share, error) {
+
+	share := codec.newShare()
+
+	if err := share.UnMarshalBinary(data); err != nil {
+		return nil, err
+	}
+
+	return share, nil
+}
@@ -0,0 +1,100 @@
+package dbfv
+
+import (
+	"errors"
+	"github.com/ldsec/lattigo/bfv"
+	"github.com/ldsec/lattigo/ring"
+)
+
+// CkgEkgProtocol is a structure storing the parameters and the state of the combined generation of the collective public-key
+// and of the collective evaluation-key. The round of the CKG protocol is merged into the first round of the EkgProtocol protocol,
+// so that both keys are generated in 3 rounds of communication instead of 4, the shares of both protocols of a round being
+// broadcast together.
+type CkgEkgProtocol struct {
+	context *ring.Context
+	ckg     *CKG
+	ekg     *EkgProtocol
+	crp     [][]*ring.Poly
+	sum     [][][2]*ring.Poly
+}
+
+// NewCkgEkgProtocol creates a new CkgEkgProtocol instance generating a collective public-key and a collective evaluation-key
+// with the given bit-decomposition among j parties. The common reference polynomials of both protocols are generated from the
+// provided CRPGenerator, which all the parties must have seeded identically : the CRP of the EkgProtocol is generated first
+// (see CRPGenerator.ClockNew), followed by the CRS of the CKG protocol. Returns an error if the bit-decomposition is not in [1, 60].
+func NewCkgEkgProtocol(context *ring.Context, bitDecomp uint64, crpGenerator *CRPGenerator) (*CkgEkgProtocol, error) {
+
+	ekg, err := NewEkgProtocolChecked(context, bitDecomp)
+	if err != nil {
+		return nil, err
+	}
+
+	protocol := new(CkgEkgProtocol)
+	protocol.context = context
+	protocol.ekg = ekg
+	protocol.crp = crpGenerator.ClockNew(ekg)
+	protocol.ckg = NewCKG(context, crpGenerator.Clock())
+
+	return protocol, nil
+}
+
+// RoundCount returns the number of rounds of communication of the CkgEkgProtocol protocol, which is 3, the number of rounds
+// of the EkgProtocol protocol.
+func (protocol *CkgEkgProtocol) RoundCount() int {
+	return protocol.ekg.RoundCount()
+}
+
+// EkgProtocol returns the underlying EkgProtocol, e.g. to set its samplers or its digit order before the first round.
+func (protocol *CkgEkgProtocol) EkgProtocol() *EkgProtocol {
+	return protocol.ekg
+}
+
+// GenShares is the first of three rounds of the CkgEkgProtocol protocol. Each party generates its public-share of the CKG protocol
+// (see CKG.GenShare) and its samples of the first round of the EkgProtocol protocol (see EkgProtocol.GenSamples), from its ephemeral
+// key u_i and its secret share sk_i, and broadcasts both to the other j-1 parties.
+func (protocol *CkgEkgProtocol) GenShares(u, sk *ring.Poly) (ckgShare *ring.Poly, samples [][]*ring.Poly) {
+
+	protocol.ckg.GenShare(sk)
+
+	return protocol.ckg.GetShare(), protocol.ekg.GenSamples(u, sk, protocol.crp)
+}
+
+// Aggregate is the second of three rounds of the CkgEkgProtocol protocol. Uppon receiving the shares of the first round of all the
+// parties, each party aggregates the public-shares into the collective public-key (see CKG.AggregateShares) and computes its share of the
+// second round of the EkgProtocol protocol (see EkgProtocol.Aggregate), which it broadcasts to the other j-1 parties.
+func (protocol *CkgEkgProtocol) Aggregate(sk *ring.Poly, ckgShares []*ring.Poly, samples [][][]*ring.Poly) (h [][][2]*ring.Poly) {
+
+	protocol.ckg.AggregateShares(ckgShares)
+
+	return protocol.ekg.Aggregate(sk, samples, protocol.crp)
+}
+
+// KeySwitch is the third and last round of the CkgEkgProtocol protocol. Uppon receiving the shares of the second round of all the parties,
+// each party sums them (see EkgProtocol.Sum), keeps the sum for Finalize, and returns its key-switched share (see EkgProtocol.KeySwitch)
+// to be broadcast to the other j-1 parties.
+func (protocol *CkgEkgProtocol) KeySwitch(u, sk *ring.Poly, samples [][][][2]*ring.Poly) (h1 [][]*ring.Poly) {
+
+	protocol.sum = protocol.ekg.Sum(samples)
+
+	return protocol.ekg.KeySwitch(u, sk, protocol.sum)
+}
+
+// Finalize computes, from the key-switched shares of all the parties, the collective evaluation-key (see EkgProtocol.ComputeEVK), and
+// returns it along with the collective public-key. The key-switched shares given as input and the sum of the second round are zeroized
+// (see EkgProtocol.FinalizeAndWipe). Returns an error if KeySwitch has not been called beforehand.
+func (protocol *CkgEkgProtocol) Finalize(h1 [][][]*ring.Poly) (pk *bfv.PublicKey, rlk *bfv.EvaluationKey, err error) {
+
+	if protocol.sum == nil {
+		return nil, nil, errors.New("cannot finalize CkgEkgProtocol -> the shares of the second round have not been summed (see KeySwitch)")
+	}
+
+	if pk, err = protocol.ckg.Finalize(); err != nil {
+		return nil, nil, err
+	}
+
+	rlk = new(bfv.EvaluationKey)
+	protocol.ekg.FinalizeAndWipe(h1, protocol.sum, rlk)
+	protocol.sum = nil
+
+	return pk, rlk, nil
+}
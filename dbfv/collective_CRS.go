@@ -1,12 +1,14 @@
 package dbfv
 
 import (
+	"bytes"
 	"encoding/binary"
 	"errors"
 	"github.com/ldsec/lattigo/ring"
 	"golang.org/x/crypto/blake2b"
 	"hash"
 	"math/bits"
+	"sort"
 )
 
 // PRNG is a structure storing the parameters used to securely and deterministicaly generate shared
@@ -78,6 +80,8 @@ type CRPGenerator struct {
 	prng    *PRNG
 	context *ring.Context
 	masks   []uint64
+	seed    []byte
+	nonce   uint64
 }
 
 // NewCRPGenerator creates a new CRPGenerator, that will deterministicaly and securely generate uniform polynomials
@@ -101,13 +105,90 @@ func (crpgenerator *CRPGenerator) GetClock() uint64 {
 }
 
 // Seed resets the CRPGenerator and instantiate it with a new seed. Does not change the key.
+// Seed will also reset the nonce to 0.
 func (crpgenerator *CRPGenerator) Seed(seed []byte) {
+	crpgenerator.seed = seed[:]
+	crpgenerator.nonce = 0
 	crpgenerator.prng.Seed(seed)
 }
 
+// CombineSeeds combines the seeds contributed by several parties into a single seed, the blake2b-256 hash of the concatenation
+// of the length-prefixed contributions sorted in lexicographic order. The combined seed thus only depends on the set of the
+// contributions, not on the order in which they are received, and no single party controls it without knowing the others.
+func CombineSeeds(seeds [][]byte) []byte {
+
+	sorted := make([][]byte, len(seeds))
+	copy(sorted, seeds)
+	sort.Slice(sorted, func(i, j int) bool { return bytes.Compare(sorted[i], sorted[j]) < 0 })
+
+	hash, _ := blake2b.New256(nil)
+
+	length := make([]byte, 8)
+	for _, seed := range sorted {
+		binary.BigEndian.PutUint64(length, uint64(len(seed)))
+		hash.Write(length)
+		hash.Write(seed)
+	}
+
+	return hash.Sum(nil)
+}
+
+// SeedFromContributions resets the CRPGenerator and instantiates it with the combination of the seeds contributed by the
+// parties (see CombineSeeds). Does not change the key and resets the nonce to 0.
+func (crpgenerator *CRPGenerator) SeedFromContributions(seeds [][]byte) {
+	crpgenerator.Seed(CombineSeeds(seeds))
+}
+
+// crpNonceDomain is the domain tag of the seeds derived by SeedWithNonce.
+var crpNonceDomain = []byte("lattigo/dbfv/crp/nonce")
+
+// SeedWithNonce resets the CRPGenerator and instantiate it with the given seed and nonce. The PRNG is seeded with
+// the blake2b-256 hash of a domain tag followed by the length-prefixed seed and by the nonce, so that a same seed can
+// be used for several protocol runs, as long as each run uses a distinct nonce, and that a (seed, nonce) pair does not
+// reach the state of Seed called on the concatenation of the seed and of the nonce. Does not change the key.
+func (crpgenerator *CRPGenerator) SeedWithNonce(seed []byte, nonce uint64) {
+
+	crpgenerator.seed = seed[:]
+	crpgenerator.nonce = nonce
+
+	hash, _ := blake2b.New256(nil)
+
+	buff := make([]byte, 8)
+
+	hash.Write(crpNonceDomain)
+	binary.BigEndian.PutUint64(buff, uint64(len(seed)))
+	hash.Write(buff)
+	hash.Write(seed)
+	binary.BigEndian.PutUint64(buff, nonce)
+	hash.Write(buff)
+
+	crpgenerator.prng.Seed(hash.Sum(nil))
+}
+
 // GetSeed returns the seed of the CRPGenerator.
 func (crpgenerator *CRPGenerator) GetSeed() []byte {
-	return crpgenerator.prng.seed[:]
+	return crpgenerator.seed[:]
+}
+
+// GetNonce returns the current nonce of the CRPGenerator.
+func (crpgenerator *CRPGenerator) GetNonce() uint64 {
+	return crpgenerator.nonce
+}
+
+// NextCRP reseeds the CRPGenerator with its seed and current nonce, returns the first uniform polynomial generated
+// from this state and advances the nonce by 1. Each nonce thus yields exactly one CRP: successive calls return CRPs
+// derived from distinct (seed, nonce) pairs, and a given CRP can be reproduced by calling SeedWithNonce with the same
+// seed and nonce followed by NextCRP. A protocol run needing several CRPs (e.g. ClockNew) should instead clock the
+// generator from the state set by SeedWithNonce, rather than consume one nonce per CRP.
+func (crpgenerator *CRPGenerator) NextCRP() (crp *ring.Poly) {
+
+	crpgenerator.SeedWithNonce(crpgenerator.seed, crpgenerator.nonce)
+
+	crp = crpgenerator.Clock()
+
+	crpgenerator.nonce++
+
+	return crp
 }
 
 // SetClock sets the clock of the CRPGenerator to the given input by clocking it until the
@@ -160,3 +241,21 @@ func (crpgenerator *CRPGenerator) Clock() *ring.Poly {
 
 	return crp
 }
+
+// ClockNew generates and returns the common reference polynomials of a run of the given EkgProtocol, i.e. for each modulus of the
+// context, one uniform polynomial per digit of the bit-decomposition of the EkgProtocol, as expected by EkgProtocol.GenSamples.
+// The polynomials are generated limb by limb and digit by digit, so that the parties clocking generators with the same seed
+// (and key) from the same clock obtain identical CRPs.
+func (crpgenerator *CRPGenerator) ClockNew(ekg *EkgProtocol) (crp [][]*ring.Poly) {
+
+	crp = make([][]*ring.Poly, len(crpgenerator.context.Modulus))
+
+	for i := range crp {
+		crp[i] = make([]*ring.Poly, ekg.bitLog)
+		for w := range crp[i] {
+			crp[i][w] = crpgenerator.Clock()
+		}
+	}
+
+	return
+}
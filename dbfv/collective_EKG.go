@@ -1,31 +1,301 @@
 package dbfv
 
 import (
+	"errors"
+	"fmt"
+	"github.com/ldsec/lattigo/bfv"
 	"github.com/ldsec/lattigo/ring"
+	"golang.org/x/crypto/blake2b"
 	"math"
+	"runtime"
 )
 
 // EkgProtocol is a structure storing the parameters for the collective evaluation-key generation.
 type EkgProtocol struct {
 	context         *ring.Context
-	ternarySampler  *ring.TernarySampler
-	gaussianSampler *ring.KYSampler
+	ternarySampler  TernarySampler
+	gaussianSampler GaussianSampler
+	noiseBound      uint64
 	bitDecomp       uint64
 	bitLog          uint64
+	crpMForm        bool
+	evkMForm        bool
+	digitOrder      DigitOrder
+	parallelSum     int
+	maxProcs        int
 	polypool        *ring.Poly
+	keypool         *ring.Poly
+	auditLogger     AuditLogger
+	ephemeralKeys   map[[32]byte]bool
 }
 
+// DigitOrder is the order in which the digits of the bit-decomposition are laid out in the shares and in the
+// evaluation-key generated by the EkgProtocol.
+type DigitOrder int
+
+const (
+	// LSBFirst lays the digits out from the least to the most significant one, i.e. w=0 is 2^0. It is the layout
+	// expected by bfv.EvaluationKey.SetRelinKeys.
+	LSBFirst DigitOrder = iota
+	// MSBFirst lays the digits out from the most to the least significant one, i.e. w=0 is 2^((bitLog-1)*bitDecomp).
+	MSBFirst
+)
+
+// DefaultParallelSumThreshold is the default minimum number of shares to sum, counted as the number of parties times the
+// number of moduli, from which the EkgProtocol sums the limbs of the round two shares in parallel. It is set from
+// Benchmark_ParallelSumCrossover (1 to 64 parties at N=8192 with 4 moduli, Intel Xeon, a single CPU with GOMAXPROCS=4, 50
+// iterations, 3 runs): below 32 shares the parallel sum is consistently slower (speedup 0.69 to 0.95, e.g. 1.87ms serial
+// against 2.72ms parallel for 8 shares), from 32 shares on it is on par or faster (speedup 0.91 to 1.18, 4.95ms against 4.90ms
+// for 32 shares). On a machine with several CPUs the crossover can only be lower, and should be re-measured and set with
+// SetParallelSumThreshold.
+const DefaultParallelSumThreshold = 32
+
+// DefaultSigma and DefaultBound are the standard deviation and the bound of the gaussian noise sampled by the EkgProtocol
+// protocol, unless specified otherwise with NewEkgProtocolWithSigma.
+const (
+	DefaultSigma = 3.19
+	DefaultBound = 19
+)
+
 // NewEkgProtocol creates a new EkgProtocol object that will be used to generate a collective evaluation-key
-// among j parties in the given context with the given bit-decomposition.
+// among j parties in the given context with the given bit-decomposition. It panics if the bit-decomposition is
+// not in [1, 60], use NewEkgProtocolChecked to validate a user-supplied bit-decomposition.
 func NewEkgProtocol(context *ring.Context, bitDecomp uint64) *EkgProtocol {
+
+	ekg, err := NewEkgProtocolChecked(context, bitDecomp)
+	if err != nil {
+		panic(err)
+	}
+
+	return ekg
+}
+
+// NewEkgProtocolChecked is a variant of NewEkgProtocol returning an error instead of panicking if the bit-decomposition
+// is not in [1, 60], the bit-size of the moduli.
+func NewEkgProtocolChecked(context *ring.Context, bitDecomp uint64) (*EkgProtocol, error) {
+
+	if bitDecomp == 0 || bitDecomp > 60 {
+		return nil, fmt.Errorf("cannot create EkgProtocol -> bitDecomp %d is not in the valid range [1, 60]", bitDecomp)
+	}
+
 	ekg := new(EkgProtocol)
 	ekg.context = context
 	ekg.ternarySampler = context.NewTernarySampler()
-	ekg.gaussianSampler = context.NewKYSampler(3.19, 19)
+	ekg.gaussianSampler = context.NewKYSampler(DefaultSigma, DefaultBound)
+	ekg.noiseBound = DefaultBound
 	ekg.bitDecomp = bitDecomp
 	ekg.bitLog = uint64(math.Ceil(float64(60) / float64(bitDecomp)))
+	ekg.parallelSum = DefaultParallelSumThreshold
+	ekg.evkMForm = true
 	ekg.polypool = context.NewPoly()
-	return ekg
+	ekg.keypool = context.NewPoly()
+	return ekg, nil
+}
+
+// NewEkgProtocolWithSigma is a variant of NewEkgProtocolChecked sampling the noise of all the rounds of the protocol from a gaussian
+// distribution of the given standard deviation, truncated to the given bound, instead of the default DefaultSigma and DefaultBound,
+// e.g. to match the parameters of an external deployment. Returns an error if the bit-decomposition is not in [1, 60] or if sigma
+// or the bound is not positive.
+func NewEkgProtocolWithSigma(context *ring.Context, bitDecomp uint64, sigma float64, bound uint64) (*EkgProtocol, error) {
+
+	if sigma <= 0 || bound == 0 {
+		return nil, fmt.Errorf("cannot create EkgProtocol -> invalid gaussian parameters sigma=%f bound=%d", sigma, bound)
+	}
+
+	ekg, err := NewEkgProtocolChecked(context, bitDecomp)
+	if err != nil {
+		return nil, err
+	}
+
+	ekg.SetGaussianSampler(context.NewKYSampler(sigma, int(bound)), bound)
+
+	return ekg, nil
+}
+
+// RoundCount returns the number of rounds of communication of the EkgProtocol protocol, which is 3 (GenSamples, Aggregate and
+// KeySwitch, the evaluation-key being computed locally from the shares of the last round).
+//
+// The rounds cannot be collapsed into a single message per party, even with a trusted CRP : the share of a party in Aggregate is
+// the product of its secret share with the sum of the samples of all the parties, and its share in KeySwitch the product of its
+// ephemeral and secret shares with the sum of the shares of Aggregate, which can only be computed once the previous round is
+// completed. Given a collective public-key, EkgProtocolNaive needs 2 rounds, and CkgEkgProtocol generates the public-key
+// within the 3 rounds.
+func (ekg *EkgProtocol) RoundCount() int {
+	return 3
+}
+
+// RelinKeySize returns the size in bytes of the binary encoding (see bfv.EvaluationKey.MarshalBinary) of the collective
+// evaluation-key generated by the EkgProtocol with the given bit-decomposition, without having to generate it. It is equal to
+//
+// 5 + modulusCount * (1 + 2 * modulusCount * bitLog * N * 8)
+//
+// i.e. a 5 bytes header, and for each modulus a 1 byte header followed by bitLog pairs of polynomials of modulusCount limbs.
+func RelinKeySize(context *bfv.BfvContext, bitDecomp uint64) int {
+
+	modulusCount := uint64(len(context.ContextQ().Modulus))
+	bitLog := uint64(math.Ceil(float64(60) / float64(bitDecomp)))
+
+	return int(5 + modulusCount*(1+2*modulusCount*bitLog*context.N()*8))
+}
+
+// EstimateProtocolMemory returns an estimate, in bytes, of the peak memory used by a party during a run of the EkgProtocol protocol
+// with the given bit-decomposition among the given number of parties, without having to run it. It is the sum of the sizes (see
+// MemoryBytes) of the CRP, of the shares of all the parties received in each round, of the sum of the second round, of the collective
+// evaluation-key and of the secret, ephemeral and scratch polynomials of the party, i.e. an upper bound reached if none of them is
+// released before the end of the protocol, and it does not account for the overhead of the runtime nor for the buffers of the
+// serialized shares. The size of the serialized evaluation-key is given by RelinKeySize.
+func EstimateProtocolMemory(context *bfv.BfvContext, bitDecomp uint64, parties int) int64 {
+
+	modulusCount := int64(len(context.ContextQ().Modulus))
+	bitLog := int64(math.Ceil(float64(60) / float64(bitDecomp)))
+
+	// One polynomial of the context, see polyShare.MemoryBytes
+	poly := modulusCount * int64(context.N()) * 8
+
+	// Number of polynomials of a share of each round, for all the moduli and digits
+	roundOne := modulusCount * bitLog
+	roundTwo := 2 * modulusCount * bitLog
+	roundThree := modulusCount * bitLog
+
+	// CRP, shares of the parties in each round, sum of the second round and collective evaluation-key
+	polys := roundOne + int64(parties)*(roundOne+roundTwo+roundThree) + roundTwo + roundTwo
+
+	// Secret share, ephemeral key, and the pools of the EkgProtocol
+	polys += 4
+
+	return polys * poly
+}
+
+// TernarySampler returns the sampler used by the EkgProtocol to generate the ephemeral keys.
+func (ekg *EkgProtocol) TernarySampler() TernarySampler {
+	return ekg.ternarySampler
+}
+
+// SetTernarySampler sets the sampler used by the EkgProtocol to generate the ephemeral keys.
+func (ekg *EkgProtocol) SetTernarySampler(sampler TernarySampler) {
+	ekg.ternarySampler = sampler
+}
+
+// GaussianSampler returns the sampler used by the EkgProtocol to generate the error polynomials.
+func (ekg *EkgProtocol) GaussianSampler() GaussianSampler {
+	return ekg.gaussianSampler
+}
+
+// SetGaussianSampler sets the sampler used by the EkgProtocol to generate the error polynomials, and the bound on the absolute
+// value of their coefficients against which VerifyRoundOne checks the noise of the round one shares.
+func (ekg *EkgProtocol) SetGaussianSampler(sampler GaussianSampler, bound uint64) {
+	ekg.gaussianSampler = sampler
+	ekg.noiseBound = bound
+}
+
+// SetCRPMontgomeryForm sets the form of the common reference polynomials provided to the rounds one and two of the EkgProtocol.
+// By default (false), they are expected as uniform polynomials in the NTT domain and are multiplied as they are with the keys,
+// which are in the Montgomery form. If set to true, they are expected in the Montgomery form (e.g. when the same CRP is stored
+// and reused in that form across many runs) : instead of converting each of them back, the protocol then converts once the keys
+// they are multiplied with out of the Montgomery form. Both forms of a same CRP produce identical shares.
+//
+// In either form, the rounds read the CRP as it is and never convert it, so that there is nothing to precompute or cache on the
+// CRP when it is reused across runs : the only conversion is that of the key multiplied with it, one polynomial per call, when
+// the CRP is in the Montgomery form.
+func (ekg *EkgProtocol) SetCRPMontgomeryForm(mform bool) {
+	ekg.crpMForm = mform
+}
+
+// SetEVKMontgomeryForm sets the form of the evaluation-key returned by ComputeEVK. By default (true), the key is in the NTT domain
+// and in the Montgomery form, the form consumed by the bfv.Evaluator (see bfv.EvaluationKey.SetRelinKeys). If set to false, the
+// final conversion to the Montgomery form is skipped and the key is returned in the NTT domain and in standard form, e.g. for
+// external evaluators expecting it. Such a key must be converted with ring.Context.MForm before being set with SetRelinKeys.
+// FinalizeAndWipe always sets the key in the Montgomery form.
+func (ekg *EkgProtocol) SetEVKMontgomeryForm(mform bool) {
+	ekg.evkMForm = mform
+}
+
+// SetParallelSumThreshold sets the minimum number of shares to sum (parties times moduli) from which Sum processes the
+// limbs in parallel, one goroutine per modulus. A negative threshold disables the parallel sum.
+func (ekg *EkgProtocol) SetParallelSumThreshold(threshold int) {
+	ekg.parallelSum = threshold
+}
+
+// MaxProcs returns the maximum number of goroutines across which the EkgProtocol distributes the moduli of its rounds.
+func (ekg *EkgProtocol) MaxProcs() int {
+	return ekg.maxProcs
+}
+
+// SetMaxProcs sets the maximum number of goroutines across which GenSamples, GenSamplesDecomposed, Aggregate, KeySwitch,
+// ComputeEVK and MulCoeffsMontgomeryAndSubShare distribute the moduli, whose computations are independent. A value lower than 2
+// (the default is 0) processes the moduli serially. The error polynomials are still sampled serially and in the same order, so
+// that the output is identical to the serial one. Sum is parallelized independently (see SetParallelSumThreshold).
+func (ekg *EkgProtocol) SetMaxProcs(procs int) {
+	ekg.maxProcs = procs
+}
+
+// workers returns the number of goroutines processing the moduli, which is at most the number of moduli.
+func (ekg *EkgProtocol) workers() int {
+	if ekg.maxProcs < 2 {
+		return 1
+	}
+	if ekg.maxProcs > len(ekg.context.Modulus) {
+		return len(ekg.context.Modulus)
+	}
+	return ekg.maxProcs
+}
+
+// sumInParallel returns true if Sum processes the limbs of the shares of the given number of parties in parallel,
+// which requires more than one modulus, more than one available CPU and enough shares to reach the threshold.
+func (ekg *EkgProtocol) sumInParallel(parties int) bool {
+	return ekg.parallelSum >= 0 && len(ekg.context.Modulus) > 1 && runtime.GOMAXPROCS(0) > 1 && parties*len(ekg.context.Modulus) >= ekg.parallelSum
+}
+
+// DigitOrder returns the order in which the digits of the bit-decomposition are laid out by the EkgProtocol.
+func (ekg *EkgProtocol) DigitOrder() DigitOrder {
+	return ekg.digitOrder
+}
+
+// SetDigitOrder sets the order in which the digits of the bit-decomposition are laid out in the round one shares
+// and in the evaluation-key, e.g. to match the layout of another library. By default (LSBFirst), w=0 is the least
+// significant digit. The CRP of index w is used for the digit laid out at index w. All the parties must use the same order.
+func (ekg *EkgProtocol) SetDigitOrder(order DigitOrder) {
+	ekg.digitOrder = order
+}
+
+// digit returns the exponent (in multiples of bitDecomp) of the digit laid out at index w.
+func (ekg *EkgProtocol) digit(w uint64) uint64 {
+	if ekg.digitOrder == MSBFirst {
+		return ekg.bitLog - 1 - w
+	}
+	return w
+}
+
+// LSBFirst returns a view of the given evaluation-key, generated by the EkgProtocol, with its digits laid out from the least
+// to the most significant one, as expected by bfv.EvaluationKey.SetRelinKeys. The polynomials are not copied.
+func (ekg *EkgProtocol) LSBFirst(collectiveEVK [][][2]*ring.Poly) (evk [][][2]*ring.Poly) {
+
+	if ekg.digitOrder == LSBFirst {
+		return collectiveEVK
+	}
+
+	evk = make([][][2]*ring.Poly, len(collectiveEVK))
+
+	for i := range collectiveEVK {
+		evk[i] = make([][2]*ring.Poly, len(collectiveEVK[i]))
+		for w := range collectiveEVK[i] {
+			evk[i][len(collectiveEVK[i])-1-w] = collectiveEVK[i][w]
+		}
+	}
+
+	return
+}
+
+// crpKey returns the form of the given key (in the Montgomery form) to multiply with the CRP, using keyOut if a conversion is required.
+func (ekg *EkgProtocol) crpKey(key, keyOut *ring.Poly) *ring.Poly {
+
+	if !ekg.crpMForm {
+		return key
+	}
+
+	ekg.context.InvMForm(key, keyOut)
+
+	return keyOut
 }
 
 // NewEphemeralKey generates a new Ephemeral Key u_i (needs to be stored for the 3 first round).
@@ -38,6 +308,50 @@ func (ekg *EkgProtocol) NewEphemeralKey(p float64) (ephemeralKey *ring.Poly, err
 	return
 }
 
+// SetEphemeralKeyReuseDetection enables (or disables, the default) the detection of the reuse of an ephemeral key across the runs
+// of the EkgProtocol : GenSamplesChecked then records the blake2b-256 hash of each ephemeral key it is given, and rejects the ones
+// already recorded, since using a same ephemeral key in two runs weakens the security of the generated keys. Enabling the detection
+// when it is already enabled keeps the recorded keys, and disabling it discards them.
+func (ekg *EkgProtocol) SetEphemeralKeyReuseDetection(enabled bool) {
+	if !enabled {
+		ekg.ephemeralKeys = nil
+	} else if ekg.ephemeralKeys == nil {
+		ekg.ephemeralKeys = make(map[[32]byte]bool)
+	}
+}
+
+// ResetEphemeralKeyHistory discards the ephemeral keys recorded by GenSamplesChecked, if the detection of their reuse is enabled
+// (see SetEphemeralKeyReuseDetection).
+func (ekg *EkgProtocol) ResetEphemeralKeyHistory() {
+	if ekg.ephemeralKeys != nil {
+		ekg.ephemeralKeys = make(map[[32]byte]bool)
+	}
+}
+
+// GenSamplesChecked is identical to GenSamples, except that, if the detection of the reuse of the ephemeral keys is enabled
+// (see SetEphemeralKeyReuseDetection), it records the ephemeral key u and returns an error instead of the samples if u was already
+// given to GenSamplesChecked in a previous run.
+func (ekg *EkgProtocol) GenSamplesChecked(u, sk *ring.Poly, crp [][]*ring.Poly) (h [][]*ring.Poly, err error) {
+
+	if ekg.ephemeralKeys != nil {
+
+		data, err := u.MarshalBinary()
+		if err != nil {
+			return nil, err
+		}
+
+		hash := blake2b.Sum256(data)
+
+		if ekg.ephemeralKeys[hash] {
+			return nil, errors.New("cannot generate samples -> the ephemeral key was already used in a previous run")
+		}
+
+		ekg.ephemeralKeys[hash] = true
+	}
+
+	return ekg.GenSamples(u, sk, crp), nil
+}
+
 // GenSamples is the first of three rounds of the EkgProtocol protocol. Each party generates a pseudo encryption of
 // its secret share of the key s_i under its ephemeral key u_i : [-u_i*a + s_i*w + e_i] and broadcasts it to the other
 // j-1 parties.
@@ -45,30 +359,186 @@ func (ekg *EkgProtocol) GenSamples(u, sk *ring.Poly, crp [][]*ring.Poly) (h [][]
 
 	h = make([][]*ring.Poly, len(ekg.context.Modulus))
 
+	uCRP := ekg.crpKey(u, ekg.keypool)
+
+	// The errors are sampled serially, the sampler being stateful
+	for i := range ekg.context.Modulus {
+		h[i] = ekg.sampleErrors()
+	}
+
+	ekg.context.ForEachLimb(ekg.workers(), func(i, worker int) {
+		ekg.genSamplesLimbWithErrors(i, uCRP, sk, crp[i], h[i])
+	})
+
+	ekg.keypool.Zero()
+
+	ekg.auditRoundOne("GenSamples", AuditSent, h)
+
+	return
+}
+
+// genSamplesLimb computes the samples of the first round of the EkgProtocol protocol for the i-th modulus only,
+// uCRP being the ephemeral key in the form to multiply with the CRP (see crpKey).
+func (ekg *EkgProtocol) genSamplesLimb(i int, uCRP, sk *ring.Poly, crp []*ring.Poly) (h []*ring.Poly) {
+	h = ekg.sampleErrors()
+	ekg.genSamplesLimbWithErrors(i, uCRP, sk, crp, h)
+	return
+}
+
+// sampleErrors returns a new error polynomial for each digit of a limb.
+func (ekg *EkgProtocol) sampleErrors() (e []*ring.Poly) {
+	e = make([]*ring.Poly, ekg.bitLog)
+	for w := range e {
+		e[w] = ekg.gaussianSampler.SampleNTTNew()
+	}
+	return
+}
+
+// genSamplesLimbWithErrors is genSamplesLimb with the error polynomials already sampled on h.
+func (ekg *EkgProtocol) genSamplesLimbWithErrors(i int, uCRP, sk *ring.Poly, crp, h []*ring.Poly) {
+
+	qi := ekg.context.Modulus[i]
 	mredParams := ekg.context.GetMredParams()
 
 	// Given a base decomposition w (here the CRT decomposition)
 	// computes [-u_i*a + s_i*w + e_i]
-	// where a = crp
-	for i, qi := range ekg.context.Modulus {
+	// where a = crp, h = e
+	for w := uint64(0); w < ekg.bitLog; w++ {
 
-		h[i] = make([]*ring.Poly, ekg.bitLog)
+		// h = sk*CrtBaseDecompQi + e
+		for j := uint64(0); j < ekg.context.N; j++ {
+			h[w].Coeffs[i][j] = ring.CRed(h[w].Coeffs[i][j]+ring.PowerOf2(sk.Coeffs[i][j], ekg.bitDecomp*ekg.digit(w), qi, mredParams[i]), qi)
+		}
+	}
 
-		for w := uint64(0); w < ekg.bitLog; w++ {
+	// h = sk*CrtBaseDecompQi + -u*a + e
+	ekg.mulCoeffsMontgomeryAndSubLimb(uCRP, crp, h)
+}
 
-			// h = e
-			h[i][w] = ekg.gaussianSampler.SampleNTTNew()
+// MulCoeffsMontgomeryAndSubShare subtracts u*a from each sample of the round one share, a being the CRP of its limb and digit,
+// i.e. it computes the -u*a term of GenSamples for the whole share at once. u is the ephemeral key, in the Montgomery and NTT form
+// expected by GenSamples. It is equivalent to, but faster than, calling ring.Context.MulCoeffsMontgomeryAndSub on each sample.
+func (ekg *EkgProtocol) MulCoeffsMontgomeryAndSubShare(u *ring.Poly, crp [][]*ring.Poly, share *EkgShareRoundOne) {
+
+	uCRP := ekg.crpKey(u, ekg.keypool)
+
+	ekg.context.ForEachLimb(ekg.workers(), func(i, worker int) {
+		ekg.mulCoeffsMontgomeryAndSubLimb(uCRP, crp[i], share.Value[i])
+	})
+
+	ekg.keypool.Zero()
+}
+
+// mulCoeffsMontgomeryAndSubLimb subtracts uCRP*crp[w] from h[w] for all the digits w of a limb. The loops are fused so that
+// each coefficient of uCRP is loaded once for all the digits, and the modulus and Montgomery parameters once per modulus.
+func (ekg *EkgProtocol) mulCoeffsMontgomeryAndSubLimb(uCRP *ring.Poly, crp, h []*ring.Poly) {
+
+	mredParams := ekg.context.GetMredParams()
+
+	for k, qk := range ekg.context.Modulus {
+
+		mredParam := mredParams[k]
+		uk := uCRP.Coeffs[k]
+
+		for w := range h {
+
+			ak := crp[w].Coeffs[k]
+			hk := h[w].Coeffs[k]
 
-			// h = sk*CrtBaseDecompQi + e
 			for j := uint64(0); j < ekg.context.N; j++ {
-				h[i][w].Coeffs[i][j] += ring.PowerOf2(sk.Coeffs[i][j], ekg.bitDecomp*w, qi, mredParams[i])
+				hk[j] = ring.CRed(hk[j]+(qk-ring.MRed(uk[j], ak[j], qk, mredParam)), qk)
 			}
+		}
+	}
+}
+
+// VerifyRoundOne checks that the round one share is a valid pseudo-encryption of s_i*w under the ephemeral key u_i, i.e. that each
+// of its samples is [-u_i*a + s_i*w + e_i] with e_i bounded by the bound of the gaussian noise of the EkgProtocol (DefaultBound, or
+// the bound given to NewEkgProtocolWithSigma or SetGaussianSampler). It recomputes the share without noise from the ephemeral key
+// u, the secret share sk and the CRP given to GenSamples, and returns an error describing the first sample whose difference is not
+// such a noise, e.g. to catch a malformed contribution before broadcasting it or in tests. The share is not modified.
+func (ekg *EkgProtocol) VerifyRoundOne(share *EkgShareRoundOne, u, sk *ring.Poly, crp [][]*ring.Poly) error {
+
+	if len(share.Value) != len(ekg.context.Modulus) || len(crp) != len(ekg.context.Modulus) {
+		return errors.New("cannot verify round one share -> the share or the crp does not match the context")
+	}
+
+	uCRP := ekg.crpKey(u, ekg.context.NewPoly())
+
+	for i := range ekg.context.Modulus {
+
+		if uint64(len(share.Value[i])) != ekg.bitLog || uint64(len(crp[i])) != ekg.bitLog {
+			return fmt.Errorf("cannot verify round one share -> limb %d does not have %d samples", i, ekg.bitLog)
+		}
 
-			// h = sk*CrtBaseDecompQi + -u*a + e
-			ekg.context.MulCoeffsMontgomeryAndSub(u, crp[i][w], h[i][w])
+		// The samples without noise
+		want := make([]*ring.Poly, ekg.bitLog)
+		for w := range want {
+			want[w] = ekg.context.NewPoly()
 		}
+
+		ekg.genSamplesLimbWithErrors(i, uCRP, sk, crp[i], want)
+
+		for w := range want {
+
+			ekg.context.Sub(share.Value[i][w], want[w], want[w])
+			ekg.context.InvNTT(want[w], want[w])
+
+			noise := ekg.context.GetCenteredCoefficients(want[w])
+
+			for j := uint64(0); j < ekg.context.N; j++ {
+
+				e := noise[0][j]
+
+				if e > int64(ekg.noiseBound) || e < -int64(ekg.noiseBound) {
+					return fmt.Errorf("cannot verify round one share -> the noise of the sample (%d, %d) exceeds the bound %d", i, w, ekg.noiseBound)
+				}
+
+				for k := range noise {
+					if noise[k][j] != e {
+						return fmt.Errorf("cannot verify round one share -> the noise of the sample (%d, %d) is not a small polynomial", i, w)
+					}
+				}
+			}
+		}
+	}
+
+	return nil
+}
+
+// GenSamplesDecomposed is a variant of GenSamples taking, instead of the secret share sk_i, its power of 2 decomposition
+// skDecomposed = context.DecomposePoly(sk_i, bitDecomp), with the bit-decomposition of the EkgProtocol. The decomposition
+// of a fixed share can be computed once and reused across several runs of the protocol, saving its recomputation in each
+// run. Both variants produce the same samples.
+func (ekg *EkgProtocol) GenSamplesDecomposed(u *ring.Poly, skDecomposed [][]*ring.Poly, crp [][]*ring.Poly) (h [][]*ring.Poly) {
+
+	h = make([][]*ring.Poly, len(ekg.context.Modulus))
+
+	uCRP := ekg.crpKey(u, ekg.keypool)
+
+	// h = e, the errors are sampled serially, the sampler being stateful
+	for i := range ekg.context.Modulus {
+		h[i] = ekg.sampleErrors()
 	}
 
+	ekg.context.ForEachLimb(ekg.workers(), func(i, worker int) {
+
+		// h = sk*CrtBaseDecompQi + e
+		for w := uint64(0); w < ekg.bitLog; w++ {
+			digit := skDecomposed[i][ekg.digit(w)].Coeffs[i]
+			for j := uint64(0); j < ekg.context.N; j++ {
+				h[i][w].Coeffs[i][j] += digit[j]
+			}
+		}
+
+		// h = sk*CrtBaseDecompQi + -u*a + e
+		ekg.mulCoeffsMontgomeryAndSubLimb(uCRP, crp[i], h[i])
+	})
+
+	ekg.keypool.Zero()
+
+	ekg.auditRoundOne("GenSamplesDecomposed", AuditSent, h)
+
 	return
 }
 
@@ -81,55 +551,100 @@ func (ekg *EkgProtocol) GenSamples(u, sk *ring.Poly, crp [][]*ring.Poly) (h [][]
 // and broadcasts both values to the other j-1 parties.
 func (ekg *EkgProtocol) Aggregate(sk *ring.Poly, samples [][][]*ring.Poly, crp [][]*ring.Poly) (h [][][2]*ring.Poly) {
 
+	for j := range samples {
+		ekg.auditRoundOne("Aggregate", AuditReceived, samples[j])
+	}
+
 	h = make([][][2]*ring.Poly, len(ekg.context.Modulus))
 
+	skCRP := ekg.crpKey(sk, ekg.keypool)
+
+	// The errors are sampled serially, the sampler being stateful
+	for i := range ekg.context.Modulus {
+		h[i] = ekg.sampleAggregateErrors()
+	}
+
+	workers := ekg.workers()
+
+	// One pool per worker, the first one being the pool of the EkgProtocol
+	pools := make([]*ring.Poly, workers)
+	pools[0] = ekg.polypool
+	for k := 1; k < workers; k++ {
+		pools[k] = ekg.context.NewPoly()
+	}
+
 	// Each sample is of the form [-u*a_i + s*w_i + e_i]
 	// So for each element of the base decomposition w_i :
-	for i := range ekg.context.Modulus {
+	ekg.context.ForEachLimb(workers, func(i, worker int) {
 
-		h[i] = make([][2]*ring.Poly, ekg.bitLog)
+		limbSamples := make([][]*ring.Poly, len(samples))
+		for j := range samples {
+			limbSamples[j] = samples[j][i]
+		}
 
-		for w := uint64(0); w < ekg.bitLog; w++ {
+		ekg.aggregateLimbWithErrors(sk, skCRP, limbSamples, crp[i], pools[worker], h[i])
+	})
 
-			// Computes [(sum samples)*sk + e_1i, sk*a + e_2i]
+	for _, pool := range pools {
+		pool.Zero()
+	}
+	ekg.keypool.Zero()
 
-			// First Element
-			h[i][w][0] = samples[0][i][w].CopyNew()
+	ekg.auditRoundTwo("Aggregate", AuditSent, h)
 
-			// Continues with the sum samples
-			for j := 1; j < len(samples); j++ {
-				ekg.context.AddNoMod(h[i][w][0], samples[j][i][w], h[i][w][0])
+	return
+}
 
-				if j&7 == 7 {
-					ekg.context.Reduce(h[i][w][0], h[i][w][0])
-				}
-			}
+// aggregateLimb computes the second round of the EkgProtocol protocol for a single modulus, given the samples
+// of each party for this modulus, skCRP being the secret key in the form to multiply with the CRP (see crpKey).
+// The provided pool is used to store intermediate values.
+func (ekg *EkgProtocol) aggregateLimb(sk, skCRP *ring.Poly, samples [][]*ring.Poly, crp []*ring.Poly, pool *ring.Poly) (h [][2]*ring.Poly) {
+	h = ekg.sampleAggregateErrors()
+	ekg.aggregateLimbWithErrors(sk, skCRP, samples, crp, pool, h)
+	return
+}
 
-			if (len(samples)-1)&7 != 7 {
-				ekg.context.Reduce(h[i][w][0], h[i][w][0])
-			}
+// sampleAggregateErrors returns the error polynomials [e_1i, e_2i] of each digit of a limb of the second round.
+func (ekg *EkgProtocol) sampleAggregateErrors() (e [][2]*ring.Poly) {
+	e = make([][2]*ring.Poly, ekg.bitLog)
+	for w := range e {
+		e[w][0] = ekg.gaussianSampler.SampleNTTNew()
+		e[w][1] = ekg.gaussianSampler.SampleNTTNew()
+	}
+	return
+}
+
+// aggregateLimbWithErrors is aggregateLimb with the error polynomials already sampled on h.
+func (ekg *EkgProtocol) aggregateLimbWithErrors(sk, skCRP *ring.Poly, samples [][]*ring.Poly, crp []*ring.Poly, pool *ring.Poly, h [][2]*ring.Poly) {
 
-			// (Sum samples) * sk
-			ekg.context.MulCoeffsMontgomery(h[i][w][0], sk, h[i][w][0])
+	for w := uint64(0); w < ekg.bitLog; w++ {
 
-			// (Sum samples) * sk + e_1i
-			ekg.gaussianSampler.SampleNTT(ekg.polypool)
-			ekg.context.Add(h[i][w][0], ekg.polypool, h[i][w][0])
+		// Computes [(sum samples)*sk + e_1i, sk*a + e_2i]
 
-			// Second Element
+		// First Element
+		pool.Copy(samples[0][w])
 
-			// e_2i
-			h[i][w][1] = ekg.gaussianSampler.SampleNTTNew()
-			// s*a + e_2i
-			ekg.context.MulCoeffsMontgomeryAndAdd(sk, crp[i][w], h[i][w][1])
+		// Continues with the sum samples
+		for j := 1; j < len(samples); j++ {
+			ekg.context.AddNoMod(pool, samples[j][w], pool)
 
+			if j&7 == 7 {
+				ekg.context.Reduce(pool, pool)
+			}
 		}
 
-	}
+		if (len(samples)-1)&7 != 7 {
+			ekg.context.Reduce(pool, pool)
+		}
 
-	ekg.polypool.Zero()
+		// (Sum samples) * sk + e_1i
+		ekg.context.MulCoeffsMontgomeryAndAdd(pool, sk, h[w][0])
 
-	return
+		// Second Element
+
+		// s*a + e_2i
+		ekg.context.MulCoeffsMontgomeryAndAdd(skCRP, crp[w], h[w][1])
+	}
 }
 
 // Sum is the first part of the third and last round of the EkgProtocol protocol. Uppon receiving the j-1 elements, each party
@@ -138,33 +653,64 @@ func (ekg *EkgProtocol) Aggregate(sk *ring.Poly, samples [][][]*ring.Poly, crp [
 // [sum(s_j * (-u*a + s*w + e) + e_j1), sum(s_j*a + e_j2)]
 //
 // = [s * (-u*a + s*w + e) + e_1, s*a + e_2].
+//
+// The limbs are summed in parallel when there are enough shares (see SetParallelSumThreshold).
 func (ekg *EkgProtocol) Sum(samples [][][][2]*ring.Poly) (h [][][2]*ring.Poly) {
 
+	for j := range samples {
+		ekg.auditRoundTwo("Sum", AuditReceived, samples[j])
+	}
+
 	h = make([][][2]*ring.Poly, len(ekg.context.Modulus))
 
+	limbSamples := make([][][][2]*ring.Poly, len(ekg.context.Modulus))
+
 	for i := range ekg.context.Modulus {
+		limbSamples[i] = make([][][2]*ring.Poly, len(samples))
+		for j := range samples {
+			limbSamples[i][j] = samples[j][i]
+		}
+	}
 
-		h[i] = make([][2]*ring.Poly, ekg.bitLog)
+	if !ekg.sumInParallel(len(samples)) {
+		for i := range ekg.context.Modulus {
+			h[i] = ekg.sumLimb(limbSamples[i])
+		}
+		return
+	}
 
-		for w := uint64(0); w < ekg.bitLog; w++ {
+	// The limbs are independent and sumLimb does not use the memory pools of the EkgProtocol
+	ekg.context.ForEachLimb(len(ekg.context.Modulus), func(i, _ int) {
+		h[i] = ekg.sumLimb(limbSamples[i])
+	})
 
-			h[i][w][0] = samples[0][i][w][0].CopyNew()
-			h[i][w][1] = samples[0][i][w][1].CopyNew()
+	return
+}
 
-			for j := 1; j < len(samples); j++ {
-				ekg.context.AddNoMod(h[i][w][0], samples[j][i][w][0], h[i][w][0])
-				ekg.context.AddNoMod(h[i][w][1], samples[j][i][w][1], h[i][w][1])
+// sumLimb computes the first part of the third round of the EkgProtocol protocol for a single modulus, given
+// the aggregated samples of each party for this modulus.
+func (ekg *EkgProtocol) sumLimb(samples [][][2]*ring.Poly) (h [][2]*ring.Poly) {
 
-				if j&7 == 7 {
-					ekg.context.Reduce(h[i][w][0], h[i][w][0])
-					ekg.context.Reduce(h[i][w][1], h[i][w][1])
-				}
-			}
-			if (len(samples)-1)&7 != 7 {
-				ekg.context.Reduce(h[i][w][0], h[i][w][0])
-				ekg.context.Reduce(h[i][w][1], h[i][w][1])
+	h = make([][2]*ring.Poly, ekg.bitLog)
+
+	for w := uint64(0); w < ekg.bitLog; w++ {
+
+		h[w][0] = samples[0][w][0].CopyNew()
+		h[w][1] = samples[0][w][1].CopyNew()
+
+		for j := 1; j < len(samples); j++ {
+			ekg.context.AddNoMod(h[w][0], samples[j][w][0], h[w][0])
+			ekg.context.AddNoMod(h[w][1], samples[j][w][1], h[w][1])
+
+			if j&7 == 7 {
+				ekg.context.Reduce(h[w][0], h[w][0])
+				ekg.context.Reduce(h[w][1], h[w][1])
 			}
 		}
+		if (len(samples)-1)&7 != 7 {
+			ekg.context.Reduce(h[w][0], h[w][0])
+			ekg.context.Reduce(h[w][1], h[w][1])
+		}
 	}
 
 	return
@@ -184,21 +730,36 @@ func (ekg *EkgProtocol) KeySwitch(u, sk *ring.Poly, samples [][][2]*ring.Poly) (
 	mask := ekg.context.NewPoly()
 	ekg.context.Sub(u, sk, mask)
 
+	// The errors are sampled serially, the sampler being stateful
 	for i := range ekg.context.Modulus {
+		h1[i] = ekg.sampleErrors()
+	}
 
-		h1[i] = make([]*ring.Poly, ekg.bitLog)
-
-		for w := uint64(0); w < ekg.bitLog; w++ {
+	ekg.context.ForEachLimb(ekg.workers(), func(i, worker int) {
+		ekg.keySwitchLimbWithErrors(mask, samples[i], h1[i])
+	})
 
-			// (u - s) * (sum [x][s*a_i + e_2i]) + e3i
-			h1[i][w] = ekg.gaussianSampler.SampleNTTNew()
-			ekg.context.MulCoeffsMontgomeryAndAdd(mask, samples[i][w][1], h1[i][w])
-		}
-	}
+	ekg.auditRoundThree("KeySwitch", AuditSent, h1)
 
 	return h1
 }
 
+// keySwitchLimb computes the second part of the third round of the EkgProtocol protocol for a single modulus,
+// given mask = (u_i - s_i) and the summed samples for this modulus.
+func (ekg *EkgProtocol) keySwitchLimb(mask *ring.Poly, samples [][2]*ring.Poly) (h1 []*ring.Poly) {
+	h1 = ekg.sampleErrors()
+	ekg.keySwitchLimbWithErrors(mask, samples, h1)
+	return
+}
+
+// keySwitchLimbWithErrors is keySwitchLimb with the error polynomials e3i already sampled on h1.
+func (ekg *EkgProtocol) keySwitchLimbWithErrors(mask *ring.Poly, samples [][2]*ring.Poly, h1 []*ring.Poly) {
+	for w := uint64(0); w < ekg.bitLog; w++ {
+		// (u - s) * (sum [x][s*a_i + e_2i]) + e3i
+		ekg.context.MulCoeffsMontgomeryAndAdd(mask, samples[w][1], h1[w])
+	}
+}
+
 // ComputeEVK is third part ot the third and last round of the EkgProtocol protocol. Uppon receiving the other j-1 elements, each party computes :
 //
 // [s * (-u*a + s*w + e) + e_1 + sum([(u_j - s_j)*(s*a + e_2)])]
@@ -211,38 +772,163 @@ func (ekg *EkgProtocol) KeySwitch(u, sk *ring.Poly, samples [][][2]*ring.Poly) (
 //
 // = [-s^2*a + s^2*w + e]
 //
-// The evaluation key is therefor : [-s*b + s^2*w + e, s*b]
+// The evaluation key is therefor : [-s*b + s^2*w + e, s*b], with its digits laid out following the DigitOrder of the
+// EkgProtocol (see LSBFirst).
 func (ekg *EkgProtocol) ComputeEVK(h1 [][][]*ring.Poly, h [][][2]*ring.Poly) (collectiveEVK [][][2]*ring.Poly) {
 
+	for j := range h1 {
+		ekg.auditRoundThree("ComputeEVK", AuditReceived, h1[j])
+	}
+
 	collectiveEVK = make([][][2]*ring.Poly, len(ekg.context.Modulus))
 
 	// collectiveEVK[i][0] = h[i][0] + sum(h1[i])
 	// collectiveEVK[i][1] = h[i][1]
-	for i := range ekg.context.Modulus {
+	ekg.context.ForEachLimb(ekg.workers(), func(i, worker int) {
 
-		collectiveEVK[i] = make([][2]*ring.Poly, ekg.bitLog)
+		limbH1 := make([][]*ring.Poly, len(h1))
+		for j := range h1 {
+			limbH1[j] = h1[j][i]
+		}
 
-		for w := uint64(0); w < ekg.bitLog; w++ {
+		collectiveEVK[i] = ekg.computeEVKLimb(limbH1, h[i])
+	})
 
-			collectiveEVK[i][w][0] = h[i][w][0].CopyNew()
-			collectiveEVK[i][w][1] = h[i][w][1].CopyNew()
+	return
+}
 
-			for j := range h1 {
-				ekg.context.AddNoMod(collectiveEVK[i][w][0], h1[j][i][w], collectiveEVK[i][w][0])
+// computeEVKLimb computes the last part of the third round of the EkgProtocol protocol for a single modulus, given
+// the key-switched shares of each party and the summed samples for this modulus.
+func (ekg *EkgProtocol) computeEVKLimb(h1 [][]*ring.Poly, h [][2]*ring.Poly) (collectiveEVK [][2]*ring.Poly) {
 
-				if j&7 == 7 {
-					ekg.context.Reduce(collectiveEVK[i][w][0], collectiveEVK[i][w][0])
-				}
-			}
+	collectiveEVK = make([][2]*ring.Poly, ekg.bitLog)
+
+	for w := uint64(0); w < ekg.bitLog; w++ {
+
+		collectiveEVK[w][0] = h[w][0].CopyNew()
+		collectiveEVK[w][1] = h[w][1].CopyNew()
 
-			if (len(h1)-1)&7 != 7 {
-				ekg.context.Reduce(collectiveEVK[i][w][0], collectiveEVK[i][w][0])
+		for j := range h1 {
+			ekg.context.AddNoMod(collectiveEVK[w][0], h1[j][w], collectiveEVK[w][0])
+
+			if j&7 == 7 {
+				ekg.context.Reduce(collectiveEVK[w][0], collectiveEVK[w][0])
 			}
+		}
 
-			ekg.context.MForm(collectiveEVK[i][w][0], collectiveEVK[i][w][0])
-			ekg.context.MForm(collectiveEVK[i][w][1], collectiveEVK[i][w][1])
+		if (len(h1)-1)&7 != 7 {
+			ekg.context.Reduce(collectiveEVK[w][0], collectiveEVK[w][0])
+		}
+
+		if ekg.evkMForm {
+			ekg.context.MForm(collectiveEVK[w][0], collectiveEVK[w][0])
+			ekg.context.MForm(collectiveEVK[w][1], collectiveEVK[w][1])
 		}
 	}
 
 	return
 }
+
+// FinalizeAndWipe is a variant of ComputeEVK that, once the collective evaluation-key is computed and set on evkOut, zeroizes
+// the key-switched shares h1 and the summed samples h given as input, as well as the internal scratch memory of the EkgProtocol,
+// so that no secret-derived intermediate material remains in memory after the completion of the protocol.
+func (ekg *EkgProtocol) FinalizeAndWipe(h1 [][][]*ring.Poly, h [][][2]*ring.Poly, evkOut *bfv.EvaluationKey) {
+
+	collectiveEVK := ekg.ComputeEVK(h1, h)
+
+	if !ekg.evkMForm {
+		for i := range collectiveEVK {
+			for w := range collectiveEVK[i] {
+				ekg.context.MForm(collectiveEVK[i][w][0], collectiveEVK[i][w][0])
+				ekg.context.MForm(collectiveEVK[i][w][1], collectiveEVK[i][w][1])
+			}
+		}
+	}
+
+	evkOut.SetRelinKeys([][][][2]*ring.Poly{ekg.LSBFirst(collectiveEVK)}, ekg.bitDecomp)
+
+	// SetRelinKeys stores a copy of the key, the intermediate values can be wiped
+	for i := range collectiveEVK {
+		for w := range collectiveEVK[i] {
+			collectiveEVK[i][w][0].Zero()
+			collectiveEVK[i][w][1].Zero()
+		}
+	}
+
+	for j := range h1 {
+		for i := range h1[j] {
+			for w := range h1[j][i] {
+				h1[j][i][w].Zero()
+			}
+		}
+	}
+
+	for i := range h {
+		for w := range h[i] {
+			h[i][w][0].Zero()
+			h[i][w][1].Zero()
+		}
+	}
+
+	ekg.polypool.Zero()
+	ekg.keypool.Zero()
+}
+
+// GenRelinKey runs the three rounds of the EkgProtocol protocol in-process among the given parties, whose secret shares are sks, and
+// returns the resulting collective relinearization key. It generates the ephemeral keys of the parties, allocates and aggregates the
+// shares of each round in the right order, and wipes the intermediate material once the key is computed. The parties must share the
+// same context and bit-decomposition, and crp must be the common reference polynomials of GenSamples. It is meant for testing and
+// single-process simulations; in a distributed deployment each party runs the rounds itself and exchanges the shares.
+func GenRelinKey(parties []*EkgProtocol, sks []*ring.Poly, crp [][]*ring.Poly) (evk *bfv.EvaluationKey, err error) {
+
+	if len(parties) == 0 {
+		return nil, errors.New("cannot generate relinearization key -> no parties")
+	}
+
+	if len(sks) != len(parties) {
+		return nil, errors.New("cannot generate relinearization key -> the number of secret shares does not match the number of parties")
+	}
+
+	for _, ekg := range parties[1:] {
+		if ekg.bitDecomp != parties[0].bitDecomp || ekg.digitOrder != parties[0].digitOrder || !ekg.context.EqualContext(parties[0].context) {
+			return nil, errors.New("cannot generate relinearization key -> the parties do not share the same parameters")
+		}
+	}
+
+	ephemeralKeys := make([]*ring.Poly, len(parties))
+	for i, ekg := range parties {
+		if ephemeralKeys[i], err = ekg.NewEphemeralKey(1.0 / 3); err != nil {
+			return nil, err
+		}
+	}
+
+	// ROUND 1
+	samples := make([][][]*ring.Poly, len(parties))
+	for i, ekg := range parties {
+		samples[i] = ekg.GenSamples(ephemeralKeys[i], sks[i], crp)
+	}
+
+	// ROUND 2
+	aggregatedSamples := make([][][][2]*ring.Poly, len(parties))
+	for i, ekg := range parties {
+		aggregatedSamples[i] = ekg.Aggregate(sks[i], samples, crp)
+	}
+
+	// ROUND 3
+	sum := parties[0].Sum(aggregatedSamples)
+
+	keySwitched := make([][][]*ring.Poly, len(parties))
+	for i, ekg := range parties {
+		keySwitched[i] = ekg.KeySwitch(ephemeralKeys[i], sks[i], sum)
+	}
+
+	evk = new(bfv.EvaluationKey)
+
+	parties[0].FinalizeAndWipe(keySwitched, sum, evk)
+
+	for i := range parties {
+		ephemeralKeys[i].Zero()
+	}
+
+	return evk, nil
+}
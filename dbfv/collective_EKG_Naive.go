@@ -28,6 +28,11 @@ func NewEkgProtocolNaive(context *ring.Context, bitDecomp uint64) *EkgProtocolNa
 	return ekg
 }
 
+// RoundCount returns the number of rounds of communication of the naive EKG protocol, which is 2 (GenSamples and Aggregate).
+func (ekg *EkgProtocolNaive) RoundCount() int {
+	return 2
+}
+
 // GenSamples is the first of two rounds of the naive EKG protocol. Using the shared public key "cpk",
 // each party generates a pseudo-encryption of s*w of the form :
 //
@@ -0,0 +1,219 @@
+package dbfv
+
+import (
+	"errors"
+	"fmt"
+	"github.com/ldsec/lattigo/ring"
+)
+
+// EkgRound is the round at which the sub-protocol of a given modulus of an EkgPipeline is.
+type EkgRound uint8
+
+// The successive rounds of the per-modulus sub-protocol of an EkgPipeline.
+const (
+	EkgRoundGenSamples EkgRound = iota
+	EkgRoundAggregate
+	EkgRoundKeySwitch
+	EkgRoundComputeEVK
+	EkgRoundDone
+)
+
+// EkgPipeline is a pipelined execution of the EkgProtocol protocol for a single party. The protocol is split
+// in one sub-protocol per modulus (limb) of the context, each advancing independently through the rounds, so that
+// the later rounds of a limb can start before the earlier rounds of the other limbs are completed.
+//
+// Distinct limbs can be advanced concurrently, but a given limb must not be advanced by several goroutines
+// at the same time, and the audit logger of the EkgProtocol, if any, must then be safe for concurrent use.
+//
+// The shares of each limb are recorded on the audit logger of the EkgProtocol as those of the EkgProtocol rounds
+// (see EkgProtocol.SetAuditLogger), each share being restricted to the limb and the step being suffixed by the limb
+// index, e.g. "GenSamples/2".
+type EkgPipeline struct {
+	ekg      *EkgProtocol
+	u        *ring.Poly
+	sk       *ring.Poly
+	uCRP     *ring.Poly
+	skCRP    *ring.Poly
+	mask     *ring.Poly
+	crp      [][]*ring.Poly
+	rounds   []EkgRound
+	sum      [][][2]*ring.Poly
+	evk      [][][2]*ring.Poly
+	polypool []*ring.Poly
+}
+
+// NewPipeline creates a new EkgPipeline for the party holding the ephemeral key u and the secret share sk, using
+// the provided common reference polynomials. All the limbs start at the round EkgRoundGenSamples. The form of the
+// common reference polynomials (see EkgProtocol.SetCRPMontgomeryForm) is fixed at the creation of the pipeline.
+func (ekg *EkgProtocol) NewPipeline(u, sk *ring.Poly, crp [][]*ring.Poly) (pipeline *EkgPipeline) {
+
+	pipeline = new(EkgPipeline)
+	pipeline.ekg = ekg
+	pipeline.u = u
+	pipeline.sk = sk
+	pipeline.crp = crp
+
+	// u and sk in the form to multiply with the crp
+	pipeline.uCRP = ekg.crpKey(u, ekg.context.NewPoly())
+	pipeline.skCRP = ekg.crpKey(sk, ekg.context.NewPoly())
+
+	// (u_i - s_i)
+	pipeline.mask = ekg.context.NewPoly()
+	ekg.context.Sub(u, sk, pipeline.mask)
+
+	pipeline.rounds = make([]EkgRound, len(ekg.context.Modulus))
+	pipeline.sum = make([][][2]*ring.Poly, len(ekg.context.Modulus))
+	pipeline.evk = make([][][2]*ring.Poly, len(ekg.context.Modulus))
+
+	// One pool per limb so that distinct limbs can be advanced concurrently
+	pipeline.polypool = make([]*ring.Poly, len(ekg.context.Modulus))
+	for i := range pipeline.polypool {
+		pipeline.polypool[i] = ekg.context.NewPoly()
+	}
+
+	return
+}
+
+// Limbs returns the number of independent sub-protocols of the pipeline.
+func (pipeline *EkgPipeline) Limbs() int {
+	return len(pipeline.rounds)
+}
+
+// Round returns the round at which the sub-protocol of the given limb is.
+func (pipeline *EkgPipeline) Round(limb int) EkgRound {
+	return pipeline.rounds[limb]
+}
+
+// Done returns true if the sub-protocols of all the limbs are completed.
+func (pipeline *EkgPipeline) Done() bool {
+	for _, round := range pipeline.rounds {
+		if round != EkgRoundDone {
+			return false
+		}
+	}
+	return true
+}
+
+// GenSamples is the first round of the sub-protocol of the given limb, see EkgProtocol.GenSamples.
+// The returned samples are to be broadcast to the other parties.
+func (pipeline *EkgPipeline) GenSamples(limb int) (h []*ring.Poly, err error) {
+
+	if err = pipeline.checkRound(limb, EkgRoundGenSamples); err != nil {
+		return nil, err
+	}
+
+	h = pipeline.ekg.genSamplesLimb(limb, pipeline.uCRP, pipeline.sk, pipeline.crp[limb])
+
+	pipeline.ekg.auditRoundOne(limbStep("GenSamples", limb), AuditSent, [][]*ring.Poly{h})
+
+	pipeline.rounds[limb] = EkgRoundAggregate
+
+	return h, nil
+}
+
+// Aggregate is the second round of the sub-protocol of the given limb, see EkgProtocol.Aggregate.
+// It takes as input the samples of all the parties for this limb and returns the aggregated
+// samples to be broadcast to the other parties.
+func (pipeline *EkgPipeline) Aggregate(limb int, samples [][]*ring.Poly) (h [][2]*ring.Poly, err error) {
+
+	if err = pipeline.checkRound(limb, EkgRoundAggregate); err != nil {
+		return nil, err
+	}
+
+	if len(samples) == 0 {
+		return nil, errors.New("cannot aggregate -> no samples")
+	}
+
+	for j := range samples {
+		pipeline.ekg.auditRoundOne(limbStep("Aggregate", limb), AuditReceived, [][]*ring.Poly{samples[j]})
+	}
+
+	h = pipeline.ekg.aggregateLimb(pipeline.sk, pipeline.skCRP, samples, pipeline.crp[limb], pipeline.polypool[limb])
+
+	pipeline.ekg.auditRoundTwo(limbStep("Aggregate", limb), AuditSent, [][][2]*ring.Poly{h})
+
+	pipeline.rounds[limb] = EkgRoundKeySwitch
+
+	return h, nil
+}
+
+// KeySwitch is the third round of the sub-protocol of the given limb, see EkgProtocol.Sum and EkgProtocol.KeySwitch.
+// It takes as input the aggregated samples of all the parties for this limb and returns the key-switched
+// share to be broadcast to the other parties.
+func (pipeline *EkgPipeline) KeySwitch(limb int, samples [][][2]*ring.Poly) (h1 []*ring.Poly, err error) {
+
+	if err = pipeline.checkRound(limb, EkgRoundKeySwitch); err != nil {
+		return nil, err
+	}
+
+	if len(samples) == 0 {
+		return nil, errors.New("cannot key-switch -> no aggregated samples")
+	}
+
+	for j := range samples {
+		pipeline.ekg.auditRoundTwo(limbStep("Sum", limb), AuditReceived, [][][2]*ring.Poly{samples[j]})
+	}
+
+	pipeline.sum[limb] = pipeline.ekg.sumLimb(samples)
+
+	h1 = pipeline.ekg.keySwitchLimb(pipeline.mask, pipeline.sum[limb])
+
+	pipeline.ekg.auditRoundThree(limbStep("KeySwitch", limb), AuditSent, [][]*ring.Poly{h1})
+
+	pipeline.rounds[limb] = EkgRoundComputeEVK
+
+	return h1, nil
+}
+
+// ComputeEVK is the last round of the sub-protocol of the given limb, see EkgProtocol.ComputeEVK.
+// It takes as input the key-switched shares of all the parties for this limb and returns the
+// collective evaluation-key for this limb.
+func (pipeline *EkgPipeline) ComputeEVK(limb int, keySwitched [][]*ring.Poly) (evk [][2]*ring.Poly, err error) {
+
+	if err = pipeline.checkRound(limb, EkgRoundComputeEVK); err != nil {
+		return nil, err
+	}
+
+	if len(keySwitched) == 0 {
+		return nil, errors.New("cannot compute evk -> no key-switched shares")
+	}
+
+	for j := range keySwitched {
+		pipeline.ekg.auditRoundThree(limbStep("ComputeEVK", limb), AuditReceived, [][]*ring.Poly{keySwitched[j]})
+	}
+
+	pipeline.evk[limb] = pipeline.ekg.computeEVKLimb(keySwitched, pipeline.sum[limb])
+	pipeline.sum[limb] = nil
+
+	pipeline.rounds[limb] = EkgRoundDone
+
+	return pipeline.evk[limb], nil
+}
+
+// EVK returns the collective evaluation-key once the sub-protocols of all the limbs are completed.
+func (pipeline *EkgPipeline) EVK() (evk [][][2]*ring.Poly, err error) {
+
+	if !pipeline.Done() {
+		return nil, errors.New("cannot get evk -> pipeline not completed")
+	}
+
+	return pipeline.evk, nil
+}
+
+// limbStep returns the step recorded in the audit logs for the given round of the sub-protocol of a limb.
+func limbStep(step string, limb int) string {
+	return fmt.Sprintf("%s/%d", step, limb)
+}
+
+func (pipeline *EkgPipeline) checkRound(limb int, round EkgRound) error {
+
+	if limb < 0 || limb >= len(pipeline.rounds) {
+		return errors.New("cannot advance limb -> invalid limb index")
+	}
+
+	if pipeline.rounds[limb] != round {
+		return errors.New("cannot advance limb -> limb is not at the expected round")
+	}
+
+	return nil
+}
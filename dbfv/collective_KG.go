@@ -28,6 +28,11 @@ func NewCKG(context *ring.Context, crs *ring.Poly) *CKG {
 	return ckg
 }
 
+// RoundCount returns the number of rounds of communication of the CKG protocol, which is 1.
+func (ckg *CKG) RoundCount() int {
+	return 1
+}
+
 // GenShare is the first and unique round of the CKG protocol. Each party generates a secret share
 // and computes from it a public-share of the form :
 //
@@ -30,6 +30,11 @@ func NewCKS(skInput, skOutput *ring.Poly, context *ring.Context, sigmaSmudging f
 	return cks
 }
 
+// RoundCount returns the number of rounds of communication of the CKS protocol, which is 1.
+func (cks *CKS) RoundCount() int {
+	return 1
+}
+
 // KeySwitch is the first and unique round of the CKS protocol. Each party holding a ciphertext ctx encrypted under a collective publick-key musth
 // compute the following :
 //
@@ -1,6 +1,8 @@
 package dbfv
 
 import (
+	"errors"
+	"github.com/ldsec/lattigo/bfv"
 	"github.com/ldsec/lattigo/ring"
 )
 
@@ -37,6 +39,11 @@ func NewPCKS(skInput *ring.Poly, pkOutput [2]*ring.Poly, context *ring.Context,
 	return pcks
 }
 
+// RoundCount returns the number of rounds of communication of the PCKS protocol, which is 1.
+func (pcks *PCKS) RoundCount() int {
+	return 1
+}
+
 // KeySwitch is the first part of the unique round of the PCKS protocol. Each party computes the following :
 //
 // [s_i * ctx[0] + u_i * pk[0] + e_0i, u_i * pk[1] + e_1i]
@@ -44,10 +51,40 @@ func NewPCKS(skInput *ring.Poly, pkOutput [2]*ring.Poly, context *ring.Context,
 // and broadcasts the result to the other j-1 parties.
 func (pcks *PCKS) KeySwitch(ct1 *ring.Poly) (h [2]*ring.Poly) {
 
+	h[0] = pcks.context.NewPoly()
+	h[1] = pcks.context.NewPoly()
+
+	pcks.keySwitch(pcks.skInput, pcks.pkOutput, ct1, h)
+
+	return h
+}
+
+// GenShare is a variant of KeySwitch taking the secret share sk_i of the party, the target public-key pk and the ciphertext ct to
+// re-encrypt, instead of the ones given to NewPCKS, and returning the share on shareOut (see NewShareEmpty), so that the shares of
+// the parties can be serialized and folded one at a time (see AggregateShares and Aggregator) before being applied with Finalize.
+// The ciphertext must be of degree 1 and not in the NTT domain.
+func (pcks *PCKS) GenShare(sk *ring.Poly, pk *bfv.PublicKey, ct *bfv.Ciphertext, shareOut *PCKSShare) error {
+
+	if ct.Degree() != 1 {
+		return errors.New("cannot generate PCKS share -> input ciphertext must be of degree 1")
+	}
+
+	if ct.IsNTT() {
+		return errors.New("cannot generate PCKS share -> input ciphertext must not be in the NTT domain")
+	}
+
+	pcks.keySwitch(sk, pk.Get(), ct.Value()[1], shareOut.Value)
+
+	return nil
+}
+
+// keySwitch computes the share [s_i * ct1 + u_i * pk[0] + e_0i, u_i * pk[1] + e_1i] on h.
+func (pcks *PCKS) keySwitch(sk *ring.Poly, pk [2]*ring.Poly, ct1 *ring.Poly, h [2]*ring.Poly) {
+
 	// h_0 = pk_0 (NTT)
-	h[0] = pcks.pkOutput[0].CopyNew()
+	pcks.context.Copy(pk[0], h[0])
 	// h_1 = pk_1 (NTT)
-	h[1] = pcks.pkOutput[1].CopyNew()
+	pcks.context.Copy(pk[1], h[1])
 
 	//u_i
 	pcks.ternarySampler.SampleMontgomeryNTT(0.5, pcks.polypool)
@@ -59,7 +96,7 @@ func (pcks *PCKS) KeySwitch(ct1 *ring.Poly) (h [2]*ring.Poly) {
 
 	// h0 = u_i * pk_0 + s_i*c_1 (NTT)
 	pcks.context.NTT(ct1, pcks.polypool)
-	pcks.context.MulCoeffsMontgomeryAndAdd(pcks.polypool, pcks.skInput, h[0])
+	pcks.context.MulCoeffsMontgomeryAndAdd(pcks.polypool, sk, h[0])
 
 	pcks.context.InvNTT(h[0], h[0])
 	pcks.context.InvNTT(h[1], h[1])
@@ -73,8 +110,30 @@ func (pcks *PCKS) KeySwitch(ct1 *ring.Poly) (h [2]*ring.Poly) {
 	pcks.context.Add(h[1], pcks.polypool, h[1])
 
 	pcks.polypool.Zero()
+}
 
-	return h
+// AggregateShares adds share1 and share2 on shareOut, so that a coordinator can fold the shares of the parties one at a time
+// into their sum [sum(s_i * ctx[1] + u_i * pk[0] + e_0i), sum(u_i * pk[1] + e_1i)], starting from NewShareEmpty.
+func (pcks *PCKS) AggregateShares(share1, share2, shareOut *PCKSShare) {
+	pcks.context.Add(share1.Value[0], share2.Value[0], shareOut.Value[0])
+	pcks.context.Add(share1.Value[1], share2.Value[1], shareOut.Value[1])
+}
+
+// Finalize re-encrypts ct under the target public-key from the aggregation of the shares of all the parties, returning on ctOut :
+//
+// [ctx[0] + sum(s_i * ctx[1] + u_i * pk[0] + e_0i), sum(u_i * pk[1] + e_1i)]
+//
+// ctOut must be of degree 1, and can be ct.
+func (pcks *PCKS) Finalize(ct *bfv.Ciphertext, aggregatedShare *PCKSShare, ctOut *bfv.Ciphertext) error {
+
+	if ct.Degree() != 1 || ctOut.Degree() != 1 {
+		return errors.New("cannot finalize PCKS -> input and output ciphertexts must be of degree 1")
+	}
+
+	pcks.context.Add(ct.Value()[0], aggregatedShare.Value[0], ctOut.Value()[0])
+	pcks.context.Copy(aggregatedShare.Value[1], ctOut.Value()[1])
+
+	return nil
 }
 
 // Aggregate is the second part of the first and unique round of the PCKS protocol. Each party uppon receiving the j-1 elements from the
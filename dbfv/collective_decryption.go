@@ -0,0 +1,151 @@
+package dbfv
+
+import (
+	"errors"
+	"github.com/ldsec/lattigo/bfv"
+	"github.com/ldsec/lattigo/ring"
+)
+
+// CollectiveDecryption is a structure storing the parameters for the collective decryption protocol, in which the parties holding
+// the secret-shares of a collective secret-key jointly reveal the plaintext of a ciphertext encrypted under the collective public-key.
+// The decryption shares are flooded with a uniform noise (see GenDecryptionShare) or smudged with a gaussian noise (see GenShare) so
+// that the aggregated plaintext does not leak the individual shares of the secret-key through their residual error. It is the
+// collective key-switching of the ciphertext to the zero secret-key.
+type CollectiveDecryption struct {
+	context  *ring.Context
+	polypool *ring.Poly
+
+	sigmaSmudging   float64
+	gaussianSampler *ring.ErrorSampler
+}
+
+// NewCollectiveDecryption creates a new CollectiveDecryption that will be used to decrypt a ciphertext of the given context
+// encrypted under a collective public-key.
+func NewCollectiveDecryption(context *ring.Context) *CollectiveDecryption {
+
+	cd := new(CollectiveDecryption)
+	cd.context = context
+	cd.polypool = context.NewPoly()
+
+	return cd
+}
+
+// NewCollectiveDecryptionWithSigma creates a new CollectiveDecryption whose GenShare smudges the decryption shares with a gaussian noise
+// of standard deviation sigmaSmudging, rounded to the nearest integer (see ring.RoundedGaussian), whose sampling cost does not depend
+// on sigmaSmudging. Returns an error if sigmaSmudging is not positive.
+func NewCollectiveDecryptionWithSigma(context *ring.Context, sigmaSmudging float64) (*CollectiveDecryption, error) {
+
+	gaussianSampler, err := context.NewErrorSampler(ring.RoundedGaussian, sigmaSmudging)
+	if err != nil {
+		return nil, errors.New("cannot create CollectiveDecryption -> sigmaSmudging must be positive")
+	}
+
+	cd := NewCollectiveDecryption(context)
+	cd.sigmaSmudging = sigmaSmudging
+	cd.gaussianSampler = gaussianSampler
+
+	return cd, nil
+}
+
+// SigmaSmudging returns the standard deviation of the gaussian noise smudging the shares of GenShare, 0 if it has not been set.
+func (cd *CollectiveDecryption) SigmaSmudging() float64 {
+	return cd.sigmaSmudging
+}
+
+// RoundCount returns the number of rounds of communication of the collective decryption protocol, which is 1.
+func (cd *CollectiveDecryption) RoundCount() int {
+	return 1
+}
+
+// GenDecryptionShare is the first and unique round of the collective decryption protocol. Each party holding a share sk_i of the
+// collective secret-key computes :
+//
+// [sk_i * ct[1] + e_i]
+//
+// where e_i is sampled uniformly in [-2^floodBits, 2^floodBits), and broadcasts the result to the other j-1 parties. The sum of the
+// flooding noises of all the parties must stay below Q/(2t) for the aggregated plaintext to be correct.
+func (cd *CollectiveDecryption) GenDecryptionShare(sk *ring.Poly, ct *bfv.Ciphertext, floodBits int, shareOut *ring.Poly) error {
+
+	if ct.Degree() != 1 {
+		return errors.New("cannot generate decryption share -> input ciphertext must be of degree 1")
+	}
+
+	if floodBits < 0 || floodBits > 61 {
+		return errors.New("cannot generate decryption share -> floodBits must be in [0, 61]")
+	}
+
+	// sk_i * ct[1]
+	cd.context.NTT(ct.Value()[1], shareOut)
+	cd.context.MulCoeffsMontgomery(shareOut, sk, shareOut)
+	cd.context.InvNTT(shareOut, shareOut)
+
+	// + e_i
+	cd.context.SampleFlooding(uint64(floodBits), cd.polypool)
+
+	cd.context.Add(shareOut, cd.polypool, shareOut)
+
+	return nil
+}
+
+// GenShare is a variant of GenDecryptionShare smudging the decryption share with a gaussian noise instead of a uniform one. Each party
+// holding a share sk_i of the collective secret-key computes :
+//
+// [sk_i * ct[1] + e_i]
+//
+// where e_i is sampled from a gaussian distribution of standard deviation the sigmaSmudging of NewCollectiveDecryptionWithSigma, and
+// broadcasts the result to the other j-1 parties, which aggregate the shares with AggregateShares. Returns an error if the
+// CollectiveDecryption was not created with NewCollectiveDecryptionWithSigma.
+func (cd *CollectiveDecryption) GenShare(sk *ring.Poly, ct *bfv.Ciphertext, shareOut *ring.Poly) error {
+
+	if cd.gaussianSampler == nil {
+		return errors.New("cannot generate share -> sigmaSmudging has not been set (see NewCollectiveDecryptionWithSigma)")
+	}
+
+	if ct.Degree() != 1 {
+		return errors.New("cannot generate share -> input ciphertext must be of degree 1")
+	}
+
+	// sk_i * ct[1]
+	cd.context.NTT(ct.Value()[1], shareOut)
+	cd.context.MulCoeffsMontgomery(shareOut, sk, shareOut)
+	cd.context.InvNTT(shareOut, shareOut)
+
+	// + e_i
+	if err := cd.gaussianSampler.SampleError(ring.RoundedGaussian, cd.sigmaSmudging, cd.polypool); err != nil {
+		return err
+	}
+	cd.context.Add(shareOut, cd.polypool, shareOut)
+
+	return nil
+}
+
+// AggregateShares is the second part of the unique round of the collective decryption protocol. Upon receiving the j-1 decryption
+// shares, each party computes :
+//
+// [ct[0] + sum(sk_i * ct[1] + e_i)] = [ct[0] + sk * ct[1] + sum(e_i)]
+//
+// and returns the result on ptOut, which can then be decoded as a plaintext obtained with a bfv.Decryptor.
+func (cd *CollectiveDecryption) AggregateShares(ct *bfv.Ciphertext, shares []*ring.Poly, ptOut *bfv.Plaintext) error {
+
+	if ct.Degree() != 1 {
+		return errors.New("cannot aggregate decryption shares -> input ciphertext must be of degree 1")
+	}
+
+	pt := ptOut.Value()[0]
+
+	cd.context.Copy(ct.Value()[0], pt)
+
+	for i := range shares {
+		cd.context.AddNoMod(pt, shares[i], pt)
+
+		if i&7 == 7 {
+			cd.context.Reduce(pt, pt)
+		}
+	}
+
+	if (len(shares)-1)&7 != 7 {
+		cd.context.Reduce(pt, pt)
+	}
+
+	return nil
+}
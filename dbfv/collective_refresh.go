@@ -0,0 +1,152 @@
+package dbfv
+
+import (
+	"errors"
+	"github.com/ldsec/lattigo/bfv"
+	"github.com/ldsec/lattigo/ring"
+	"math/bits"
+)
+
+// RefreshProtocol is a structure storing the parameters for the collective refresh protocol, in which the parties holding the
+// secret-shares of a collective secret-key jointly re-encrypt a ciphertext encrypted under the collective key into a fresh ciphertext
+// of the same plaintext, with a low noise, without revealing the plaintext. The ciphertext is decrypted under an additive mask in
+// the plaintext space, which the parties re-encrypt at the same time under the collective key.
+type RefreshProtocol struct {
+	bfvContext *bfv.BfvContext
+	context    *ring.Context
+	contextT   *ring.Context
+
+	gaussianSampler *ring.KYSampler
+	floodBits       uint64
+	scaler          *ring.SimpleScaler
+
+	maskpool *bfv.Plaintext
+	polypool *ring.Poly
+	tpool    *ring.Poly
+}
+
+// NewRefreshProtocol creates a new RefreshProtocol that will be used to refresh the ciphertexts of the given bfvcontext encrypted
+// under a collective public-key. The decryption shares are flooded with a noise uniform in [-2^floodBits, 2^floodBits) (see
+// GenDecryptionShare), which must be large enough to hide the residual noise of the ciphertexts to refresh, while the sum of the
+// flooding noises of all the parties must stay below Q/(2t). Returns an error if 2^floodBits is not smaller than Q/(2t).
+func NewRefreshProtocol(bfvContext *bfv.BfvContext, floodBits uint64) (*RefreshProtocol, error) {
+
+	if floodBits+uint64(bits.Len64(bfvContext.T()))+1 >= bfvContext.LogQ() {
+		return nil, errors.New("cannot create RefreshProtocol -> 2^floodBits must be smaller than Q/(2t)")
+	}
+
+	refresh := new(RefreshProtocol)
+	refresh.bfvContext = bfvContext
+	refresh.context = bfvContext.ContextQ()
+	refresh.contextT = bfvContext.ContextT()
+
+	refresh.gaussianSampler = refresh.context.NewKYSampler(bfvContext.Sigma(), int(6*bfvContext.Sigma()))
+	refresh.floodBits = floodBits
+	refresh.scaler = ring.NewSimpleScaler(bfvContext.T(), refresh.context)
+
+	refresh.maskpool = bfvContext.NewPlaintext()
+	refresh.polypool = refresh.context.NewPoly()
+	refresh.tpool = refresh.contextT.NewPoly()
+
+	return refresh, nil
+}
+
+// RoundCount returns the number of rounds of communication of the refresh protocol, which is 1.
+func (refresh *RefreshProtocol) RoundCount() int {
+	return 1
+}
+
+// AllocateShares allocates a new RefreshShare with all its coefficients set to 0, e.g. to receive the shares of GenShare or
+// the aggregation of AggregateShares.
+func (refresh *RefreshProtocol) AllocateShares() *RefreshShare {
+	return refresh.NewShare([2]*ring.Poly{refresh.context.NewPoly(), refresh.context.NewPoly()})
+}
+
+// GenShare is the first and unique round of the refresh protocol. Each party holding a share sk_i of the collective secret-key
+// samples a uniform mask M_i in the plaintext space and computes, a being the common reference polynomial :
+//
+// [sk_i * ct[1] - Delta * M_i + e_0i, -sk_i * a + Delta * M_i + e_1i]
+//
+// i.e. a decryption share of the ciphertext masked by M_i, flooded by e_0i (see NewRefreshProtocol), and a re-encryption of M_i under
+// sk_i, and broadcasts the result on shareOut to the other j-1 parties. The ciphertext must be of degree 1 and not in the NTT domain.
+func (refresh *RefreshProtocol) GenShare(sk *ring.Poly, ciphertext *bfv.Ciphertext, crp *ring.Poly, shareOut *RefreshShare) error {
+
+	if ciphertext.Degree() != 1 {
+		return errors.New("cannot generate refresh share -> input ciphertext must be of degree 1")
+	}
+
+	if ciphertext.IsNTT() {
+		return errors.New("cannot generate refresh share -> input ciphertext must not be in the NTT domain")
+	}
+
+	context := refresh.context
+
+	// Delta * M_i
+	mask := refresh.maskpool.Value()[0]
+	copy(mask.Coeffs[0], refresh.contextT.NewUniformPoly().Coeffs[0])
+	refresh.maskpool.Lift(refresh.bfvContext)
+
+	// h0 = sk_i * ct[1] - Delta * M_i + e_0i
+	context.NTT(ciphertext.Value()[1], shareOut.Value[0])
+	context.MulCoeffsMontgomery(shareOut.Value[0], sk, shareOut.Value[0])
+	context.InvNTT(shareOut.Value[0], shareOut.Value[0])
+	context.Sub(shareOut.Value[0], mask, shareOut.Value[0])
+	context.SampleFlooding(refresh.floodBits, refresh.polypool)
+	context.Add(shareOut.Value[0], refresh.polypool, shareOut.Value[0])
+
+	// h1 = -sk_i * a + Delta * M_i + e_1i
+	context.NTT(crp, shareOut.Value[1])
+	context.MulCoeffsMontgomery(shareOut.Value[1], sk, shareOut.Value[1])
+	context.InvNTT(shareOut.Value[1], shareOut.Value[1])
+	context.Neg(shareOut.Value[1], shareOut.Value[1])
+	context.Add(shareOut.Value[1], mask, shareOut.Value[1])
+	refresh.gaussianSampler.Sample(refresh.polypool)
+	context.Add(shareOut.Value[1], refresh.polypool, shareOut.Value[1])
+
+	mask.Zero()
+	refresh.polypool.Zero()
+
+	return nil
+}
+
+// AggregateShares is the second part of the unique round of the refresh protocol. It adds share1 and share2 on shareOut, so that
+// the shares of all the parties can be aggregated one by one into :
+//
+// [sk * ct[1] - Delta * M + e_0, -sk * a + Delta * M + e_1]
+func (refresh *RefreshProtocol) AggregateShares(share1, share2, shareOut *RefreshShare) {
+	refresh.context.Add(share1.Value[0], share2.Value[0], shareOut.Value[0])
+	refresh.context.Add(share1.Value[1], share2.Value[1], shareOut.Value[1])
+}
+
+// Finalize computes, from the aggregation of the shares of all the parties, the refreshed ciphertext on ctOut :
+//
+// [Delta * round(t/Q * (ct[0] + sk * ct[1] - Delta * M + e_0)) - sk * a + Delta * M + e_1, a] = [Delta * m - sk * a + e_1, a]
+//
+// the masked plaintext m - M being publicly decrypted, and a being the common reference polynomial used by the parties in GenShare.
+// The noise of ctOut is that of a fresh encryption, regardless of the noise of the input ciphertext, as long as it is decryptable.
+// ctOut must be of degree 1, and can be the input ciphertext.
+func (refresh *RefreshProtocol) Finalize(ciphertext *bfv.Ciphertext, crp *ring.Poly, aggregatedShare *RefreshShare, ctOut *bfv.Ciphertext) error {
+
+	if ciphertext.Degree() != 1 || ctOut.Degree() != 1 {
+		return errors.New("cannot finalize refresh -> input and output ciphertexts must be of degree 1")
+	}
+
+	context := refresh.context
+
+	// m - M = round(t/Q * (ct[0] + sk * ct[1] - Delta * M + e_0))
+	context.Add(ciphertext.Value()[0], aggregatedShare.Value[0], refresh.polypool)
+	refresh.scaler.Scale(refresh.polypool, refresh.tpool)
+
+	// Delta * (m - M)
+	copy(refresh.maskpool.Value()[0].Coeffs[0], refresh.tpool.Coeffs[0])
+	refresh.maskpool.Lift(refresh.bfvContext)
+
+	context.Add(refresh.maskpool.Value()[0], aggregatedShare.Value[1], ctOut.Value()[0])
+	context.Copy(crp, ctOut.Value()[1])
+
+	refresh.maskpool.Value()[0].Zero()
+	refresh.polypool.Zero()
+	refresh.tpool.Zero()
+
+	return nil
+}
@@ -0,0 +1,35 @@
+package dbfv
+
+import (
+	"crypto/subtle"
+	"github.com/ldsec/lattigo/ring"
+	"golang.org/x/crypto/blake2b"
+)
+
+// commitmentDomain separates the hashes of the secret share commitments from the other uses of blake2b.
+var commitmentDomain = []byte("lattigo/dbfv/secret-share-commitment")
+
+// CommitSecretShare returns a binding commitment to the secret share sk, the blake2b-256 hash of its binary encoding. A party can
+// publish it before the protocol for accountability and later reveal its share, which is then checked with VerifySecretShare. The
+// share must be revealed in the same form (e.g. NTT and Montgomery) as when it was committed. Returns nil if sk cannot be encoded.
+func CommitSecretShare(sk *ring.Poly) []byte {
+
+	data, err := sk.MarshalBinary()
+	if err != nil {
+		return nil
+	}
+
+	hash, _ := blake2b.New256(nil)
+	hash.Write(commitmentDomain)
+	hash.Write(data)
+
+	return hash.Sum(nil)
+}
+
+// VerifySecretShare returns true if the revealed secret share sk matches the commitment previously produced by CommitSecretShare.
+func VerifySecretShare(commitment []byte, sk *ring.Poly) bool {
+
+	opening := CommitSecretShare(sk)
+
+	return opening != nil && subtle.ConstantTimeCompare(commitment, opening) == 1
+}
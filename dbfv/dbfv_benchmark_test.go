@@ -1,14 +1,73 @@
 package dbfv
 
 import (
+	"encoding/json"
+	"flag"
 	"fmt"
 	"github.com/ldsec/lattigo/bfv"
 	"github.com/ldsec/lattigo/ring"
+	"io/ioutil"
+	"math"
+	"runtime"
+	"sync"
 	"testing"
+	"time"
 )
 
+var flagResults = flag.String("results", "", "writes the per-round timings, share sizes and noise of the EKG benchmarks as JSON in the given file.")
+
+// benchmarkResult is the machine-readable record of the benchmarks of the EkgProtocol for one configuration.
+type benchmarkResult struct {
+	N         uint64             `json:"n"`
+	LogQ      int                `json:"logQ"`
+	Parties   int                `json:"parties"`
+	BitDecomp uint64             `json:"bitDecomp"`
+	Rounds    map[string]float64 `json:"roundsNsPerOp"`
+	ShareSize map[string]int     `json:"shareSizeBytes"`
+	Noise     float64            `json:"noiseLog2"`
+}
+
+func newBenchmarkResult(context *ring.Context, parties int, bitDecomp uint64) *benchmarkResult {
+	return &benchmarkResult{
+		N:         context.N,
+		LogQ:      context.ModulusBigint.Value.BitLen(),
+		Parties:   parties,
+		BitDecomp: bitDecomp,
+		Rounds:    make(map[string]float64),
+		ShareSize: make(map[string]int),
+	}
+}
+
+// record stores the time per operation of the run of the benchmark started at the given time. To be deferred at
+// the beginning of the benchmark function, so that the last (and longest) run is the one stored.
+func (result *benchmarkResult) record(round string, b *testing.B, start time.Time) {
+	result.Rounds[round] = float64(time.Since(start).Nanoseconds()) / float64(b.N)
+}
+
+// sharesSize returns the size in bytes of the binary encoding of the given polynomials.
+func sharesSize(polys ...*ring.Poly) (size int) {
+	for _, pol := range polys {
+		data, _ := pol.MarshalBinary()
+		size += len(data)
+	}
+	return
+}
+
+// writeBenchmarkResults writes the given results as JSON in the file at the given path.
+func writeBenchmarkResults(path string, results []*benchmarkResult) error {
+
+	data, err := json.MarshalIndent(results, "", "\t")
+	if err != nil {
+		return err
+	}
+
+	return ioutil.WriteFile(path, data, 0644)
+}
+
 func Benchmark_DBFVScheme(b *testing.B) {
 
+	results := []*benchmarkResult{}
+
 	paramSets := bfv.DefaultParams[3:4]
 	bitDecomps := []uint64{60}
 	nParties := []int{2}
@@ -111,15 +170,101 @@ func Benchmark_DBFVScheme(b *testing.B) {
 					keySwitched[i] = EkgProtocol.KeySwitch(sk0.Get(), sk1.Get(), sum)
 				}
 
+				result := newBenchmarkResult(context, parties, bitDecomp)
+				results = append(results, result)
+
+				for i := range samples[0] {
+					result.ShareSize["EKG_Round0"] += sharesSize(samples[0][i]...)
+					result.ShareSize["EKG_Round2"] += sharesSize(keySwitched[0][i]...)
+					for _, share := range aggregatedSamples[0][i] {
+						result.ShareSize["EKG_Round1"] += sharesSize(share[0], share[1])
+					}
+				}
+
+				// The collective secret-key is the sum of the shares sk1 of all the parties
+				skCollective := kgen.NewSecretKeyEmpty()
+				context.MulScalar(sk1.Get(), uint64(parties), skCollective.Get())
+
+				rlk := new(bfv.EvaluationKey)
+				rlk.SetRelinKeys([][][][2]*ring.Poly{EkgProtocol.ComputeEVK(keySwitched, sum)}, bitDecomp)
+
+				relationError, err := rlk.RelationError(skCollective, bfvContext)
+				if err != nil {
+					b.Fatal(err)
+				}
+
+				result.Noise = math.Inf(-1)
+				for _, limb := range relationError {
+					for _, digit := range limb {
+						result.Noise = math.Max(result.Noise, digit)
+					}
+				}
+
 				//EKG_V2_Round_0
 				b.Run(fmt.Sprintf("params=%d/parties=%d/decomp=%d/EKG_Round0", params.N, parties, bitDecomp), func(b *testing.B) {
+					defer result.record("EKG_Round0", b, time.Now())
 					for i := 0; i < b.N; i++ {
 						EkgProtocol.GenSamples(sk0.Get(), sk1.Get(), crp)
 					}
 				})
 
+				//EKG_V2_Round_0 with the decomposition of the secret share precomputed once for all the runs
+				skDecomposed := context.DecomposePoly(sk1.Get(), bitDecomp)
+				b.Run(fmt.Sprintf("params=%d/parties=%d/decomp=%d/EKG_Round0_Decomposed", params.N, parties, bitDecomp), func(b *testing.B) {
+					defer result.record("EKG_Round0_Decomposed", b, time.Now())
+					for i := 0; i < b.N; i++ {
+						EkgProtocol.GenSamplesDecomposed(sk0.Get(), skDecomposed, crp)
+					}
+				})
+
+				//EKG_V2_Round_0 -u*a step, per sample and batched over the whole share
+				shareMulSub := EkgProtocol.NewShareRoundOne(samples[0])
+				b.Run(fmt.Sprintf("params=%d/parties=%d/decomp=%d/EKG_Round0_MulSubPerSample", params.N, parties, bitDecomp), func(b *testing.B) {
+					defer result.record("EKG_Round0_MulSubPerSample", b, time.Now())
+					for i := 0; i < b.N; i++ {
+						for j := range shareMulSub.Value {
+							for w := range shareMulSub.Value[j] {
+								context.MulCoeffsMontgomeryAndSub(sk0.Get(), crp[j][w], shareMulSub.Value[j][w])
+							}
+						}
+					}
+				})
+
+				b.Run(fmt.Sprintf("params=%d/parties=%d/decomp=%d/EKG_Round0_MulSubShare", params.N, parties, bitDecomp), func(b *testing.B) {
+					defer result.record("EKG_Round0_MulSubShare", b, time.Now())
+					for i := 0; i < b.N; i++ {
+						EkgProtocol.MulCoeffsMontgomeryAndSubShare(sk0.Get(), crp, shareMulSub)
+					}
+				})
+
+				//EKG_V2_Round_0 aggregation of two serialized shares, with and without decoding them
+				serialized := make([][]byte, 2)
+				for i := range serialized {
+					serialized[i], _ = EkgProtocol.NewShareRoundOne(samples[0]).MarshalBinary()
+				}
+
+				b.Run(fmt.Sprintf("params=%d/parties=%d/decomp=%d/EKG_Round0_AggregateDecoded", params.N, parties, bitDecomp), func(b *testing.B) {
+					defer result.record("EKG_Round0_AggregateDecoded", b, time.Now())
+					shares := []Share{EkgProtocol.NewShareRoundOneEmpty(), EkgProtocol.NewShareRoundOneEmpty()}
+					for i := 0; i < b.N; i++ {
+						for j := range shares {
+							shares[j].UnMarshalBinary(serialized[j])
+						}
+						shares[0].Aggregate(shares[1])
+						shares[0].MarshalBinary()
+					}
+				})
+
+				b.Run(fmt.Sprintf("params=%d/parties=%d/decomp=%d/EKG_Round0_AggregateSerialized", params.N, parties, bitDecomp), func(b *testing.B) {
+					defer result.record("EKG_Round0_AggregateSerialized", b, time.Now())
+					for i := 0; i < b.N; i++ {
+						EkgProtocol.AggregateSerializedRoundOne(serialized[0], serialized[1])
+					}
+				})
+
 				//EKG_V2_Round_1
 				b.Run(fmt.Sprintf("params=%d/parties=%d/decomp=%d/EKG_Round1", params.N, parties, bitDecomp), func(b *testing.B) {
+					defer result.record("EKG_Round1", b, time.Now())
 					for i := 0; i < b.N; i++ {
 						EkgProtocol.Aggregate(sk1.Get(), samples, crp)
 					}
@@ -127,6 +272,7 @@ func Benchmark_DBFVScheme(b *testing.B) {
 
 				//EKG_V2_Round_2
 				b.Run(fmt.Sprintf("params=%d/parties=%d/decomp=%d/EKG_Round2", params.N, parties, bitDecomp), func(b *testing.B) {
+					defer result.record("EKG_Round2", b, time.Now())
 					for i := 0; i < b.N; i++ {
 						EkgProtocol.KeySwitch(sk1.Get(), sk1.Get(), EkgProtocol.Sum(aggregatedSamples))
 					}
@@ -134,10 +280,29 @@ func Benchmark_DBFVScheme(b *testing.B) {
 
 				//EKG_V2_Round_3
 				b.Run(fmt.Sprintf("params=%d/parties=%d/decomp=%d/EKG_Round3", params.N, parties, bitDecomp), func(b *testing.B) {
+					defer result.record("EKG_Round3", b, time.Now())
 					for i := 0; i < b.N; i++ {
 						EkgProtocol.ComputeEVK(keySwitched, sum)
 					}
 				})
+
+				// End-to-end latency of the sequential execution, all the limbs advancing round by round
+				b.Run(fmt.Sprintf("params=%d/parties=%d/decomp=%d/EKG_Sequential", params.N, parties, bitDecomp), func(b *testing.B) {
+					defer result.record("EKG_Sequential", b, time.Now())
+					for i := 0; i < b.N; i++ {
+						benchmarkEKGSequential(EkgProtocol, parties, sk0.Get(), sk1.Get(), crp)
+					}
+				})
+
+				// End-to-end latency of the pipelined execution, each limb advancing independently
+				b.Run(fmt.Sprintf("params=%d/parties=%d/decomp=%d/EKG_Pipelined", params.N, parties, bitDecomp), func(b *testing.B) {
+					defer result.record("EKG_Pipelined", b, time.Now())
+					for i := 0; i < b.N; i++ {
+						if err := benchmarkEKGPipelined(EkgProtocol, parties, sk0.Get(), sk1.Get(), crp); err != nil {
+							b.Error(err)
+						}
+					}
+				})
 			}
 		}
 
@@ -223,4 +388,270 @@ func Benchmark_DBFVScheme(b *testing.B) {
 			})
 		}
 	}
+
+	if *flagResults != "" {
+		if err := writeBenchmarkResults(*flagResults, results); err != nil {
+			b.Error(err)
+		}
+	}
+}
+
+// benchmarkEKGSequential runs the EkgProtocol protocol for the given number of parties, all sharing
+// the keys u and sk, with all the limbs of a round completed before starting the next round.
+func benchmarkEKGSequential(ekg *EkgProtocol, parties int, u, sk *ring.Poly, crp [][]*ring.Poly) {
+
+	samples := make([][][]*ring.Poly, parties)
+	for i := 0; i < parties; i++ {
+		samples[i] = ekg.GenSamples(u, sk, crp)
+	}
+
+	aggregatedSamples := make([][][][2]*ring.Poly, parties)
+	for i := 0; i < parties; i++ {
+		aggregatedSamples[i] = ekg.Aggregate(sk, samples, crp)
+	}
+
+	keySwitched := make([][][]*ring.Poly, parties)
+	sum := ekg.Sum(aggregatedSamples)
+	for i := 0; i < parties; i++ {
+		keySwitched[i] = ekg.KeySwitch(u, sk, sum)
+	}
+
+	ekg.ComputeEVK(keySwitched, sum)
+}
+
+// benchmarkEKGPipelined runs the EkgProtocol protocol for the given number of parties, all sharing
+// the keys u and sk, with each limb advancing independently through the rounds in its own goroutine.
+func benchmarkEKGPipelined(ekg *EkgProtocol, parties int, u, sk *ring.Poly, crp [][]*ring.Poly) error {
+
+	pipelines := make([]*EkgPipeline, parties)
+	for i := 0; i < parties; i++ {
+		pipelines[i] = ekg.NewPipeline(u, sk, crp)
+	}
+
+	var wg sync.WaitGroup
+	errs := make([]error, pipelines[0].Limbs())
+
+	for limb := 0; limb < pipelines[0].Limbs(); limb++ {
+
+		wg.Add(1)
+
+		go func(limb int) {
+
+			defer wg.Done()
+
+			var err error
+
+			samples := make([][]*ring.Poly, parties)
+			for i := 0; i < parties; i++ {
+				if samples[i], err = pipelines[i].GenSamples(limb); err != nil {
+					errs[limb] = err
+					return
+				}
+			}
+
+			aggregatedSamples := make([][][2]*ring.Poly, parties)
+			for i := 0; i < parties; i++ {
+				if aggregatedSamples[i], err = pipelines[i].Aggregate(limb, samples); err != nil {
+					errs[limb] = err
+					return
+				}
+			}
+
+			keySwitched := make([][]*ring.Poly, parties)
+			for i := 0; i < parties; i++ {
+				if keySwitched[i], err = pipelines[i].KeySwitch(limb, aggregatedSamples); err != nil {
+					errs[limb] = err
+					return
+				}
+			}
+
+			for i := 0; i < parties; i++ {
+				if _, err = pipelines[i].ComputeEVK(limb, keySwitched); err != nil {
+					errs[limb] = err
+					return
+				}
+			}
+		}(limb)
+	}
+
+	wg.Wait()
+
+	for _, err := range errs {
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Benchmark_ParallelSumCrossover sweeps the number of parties and compares the serial and the parallel Sum of the
+// round two shares of the EkgProtocol, then logs a table of the timings and the number of parties from which the
+// parallel sum remains faster (the crossover, to set with EkgProtocol.SetParallelSumThreshold).
+func Benchmark_ParallelSumCrossover(b *testing.B) {
+
+	params := bfv.DefaultParams[1]
+
+	bfvContext := bfv.NewBfvContext()
+	if err := bfvContext.SetParameters(&params); err != nil {
+		b.Fatal(err)
+	}
+
+	context := bfvContext.ContextQ()
+
+	bitDecomp := uint64(60)
+
+	ekg := map[string]*EkgProtocol{"serial": NewEkgProtocol(context, bitDecomp), "parallel": NewEkgProtocol(context, bitDecomp)}
+	ekg["serial"].SetParallelSumThreshold(-1)
+	ekg["parallel"].SetParallelSumThreshold(0)
+
+	share := ekg["serial"].NewShareRoundTwoEmpty()
+
+	nParties := []int{1, 2, 4, 8, 16, 32, 64}
+
+	result := newBenchmarkResult(context, 0, bitDecomp)
+
+	for _, parties := range nParties {
+
+		samples := make([][][][2]*ring.Poly, parties)
+		for i := range samples {
+			samples[i] = share.Value
+		}
+
+		for _, mode := range []string{"serial", "parallel"} {
+
+			name := fmt.Sprintf("parties=%d/%s", parties, mode)
+
+			b.Run(fmt.Sprintf("params=%d/%s/EKG_Sum", params.N, name), func(b *testing.B) {
+				defer result.record(name, b, time.Now())
+				for i := 0; i < b.N; i++ {
+					ekg[mode].Sum(samples)
+				}
+			})
+		}
+	}
+
+	crossover := 0
+
+	b.Logf("GOMAXPROCS=%d, N=%d, moduli=%d", runtime.GOMAXPROCS(0), context.N, len(context.Modulus))
+	b.Logf("%8s | %8s | %14s | %14s | %7s", "parties", "shares", "serial ns/op", "parallel ns/op", "speedup")
+
+	for _, parties := range nParties {
+
+		serial := result.Rounds[fmt.Sprintf("parties=%d/serial", parties)]
+		parallel := result.Rounds[fmt.Sprintf("parties=%d/parallel", parties)]
+
+		b.Logf("%8d | %8d | %14.0f | %14.0f | %7.2f", parties, parties*len(context.Modulus), serial, parallel, serial/parallel)
+
+		// The crossover is the smallest number of parties from which the parallel sum remains faster
+		if parallel >= serial {
+			crossover = 0
+		} else if crossover == 0 {
+			crossover = parties
+		}
+	}
+
+	if crossover == 0 {
+		b.Logf("crossover : the parallel sum is never faster")
+	} else {
+		b.Logf("crossover : %d parties (%d shares)", crossover, crossover*len(context.Modulus))
+	}
+}
+
+// Benchmark_EkgMaxProcs compares the rounds of the EkgProtocol processing the moduli serially and across GOMAXPROCS
+// goroutines (see EkgProtocol.SetMaxProcs), for 8 moduli at N=8192, then logs a table of the timings and of the speedups.
+func Benchmark_EkgMaxProcs(b *testing.B) {
+
+	N := uint64(8192)
+
+	moduli, err := ring.GenerateNTTPrimes(N, (1<<54)+1, 8, 55, true)
+	if err != nil {
+		b.Fatal(err)
+	}
+
+	context, err := ring.NewContextWithParameters(N, moduli)
+	if err != nil {
+		b.Fatal(err)
+	}
+
+	parties := 4
+	bitDecomp := uint64(60)
+
+	crpGenerator, _ := NewCRPGenerator(nil, context)
+	crpGenerator.Seed([]byte{})
+	crp := crpGenerator.ClockNew(NewEkgProtocol(context, bitDecomp))
+
+	kgen := context.NewTernarySampler()
+
+	sk := make([]*ring.Poly, parties)
+	u := make([]*ring.Poly, parties)
+	for i := 0; i < parties; i++ {
+		sk[i], _ = kgen.SampleMontgomeryNTTNew(1.0 / 3)
+		u[i], _ = kgen.SampleMontgomeryNTTNew(1.0 / 3)
+	}
+
+	modes := map[string]int{"serial": 0, "parallel": runtime.GOMAXPROCS(0)}
+	rounds := []string{"GenSamples", "Aggregate", "KeySwitch", "ComputeEVK"}
+
+	result := newBenchmarkResult(context, parties, bitDecomp)
+
+	for _, mode := range []string{"serial", "parallel"} {
+
+		ekg := NewEkgProtocol(context, bitDecomp)
+		ekg.SetMaxProcs(modes[mode])
+
+		samples := make([][][]*ring.Poly, parties)
+		for i := 0; i < parties; i++ {
+			samples[i] = ekg.GenSamples(u[i], sk[i], crp)
+		}
+
+		aggregated := make([][][][2]*ring.Poly, parties)
+		for i := 0; i < parties; i++ {
+			aggregated[i] = ekg.Aggregate(sk[i], samples, crp)
+		}
+
+		sum := ekg.Sum(aggregated)
+
+		keySwitched := make([][][]*ring.Poly, parties)
+		for i := 0; i < parties; i++ {
+			keySwitched[i] = ekg.KeySwitch(u[i], sk[i], sum)
+		}
+
+		b.Run(fmt.Sprintf("params=%d/moduli=%d/%s/EKG_GenSamples", N, len(moduli), mode), func(b *testing.B) {
+			defer result.record(mode+"/GenSamples", b, time.Now())
+			for i := 0; i < b.N; i++ {
+				ekg.GenSamples(u[0], sk[0], crp)
+			}
+		})
+
+		b.Run(fmt.Sprintf("params=%d/moduli=%d/%s/EKG_Aggregate", N, len(moduli), mode), func(b *testing.B) {
+			defer result.record(mode+"/Aggregate", b, time.Now())
+			for i := 0; i < b.N; i++ {
+				ekg.Aggregate(sk[0], samples, crp)
+			}
+		})
+
+		b.Run(fmt.Sprintf("params=%d/moduli=%d/%s/EKG_KeySwitch", N, len(moduli), mode), func(b *testing.B) {
+			defer result.record(mode+"/KeySwitch", b, time.Now())
+			for i := 0; i < b.N; i++ {
+				ekg.KeySwitch(u[0], sk[0], sum)
+			}
+		})
+
+		b.Run(fmt.Sprintf("params=%d/moduli=%d/%s/EKG_ComputeEVK", N, len(moduli), mode), func(b *testing.B) {
+			defer result.record(mode+"/ComputeEVK", b, time.Now())
+			for i := 0; i < b.N; i++ {
+				ekg.ComputeEVK(keySwitched, sum)
+			}
+		})
+	}
+
+	b.Logf("GOMAXPROCS=%d, N=%d, moduli=%d", runtime.GOMAXPROCS(0), N, len(moduli))
+	b.Logf("%10s | %14s | %14s | %7s", "round", "serial ns/op", "parallel ns/op", "speedup")
+
+	for _, round := range rounds {
+		serial := result.Rounds["serial/"+round]
+		parallel := result.Rounds["parallel/"+round]
+		b.Logf("%10s | %14.0f | %14.0f | %7.2f", round, serial, parallel, serial/parallel)
+	}
 }
@@ -1,10 +1,22 @@
 package dbfv
 
 import (
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"github.com/ldsec/lattigo/bfv"
 	"github.com/ldsec/lattigo/ring"
+	"io"
+	"io/ioutil"
+	"math"
+	"math/bits"
+	"math/rand"
+	"os"
+	"runtime"
 	"testing"
+	"time"
 )
 
 func Test_DBFVScheme(t *testing.T) {
@@ -161,6 +173,145 @@ func Test_DBFVScheme(t *testing.T) {
 				}
 			})
 
+			t.Run(fmt.Sprintf("N=%d/logQ=%d/CRS_Nonce", context.N, context.ModulusBigint.Value.BitLen()), func(t *testing.T) {
+
+				seed := []byte{0x48, 0xc3, 0x31, 0x12, 0x74, 0x98, 0xd3, 0xf2}
+
+				crpGeneratorA, _ := NewCRPGenerator(nil, context)
+				crpGeneratorB, _ := NewCRPGenerator(nil, context)
+
+				crpGeneratorA.SeedWithNonce(seed, 0)
+
+				crps := make([]*ring.Poly, 4)
+				for i := range crps {
+					crps[i] = crpGeneratorA.NextCRP()
+				}
+
+				if crpGeneratorA.GetNonce() != 4 {
+					t.Errorf("error : NextCRP does not advance the nonce")
+				}
+
+				// Successive CRPs must be distinct
+				for i := range crps {
+					for j := i + 1; j < len(crps); j++ {
+						if context.Equal(crps[i], crps[j]) {
+							t.Errorf("error : NextCRP returned twice the same CRP")
+						}
+					}
+				}
+
+				// The same (seed, nonce) must reproduce the same CRP
+				for i := range crps {
+					crpGeneratorB.SeedWithNonce(seed, uint64(i))
+					if context.Equal(crps[i], crpGeneratorB.NextCRP()) != true {
+						t.Errorf("error : SeedWithNonce does not reproduce the CRP of nonce %d", i)
+					}
+				}
+
+				// A different seed must give a different CRP for the same nonce
+				crpGeneratorB.SeedWithNonce(append(seed, 0x00), 0)
+				if context.Equal(crps[0], crpGeneratorB.NextCRP()) {
+					t.Errorf("error : NextCRP does not depend on the seed")
+				}
+
+				// The seed followed by the nonce must not be confused with a seed given to Seed
+				crpGeneratorB.Seed(append(append([]byte{}, seed...), 0, 0, 0, 0, 0, 0, 0, 0))
+				if context.Equal(crps[0], crpGeneratorB.Clock()) {
+					t.Errorf("error : SeedWithNonce is not domain-separated from Seed")
+				}
+			})
+
+			t.Run(fmt.Sprintf("N=%d/logQ=%d/CRS_CombinedSeeds", context.N, context.ModulusBigint.Value.BitLen()), func(t *testing.T) {
+
+				seeds := make([][]byte, parties)
+				for i := range seeds {
+					seeds[i] = []byte{byte(i), 0x48, 0xc3, 0x31, 0x12}
+				}
+
+				crpGenerator, _ := NewCRPGenerator(nil, context)
+
+				crpGenerator.SeedFromContributions(seeds)
+				want := crpGenerator.Clock()
+
+				// Same set of seeds, in the reverse order
+				reversed := make([][]byte, parties)
+				for i := range seeds {
+					reversed[i] = seeds[parties-1-i]
+				}
+
+				crpGenerator.SeedFromContributions(reversed)
+				if context.Equal(want, crpGenerator.Clock()) != true {
+					t.Errorf("error : the combined CRP depends on the order of the seeds")
+				}
+
+				// Any modified contribution changes the CRP
+				for i := range seeds {
+
+					modified := make([][]byte, parties)
+					copy(modified, seeds)
+					modified[i] = append([]byte{}, seeds[i]...)
+					modified[i][len(modified[i])-1] ^= 1
+
+					crpGenerator.SeedFromContributions(modified)
+					if context.Equal(want, crpGenerator.Clock()) {
+						t.Errorf("error : the combined CRP does not depend on the seed of the party %d", i)
+					}
+				}
+
+				// The contributions are length-prefixed
+				if bytes.Equal(CombineSeeds([][]byte{{0x01, 0x02}, {0x03}}), CombineSeeds([][]byte{{0x01}, {0x02, 0x03}})) {
+					t.Errorf("error : CombineSeeds is ambiguous on the boundaries of the seeds")
+				}
+			})
+
+			t.Run(fmt.Sprintf("N=%d/logQ=%d/CRS_ClockNew", context.N, context.ModulusBigint.Value.BitLen()), func(t *testing.T) {
+
+				seed := make([]byte, 32)
+				for i := range seed {
+					seed[i] = byte(i)
+				}
+
+				generators := make([]*CRPGenerator, 2)
+				for i := range generators {
+					generators[i], _ = NewCRPGenerator(nil, context)
+					generators[i].Seed(seed)
+				}
+
+				otherGenerator, _ := NewCRPGenerator(nil, context)
+				otherGenerator.Seed(append([]byte{0xff}, seed[1:]...))
+
+				for clock, bitDecomp := range []uint64{60, 30, 20} {
+
+					bitLog := (60 + bitDecomp - 1) / bitDecomp
+
+					crp0 := generators[0].ClockNew(NewEkgProtocol(context, bitDecomp))
+					crp1 := generators[1].ClockNew(NewEkgProtocol(context, bitDecomp))
+					other := otherGenerator.ClockNew(NewEkgProtocol(context, bitDecomp))
+
+					if len(crp0) != len(context.Modulus) {
+						t.Fatalf("error : ClockNew returned %d limbs, want %d", len(crp0), len(context.Modulus))
+					}
+
+					for i := range crp0 {
+
+						if uint64(len(crp0[i])) != bitLog {
+							t.Fatalf("error : ClockNew returned %d digits for bitDecomp %d, want %d", len(crp0[i]), bitDecomp, bitLog)
+						}
+
+						for w := range crp0[i] {
+
+							if context.Equal(crp0[i][w], crp1[i][w]) != true {
+								t.Errorf("error : clock %d, generators with the same seed emit different CRPs (limb %d, digit %d)", clock, i, w)
+							}
+
+							if context.Equal(crp0[i][w], other[i][w]) {
+								t.Errorf("error : clock %d, generators with different seeds emit the same CRP (limb %d, digit %d)", clock, i, w)
+							}
+						}
+					}
+				}
+			})
+
 			// EKG_Naive
 			for _, bitDecomp := range bitDecomps {
 
@@ -200,217 +351,3546 @@ func Test_DBFVScheme(t *testing.T) {
 						t.Errorf("error : ekg rlk bad decrypt")
 					}
 
+					data, err := rlk.MarshalBinary()
+					if err != nil {
+						t.Error(err)
+					}
+
+					if RelinKeySize(bfvContext, bitDecomp) != len(data) {
+						t.Errorf("error : RelinKeySize, want %d have %d", len(data), RelinKeySize(bfvContext, bitDecomp))
+					}
+
 				})
-			}
 
-			// EKG_Naive
-			for _, bitDecomp := range bitDecomps {
+				t.Run(fmt.Sprintf("N=%d/logQ=%d/bitdecomp=%d/EKG_GenRelinKey", context.N, context.ModulusBigint.Value.BitLen(), bitDecomp), func(t *testing.T) {
 
-				t.Run(fmt.Sprintf("N=%d/logQ=%d/bitdecomp=%d/EKG_Naive", context.N, context.ModulusBigint.Value.BitLen(), bitDecomp), func(t *testing.T) {
+					bitLog := uint64((60 + bitDecomp - 1) / bitDecomp)
 
-					// Each party instantiate an ekg naive protocole
-					ekgNaive := make([]*EkgProtocolNaive, parties)
+					crpGenerator, _ := NewCRPGenerator(nil, context)
+					crpGenerator.Seed([]byte{})
+
+					crp := make([][]*ring.Poly, len(context.Modulus))
+					for j := range crp {
+						crp[j] = make([]*ring.Poly, bitLog)
+						for w := range crp[j] {
+							crp[j][w] = crpGenerator.Clock()
+						}
+					}
+
+					ekg := make([]*EkgProtocol, parties)
+					sks := make([]*ring.Poly, parties)
 					for i := 0; i < parties; i++ {
-						ekgNaive[i] = NewEkgProtocolNaive(context, bitDecomp)
+						ekg[i] = NewEkgProtocol(context, bitDecomp)
+						sks[i] = sk0_shards[i].Get()
 					}
 
-					evk := test_EKG_Protocol_Naive(parties, sk0_shards, pk0, ekgNaive)
+					rlk, err := GenRelinKey(ekg, sks, crp)
+					if err != nil {
+						t.Fatal(err)
+					}
 
-					rlk := new(bfv.EvaluationKey)
-					rlk.SetRelinKeys([][][][2]*ring.Poly{evk[0]}, bitDecomp)
+					// Centrally generated relinearization key under the collective secret-key
+					rlkWant := kgen.NewRelinKey(sk0, 1, bitDecomp)
 
-					if err := evaluator.Relinearize(ciphertext, rlk, ciphertextTest); err != nil {
-						t.Error(err)
+					for _, key := range []*bfv.EvaluationKey{rlk, rlkWant} {
+
+						if err := evaluator.Relinearize(ciphertext, key, ciphertextTest); err != nil {
+							t.Error(err)
+						}
+
+						if equalslice(coeffsMul.Coeffs[0], encoder.DecodeUint(decryptor_sk0.DecryptNew(ciphertextTest))) != true {
+							t.Errorf("error : GenRelinKey rlk bad decrypt")
+						}
 					}
 
-					if equalslice(coeffsMul.Coeffs[0], encoder.DecodeUint(decryptor_sk0.DecryptNew(ciphertextTest))) != true {
-						t.Errorf("error : ekg_naive rlk bad decrypt")
+					if _, err := GenRelinKey(ekg, sks[1:], crp); err == nil {
+						t.Errorf("error : GenRelinKey accepted fewer secret shares than parties")
+					}
+
+					if _, err := GenRelinKey(append(ekg[1:], NewEkgProtocol(context, bitDecomp/2)), sks, crp); err == nil {
+						t.Errorf("error : GenRelinKey accepted parties with different bit-decompositions")
 					}
 				})
-			}
 
-			t.Run(fmt.Sprintf("N=%d/logQ=%d/CKG", context.N, context.ModulusBigint.Value.BitLen()), func(t *testing.T) {
+				t.Run(fmt.Sprintf("N=%d/logQ=%d/bitdecomp=%d/EKG_Threshold", context.N, context.ModulusBigint.Value.BitLen(), bitDecomp), func(t *testing.T) {
 
-				crp := make([]*ring.Poly, parties)
-				for i := 0; i < parties; i++ {
-					crp[i] = crpGenerators[i].Clock()
-				}
+					crpGenerator, _ := NewCRPGenerator(nil, context)
+					crpGenerator.Seed([]byte{})
+					crp := crpGenerator.ClockNew(NewEkgProtocol(context, bitDecomp))
 
-				ckg := make([]*CKG, parties)
-				for i := 0; i < parties; i++ {
-					ckg[i] = NewCKG(context, crp[i])
-				}
+					// 3-out-of-5 Shamir sharing of the collective secret-key : f(X) = sk0 + a1*X + a2*X^2
+					a1, a2 := context.NewUniformPoly(), context.NewUniformPoly()
 
-				// Each party creates a new CKG instance
-				shares := make([]*ring.Poly, parties)
-				for i := 0; i < parties; i++ {
-					ckg[i].GenShare(sk0_shards[i].Get())
-					shares[i] = ckg[i].GetShare()
-				}
+					shamir := make(map[uint64]*ring.Poly)
+					for x := uint64(1); x <= 5; x++ {
+						shamir[x] = sk0.Get().CopyNew()
+						context.AddScaled(a1, x, shamir[x])
+						context.AddScaled(a2, x*x, shamir[x])
+					}
 
-				pkTest := make([]*bfv.PublicKey, parties)
-				for i := 0; i < parties; i++ {
-					ckg[i].AggregateShares(shares)
-					pkTest[i], err = ckg[i].Finalize()
+					active := []uint64{1, 3, 4}
+
+					ekg := make([]*EkgProtocol, len(active))
+					sks := make([]*ring.Poly, len(active))
+					for i, x := range active {
+						ekg[i] = NewEkgProtocol(context, bitDecomp)
+						if sks[i], err = ThresholdShare(context, shamir[x], x, active); err != nil {
+							t.Fatal(err)
+						}
+					}
+
+					// The additive shares of the active parties sum to the collective secret-key
+					sum := context.NewPoly()
+					for i := range sks {
+						context.Add(sum, sks[i], sum)
+					}
+
+					if context.Equal(sum, sk0.Get()) != true {
+						t.Errorf("error : the threshold shares do not sum to the collective secret-key")
+					}
+
+					rlk, err := GenRelinKey(ekg, sks, crp)
 					if err != nil {
+						t.Fatal(err)
+					}
+
+					if err := evaluator.Relinearize(ciphertext, rlk, ciphertextTest); err != nil {
 						t.Error(err)
 					}
-				}
 
-				// Verifies that all parties have the same share collective public key
-				for i := 1; i < parties; i++ {
-					if context.Equal(pkTest[0].Get()[0], pkTest[i].Get()[0]) != true || bfvContext.ContextQ().Equal(pkTest[0].Get()[1], pkTest[i].Get()[1]) != true {
-						t.Errorf("error : ckg protocol, cpk establishement")
+					if equalslice(coeffsMul.Coeffs[0], encoder.DecodeUint(decryptor_sk0.DecryptNew(ciphertextTest))) != true {
+						t.Errorf("error : threshold rlk bad decrypt")
 					}
-				}
 
-				// Verifies that decrypt((encryptp(collectiveSk, m), collectivePk) = m
-				encryptorTest, err := bfvContext.NewEncryptorFromPk(pkTest[0])
-				if err != nil {
-					t.Error(err)
-				}
+					if _, err := ThresholdShare(context, shamir[2], 2, active); err == nil {
+						t.Errorf("error : ThresholdShare accepted a party outside of the active set")
+					}
 
-				ciphertextTest, err := encryptorTest.EncryptNew(plaintextWant)
+					if _, err := ThresholdShare(context, shamir[1], 1, []uint64{1, 3, 3}); err == nil {
+						t.Errorf("error : ThresholdShare accepted an active set with duplicate points")
+					}
+				})
 
-				if err != nil {
-					t.Error(err)
-				}
+				t.Run(fmt.Sprintf("N=%d/logQ=%d/bitdecomp=%d/EKG_NoMForm", context.N, context.ModulusBigint.Value.BitLen(), bitDecomp), func(t *testing.T) {
 
-				if equalslice(coeffsWant.Coeffs[0], encoder.DecodeUint(decryptor_sk0.DecryptNew(ciphertextTest))) != true {
-					t.Errorf("error : ckg protocol, cpk encrypt/decrypt test")
-				}
+					crpGenerator, _ := NewCRPGenerator(nil, context)
+					crpGenerator.Seed([]byte{})
+					crp := crpGenerator.ClockNew(NewEkgProtocol(context, bitDecomp))
 
-			})
+					ekg := NewEkgProtocol(context, bitDecomp)
 
-			t.Run(fmt.Sprintf("N=%d/logQ=%d/CKS", context.N, context.ModulusBigint.Value.BitLen()), func(t *testing.T) {
+					ephemeralKeys := make([]*ring.Poly, parties)
+					samples := make([][][]*ring.Poly, parties)
+					for i := 0; i < parties; i++ {
+						ephemeralKeys[i], _ = ekg.NewEphemeralKey(1.0 / 3)
+						samples[i] = ekg.GenSamples(ephemeralKeys[i], sk0_shards[i].Get(), crp)
+					}
 
-				ciphertext, err := encryptor_pk0.EncryptNew(plaintextWant)
-				if err != nil {
-					t.Error(err)
-				}
+					aggregatedSamples := make([][][][2]*ring.Poly, parties)
+					for i := 0; i < parties; i++ {
+						aggregatedSamples[i] = ekg.Aggregate(sk0_shards[i].Get(), samples, crp)
+					}
 
-				ciphertexts := make([]*bfv.Ciphertext, parties)
-				for i := 0; i < parties; i++ {
-					ciphertexts[i] = ciphertext.CopyNew().Ciphertext()
-				}
+					sum := ekg.Sum(aggregatedSamples)
 
-				// Each party creates its CKS instance with deltaSk = si-si'
-				cks := make([]*CKS, parties)
-				for i := 0; i < parties; i++ {
-					cks[i] = NewCKS(sk0_shards[i].Get(), sk1_shards[i].Get(), context, 6.36)
-				}
+					keySwitched := make([][][]*ring.Poly, parties)
+					for i := 0; i < parties; i++ {
+						keySwitched[i] = ekg.KeySwitch(ephemeralKeys[i], sk0_shards[i].Get(), sum)
+					}
 
-				// Each party computes its hi share from the shared ciphertext
-				// Each party encodes its share and sends it to the other n-1 parties
-				hi := make([]*ring.Poly, parties)
-				for i := 0; i < parties; i++ {
-					hi[i] = cks[i].KeySwitch(ciphertexts[i].Value()[1])
-				}
-				// Each party receive the shares n-1 shares from the other parties and decodes them
-				for i := 0; i < parties; i++ {
-					// Then keyswitch the ciphertext with the decoded shares
-					cks[i].Aggregate(ciphertexts[i].Value()[0], hi)
-				}
+					evkMForm := ekg.ComputeEVK(keySwitched, sum)
 
-				for i := 0; i < parties; i++ {
+					ekg.SetEVKMontgomeryForm(false)
+					evkStandard := ekg.ComputeEVK(keySwitched, sum)
 
-					if equalslice(coeffsWant.Coeffs[0], encoder.DecodeUint(decryptor_sk1.DecryptNew(ciphertexts[i]))) != true {
-						t.Errorf("error : CKS")
+					for j := range evkStandard {
+						for w := range evkStandard[j] {
+							for u := 0; u < 2; u++ {
+								pol := evkStandard[j][w][u].CopyNew()
+								context.MForm(pol, pol)
+								if !context.Equal(pol, evkMForm[j][w][u]) {
+									t.Errorf("error : the key without Montgomery form differs from the converted key (limb %d digit %d)", j, w)
+								}
+							}
+						}
 					}
 
-				}
-			})
+					rlk := new(bfv.EvaluationKey)
+					rlk.SetRelinKeys([][][][2]*ring.Poly{ekg.LSBFirst(evkMForm)}, bitDecomp)
+
+					// The bfv.Evaluator consumes the Montgomery form, the standard-form key is converted before being set
+					converted := make([][][2]*ring.Poly, len(evkStandard))
+					for j := range evkStandard {
+						converted[j] = make([][2]*ring.Poly, len(evkStandard[j]))
+						for w := range evkStandard[j] {
+							for u := 0; u < 2; u++ {
+								converted[j][w][u] = evkStandard[j][w][u].CopyNew()
+								context.MForm(converted[j][w][u], converted[j][w][u])
+							}
+						}
+					}
 
-			t.Run(fmt.Sprintf("N=%d/logQ=%d/PCKS", context.N, context.ModulusBigint.Value.BitLen()), func(t *testing.T) {
+					rlkStandard := new(bfv.EvaluationKey)
+					rlkStandard.SetRelinKeys([][][][2]*ring.Poly{ekg.LSBFirst(converted)}, bitDecomp)
 
-				ciphertext, err := encryptor_pk0.EncryptNew(plaintextWant)
-				if err != nil {
-					t.Error(err)
-				}
+					ciphertextStandard := bfvContext.NewCiphertext(1)
 
-				ciphertexts := make([]*bfv.Ciphertext, parties)
-				for i := 0; i < parties; i++ {
-					ciphertexts[i] = ciphertext.CopyNew().Ciphertext()
-				}
+					if err := evaluator.Relinearize(ciphertext, rlk, ciphertextTest); err != nil {
+						t.Fatal(err)
+					}
 
-				pcks := make([]*PCKS, parties)
-				for i := 0; i < parties; i++ {
-					pcks[i] = NewPCKS(sk0_shards[i].Get(), pk1.Get(), context, 6.36)
-				}
+					if err := evaluator.Relinearize(ciphertext, rlkStandard, ciphertextStandard); err != nil {
+						t.Fatal(err)
+					}
 
-				hi := make([][2]*ring.Poly, parties)
-				for i := 0; i < parties; i++ {
-					hi[i] = pcks[i].KeySwitch(ciphertexts[i].Value()[1])
-				}
+					for i := range ciphertextTest.Value() {
+						if !context.Equal(ciphertextTest.Value()[i], ciphertextStandard.Value()[i]) {
+							t.Errorf("error : relinearization with the key without Montgomery form differs")
+						}
+					}
 
-				for i := 0; i < parties; i++ {
-					pcks[i].Aggregate(ciphertexts[i].Value(), hi)
-				}
+					if equalslice(coeffsMul.Coeffs[0], encoder.DecodeUint(decryptor_sk0.DecryptNew(ciphertextStandard))) != true {
+						t.Errorf("error : key without Montgomery form bad decrypt")
+					}
 
-				for i := 0; i < parties; i++ {
+					// FinalizeAndWipe sets the key in the Montgomery form regardless
+					rlkFinalized := new(bfv.EvaluationKey)
+					ekg.FinalizeAndWipe(keySwitched, sum, rlkFinalized)
 
-					if equalslice(coeffsWant.Coeffs[0], encoder.DecodeUint(decryptor_sk1.DecryptNew(ciphertexts[i]))) != true {
-						t.Errorf("error : PCKS")
+					if err := evaluator.Relinearize(ciphertext, rlkFinalized, ciphertextStandard); err != nil {
+						t.Fatal(err)
 					}
-				}
-			})
-		}
-	}
-}
 
-func test_EKG_Protocol_Naive(parties int, sk []*bfv.SecretKey, collectivePk *bfv.PublicKey, ekgNaive []*EkgProtocolNaive) [][][][2]*ring.Poly {
+					for i := range ciphertextTest.Value() {
+						if !context.Equal(ciphertextTest.Value()[i], ciphertextStandard.Value()[i]) {
+							t.Errorf("error : FinalizeAndWipe without Montgomery form")
+						}
+					}
+				})
 
-	// ROUND 0
-	// Each party generates its samples
-	samples := make([][][][2]*ring.Poly, parties)
-	for i := 0; i < parties; i++ {
-		samples[i] = ekgNaive[i].GenSamples(sk[i].Get(), collectivePk.Get())
-	}
+				t.Run(fmt.Sprintf("N=%d/logQ=%d/bitdecomp=%d/EKG_Sigma", context.N, context.ModulusBigint.Value.BitLen(), bitDecomp), func(t *testing.T) {
 
-	// ROUND 1
-	// Each party aggretates its sample with the other n-1 samples
-	aggregatedSamples := make([][][][2]*ring.Poly, parties)
-	for i := 0; i < parties; i++ {
-		aggregatedSamples[i] = ekgNaive[i].Aggregate(sk[i].Get(), collectivePk.Get(), samples)
-	}
+					crpGenerator, _ := NewCRPGenerator(nil, context)
+					crpGenerator.Seed([]byte{})
+					crp := crpGenerator.ClockNew(NewEkgProtocol(context, bitDecomp))
 
-	// ROUND 2
-	// Each party aggregates sums its aggregatedSample with the other n-1 aggregated samples
-	evk := make([][][][2]*ring.Poly, parties)
-	for i := 0; i < parties; i++ {
-		evk[i] = ekgNaive[i].Finalize(aggregatedSamples)
-	}
+					sks := make([]*ring.Poly, parties)
+					for i := range sks {
+						sks[i] = sk0_shards[i].Get()
+					}
 
-	return evk
-}
+					// maxNoise runs the protocol with the given gaussian parameters and returns the largest relation error of the key
+					maxNoise := func(sigma float64, bound uint64) float64 {
 
-func test_EKG_Protocol(parties int, ekgProtocols []*EkgProtocol, sk []*bfv.SecretKey, ephemeralKeys []*ring.Poly, crp [][][]*ring.Poly) [][][][2]*ring.Poly {
+						ekg := make([]*EkgProtocol, parties)
+						for i := range ekg {
+							var err error
+							if ekg[i], err = NewEkgProtocolWithSigma(context, bitDecomp, sigma, bound); err != nil {
+								t.Fatal(err)
+							}
+						}
 
-	// ROUND 1
+						rlk, err := GenRelinKey(ekg, sks, crp)
+						if err != nil {
+							t.Fatal(err)
+						}
+
+						if err := evaluator.Relinearize(ciphertext, rlk, ciphertextTest); err != nil {
+							t.Error(err)
+						}
+
+						if equalslice(coeffsMul.Coeffs[0], encoder.DecodeUint(decryptor_sk0.DecryptNew(ciphertextTest))) != true {
+							t.Errorf("error : rlk generated with sigma %f bad decrypt", sigma)
+						}
+
+						relationError, err := rlk.RelationError(sk0, bfvContext)
+						if err != nil {
+							t.Fatal(err)
+						}
+
+						noise := math.Inf(-1)
+						for _, limb := range relationError {
+							for _, digit := range limb {
+								noise = math.Max(noise, digit)
+							}
+						}
+
+						return noise
+					}
+
+					noiseDefault := maxNoise(DefaultSigma, DefaultBound)
+					noiseLarge := maxNoise(DefaultSigma*16, DefaultBound*16)
+
+					// 16 times the standard deviation, i.e. about 4 more bits of noise
+					if noiseLarge < noiseDefault+2 {
+						t.Errorf("error : the key noise is %.2f bits with sigma %f and %.2f bits with sigma %f", noiseDefault, DefaultSigma, noiseLarge, DefaultSigma*16)
+					}
+
+					if _, err := NewEkgProtocolWithSigma(context, bitDecomp, 0, DefaultBound); err == nil {
+						t.Errorf("error : NewEkgProtocolWithSigma accepted a null sigma")
+					}
+				})
+
+				t.Run(fmt.Sprintf("N=%d/logQ=%d/bitdecomp=%d/EKG_ErrorSampler", context.N, context.ModulusBigint.Value.BitLen(), bitDecomp), func(t *testing.T) {
+
+					bitLog := uint64((60 + (60 % bitDecomp)) / bitDecomp)
+
+					// Centered binomial error of variance 10/2, instead of the default discrete gaussian
+					sampler, err := context.NewErrorSampler(ring.CenteredBinomial, 10)
+					if err != nil {
+						t.Fatal(err)
+					}
+
+					ekg := make([]*EkgProtocol, parties)
+					ephemeralKeys := make([]*ring.Poly, parties)
+					crp := make([][][]*ring.Poly, parties)
+
+					for i := 0; i < parties; i++ {
+
+						ekg[i] = NewEkgProtocol(context, bitDecomp)
+						ekg[i].SetGaussianSampler(sampler, DefaultBound)
+						ephemeralKeys[i], _ = ekg[i].NewEphemeralKey(1.0 / 3)
+						crp[i] = make([][]*ring.Poly, len(context.Modulus))
+
+						for j := 0; j < len(context.Modulus); j++ {
+							crp[i][j] = make([]*ring.Poly, bitLog)
+							for u := uint64(0); u < bitLog; u++ {
+								crp[i][j][u] = crpGenerators[i].Clock()
+							}
+						}
+					}
+
+					evk := test_EKG_Protocol(parties, ekg, sk0_shards, ephemeralKeys, crp)
+
+					rlk := new(bfv.EvaluationKey)
+					rlk.SetRelinKeys([][][][2]*ring.Poly{evk[0]}, bitDecomp)
+
+					if err := evaluator.Relinearize(ciphertext, rlk, ciphertextTest); err != nil {
+						t.Error(err)
+					}
+
+					if equalslice(coeffsMul.Coeffs[0], encoder.DecodeUint(decryptor_sk0.DecryptNew(ciphertextTest))) != true {
+						t.Errorf("error : ekg rlk bad decrypt")
+					}
+				})
+
+				t.Run(fmt.Sprintf("N=%d/logQ=%d/bitdecomp=%d/EKG_DigitOrder", context.N, context.ModulusBigint.Value.BitLen(), bitDecomp), func(t *testing.T) {
+
+					// A bit-decomposition with several digits, so that the two layouts differ
+					digitBitDecomp := uint64(20)
+					bitLog := (60 + digitBitDecomp - 1) / digitBitDecomp
+
+					crpGenerator, _ := NewCRPGenerator(nil, context)
+					crpGenerator.Seed([]byte{})
+
+					// The MSB first protocol uses the same crp in the reversed order, so that each digit is paired with the same crp
+					crpLSB := make([][]*ring.Poly, len(context.Modulus))
+					crpMSB := make([][]*ring.Poly, len(context.Modulus))
+					for j := range context.Modulus {
+						crpLSB[j] = make([]*ring.Poly, bitLog)
+						crpMSB[j] = make([]*ring.Poly, bitLog)
+						for u := uint64(0); u < bitLog; u++ {
+							crpLSB[j][u] = crpGenerator.Clock()
+							crpMSB[j][bitLog-1-u] = crpLSB[j][u]
+						}
+					}
+
+					ternarySampler := context.NewTernarySampler()
+					noise := context.NewKYSampler(3.19, 19).SampleNTTNew()
+
+					ekgLSB := make([]*EkgProtocol, parties)
+					ekgMSB := make([]*EkgProtocol, parties)
+					ephemeralKeys := make([]*ring.Poly, parties)
+					crpLSBParties := make([][][]*ring.Poly, parties)
+					crpMSBParties := make([][][]*ring.Poly, parties)
+
+					for i := 0; i < parties; i++ {
+
+						ephemeralKeys[i], _ = ternarySampler.SampleMontgomeryNTTNew(1.0 / 3)
+
+						ekgLSB[i] = NewEkgProtocol(context, digitBitDecomp)
+						ekgLSB[i].SetGaussianSampler(newTestMockSampler(t, noise), DefaultBound)
+
+						ekgMSB[i] = NewEkgProtocol(context, digitBitDecomp)
+						ekgMSB[i].SetGaussianSampler(newTestMockSampler(t, noise), DefaultBound)
+						ekgMSB[i].SetDigitOrder(MSBFirst)
+
+						crpLSBParties[i] = crpLSB
+						crpMSBParties[i] = crpMSB
+					}
+
+					if ekgLSB[0].DigitOrder() != LSBFirst || ekgMSB[0].DigitOrder() != MSBFirst {
+						t.Errorf("error : ekg default or set digit order")
+					}
+
+					// Round one shares are laid out in reverse order
+					samplesLSB := ekgLSB[0].GenSamples(ephemeralKeys[0], sk0_shards[0].Get(), crpLSB)
+					samplesMSB := ekgMSB[0].GenSamples(ephemeralKeys[0], sk0_shards[0].Get(), crpMSB)
+
+					for j := range samplesLSB {
+						for w := uint64(0); w < bitLog; w++ {
+							if context.Equal(samplesLSB[j][w], samplesMSB[j][bitLog-1-w]) != true {
+								t.Errorf("error : round one shares are not reversed with MSBFirst")
+							}
+						}
+					}
+
+					evkLSB := test_EKG_Protocol(parties, ekgLSB, sk0_shards, ephemeralKeys, crpLSBParties)[0]
+					evkMSB := test_EKG_Protocol(parties, ekgMSB, sk0_shards, ephemeralKeys, crpMSBParties)[0]
+
+					// Evaluation keys are laid out in reverse order
+					for j := range evkLSB {
+						for w := uint64(0); w < bitLog; w++ {
+							if context.Equal(evkLSB[j][w][0], evkMSB[j][bitLog-1-w][0]) != true || context.Equal(evkLSB[j][w][1], evkMSB[j][bitLog-1-w][1]) != true {
+								t.Errorf("error : evaluation-keys are not reversed with MSBFirst")
+							}
+						}
+					}
+
+					// Both orderings relinearize correctly
+					for _, order := range []struct {
+						name string
+						ekg  *EkgProtocol
+						evk  [][][2]*ring.Poly
+					}{{"LSBFirst", ekgLSB[0], evkLSB}, {"MSBFirst", ekgMSB[0], evkMSB}} {
+
+						rlk := new(bfv.EvaluationKey)
+						rlk.SetRelinKeys([][][][2]*ring.Poly{order.ekg.LSBFirst(order.evk)}, digitBitDecomp)
+
+						if err := evaluator.Relinearize(ciphertext, rlk, ciphertextTest); err != nil {
+							t.Error(err)
+						}
+
+						if equalslice(coeffsMul.Coeffs[0], encoder.DecodeUint(decryptor_sk0.DecryptNew(ciphertextTest))) != true {
+							t.Errorf("error : ekg rlk bad decrypt with %s", order.name)
+						}
+					}
+				})
+
+				t.Run(fmt.Sprintf("N=%d/logQ=%d/bitdecomp=%d/EKG_MockSampler", context.N, context.ModulusBigint.Value.BitLen(), bitDecomp), func(t *testing.T) {
+
+					if bitDecomp != 60 {
+						t.Skip("the expected key relation is computed for a bitDecomp of 60")
+					}
+
+					if _, err := NewMockSampler(); err == nil {
+						t.Errorf("error : a MockSampler without polynomial was created")
+					}
+
+					ternarySampler := context.NewTernarySampler()
+
+					ekg := make([]*EkgProtocol, parties)
+					ephemeralKeys := make([]*ring.Poly, parties)
+					crp := make([][][]*ring.Poly, parties)
+
+					for i := 0; i < parties; i++ {
+
+						// Scripted ephemeral key and zero noise
+						u, _ := ternarySampler.SampleMontgomeryNTTNew(1.0 / 3)
+
+						ekg[i] = NewEkgProtocol(context, bitDecomp)
+						ekg[i].SetTernarySampler(newTestMockSampler(t, u))
+						ekg[i].SetGaussianSampler(newTestMockSampler(t, context.NewPoly()), DefaultBound)
+
+						ephemeralKeys[i], _ = ekg[i].NewEphemeralKey(1.0 / 3)
+
+						if context.Equal(u, ephemeralKeys[i]) != true {
+							t.Errorf("error : ekg ephemeral key is not the scripted one")
+						}
+					}
+
+					// All the parties share the same crp
+					crpGenerator, _ := NewCRPGenerator(nil, context)
+					crpGenerator.Seed([]byte{})
+					crp[0] = make([][]*ring.Poly, len(context.Modulus))
+					for j := range context.Modulus {
+						crp[0][j] = []*ring.Poly{crpGenerator.Clock()}
+					}
+					for i := 1; i < parties; i++ {
+						crp[i] = crp[0]
+					}
+
+					evk := test_EKG_Protocol(parties, ekg, sk0_shards, ephemeralKeys, crp)
+
+					// Without noise, evk[i][0] + evk[i][1]*s = s^2 * w_i exactly, with w_i the i-th element of the CRT basis
+					sk := sk0.Get()
+					have := context.NewPoly()
+					want := context.NewPoly()
+
+					for i := range context.Modulus {
+
+						context.MulCoeffsMontgomery(evk[0][i][0][1], sk, have)
+						context.Add(have, evk[0][i][0][0], have)
+
+						want.Zero()
+						copy(want.Coeffs[i], sk.Coeffs[i])
+						context.MulCoeffsMontgomery(want, sk, want)
+
+						if context.Equal(have, want) != true {
+							t.Errorf("error : noiseless ekg key relation does not hold for limb %d", i)
+						}
+					}
+				})
+
+				t.Run(fmt.Sprintf("N=%d/logQ=%d/bitdecomp=%d/EKG_GenSamplesDecomposed", context.N, context.ModulusBigint.Value.BitLen(), bitDecomp), func(t *testing.T) {
+
+					bitLog := uint64((60 + (60 % bitDecomp)) / bitDecomp)
+
+					crpGenerator, _ := NewCRPGenerator(nil, context)
+					crpGenerator.Seed([]byte{})
+
+					crp := make([][]*ring.Poly, len(context.Modulus))
+					for j := range crp {
+						crp[j] = make([]*ring.Poly, bitLog)
+						for u := uint64(0); u < bitLog; u++ {
+							crp[j][u] = crpGenerator.Clock()
+						}
+					}
+
+					u, _ := context.NewTernarySampler().SampleMontgomeryNTTNew(1.0 / 3)
+					noise := context.NewKYSampler(3.19, 19).SampleNTTNew()
+
+					ekgInline := NewEkgProtocol(context, bitDecomp)
+					ekgInline.SetGaussianSampler(newTestMockSampler(t, noise), DefaultBound)
+
+					ekgDecomposed := NewEkgProtocol(context, bitDecomp)
+					ekgDecomposed.SetGaussianSampler(newTestMockSampler(t, noise), DefaultBound)
+
+					samplesInline := ekgInline.GenSamples(u, sk0_shards[0].Get(), crp)
+					samplesDecomposed := ekgDecomposed.GenSamplesDecomposed(u, context.DecomposePoly(sk0_shards[0].Get(), bitDecomp), crp)
+
+					for j := range samplesInline {
+						for w := range samplesInline[j] {
+							if context.Equal(samplesInline[j][w], samplesDecomposed[j][w]) != true {
+								t.Errorf("error : round one shares differ with a precomputed decomposition")
+							}
+						}
+					}
+				})
+
+				t.Run(fmt.Sprintf("N=%d/logQ=%d/bitdecomp=%d/EKG_MaxProcs", context.N, context.ModulusBigint.Value.BitLen(), bitDecomp), func(t *testing.T) {
+
+					crpGenerator, _ := NewCRPGenerator(nil, context)
+					crpGenerator.Seed([]byte{})
+					crp := crpGenerator.ClockNew(NewEkgProtocol(context, bitDecomp))
+
+					// Distinct scripted errors, so that a change in the order of the sampling changes the shares
+					kysampler := context.NewKYSampler(3.19, 19)
+					noise := make([]*ring.Poly, 7)
+					for i := range noise {
+						noise[i] = kysampler.SampleNTTNew()
+					}
+
+					ephemeralKeys := make([]*ring.Poly, parties)
+					for i := range ephemeralKeys {
+						ephemeralKeys[i], _ = context.NewTernarySampler().SampleMontgomeryNTTNew(1.0 / 3)
+					}
+
+					run := func(procs int) (samples [][][]*ring.Poly, decomposed [][]*ring.Poly, aggregated [][][][2]*ring.Poly, keySwitched [][][]*ring.Poly, evk [][][2]*ring.Poly) {
+
+						ekg := NewEkgProtocol(context, bitDecomp)
+						ekg.SetGaussianSampler(newTestMockSampler(t, noise...), DefaultBound)
+						ekg.SetMaxProcs(procs)
+
+						samples = make([][][]*ring.Poly, parties)
+						for i := 0; i < parties; i++ {
+							samples[i] = ekg.GenSamples(ephemeralKeys[i], sk0_shards[i].Get(), crp)
+						}
+
+						decomposed = ekg.GenSamplesDecomposed(ephemeralKeys[0], context.DecomposePoly(sk0_shards[0].Get(), bitDecomp), crp)
+
+						aggregated = make([][][][2]*ring.Poly, parties)
+						for i := 0; i < parties; i++ {
+							aggregated[i] = ekg.Aggregate(sk0_shards[i].Get(), samples, crp)
+						}
+
+						sum := ekg.Sum(aggregated)
+
+						keySwitched = make([][][]*ring.Poly, parties)
+						for i := 0; i < parties; i++ {
+							keySwitched[i] = ekg.KeySwitch(ephemeralKeys[i], sk0_shards[i].Get(), sum)
+						}
+
+						evk = ekg.ComputeEVK(keySwitched, sum)
+
+						return
+					}
+
+					samples, decomposed, aggregated, keySwitched, evk := run(0)
+
+					for _, procs := range []int{2, len(context.Modulus), 16} {
+
+						samplesPar, decomposedPar, aggregatedPar, keySwitchedPar, evkPar := run(procs)
+
+						for j := range evk {
+							for w := range evk[j] {
+								for i := 0; i < parties; i++ {
+									if !context.Equal(samples[i][j][w], samplesPar[i][j][w]) ||
+										!context.Equal(aggregated[i][j][w][0], aggregatedPar[i][j][w][0]) ||
+										!context.Equal(aggregated[i][j][w][1], aggregatedPar[i][j][w][1]) ||
+										!context.Equal(keySwitched[i][j][w], keySwitchedPar[i][j][w]) {
+										t.Errorf("error : shares of party %d differ with %d goroutines (limb %d digit %d)", i, procs, j, w)
+									}
+								}
+
+								if !context.Equal(decomposed[j][w], decomposedPar[j][w]) {
+									t.Errorf("error : decomposed round one shares differ with %d goroutines (limb %d digit %d)", procs, j, w)
+								}
+
+								if !context.Equal(evk[j][w][0], evkPar[j][w][0]) || !context.Equal(evk[j][w][1], evkPar[j][w][1]) {
+									t.Errorf("error : evaluation-key differs with %d goroutines (limb %d digit %d)", procs, j, w)
+								}
+							}
+						}
+					}
+				})
+
+				t.Run(fmt.Sprintf("N=%d/logQ=%d/bitdecomp=%d/EKG_MulCoeffsMontgomeryAndSubShare", context.N, context.ModulusBigint.Value.BitLen(), bitDecomp), func(t *testing.T) {
+
+					for _, crpMForm := range []bool{false, true} {
+
+						ekg := NewEkgProtocol(context, bitDecomp)
+						ekg.SetCRPMontgomeryForm(crpMForm)
+
+						u, _ := ekg.NewEphemeralKey(1.0 / 3)
+
+						crpGenerator, _ := NewCRPGenerator(nil, context)
+						crpGenerator.Seed([]byte{})
+
+						uCRP := u
+						if crpMForm {
+							uCRP = context.NewPoly()
+							context.InvMForm(u, uCRP)
+						}
+
+						crp := make([][]*ring.Poly, len(context.Modulus))
+						share := ekg.NewShareRoundOneEmpty()
+						want := ekg.NewShareRoundOneEmpty()
+
+						for j := range crp {
+							crp[j] = make([]*ring.Poly, len(share.Value[j]))
+							for w := range crp[j] {
+								crp[j][w] = crpGenerator.Clock()
+
+								share.Value[j][w].Copy(context.NewUniformPoly())
+								want.Value[j][w].Copy(share.Value[j][w])
+
+								context.MulCoeffsMontgomeryAndSub(uCRP, crp[j][w], want.Value[j][w])
+							}
+						}
+
+						ekg.MulCoeffsMontgomeryAndSubShare(u, crp, share)
+
+						for j := range share.Value {
+							for w := range share.Value[j] {
+								if context.Equal(want.Value[j][w], share.Value[j][w]) != true {
+									t.Errorf("error : MulCoeffsMontgomeryAndSubShare differs from the per sample computation (limb %d, digit %d, crp Montgomery form %t)", j, w, crpMForm)
+								}
+							}
+						}
+					}
+				})
+
+				t.Run(fmt.Sprintf("N=%d/logQ=%d/bitdecomp=%d/EKG_CRPMontgomery", context.N, context.ModulusBigint.Value.BitLen(), bitDecomp), func(t *testing.T) {
+
+					bitLog := uint64((60 + (60 % bitDecomp)) / bitDecomp)
+
+					crpGenerator, _ := NewCRPGenerator(nil, context)
+					crpGenerator.Seed([]byte{})
+
+					crp := make([][]*ring.Poly, len(context.Modulus))
+					crpMForm := make([][]*ring.Poly, len(context.Modulus))
+					for j := range crp {
+						crp[j] = make([]*ring.Poly, bitLog)
+						crpMForm[j] = make([]*ring.Poly, bitLog)
+						for u := uint64(0); u < bitLog; u++ {
+							crp[j][u] = crpGenerator.Clock()
+							crpMForm[j][u] = context.NewPoly()
+							context.MForm(crp[j][u], crpMForm[j][u])
+						}
+					}
+
+					// Deterministic samplers so that both runs can be compared
+					ternarySampler := context.NewTernarySampler()
+					noise := context.NewPoly()
+
+					ekgRaw := make([]*EkgProtocol, parties)
+					ekgMForm := make([]*EkgProtocol, parties)
+					samplesRaw := make([][][]*ring.Poly, parties)
+					samplesMForm := make([][][]*ring.Poly, parties)
+
+					for i := 0; i < parties; i++ {
+
+						u, _ := ternarySampler.SampleMontgomeryNTTNew(1.0 / 3)
+
+						ekgRaw[i] = NewEkgProtocol(context, bitDecomp)
+						ekgRaw[i].SetGaussianSampler(newTestMockSampler(t, noise), DefaultBound)
+
+						ekgMForm[i] = NewEkgProtocol(context, bitDecomp)
+						ekgMForm[i].SetGaussianSampler(newTestMockSampler(t, noise), DefaultBound)
+						ekgMForm[i].SetCRPMontgomeryForm(true)
+
+						samplesRaw[i] = ekgRaw[i].GenSamples(u, sk0_shards[i].Get(), crp)
+						samplesMForm[i] = ekgMForm[i].GenSamples(u, sk0_shards[i].Get(), crpMForm)
+
+						for j := range samplesRaw[i] {
+							for w := range samplesRaw[i][j] {
+								if context.Equal(samplesRaw[i][j][w], samplesMForm[i][j][w]) != true {
+									t.Errorf("error : round one shares differ with a Montgomery form crp")
+								}
+							}
+						}
+					}
+
+					for i := 0; i < parties; i++ {
+
+						aggregatedRaw := ekgRaw[i].Aggregate(sk0_shards[i].Get(), samplesRaw, crp)
+						aggregatedMForm := ekgMForm[i].Aggregate(sk0_shards[i].Get(), samplesMForm, crpMForm)
+
+						for j := range aggregatedRaw {
+							for w := range aggregatedRaw[j] {
+								if context.Equal(aggregatedRaw[j][w][0], aggregatedMForm[j][w][0]) != true || context.Equal(aggregatedRaw[j][w][1], aggregatedMForm[j][w][1]) != true {
+									t.Errorf("error : round two shares differ with a Montgomery form crp")
+								}
+							}
+						}
+					}
+				})
+
+				t.Run(fmt.Sprintf("N=%d/logQ=%d/bitdecomp=%d/EKG_FinalizeAndWipe", context.N, context.ModulusBigint.Value.BitLen(), bitDecomp), func(t *testing.T) {
+
+					bitLog := uint64((60 + (60 % bitDecomp)) / bitDecomp)
+
+					crpGenerator, _ := NewCRPGenerator(nil, context)
+					crpGenerator.Seed([]byte{})
+
+					crp := make([][]*ring.Poly, len(context.Modulus))
+					for j := range crp {
+						crp[j] = make([]*ring.Poly, bitLog)
+						for u := uint64(0); u < bitLog; u++ {
+							crp[j][u] = crpGenerator.Clock()
+						}
+					}
+
+					ekg := make([]*EkgProtocol, parties)
+					ephemeralKeys := make([]*ring.Poly, parties)
+					samples := make([][][]*ring.Poly, parties)
+
+					for i := 0; i < parties; i++ {
+						ekg[i] = NewEkgProtocol(context, bitDecomp)
+						ephemeralKeys[i], _ = ekg[i].NewEphemeralKey(1.0 / 3)
+						samples[i] = ekg[i].GenSamples(ephemeralKeys[i], sk0_shards[i].Get(), crp)
+					}
+
+					aggregatedSamples := make([][][][2]*ring.Poly, parties)
+					for i := 0; i < parties; i++ {
+						aggregatedSamples[i] = ekg[i].Aggregate(sk0_shards[i].Get(), samples, crp)
+					}
+
+					sum := ekg[0].Sum(aggregatedSamples)
+
+					keySwitched := make([][][]*ring.Poly, parties)
+					for i := 0; i < parties; i++ {
+						keySwitched[i] = ekg[i].KeySwitch(ephemeralKeys[i], sk0_shards[i].Get(), sum)
+					}
+
+					rlk := new(bfv.EvaluationKey)
+					ekg[0].FinalizeAndWipe(keySwitched, sum, rlk)
+
+					if err := evaluator.Relinearize(ciphertext, rlk, ciphertextTest); err != nil {
+						t.Error(err)
+					}
+
+					if equalslice(coeffsMul.Coeffs[0], encoder.DecodeUint(decryptor_sk0.DecryptNew(ciphertextTest))) != true {
+						t.Errorf("error : ekg rlk bad decrypt after FinalizeAndWipe")
+					}
+
+					zero := context.NewPoly()
+
+					for i := range sum {
+						for w := range sum[i] {
+							if context.Equal(sum[i][w][0], zero) != true || context.Equal(sum[i][w][1], zero) != true {
+								t.Errorf("error : FinalizeAndWipe did not wipe the summed samples")
+							}
+						}
+					}
+
+					for j := range keySwitched {
+						for i := range keySwitched[j] {
+							for w := range keySwitched[j][i] {
+								if context.Equal(keySwitched[j][i][w], zero) != true {
+									t.Errorf("error : FinalizeAndWipe did not wipe the key-switched shares")
+								}
+							}
+						}
+					}
+				})
+
+				t.Run(fmt.Sprintf("N=%d/logQ=%d/bitdecomp=%d/EKG_Pipelined", context.N, context.ModulusBigint.Value.BitLen(), bitDecomp), func(t *testing.T) {
+
+					bitLog := uint64((60 + (60 % bitDecomp)) / bitDecomp)
+
+					ekg := make([]*EkgProtocol, parties)
+					ephemeralKeys := make([]*ring.Poly, parties)
+					crp := make([][][]*ring.Poly, parties)
+
+					for i := 0; i < parties; i++ {
+
+						ekg[i] = NewEkgProtocol(context, bitDecomp)
+						ephemeralKeys[i], _ = ekg[i].NewEphemeralKey(1.0 / 3)
+						crp[i] = make([][]*ring.Poly, len(context.Modulus))
+
+						for j := 0; j < len(context.Modulus); j++ {
+							crp[i][j] = make([]*ring.Poly, bitLog)
+							for u := uint64(0); u < bitLog; u++ {
+								crp[i][j][u] = crpGenerators[i].Clock()
+							}
+						}
+					}
+
+					evkSequential := test_EKG_Protocol(parties, ekg, sk0_shards, ephemeralKeys, crp)
+
+					pipelines := make([]*EkgPipeline, parties)
+					for i := 0; i < parties; i++ {
+						pipelines[i] = ekg[i].NewPipeline(ephemeralKeys[i], sk0_shards[i].Get(), crp[i])
+					}
+
+					if _, err := pipelines[0].Aggregate(0, nil); err == nil {
+						t.Errorf("error : pipeline accepted a limb at the wrong round")
+					}
+
+					evkPipelined, err := test_EKG_Protocol_Pipelined(parties, pipelines)
+					if err != nil {
+						t.Fatal(err)
+					}
+
+					rlkSequential := new(bfv.EvaluationKey)
+					rlkSequential.SetRelinKeys([][][][2]*ring.Poly{evkSequential[0]}, bitDecomp)
+
+					rlkPipelined := new(bfv.EvaluationKey)
+					rlkPipelined.SetRelinKeys([][][][2]*ring.Poly{evkPipelined[0]}, bitDecomp)
+
+					if err := evaluator.Relinearize(ciphertext, rlkSequential, ciphertextTest); err != nil {
+						t.Error(err)
+					}
+
+					coeffsSequential := encoder.DecodeUint(decryptor_sk0.DecryptNew(ciphertextTest))
+
+					if err := evaluator.Relinearize(ciphertext, rlkPipelined, ciphertextTest); err != nil {
+						t.Error(err)
+					}
+
+					coeffsPipelined := encoder.DecodeUint(decryptor_sk0.DecryptNew(ciphertextTest))
+
+					if equalslice(coeffsSequential, coeffsPipelined) != true {
+						t.Errorf("error : ekg pipelined rlk does not match sequential rlk")
+					}
+
+					if equalslice(coeffsMul.Coeffs[0], coeffsPipelined) != true {
+						t.Errorf("error : ekg pipelined rlk bad decrypt")
+					}
+				})
+			}
+
+			// EKG_Naive
+			for _, bitDecomp := range bitDecomps {
+
+				t.Run(fmt.Sprintf("N=%d/logQ=%d/bitdecomp=%d/EKG_Naive", context.N, context.ModulusBigint.Value.BitLen(), bitDecomp), func(t *testing.T) {
+
+					// Each party instantiate an ekg naive protocole
+					ekgNaive := make([]*EkgProtocolNaive, parties)
+					for i := 0; i < parties; i++ {
+						ekgNaive[i] = NewEkgProtocolNaive(context, bitDecomp)
+					}
+
+					evk := test_EKG_Protocol_Naive(parties, sk0_shards, pk0, ekgNaive)
+
+					rlk := new(bfv.EvaluationKey)
+					rlk.SetRelinKeys([][][][2]*ring.Poly{evk[0]}, bitDecomp)
+
+					if err := evaluator.Relinearize(ciphertext, rlk, ciphertextTest); err != nil {
+						t.Error(err)
+					}
+
+					if equalslice(coeffsMul.Coeffs[0], encoder.DecodeUint(decryptor_sk0.DecryptNew(ciphertextTest))) != true {
+						t.Errorf("error : ekg_naive rlk bad decrypt")
+					}
+				})
+			}
+
+			t.Run(fmt.Sprintf("N=%d/logQ=%d/CKG", context.N, context.ModulusBigint.Value.BitLen()), func(t *testing.T) {
+
+				crp := make([]*ring.Poly, parties)
+				for i := 0; i < parties; i++ {
+					crp[i] = crpGenerators[i].Clock()
+				}
+
+				ckg := make([]*CKG, parties)
+				for i := 0; i < parties; i++ {
+					ckg[i] = NewCKG(context, crp[i])
+				}
+
+				// Each party creates a new CKG instance
+				shares := make([]*ring.Poly, parties)
+				for i := 0; i < parties; i++ {
+					ckg[i].GenShare(sk0_shards[i].Get())
+					shares[i] = ckg[i].GetShare()
+				}
+
+				pkTest := make([]*bfv.PublicKey, parties)
+				for i := 0; i < parties; i++ {
+					ckg[i].AggregateShares(shares)
+					pkTest[i], err = ckg[i].Finalize()
+					if err != nil {
+						t.Error(err)
+					}
+				}
+
+				// Verifies that all parties have the same share collective public key
+				for i := 1; i < parties; i++ {
+					if context.Equal(pkTest[0].Get()[0], pkTest[i].Get()[0]) != true || bfvContext.ContextQ().Equal(pkTest[0].Get()[1], pkTest[i].Get()[1]) != true {
+						t.Errorf("error : ckg protocol, cpk establishement")
+					}
+				}
+
+				// Verifies that decrypt((encryptp(collectiveSk, m), collectivePk) = m
+				encryptorTest, err := bfvContext.NewEncryptorFromPk(pkTest[0])
+				if err != nil {
+					t.Error(err)
+				}
+
+				ciphertextTest, err := encryptorTest.EncryptNew(plaintextWant)
+
+				if err != nil {
+					t.Error(err)
+				}
+
+				if equalslice(coeffsWant.Coeffs[0], encoder.DecodeUint(decryptor_sk0.DecryptNew(ciphertextTest))) != true {
+					t.Errorf("error : ckg protocol, cpk encrypt/decrypt test")
+				}
+
+			})
+
+			t.Run(fmt.Sprintf("N=%d/logQ=%d/CKS", context.N, context.ModulusBigint.Value.BitLen()), func(t *testing.T) {
+
+				ciphertext, err := encryptor_pk0.EncryptNew(plaintextWant)
+				if err != nil {
+					t.Error(err)
+				}
+
+				ciphertexts := make([]*bfv.Ciphertext, parties)
+				for i := 0; i < parties; i++ {
+					ciphertexts[i] = ciphertext.CopyNew().Ciphertext()
+				}
+
+				// Each party creates its CKS instance with deltaSk = si-si'
+				cks := make([]*CKS, parties)
+				for i := 0; i < parties; i++ {
+					cks[i] = NewCKS(sk0_shards[i].Get(), sk1_shards[i].Get(), context, 6.36)
+				}
+
+				// Each party computes its hi share from the shared ciphertext
+				// Each party encodes its share and sends it to the other n-1 parties
+				hi := make([]*ring.Poly, parties)
+				for i := 0; i < parties; i++ {
+					hi[i] = cks[i].KeySwitch(ciphertexts[i].Value()[1])
+				}
+				// Each party receive the shares n-1 shares from the other parties and decodes them
+				for i := 0; i < parties; i++ {
+					// Then keyswitch the ciphertext with the decoded shares
+					cks[i].Aggregate(ciphertexts[i].Value()[0], hi)
+				}
+
+				for i := 0; i < parties; i++ {
+
+					if equalslice(coeffsWant.Coeffs[0], encoder.DecodeUint(decryptor_sk1.DecryptNew(ciphertexts[i]))) != true {
+						t.Errorf("error : CKS")
+					}
+
+				}
+			})
+
+			t.Run(fmt.Sprintf("N=%d/logQ=%d/PCKS", context.N, context.ModulusBigint.Value.BitLen()), func(t *testing.T) {
+
+				ciphertext, err := encryptor_pk0.EncryptNew(plaintextWant)
+				if err != nil {
+					t.Error(err)
+				}
+
+				ciphertexts := make([]*bfv.Ciphertext, parties)
+				for i := 0; i < parties; i++ {
+					ciphertexts[i] = ciphertext.CopyNew().Ciphertext()
+				}
+
+				pcks := make([]*PCKS, parties)
+				for i := 0; i < parties; i++ {
+					pcks[i] = NewPCKS(sk0_shards[i].Get(), pk1.Get(), context, 6.36)
+				}
+
+				hi := make([][2]*ring.Poly, parties)
+				for i := 0; i < parties; i++ {
+					hi[i] = pcks[i].KeySwitch(ciphertexts[i].Value()[1])
+				}
+
+				for i := 0; i < parties; i++ {
+					pcks[i].Aggregate(ciphertexts[i].Value(), hi)
+				}
+
+				for i := 0; i < parties; i++ {
+
+					if equalslice(coeffsWant.Coeffs[0], encoder.DecodeUint(decryptor_sk1.DecryptNew(ciphertexts[i]))) != true {
+						t.Errorf("error : PCKS")
+					}
+				}
+			})
+
+			t.Run(fmt.Sprintf("N=%d/logQ=%d/CollectiveDecryption", context.N, context.ModulusBigint.Value.BitLen()), func(t *testing.T) {
+
+				ciphertext, err := encryptor_pk0.EncryptNew(plaintextWant)
+				if err != nil {
+					t.Error(err)
+				}
+
+				cd := make([]*CollectiveDecryption, parties)
+				for i := 0; i < parties; i++ {
+					cd[i] = NewCollectiveDecryption(context)
+				}
+
+				skc1 := context.NewPoly()
+				noise := context.NewPoly()
+
+				var previousNorm int64
+
+				for _, floodBits := range []int{8, 24, 40} {
+
+					shares := make([]*ring.Poly, parties)
+					for i := 0; i < parties; i++ {
+						shares[i] = context.NewPoly()
+						if err := cd[i].GenDecryptionShare(sk0_shards[i].Get(), ciphertext, floodBits, shares[i]); err != nil {
+							t.Error(err)
+						}
+					}
+
+					// The revealed plaintext must be correct
+					plaintext := bfvContext.NewPlaintext()
+					if err := cd[0].AggregateShares(ciphertext, shares, plaintext); err != nil {
+						t.Error(err)
+					}
+
+					if equalslice(coeffsWant.Coeffs[0], encoder.DecodeUint(plaintext)) != true {
+						t.Errorf("error : collective decryption with floodBits=%d", floodBits)
+					}
+
+					// The noise of the share must be bounded by 2^floodBits and grow with floodBits
+					context.NTT(ciphertext.Value()[1], skc1)
+					context.MulCoeffsMontgomery(skc1, sk0_shards[0].Get(), skc1)
+					context.InvNTT(skc1, skc1)
+					context.Sub(shares[0], skc1, noise)
+
+					var norm int64
+					for _, c := range context.GetCenteredCoefficients(noise)[0] {
+						if c < 0 {
+							c = -c
+						}
+						if c > norm {
+							norm = c
+						}
+					}
+
+					if norm > int64(1)<<uint64(floodBits) {
+						t.Errorf("error : decryption share noise exceeds 2^%d", floodBits)
+					}
+
+					if norm <= previousNorm {
+						t.Errorf("error : decryption share noise does not grow with floodBits")
+					}
+
+					previousNorm = norm
+				}
+			})
+
+			t.Run(fmt.Sprintf("N=%d/logQ=%d/Share", context.N, context.ModulusBigint.Value.BitLen()), func(t *testing.T) {
+
+				ekg := NewEkgProtocol(context, 60)
+				ckg := NewCKG(context, crpGenerators[0].Clock())
+
+				crp := make([][]*ring.Poly, len(context.Modulus))
+				for j := range crp {
+					crp[j] = []*ring.Poly{crpGenerators[0].Clock()}
+				}
+
+				ekgShares := make([]Share, parties)
+				ckgShares := make([]Share, parties)
+
+				ekgWant := ekg.NewShareRoundOneEmpty()
+				ckgWant := ckg.NewShareEmpty()
+
+				for i := 0; i < parties; i++ {
+
+					u, _ := ekg.NewEphemeralKey(1.0 / 3)
+
+					ekgShare := ekg.NewShareRoundOne(ekg.GenSamples(u, sk0_shards[i].Get(), crp))
+					for j := range ekgShare.Value {
+						context.Add(ekgWant.Value[j][0], ekgShare.Value[j][0], ekgWant.Value[j][0])
+					}
+					ekgShares[i] = ekgShare
+
+					ckg.GenShare(sk0_shards[i].Get())
+					ckgShare := ckg.NewShare(ckg.GetShare().CopyNew())
+					context.Add(ckgWant.Value, ckgShare.Value, ckgWant.Value)
+					ckgShares[i] = ckgShare
+				}
+
+				// The aggregation of the shares must be equal to their sum and must not modify the input shares
+				ekgFirst := ekgShares[0].Copy().(*EkgShareRoundOne)
+
+				ekgAggregated, err := AggregateShares(ekgShares)
+				if err != nil {
+					t.Error(err)
+				}
+
+				for j := range ekgWant.Value {
+					if context.Equal(ekgWant.Value[j][0], ekgAggregated.(*EkgShareRoundOne).Value[j][0]) != true {
+						t.Errorf("error : ekg round one share aggregation")
+					}
+
+					if context.Equal(ekgFirst.Value[j][0], ekgShares[0].(*EkgShareRoundOne).Value[j][0]) != true {
+						t.Errorf("error : ekg round one share aggregation modified its inputs")
+					}
+				}
+
+				ckgAggregated, err := AggregateShares(ckgShares)
+				if err != nil {
+					t.Error(err)
+				}
+
+				if context.Equal(ckgWant.Value, ckgAggregated.(*CKGShare).Value) != true {
+					t.Errorf("error : ckg share aggregation")
+				}
+
+				// Shares of different types cannot be aggregated
+				if ekgAggregated.Aggregate(ckgAggregated) == nil {
+					t.Errorf("error : aggregation of shares of different types")
+				}
+
+				// Folding the shares from the empty share gives their aggregation, and the empty share for no shares
+				for _, test := range []struct {
+					identity   Share
+					shares     []Share
+					aggregated Share
+				}{
+					{ekg.NewShareRoundOneEmpty(), ekgShares, ekgAggregated},
+					{ckg.NewShareEmpty(), ckgShares, ckgAggregated},
+				} {
+
+					folded, err := FoldShares(test.identity, test.shares...)
+					if err != nil {
+						t.Error(err)
+					}
+
+					if !equalSharePolys(context, test.aggregated, folded) {
+						t.Errorf("error : folding the shares differs from their aggregation")
+					}
+
+					folded, err = FoldShares(test.identity)
+					if err != nil {
+						t.Error(err)
+					}
+
+					if !equalSharePolys(context, test.identity, folded) || folded == test.identity {
+						t.Errorf("error : folding no shares does not return a copy of the identity")
+					}
+				}
+
+				if _, err := FoldShares(ckg.NewShareEmpty(), ekgShares...); err == nil {
+					t.Errorf("error : folding shares of different types")
+				}
+
+				// Marshal/UnMarshal round trip
+				data, err := ekgAggregated.MarshalBinary()
+				if err != nil {
+					t.Error(err)
+				}
+
+				ekgTest := ekg.NewShareRoundOneEmpty()
+				if err := ekgTest.UnMarshalBinary(data); err != nil {
+					t.Error(err)
+				}
+
+				for j := range ekgWant.Value {
+					if context.Equal(ekgWant.Value[j][0], ekgTest.Value[j][0]) != true {
+						t.Errorf("error : ekg round one share marshal/unmarshal")
+					}
+				}
+
+				// The shares are in the NTT domain, their coefficient domain counterparts must survive the round trip of the share through it
+				pols := make([][]*ring.Poly, len(ekgWant.Value))
+				for j := range ekgWant.Value {
+					pols[j] = make([]*ring.Poly, len(ekgWant.Value[j]))
+					for w := range ekgWant.Value[j] {
+						pols[j][w] = ekgWant.Value[j][w].CopyNew()
+						context.InvNTT(pols[j][w], pols[j][w])
+					}
+				}
+
+				if err := assertNTTPreservedThroughShareSerialization(ekg, pols); err != nil {
+					t.Error(err)
+				}
+
+				if ckg.NewShareEmpty().UnMarshalBinary(data) == nil {
+					t.Errorf("error : unmarshal of a share with invalid length")
+				}
+
+				ekgUnreduced := ekgAggregated.Copy().(*EkgShareRoundOne)
+				ekgUnreduced.Value[0][0].Coeffs[0][0] += context.Modulus[0]
+
+				if data, err = ekgUnreduced.MarshalBinary(); err != nil {
+					t.Error(err)
+				}
+
+				if ekg.NewShareRoundOneEmpty().UnMarshalBinary(data) == nil {
+					t.Errorf("error : unmarshal of a share with unreduced coefficients")
+				}
+
+				data, err = ckgAggregated.MarshalBinary()
+				if err != nil {
+					t.Error(err)
+				}
+
+				ckgTest := ckg.NewShareEmpty()
+				if err := ckgTest.UnMarshalBinary(data); err != nil {
+					t.Error(err)
+				}
+
+				if context.Equal(ckgWant.Value, ckgTest.Value) != true {
+					t.Errorf("error : ckg share marshal/unmarshal")
+				}
+			})
+
+			t.Run(fmt.Sprintf("N=%d/logQ=%d/ShareTransport", context.N, context.ModulusBigint.Value.BitLen()), func(t *testing.T) {
+
+				bitDecomp := uint64(30)
+				bitLog := uint64((60 + bitDecomp - 1) / bitDecomp)
+
+				ekg := NewEkgProtocol(context, bitDecomp)
+
+				crp := make([][]*ring.Poly, len(context.Modulus))
+				for j := range crp {
+					crp[j] = make([]*ring.Poly, bitLog)
+					for w := range crp[j] {
+						crp[j][w] = crpGenerators[0].Clock()
+					}
+				}
+
+				ephemeralKeys := make([]*ring.Poly, parties)
+				for i := range ephemeralKeys {
+					ephemeralKeys[i], _ = ekg.NewEphemeralKey(1.0 / 3)
+				}
+
+				// transport marshals the share, sends it over a buffer and decodes it on a new empty share
+				transport := func(share, received Share) Share {
+
+					var buffer bytes.Buffer
+
+					data, err := share.MarshalBinary()
+					if err != nil {
+						t.Fatal(err)
+					}
+
+					buffer.Write(data)
+
+					if err := received.UnMarshalBinary(buffer.Bytes()); err != nil {
+						t.Fatal(err)
+					}
+
+					return received
+				}
+
+				// aggregateBoth aggregates the shares and their transported counterparts, and checks that both aggregations are identical
+				aggregateBoth := func(round string, shares, received []Share) {
+
+					want, _ := AggregateShares(shares)
+					have, _ := AggregateShares(received)
+
+					wantData, _ := want.MarshalBinary()
+					haveData, _ := have.MarshalBinary()
+
+					if !bytes.Equal(wantData, haveData) {
+						t.Errorf("error : aggregation of the transported %s shares differs", round)
+					}
+				}
+
+				samples := make([][][]*ring.Poly, parties)
+				sharesOne := make([]Share, parties)
+				receivedOne := make([]Share, parties)
+				for i := 0; i < parties; i++ {
+					samples[i] = ekg.GenSamples(ephemeralKeys[i], sk0_shards[i].Get(), crp)
+					sharesOne[i] = ekg.NewShareRoundOne(samples[i])
+					receivedOne[i] = transport(sharesOne[i], ekg.NewShareRoundOneEmpty())
+				}
+
+				aggregateBoth("round one", sharesOne, receivedOne)
+
+				for j := range receivedOne[0].(*EkgShareRoundOne).Value {
+					if uint64(len(receivedOne[0].(*EkgShareRoundOne).Value[j])) != bitLog {
+						t.Errorf("error : transported round one share has %d digits on limb %d, want %d", len(receivedOne[0].(*EkgShareRoundOne).Value[j]), j, bitLog)
+					}
+				}
+
+				aggregated := make([][][][2]*ring.Poly, parties)
+				sharesTwo := make([]Share, parties)
+				receivedTwo := make([]Share, parties)
+				for i := 0; i < parties; i++ {
+					aggregated[i] = ekg.Aggregate(sk0_shards[i].Get(), samples, crp)
+					sharesTwo[i] = ekg.NewShareRoundTwo(aggregated[i])
+					receivedTwo[i] = transport(sharesTwo[i], ekg.NewShareRoundTwoEmpty())
+				}
+
+				aggregateBoth("round two", sharesTwo, receivedTwo)
+
+				sum := ekg.Sum(aggregated)
+
+				sharesThree := make([]Share, parties)
+				receivedThree := make([]Share, parties)
+				for i := 0; i < parties; i++ {
+					sharesThree[i] = ekg.NewShareRoundThree(ekg.KeySwitch(ephemeralKeys[i], sk0_shards[i].Get(), sum))
+					receivedThree[i] = transport(sharesThree[i], ekg.NewShareRoundThreeEmpty())
+				}
+
+				aggregateBoth("round three", sharesThree, receivedThree)
+
+				// A share whose embedded dimensions do not match the context of the receiving protocol is rejected
+				data, _ := sharesOne[0].MarshalBinary()
+				data[1]--
+				if ekg.NewShareRoundOneEmpty().UnMarshalBinary(data) == nil {
+					t.Errorf("error : unmarshal of a share whose number of moduli does not match the context")
+				}
+
+				// The shares of a protocol with another bit-decomposition are rejected
+				data, _ = sharesOne[0].MarshalBinary()
+				if NewEkgProtocol(context, 20).NewShareRoundOneEmpty().UnMarshalBinary(data) == nil {
+					t.Errorf("error : unmarshal of a share generated with another bit-decomposition")
+				}
+			})
+
+			t.Run(fmt.Sprintf("N=%d/logQ=%d/Aggregator_SaveState", context.N, context.ModulusBigint.Value.BitLen()), func(t *testing.T) {
+
+				ekg := NewEkgProtocol(context, 60)
+
+				crp := make([][]*ring.Poly, len(context.Modulus))
+				for j := range crp {
+					crp[j] = []*ring.Poly{crpGenerators[0].Clock()}
+				}
+
+				shares := make([]Share, parties)
+				for i := range shares {
+					u, _ := ekg.NewEphemeralKey(1.0 / 3)
+					shares[i] = ekg.NewShareRoundOne(ekg.GenSamples(u, sk0_shards[i].Get(), crp))
+				}
+
+				want, err := AggregateShares(shares)
+				if err != nil {
+					t.Fatal(err)
+				}
+
+				// Folds the first half of the shares and saves the state
+				aggregator := NewAggregator(ekg.NewShareRoundOneEmpty())
+				for i := 0; i < parties/2; i++ {
+					if err := aggregator.Fold(uint32(i), shares[i]); err != nil {
+						t.Fatal(err)
+					}
+				}
+
+				if aggregator.Fold(0, shares[0]) == nil {
+					t.Errorf("error : Aggregator folded twice the share of the same party")
+				}
+
+				state, err := aggregator.SaveState()
+				if err != nil {
+					t.Fatal(err)
+				}
+
+				// Restores the state and folds the rest of the shares
+				aggregator, err = LoadAggregatorState(state, ekg.NewShareRoundOneEmpty())
+				if err != nil {
+					t.Fatal(err)
+				}
+
+				if len(aggregator.Folded()) != parties/2 {
+					t.Errorf("error : LoadAggregatorState, want %d folded parties have %d", parties/2, len(aggregator.Folded()))
+				}
+
+				for i := parties / 2; i < parties; i++ {
+					if err := aggregator.Fold(uint32(i), shares[i]); err != nil {
+						t.Fatal(err)
+					}
+				}
+
+				for j := range want.(*EkgShareRoundOne).Value {
+					if context.Equal(want.(*EkgShareRoundOne).Value[j][0], aggregator.Aggregate().(*EkgShareRoundOne).Value[j][0]) != true {
+						t.Errorf("error : aggregation resumed from a saved state differs from the one-shot aggregation")
+					}
+				}
+
+				if _, err := LoadAggregatorState(state[:len(state)-1], ekg.NewShareRoundOneEmpty()); err == nil {
+					t.Errorf("error : LoadAggregatorState accepted a truncated state")
+				}
+			})
+
+			t.Run(fmt.Sprintf("N=%d/logQ=%d/Aggregator_SortedFolding", context.N, context.ModulusBigint.Value.BitLen()), func(t *testing.T) {
+
+				ekg := NewEkgProtocol(context, 60)
+
+				crpGenerator, err := NewCRPGenerator(nil, context)
+				if err != nil {
+					t.Fatal(err)
+				}
+				crpGenerator.Seed([]byte{})
+				crp := crpGenerator.ClockNew(ekg)
+
+				shares := make([]Share, parties)
+				for i := range shares {
+					u, _ := ekg.NewEphemeralKey(1.0 / 3)
+					shares[i] = ekg.NewShareRoundOne(ekg.GenSamples(u, sk0_shards[i].Get(), crp))
+				}
+
+				// The same set of parties, arriving in different orders
+				arrivals := [][]int{make([]int, parties), make([]int, parties)}
+				for i := 0; i < parties; i++ {
+					arrivals[0][i] = parties - 1 - i
+					arrivals[1][i] = (i + parties/2) % parties
+				}
+
+				states := make([][]byte, len(arrivals))
+
+				for k, arrival := range arrivals {
+
+					aggregator := NewAggregator(ekg.NewShareRoundOneEmpty())
+					aggregator.SetSortedFolding(true)
+
+					for _, i := range arrival {
+						if err := aggregator.Fold(uint32(i), shares[i]); err != nil {
+							t.Fatal(err)
+						}
+					}
+
+					if aggregator.Fold(uint32(arrival[0]), shares[arrival[0]]) == nil {
+						t.Errorf("error : Aggregator buffered twice the share of the same party")
+					}
+
+					if aggregator.Fold(uint32(parties), ekg.NewShareRoundTwoEmpty()) == nil {
+						t.Errorf("error : Aggregator buffered a share of another type")
+					}
+
+					if len(aggregator.Order()) != 0 {
+						t.Errorf("error : Aggregator with sorted folding folded before Flush")
+					}
+
+					if err := aggregator.Flush(); err != nil {
+						t.Fatal(err)
+					}
+
+					for i, party := range aggregator.Order() {
+						if party != uint32(i) {
+							t.Fatalf("error : Aggregator with sorted folding, order %v for the arrival %v", aggregator.Order(), arrival)
+						}
+					}
+
+					if states[k], err = aggregator.SaveState(); err != nil {
+						t.Fatal(err)
+					}
+				}
+
+				if !bytes.Equal(states[0], states[1]) {
+					t.Errorf("error : Aggregator with sorted folding, the states differ for two arrival orders")
+				}
+
+				// Without sorting, the shares are folded in their order of arrival
+				aggregator := NewAggregator(ekg.NewShareRoundOneEmpty())
+				for _, i := range arrivals[0] {
+					aggregator.Fold(uint32(i), shares[i])
+				}
+
+				for i, party := range aggregator.Order() {
+					if party != uint32(arrivals[0][i]) {
+						t.Fatalf("error : Aggregator, order %v for the arrival %v", aggregator.Order(), arrivals[0])
+					}
+				}
+			})
+
+			t.Run(fmt.Sprintf("N=%d/logQ=%d/Codec", context.N, context.ModulusBigint.Value.BitLen()), func(t *testing.T) {
+
+				crp := make([][][]*ring.Poly, parties)
+				crp[0] = make([][]*ring.Poly, len(context.Modulus))
+				for j := range crp[0] {
+					crp[0][j] = []*ring.Poly{crpGenerators[0].Clock()}
+				}
+				for i := 1; i < parties; i++ {
+					crp[i] = crp[0]
+				}
+
+				ternarySampler := context.NewTernarySampler()
+				noise := context.NewKYSampler(3.19, 19).SampleNTTNew()
+
+				ephemeralKeys := make([]*ring.Poly, parties)
+				for i := range ephemeralKeys {
+					ephemeralKeys[i], _ = ternarySampler.SampleMontgomeryNTTNew(1.0 / 3)
+				}
+
+				newEkg := func() []*EkgProtocol {
+					ekg := make([]*EkgProtocol, parties)
+					for i := range ekg {
+						ekg[i] = NewEkgProtocol(context, 60)
+						ekg[i].SetGaussianSampler(newTestMockSampler(t, noise), DefaultBound)
+					}
+					return ekg
+				}
+
+				ekg := newEkg()[0]
+
+				codecs := map[string][3]Codec{
+					"binary": {
+						NewBinaryCodec(func() Share { return ekg.NewShareRoundOneEmpty() }),
+						NewBinaryCodec(func() Share { return ekg.NewShareRoundTwoEmpty() }),
+						NewBinaryCodec(func() Share { return ekg.NewShareRoundThreeEmpty() }),
+					},
+					"json": {
+						&jsonCodec{func() Share { return ekg.NewShareRoundOneEmpty() }},
+						&jsonCodec{func() Share { return ekg.NewShareRoundTwoEmpty() }},
+						&jsonCodec{func() Share { return ekg.NewShareRoundThreeEmpty() }},
+					},
+				}
+
+				want := test_EKG_Protocol(parties, newEkg(), sk0_shards, ephemeralKeys, crp)[0]
+
+				for name, codec := range codecs {
+
+					evk, err := test_EKG_Protocol_Codec(parties, newEkg(), sk0_shards, ephemeralKeys, crp, codec)
+					if err != nil {
+						t.Fatal(err)
+					}
+
+					for j := range want {
+						for w := range want[j] {
+							if context.Equal(want[j][w][0], evk[j][w][0]) != true || context.Equal(want[j][w][1], evk[j][w][1]) != true {
+								t.Errorf("error : ekg with the shares transported with the %s codec differs", name)
+							}
+						}
+					}
+				}
+
+				if _, err := codecs["binary"][0].Decode([]byte{1, 2, 3}); err == nil {
+					t.Errorf("error : binary codec decoded an invalid share")
+				}
+
+				if _, err := codecs["binary"][0].Encode(nil); err == nil {
+					t.Errorf("error : binary codec encoded a nil share")
+				}
+			})
+
+			t.Run(fmt.Sprintf("N=%d/logQ=%d/AggregateSerializedRoundOne", context.N, context.ModulusBigint.Value.BitLen()), func(t *testing.T) {
+
+				ekg := NewEkgProtocol(context, 60)
+
+				crp := make([][]*ring.Poly, len(context.Modulus))
+				for j := range crp {
+					crp[j] = []*ring.Poly{crpGenerators[0].Clock()}
+				}
+
+				shares := make([]Share, 2)
+				data := make([][]byte, 2)
+				for i := range shares {
+					u, _ := ekg.NewEphemeralKey(1.0 / 3)
+					shares[i] = ekg.NewShareRoundOne(ekg.GenSamples(u, sk0_shards[i].Get(), crp))
+					data[i], _ = shares[i].MarshalBinary()
+				}
+
+				// Deserialize, aggregate and reserialize
+				decoded := make([]Share, 2)
+				for i := range decoded {
+					decoded[i] = ekg.NewShareRoundOneEmpty()
+					if err := decoded[i].UnMarshalBinary(data[i]); err != nil {
+						t.Fatal(err)
+					}
+				}
+
+				aggregated, err := AggregateShares(decoded)
+				if err != nil {
+					t.Fatal(err)
+				}
+
+				want, _ := aggregated.MarshalBinary()
+
+				have, err := ekg.AggregateSerializedRoundOne(data[0], data[1])
+				if err != nil {
+					t.Fatal(err)
+				}
+
+				if !bytes.Equal(want, have) {
+					t.Errorf("error : aggregation of the serialized shares differs from the aggregation of the decoded shares")
+				}
+
+				if _, err := ekg.AggregateSerializedRoundOne(data[0], data[1][1:]); err == nil {
+					t.Errorf("error : AggregateSerializedRoundOne accepted a truncated share")
+				}
+
+				corrupted := append([]byte{}, data[1]...)
+				corrupted[1]++
+				if _, err := ekg.AggregateSerializedRoundOne(data[0], corrupted); err == nil {
+					t.Errorf("error : AggregateSerializedRoundOne accepted a share with an invalid header")
+				}
+
+				// The last coefficient of the encoding, on the last modulus, set to the modulus
+				unreduced := append([]byte{}, data[1]...)
+				binary.BigEndian.PutUint64(unreduced[len(unreduced)-8:], context.Modulus[len(context.Modulus)-1])
+				if _, err := ekg.AggregateSerializedRoundOne(data[0], unreduced); err == nil {
+					t.Errorf("error : AggregateSerializedRoundOne accepted a share with unreduced coefficients")
+				}
+			})
+		}
+	}
+}
+
+func test_EKG_Protocol_Naive(parties int, sk []*bfv.SecretKey, collectivePk *bfv.PublicKey, ekgNaive []*EkgProtocolNaive) [][][][2]*ring.Poly {
+
+	// ROUND 0
+	// Each party generates its samples
+	samples := make([][][][2]*ring.Poly, parties)
+	for i := 0; i < parties; i++ {
+		samples[i] = ekgNaive[i].GenSamples(sk[i].Get(), collectivePk.Get())
+	}
+
+	// ROUND 1
+	// Each party aggretates its sample with the other n-1 samples
+	aggregatedSamples := make([][][][2]*ring.Poly, parties)
+	for i := 0; i < parties; i++ {
+		aggregatedSamples[i] = ekgNaive[i].Aggregate(sk[i].Get(), collectivePk.Get(), samples)
+	}
+
+	// ROUND 2
+	// Each party aggregates sums its aggregatedSample with the other n-1 aggregated samples
+	evk := make([][][][2]*ring.Poly, parties)
+	for i := 0; i < parties; i++ {
+		evk[i] = ekgNaive[i].Finalize(aggregatedSamples)
+	}
+
+	return evk
+}
+
+func test_EKG_Protocol(parties int, ekgProtocols []*EkgProtocol, sk []*bfv.SecretKey, ephemeralKeys []*ring.Poly, crp [][][]*ring.Poly) [][][][2]*ring.Poly {
+
+	// ROUND 1
+	samples := make([][][]*ring.Poly, parties)
+	for i := 0; i < parties; i++ {
+		samples[i] = ekgProtocols[i].GenSamples(ephemeralKeys[i], sk[i].Get(), crp[i])
+	}
+
+	//ROUND 2
+	aggregatedSamples := make([][][][2]*ring.Poly, parties)
+	for i := 0; i < parties; i++ {
+		aggregatedSamples[i] = ekgProtocols[i].Aggregate(sk[i].Get(), samples, crp[i])
+	}
+
+	// ROUND 3
+	keySwitched := make([][][]*ring.Poly, parties)
+	sum := make([][][][2]*ring.Poly, parties)
+	for i := 0; i < parties; i++ {
+		sum[i] = ekgProtocols[i].Sum(aggregatedSamples)
+		keySwitched[i] = ekgProtocols[i].KeySwitch(ephemeralKeys[i], sk[i].Get(), sum[i])
+	}
+
+	// ROUND 4
+	collectiveEvaluationKey := make([][][][2]*ring.Poly, parties)
+	for i := 0; i < parties; i++ {
+		collectiveEvaluationKey[i] = ekgProtocols[i].ComputeEVK(keySwitched, sum[i])
+	}
+
+	return collectiveEvaluationKey
+}
+
+// test_EKG_Protocol_Codec runs the EkgProtocol protocol, transporting the shares of each round through the codec of the round.
+func test_EKG_Protocol_Codec(parties int, ekgProtocols []*EkgProtocol, sk []*bfv.SecretKey, ephemeralKeys []*ring.Poly, crp [][][]*ring.Poly, codecs [3]Codec) ([][][2]*ring.Poly, error) {
+
+	transport := func(codec Codec, share Share) (Share, error) {
+		data, err := codec.Encode(share)
+		if err != nil {
+			return nil, err
+		}
+		return codec.Decode(data)
+	}
+
+	// ROUND 1
+	samples := make([][][]*ring.Poly, parties)
+	for i := 0; i < parties; i++ {
+		share, err := transport(codecs[0], ekgProtocols[i].NewShareRoundOne(ekgProtocols[i].GenSamples(ephemeralKeys[i], sk[i].Get(), crp[i])))
+		if err != nil {
+			return nil, err
+		}
+		samples[i] = share.(*EkgShareRoundOne).Value
+	}
+
+	//ROUND 2
+	aggregatedSamples := make([][][][2]*ring.Poly, parties)
+	for i := 0; i < parties; i++ {
+		share, err := transport(codecs[1], ekgProtocols[i].NewShareRoundTwo(ekgProtocols[i].Aggregate(sk[i].Get(), samples, crp[i])))
+		if err != nil {
+			return nil, err
+		}
+		aggregatedSamples[i] = share.(*EkgShareRoundTwo).Value
+	}
+
+	// ROUND 3
+	sum := ekgProtocols[0].Sum(aggregatedSamples)
+
+	keySwitched := make([][][]*ring.Poly, parties)
+	for i := 0; i < parties; i++ {
+		share, err := transport(codecs[2], ekgProtocols[i].NewShareRoundThree(ekgProtocols[i].KeySwitch(ephemeralKeys[i], sk[i].Get(), sum)))
+		if err != nil {
+			return nil, err
+		}
+		keySwitched[i] = share.(*EkgShareRoundThree).Value
+	}
+
+	// ROUND 4
+	return ekgProtocols[0].ComputeEVK(keySwitched, sum), nil
+}
+
+// jsonCodec is a Codec wrapping the binary encoding of the shares in a JSON document.
+type jsonCodec struct {
+	newShare func() Share
+}
+
+type jsonShare struct {
+	Version int    `json:"version"`
+	Data    []byte `json:"data"`
+}
+
+func (codec *jsonCodec) Encode(share Share) ([]byte, error) {
+
+	data, err := share.MarshalBinary()
+	if err != nil {
+		return nil, err
+	}
+
+	return json.Marshal(&jsonShare{Version: 1, Data: data})
+}
+
+func (codec *jsonCodec) Decode(data []byte) (Share, error) {
+
+	encoded := new(jsonShare)
+	if err := json.Unmarshal(data, encoded); err != nil {
+		return nil, err
+	}
+
+	share := codec.newShare()
+
+	return share, share.UnMarshalBinary(encoded.Data)
+}
+
+// test_EKG_Protocol_Pipelined runs the EkgProtocol protocol limb per limb, completing all the rounds
+// of a limb before starting the next one.
+func test_EKG_Protocol_Pipelined(parties int, pipelines []*EkgPipeline) ([][][][2]*ring.Poly, error) {
+
+	var err error
+
+	for limb := pipelines[0].Limbs() - 1; limb >= 0; limb-- {
+
+		// ROUND 1
+		samples := make([][]*ring.Poly, parties)
+		for i := 0; i < parties; i++ {
+			if samples[i], err = pipelines[i].GenSamples(limb); err != nil {
+				return nil, err
+			}
+		}
+
+		// ROUND 2
+		aggregatedSamples := make([][][2]*ring.Poly, parties)
+		for i := 0; i < parties; i++ {
+			if aggregatedSamples[i], err = pipelines[i].Aggregate(limb, samples); err != nil {
+				return nil, err
+			}
+		}
+
+		// ROUND 3
+		keySwitched := make([][]*ring.Poly, parties)
+		for i := 0; i < parties; i++ {
+			if keySwitched[i], err = pipelines[i].KeySwitch(limb, aggregatedSamples); err != nil {
+				return nil, err
+			}
+		}
+
+		// ROUND 4
+		for i := 0; i < parties; i++ {
+			if _, err = pipelines[i].ComputeEVK(limb, keySwitched); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	collectiveEvaluationKey := make([][][][2]*ring.Poly, parties)
+	for i := 0; i < parties; i++ {
+		if collectiveEvaluationKey[i], err = pipelines[i].EVK(); err != nil {
+			return nil, err
+		}
+	}
+
+	return collectiveEvaluationKey, nil
+}
+
+func Test_NegotiateParameters(t *testing.T) {
+
+	params0 := bfv.DefaultParams[0]
+	params1 := bfv.DefaultParams[1]
+
+	t.Run("Compatible", func(t *testing.T) {
+
+		params1Sigma := params1
+		params1Sigma.Sigma = 6.4
+
+		params, err := NegotiateParameters([]*bfv.Parameters{&params0, &params1, &params0, &params1Sigma})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if params.Equals(&params1Sigma) != true {
+			t.Errorf("error : negotiated parameters are not the most conservative set")
+		}
+
+		// The negotiated set must not share memory with the preferences
+		params.Qi[0] = 0
+		if params1.Qi[0] == 0 {
+			t.Errorf("error : negotiated parameters share memory with the preferences")
+		}
+	})
+
+	t.Run("Incompatible", func(t *testing.T) {
+
+		if _, err := NegotiateParameters([]*bfv.Parameters{}); err == nil {
+			t.Errorf("error : negotiation of an empty list of preferences")
+		}
+
+		paramsT := params0
+		paramsT.T = 40961
+
+		if _, err := NegotiateParameters([]*bfv.Parameters{&params1, &paramsT}); err == nil {
+			t.Errorf("error : negotiation of preferences with different plaintext moduli")
+		}
+
+		paramsQi := params1
+		paramsQi.Qi = params1.Qi[:len(params1.Qi)-1]
+
+		if _, err := NegotiateParameters([]*bfv.Parameters{&params0, &params1, &paramsQi}); err == nil {
+			t.Errorf("error : negotiation of preferences with different moduli")
+		}
+	})
+}
+
+func Test_BenchmarkResults(t *testing.T) {
+
+	bfvContext := bfv.NewBfvContext()
+	if err := bfvContext.SetParameters(&bfv.DefaultParams[0]); err != nil {
+		t.Fatal(err)
+	}
+
+	context := bfvContext.ContextQ()
+
+	result := newBenchmarkResult(context, 3, 60)
+	result.Rounds["EKG_Round0"] = 1024
+	result.ShareSize["EKG_Round0"] = sharesSize(context.NewPoly(), context.NewPoly())
+	result.Noise = 4.5
+
+	file, err := ioutil.TempFile("", "dbfv_results")
+	if err != nil {
+		t.Fatal(err)
+	}
+	file.Close()
+	defer os.Remove(file.Name())
+
+	if err := writeBenchmarkResults(file.Name(), []*benchmarkResult{result}); err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := ioutil.ReadFile(file.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var parsed []map[string]interface{}
+	if err := json.Unmarshal(data, &parsed); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(parsed) != 1 {
+		t.Fatalf("error : benchmark results, want 1 record have %d", len(parsed))
+	}
+
+	for _, field := range []string{"n", "logQ", "parties", "bitDecomp", "roundsNsPerOp", "shareSizeBytes", "noiseLog2"} {
+		if _, ok := parsed[0][field]; !ok {
+			t.Errorf("error : benchmark results, missing field %s", field)
+		}
+	}
+
+	if parsed[0]["n"] != float64(context.N) || parsed[0]["parties"] != float64(3) || parsed[0]["noiseLog2"] != 4.5 {
+		t.Errorf("error : benchmark results, bad configuration or noise")
+	}
+
+	if parsed[0]["roundsNsPerOp"].(map[string]interface{})["EKG_Round0"] != float64(1024) {
+		t.Errorf("error : benchmark results, bad round timing")
+	}
+
+	if parsed[0]["shareSizeBytes"].(map[string]interface{})["EKG_Round0"] != float64(2*(2+8*context.N*uint64(len(context.Modulus)))) {
+		t.Errorf("error : benchmark results, bad share size")
+	}
+}
+
+func Test_HealthCheck(t *testing.T) {
+
+	t.Run("Healthy", func(t *testing.T) {
+
+		bfvContext, err := bfv.NewBfvContextWithParam(&bfv.DefaultParams[0])
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if err := HealthCheck(bfvContext); err != nil {
+			t.Error(err)
+		}
+	})
+
+	t.Run("Corrupted", func(t *testing.T) {
+
+		bfvContext, err := bfv.NewBfvContextWithParam(&bfv.DefaultParams[0])
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		// The reductions modulo the first modulus no longer match its precomputed parameters
+		bfvContext.ContextQ().Modulus[0] -= 2
+
+		if HealthCheck(bfvContext) == nil {
+			t.Errorf("error : HealthCheck passed on a corrupted context")
+		}
+	})
+}
+
+func Test_ParallelSum(t *testing.T) {
+
+	bfvContext, err := bfv.NewBfvContextWithParam(&bfv.DefaultParams[1])
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	context := bfvContext.ContextQ()
+
+	// The auto-selection depends on the number of available CPUs
+	defer runtime.GOMAXPROCS(runtime.GOMAXPROCS(4))
+
+	ekg := NewEkgProtocol(context, 60)
+
+	t.Run("AutoSelection", func(t *testing.T) {
+
+		if ekg.sumInParallel(2) {
+			t.Errorf("error : parallel sum selected for 2 parties")
+		}
+
+		if !ekg.sumInParallel(64) {
+			t.Errorf("error : serial sum selected for 64 parties")
+		}
+
+		contextSingleModulus := ring.NewContext()
+		if err := contextSingleModulus.SetParameters(context.N, context.Modulus[:1]); err != nil {
+			t.Fatal(err)
+		}
+
+		if NewEkgProtocol(contextSingleModulus, 60).sumInParallel(64) {
+			t.Errorf("error : parallel sum selected for a single modulus")
+		}
+
+		runtime.GOMAXPROCS(1)
+		if ekg.sumInParallel(64) {
+			t.Errorf("error : parallel sum selected for a single CPU")
+		}
+		runtime.GOMAXPROCS(4)
+	})
+
+	t.Run("SerialEqualsParallel", func(t *testing.T) {
+
+		parties := 16
+
+		samples := make([][][][2]*ring.Poly, parties)
+		for i := range samples {
+			samples[i] = make([][][2]*ring.Poly, len(context.Modulus))
+			for j := range samples[i] {
+				samples[i][j] = [][2]*ring.Poly{{context.NewUniformPoly(), context.NewUniformPoly()}}
+			}
+		}
+
+		ekgSerial := NewEkgProtocol(context, 60)
+		ekgSerial.SetParallelSumThreshold(-1)
+
+		serial := ekgSerial.Sum(samples)
+		parallel := ekg.Sum(samples)
+
+		for i := range serial {
+			if context.Equal(serial[i][0][0], parallel[i][0][0]) != true || context.Equal(serial[i][0][1], parallel[i][0][1]) != true {
+				t.Errorf("error : parallel sum differs from the serial sum on limb %d", i)
+			}
+		}
+	})
+}
+
+func Test_CommitSecretShare(t *testing.T) {
+
+	bfvContext, err := bfv.NewBfvContextWithParam(&bfv.DefaultParams[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	kgen := bfvContext.NewKeyGenerator()
+
+	sk0 := kgen.NewSecretKey()
+	sk1 := kgen.NewSecretKey()
+
+	commitment := CommitSecretShare(sk0.Get())
+
+	t.Run("MatchingReveal", func(t *testing.T) {
+
+		if len(commitment) != 32 {
+			t.Errorf("error : commitment of %d bytes, want 32", len(commitment))
+		}
+
+		if !VerifySecretShare(commitment, sk0.Get()) {
+			t.Errorf("error : the committed share is rejected")
+		}
+
+		if !VerifySecretShare(commitment, sk0.Get().CopyNew()) {
+			t.Errorf("error : a copy of the committed share is rejected")
+		}
+	})
+
+	t.Run("MismatchedReveal", func(t *testing.T) {
+
+		if VerifySecretShare(commitment, sk1.Get()) {
+			t.Errorf("error : another share is accepted")
+		}
+
+		tampered := sk0.Get().CopyNew()
+		tampered.Coeffs[0][0] ^= 1
+
+		if VerifySecretShare(commitment, tampered) {
+			t.Errorf("error : a share with a modified coefficient is accepted")
+		}
+
+		if VerifySecretShare(commitment[:16], sk0.Get()) {
+			t.Errorf("error : a truncated commitment is accepted")
+		}
+	})
+}
+
+// assertNTTPreservedThroughShareSerialization guards the handling of the NTT domain by the share serializers. It transforms the
+// polynomials pols in the NTT domain, wraps them in an EkgShareRoundOne, marshals it, unmarshals it on an empty share, transforms the
+// polynomials back and compares them with pols modulo the moduli, returning an error describing the first mismatch.
+func assertNTTPreservedThroughShareSerialization(ekg *EkgProtocol, pols [][]*ring.Poly) error {
+
+	context := ekg.context
+
+	h := make([][]*ring.Poly, len(pols))
+	for i := range pols {
+		h[i] = make([]*ring.Poly, len(pols[i]))
+		for w := range pols[i] {
+			h[i][w] = context.NewPoly()
+			context.NTT(pols[i][w], h[i][w])
+		}
+	}
+
+	data, err := ekg.NewShareRoundOne(h).MarshalBinary()
+	if err != nil {
+		return err
+	}
+
+	shareTest := ekg.NewShareRoundOneEmpty()
+	if err := shareTest.UnMarshalBinary(data); err != nil {
+		return err
+	}
+
+	for i := range pols {
+		for w := range pols[i] {
+
+			pTest := shareTest.Value[i][w]
+			context.InvNTT(pTest, pTest)
+
+			for k, qi := range context.Modulus {
+				for j := uint64(0); j < context.N; j++ {
+					if pTest.Coeffs[k][j] != pols[i][w].Coeffs[k][j]%qi {
+						return fmt.Errorf("NTT not preserved through share serialization -> coefficient %d of limb %d of polynomial (%d, %d), want %d have %d", j, k, i, w, pols[i][w].Coeffs[k][j]%qi, pTest.Coeffs[k][j])
+					}
+				}
+			}
+		}
+	}
+
+	return nil
+}
+
+// newTestMockSampler returns a new MockSampler returning the given polynomials, failing the test if it cannot be created.
+func newTestMockSampler(t *testing.T, polys ...*ring.Poly) *MockSampler {
+
+	sampler, err := NewMockSampler(polys...)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return sampler
+}
+
+// equalSharePolys returns true if both shares are of the same type and their polynomials are equal modulo the moduli of the context.
+func equalSharePolys(context *ring.Context, share0, share1 Share) bool {
+
+	polys := func(share Share) []*ring.Poly {
+		switch share := share.(type) {
+		case *EkgShareRoundOne:
+			return share.polys
+		case *CKGShare:
+			return share.polys
+		}
+		return nil
+	}
+
+	polys0, polys1 := polys(share0), polys(share1)
+
+	if polys0 == nil || len(polys0) != len(polys1) {
+		return false
+	}
+
+	for i := range polys0 {
+		if context.Equal(polys0[i], polys1[i]) != true {
+			return false
+		}
+	}
+
+	return true
+}
+
+func Test_RoundCount(t *testing.T) {
+
+	bfvContext, err := bfv.NewBfvContextWithParam(&bfv.DefaultParams[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	context := bfvContext.ContextQ()
+	sk := bfvContext.NewKeyGenerator().NewSecretKey()
+
+	crpGenerator, _ := NewCRPGenerator(nil, context)
+	ckgEkg, err := NewCkgEkgProtocol(context, 60, crpGenerator)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	refresh, err := NewRefreshProtocol(bfvContext, 40)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, test := range []struct {
+		name     string
+		protocol Protocol
+		rounds   int
+	}{
+		{"EKG", NewEkgProtocol(context, 60), 3},
+		{"EKG_Naive", NewEkgProtocolNaive(context, 60), 2},
+		{"CKG", NewCKG(context, context.NewUniformPoly()), 1},
+		{"CKG_EKG", ckgEkg, 3},
+		{"CKS", NewCKS(sk.Get(), sk.Get(), context, 3.19), 1},
+		{"PCKS", NewPCKS(sk.Get(), [2]*ring.Poly{context.NewPoly(), context.NewPoly()}, context, 3.19), 1},
+		{"CollectiveDecryption", NewCollectiveDecryption(context), 1},
+		{"Refresh", refresh, 1},
+	} {
+		if test.protocol.RoundCount() != test.rounds {
+			t.Errorf("error : %s RoundCount, want %d have %d", test.name, test.rounds, test.protocol.RoundCount())
+		}
+	}
+}
+
+func Test_NewEkgProtocolChecked(t *testing.T) {
+
+	context := bfv.NewBfvContext()
+	if err := context.SetParameters(&bfv.DefaultParams[0]); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, bitDecomp := range []uint64{0, 1, 60, 61} {
+
+		valid := bitDecomp >= 1 && bitDecomp <= 60
+
+		t.Run(fmt.Sprintf("bitDecomp=%d", bitDecomp), func(t *testing.T) {
+
+			ekg, err := NewEkgProtocolChecked(context.ContextQ(), bitDecomp)
+
+			if valid {
+				if err != nil {
+					t.Fatal(err)
+				}
+
+				if ekg.bitLog != (60+bitDecomp-1)/bitDecomp {
+					t.Errorf("error : bitLog is %d for bitDecomp %d", ekg.bitLog, bitDecomp)
+				}
+			} else if err == nil {
+				t.Errorf("error : NewEkgProtocolChecked accepted bitDecomp %d", bitDecomp)
+			}
+
+			defer func() {
+				if recovered := recover(); (recovered != nil) == valid {
+					t.Errorf("error : NewEkgProtocol with bitDecomp %d, panicked : %t", bitDecomp, recovered != nil)
+				}
+			}()
+
+			NewEkgProtocol(context.ContextQ(), bitDecomp)
+		})
+	}
+}
+
+// interruptedWriter simulates a write interrupted after a given number of calls to Write.
+type interruptedWriter struct {
+	w      io.Writer
+	writes int
+}
+
+func (writer *interruptedWriter) Write(p []byte) (int, error) {
+	if writer.writes == 0 {
+		return 0, errors.New("write interrupted")
+	}
+	writer.writes--
+	return writer.w.Write(p)
+}
+
+func Test_ShareWriterReader(t *testing.T) {
+
+	bfvContext := bfv.NewBfvContext()
+	if err := bfvContext.SetParameters(&bfv.DefaultParams[0]); err != nil {
+		t.Fatal(err)
+	}
+
+	context := bfvContext.ContextQ()
+
+	ekg := NewEkgProtocol(context, 20)
+
+	crpGenerator, _ := NewCRPGenerator(nil, context)
+	crpGenerator.Seed([]byte{})
+
+	u, _ := ekg.NewEphemeralKey(1.0 / 3)
+	share := ekg.NewShareRoundOne(ekg.GenSamples(u, bfvContext.NewKeyGenerator().NewSecretKey().Get(), crpGenerator.ClockNew(ekg)))
+
+	want, err := share.MarshalBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// One chunk for the header, then one chunk per modulus and digit, the limb boundaries are every bitLog chunks
+	chunkSize := uint64(len(want)-shareHeaderSize) / (uint64(len(context.Modulus)) * ekg.bitLog)
+	firstLimb := shareHeaderSize + ekg.bitLog*chunkSize
+
+	t.Run("InterruptedWrite", func(t *testing.T) {
+
+		var buffer bytes.Buffer
+
+		writer, err := NewShareWriter(share, &interruptedWriter{w: &buffer, writes: int(ekg.bitLog) + 1}, 0)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if writer.WriteAll() == nil {
+			t.Fatal("error : the interrupted write did not return an error")
+		}
+
+		checkpoint := writer.Checkpoint()
+
+		if checkpoint != firstLimb || uint64(buffer.Len()) != checkpoint {
+			t.Fatalf("error : interrupted at the end of the first limb, the checkpoint is %d and %d bytes are written, want %d", checkpoint, buffer.Len(), firstLimb)
+		}
+
+		writer, err = NewShareWriter(share, &buffer, checkpoint)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if err := writer.WriteAll(); err != nil {
+			t.Fatal(err)
+		}
+
+		if !bytes.Equal(want, buffer.Bytes()) || writer.Checkpoint() != uint64(len(want)) {
+			t.Errorf("error : the resumed write does not produce the encoding of the share")
+		}
+
+		if writer.WriteChunk() != io.EOF {
+			t.Errorf("error : WriteChunk on a fully written share does not return io.EOF")
+		}
+	})
+
+	t.Run("InterruptedRead", func(t *testing.T) {
+
+		received := ekg.NewShareRoundOneEmpty()
+
+		// The read is interrupted in the middle of the second limb
+		interruptedAt := firstLimb + chunkSize/2
+
+		reader, err := NewShareReader(received, bytes.NewReader(want[:interruptedAt]), 0)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if reader.ReadAll() != io.ErrUnexpectedEOF {
+			t.Fatal("error : the interrupted read did not return io.ErrUnexpectedEOF")
+		}
+
+		checkpoint := reader.Checkpoint()
+
+		if checkpoint != firstLimb {
+			t.Fatalf("error : the checkpoint of the interrupted read is %d, want %d", checkpoint, firstLimb)
+		}
+
+		reader, err = NewShareReader(received, bytes.NewReader(want[checkpoint:]), checkpoint)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if err := reader.ReadAll(); err != nil {
+			t.Fatal(err)
+		}
+
+		have, _ := received.MarshalBinary()
+
+		if !bytes.Equal(want, have) {
+			t.Errorf("error : the resumed read does not decode the share")
+		}
+	})
+
+	t.Run("InvalidCheckpoint", func(t *testing.T) {
+
+		if _, err := NewShareWriter(share, ioutil.Discard, shareHeaderSize+chunkSize/2); err == nil {
+			t.Errorf("error : NewShareWriter accepted a checkpoint within a chunk")
+		}
+
+		if _, err := NewShareReader(share, bytes.NewReader(nil), uint64(len(want))+chunkSize); err == nil {
+			t.Errorf("error : NewShareReader accepted a checkpoint beyond the share")
+		}
+	})
+
+	t.Run("BitDecompMismatch", func(t *testing.T) {
+
+		// The bit-decompositions 30 and 59 both give two digits for 60-bit moduli, i.e. encodings of the same length
+		ekg30, ekg59 := NewEkgProtocol(context, 30), NewEkgProtocol(context, 59)
+
+		if ekg30.bitLog != ekg59.bitLog {
+			t.Fatalf("error : the bit-decompositions 30 and 59 give %d and %d digits", ekg30.bitLog, ekg59.bitLog)
+		}
+
+		data, err := ekg30.NewShareRoundOneEmpty().MarshalBinary()
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if ekg59.NewShareRoundOneEmpty().UnMarshalBinary(data) == nil {
+			t.Errorf("error : a share of bit-decomposition 30 is decoded by an EkgProtocol of bit-decomposition 59")
+		}
+
+		reader, err := NewShareReader(ekg59.NewShareRoundOneEmpty(), bytes.NewReader(data), 0)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if reader.ReadAll() == nil {
+			t.Errorf("error : a share of bit-decomposition 30 is read by an EkgProtocol of bit-decomposition 59")
+		}
+
+		if _, err := ekg59.AggregateSerializedRoundOne(data, data); err == nil {
+			t.Errorf("error : shares of bit-decomposition 30 are aggregated by an EkgProtocol of bit-decomposition 59")
+		}
+	})
+}
+
+func Test_BitDecompTradeoff(t *testing.T) {
+
+	bfvContext := bfv.NewBfvContext()
+	if err := bfvContext.SetParameters(&bfv.DefaultParams[0]); err != nil {
+		t.Fatal(err)
+	}
+
+	values := []uint64{15, 20, 30, 60}
+
+	points, err := BitDecompTradeoff(bfvContext, values)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for i, point := range points {
+
+		t.Logf("bitDecomp=%2d keyBytes=%8d noise=%6.2f bits", point.BitDecomp, point.KeyBytes, point.Noise)
+
+		if point.BitDecomp != values[i] {
+			t.Errorf("error : point %d is for bitDecomp %d, want %d", i, point.BitDecomp, values[i])
+		}
+
+		if i == 0 {
+			continue
+		}
+
+		// A larger bit-decomposition gives a smaller key but a larger noise
+		if point.KeyBytes >= points[i-1].KeyBytes {
+			t.Errorf("error : the key size does not decrease from bitDecomp %d to %d", points[i-1].BitDecomp, point.BitDecomp)
+		}
+
+		if point.Noise <= points[i-1].Noise {
+			t.Errorf("error : the noise does not increase from bitDecomp %d to %d", points[i-1].BitDecomp, point.BitDecomp)
+		}
+	}
+
+	if _, err := BitDecompTradeoff(bfvContext, []uint64{0}); err == nil {
+		t.Errorf("error : BitDecompTradeoff accepted bitDecomp 0")
+	}
+}
+
+func Test_EkgAuditLog(t *testing.T) {
+
+	bfvContext := bfv.NewBfvContext()
+	if err := bfvContext.SetParameters(&bfv.DefaultParams[0]); err != nil {
+		t.Fatal(err)
+	}
+
+	context := bfvContext.ContextQ()
+	kgen := bfvContext.NewKeyGenerator()
+
+	parties := 3
+	bitDecomp := uint64(60)
+
+	crpGenerator, err := NewCRPGenerator(nil, context)
+	if err != nil {
+		t.Fatal(err)
+	}
+	crpGenerator.Seed([]byte{})
+	crp := crpGenerator.ClockNew(NewEkgProtocol(context, bitDecomp))
+
+	ekgs := make([]*EkgProtocol, parties)
+	loggers := make([]*MemoryAuditLogger, parties)
+	sks := make([]*ring.Poly, parties)
+	ephemeralKeys := make([]*ring.Poly, parties)
+
+	for i := range ekgs {
+		ekgs[i] = NewEkgProtocol(context, bitDecomp)
+		loggers[i] = NewMemoryAuditLogger()
+		ekgs[i].SetAuditLogger(loggers[i])
+		sks[i] = kgen.NewSecretKey().Get()
+		if ephemeralKeys[i], err = ekgs[i].NewEphemeralKey(1.0 / 3); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	start := time.Now()
+
+	samples := make([][][]*ring.Poly, parties)
+	for i, ekg := range ekgs {
+		samples[i] = ekg.GenSamples(ephemeralKeys[i], sks[i], crp)
+	}
+
+	aggregatedSamples := make([][][][2]*ring.Poly, parties)
+	for i, ekg := range ekgs {
+		aggregatedSamples[i] = ekg.Aggregate(sks[i], samples, crp)
+	}
+
+	sums := make([][][][2]*ring.Poly, parties)
+	keySwitched := make([][][]*ring.Poly, parties)
+	for i, ekg := range ekgs {
+		sums[i] = ekg.Sum(aggregatedSamples)
+		keySwitched[i] = ekg.KeySwitch(ephemeralKeys[i], sks[i], sums[i])
+	}
+
+	for i, ekg := range ekgs {
+		ekg.ComputeEVK(keySwitched, sums[i])
+	}
+
+	hash := func(share Share) [32]byte {
+		h, err := HashShare(share)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return h
+	}
+
+	for i, ekg := range ekgs {
+
+		type expectedEntry struct {
+			step      string
+			direction AuditDirection
+			hash      [32]byte
+		}
+
+		var expected []expectedEntry
+
+		expected = append(expected, expectedEntry{"GenSamples", AuditSent, hash(ekg.NewShareRoundOne(samples[i]))})
+		for j := range samples {
+			expected = append(expected, expectedEntry{"Aggregate", AuditReceived, hash(ekg.NewShareRoundOne(samples[j]))})
+		}
+		expected = append(expected, expectedEntry{"Aggregate", AuditSent, hash(ekg.NewShareRoundTwo(aggregatedSamples[i]))})
+		for j := range aggregatedSamples {
+			expected = append(expected, expectedEntry{"Sum", AuditReceived, hash(ekg.NewShareRoundTwo(aggregatedSamples[j]))})
+		}
+		expected = append(expected, expectedEntry{"KeySwitch", AuditSent, hash(ekg.NewShareRoundThree(keySwitched[i]))})
+		for j := range keySwitched {
+			expected = append(expected, expectedEntry{"ComputeEVK", AuditReceived, hash(ekg.NewShareRoundThree(keySwitched[j]))})
+		}
+
+		entries := loggers[i].Entries()
+
+		if len(entries) != len(expected) {
+			t.Fatalf("error : party %d, the log has %d entries, want %d", i, len(entries), len(expected))
+		}
+
+		for k, entry := range entries {
+
+			if entry.Step != expected[k].step || entry.Direction != expected[k].direction {
+				t.Errorf("error : party %d, entry %d is (%s, %d), want (%s, %d)", i, k, entry.Step, entry.Direction, expected[k].step, expected[k].direction)
+			}
+
+			if entry.Hash != expected[k].hash {
+				t.Errorf("error : party %d, entry %d (%s) has a wrong hash", i, k, entry.Step)
+			}
+
+			if entry.Time.Before(start) || (k > 0 && entry.Time.Before(entries[k-1].Time)) {
+				t.Errorf("error : party %d, entry %d has an inconsistent timestamp", i, k)
+			}
+		}
+	}
+
+	// The share sent by a party is the one recorded as received by the others
+	if loggers[0].Entries()[0].Hash != loggers[1].Entries()[1].Hash {
+		t.Errorf("error : the hash of the share sent by party 0 does not match the hash received by party 1")
+	}
+
+	// The round one with a precomputed decomposition of the secret share is recorded as well
+	ephemeralKey, err := ekgs[1].NewEphemeralKey(1.0 / 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	decomposed := ekgs[1].GenSamplesDecomposed(ephemeralKey, context.DecomposePoly(sks[1], bitDecomp), crp)
+
+	if entries := loggers[1].Entries(); len(entries) != 3*parties+4 {
+		t.Errorf("error : GenSamplesDecomposed recorded %d entries, want 1", len(entries)-3*parties-3)
+	} else if last := entries[len(entries)-1]; last.Step != "GenSamplesDecomposed" || last.Direction != AuditSent || last.Hash != hash(ekgs[1].NewShareRoundOne(decomposed)) {
+		t.Errorf("error : GenSamplesDecomposed recorded the entry (%s, %d) or a wrong hash", last.Step, last.Direction)
+	}
+
+	ekgs[0].SetAuditLogger(nil)
+	ekgs[0].GenSamples(ephemeralKeys[0], sks[0], crp)
+	if len(loggers[0].Entries()) != 3*parties+3 {
+		t.Errorf("error : the audit logger still records entries once unset")
+	}
+}
+
+func Test_EkgAuditLogPipeline(t *testing.T) {
+
+	bfvContext, err := bfv.NewBfvContextWithParam(&bfv.DefaultParams[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	context := bfvContext.ContextQ()
+	kgen := bfvContext.NewKeyGenerator()
+
+	parties := 2
+	bitDecomp := uint64(60)
+
+	crpGenerator, err := NewCRPGenerator(nil, context)
+	if err != nil {
+		t.Fatal(err)
+	}
+	crpGenerator.Seed([]byte{})
+	crp := crpGenerator.ClockNew(NewEkgProtocol(context, bitDecomp))
+
+	ekgs := make([]*EkgProtocol, parties)
+	loggers := make([]*MemoryAuditLogger, parties)
+	pipelines := make([]*EkgPipeline, parties)
+
+	for i := range ekgs {
+		ekgs[i] = NewEkgProtocol(context, bitDecomp)
+		loggers[i] = NewMemoryAuditLogger()
+		ekgs[i].SetAuditLogger(loggers[i])
+
+		ephemeralKey, err := ekgs[i].NewEphemeralKey(1.0 / 3)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		pipelines[i] = ekgs[i].NewPipeline(ephemeralKey, kgen.NewSecretKey().Get(), crp)
+	}
+
+	hash := func(share Share) [32]byte {
+		h, err := HashShare(share)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return h
+	}
+
+	type expectedEntry struct {
+		step      string
+		direction AuditDirection
+		hash      [32]byte
+	}
+
+	expected := make([][]expectedEntry, parties)
+
+	// The limbs are advanced in decreasing order, each through all its rounds
+	for limb := pipelines[0].Limbs() - 1; limb >= 0; limb-- {
+
+		samples := make([][]*ring.Poly, parties)
+		for i := range pipelines {
+			if samples[i], err = pipelines[i].GenSamples(limb); err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		aggregatedSamples := make([][][2]*ring.Poly, parties)
+		for i := range pipelines {
+			if aggregatedSamples[i], err = pipelines[i].Aggregate(limb, samples); err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		keySwitched := make([][]*ring.Poly, parties)
+		for i := range pipelines {
+			if keySwitched[i], err = pipelines[i].KeySwitch(limb, aggregatedSamples); err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		for i := range pipelines {
+			if _, err = pipelines[i].ComputeEVK(limb, keySwitched); err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		for i, ekg := range ekgs {
+
+			step := func(name string) string {
+				return fmt.Sprintf("%s/%d", name, limb)
+			}
+
+			expected[i] = append(expected[i], expectedEntry{step("GenSamples"), AuditSent, hash(ekg.NewShareRoundOne([][]*ring.Poly{samples[i]}))})
+			for j := range samples {
+				expected[i] = append(expected[i], expectedEntry{step("Aggregate"), AuditReceived, hash(ekg.NewShareRoundOne([][]*ring.Poly{samples[j]}))})
+			}
+			expected[i] = append(expected[i], expectedEntry{step("Aggregate"), AuditSent, hash(ekg.NewShareRoundTwo([][][2]*ring.Poly{aggregatedSamples[i]}))})
+			for j := range aggregatedSamples {
+				expected[i] = append(expected[i], expectedEntry{step("Sum"), AuditReceived, hash(ekg.NewShareRoundTwo([][][2]*ring.Poly{aggregatedSamples[j]}))})
+			}
+			expected[i] = append(expected[i], expectedEntry{step("KeySwitch"), AuditSent, hash(ekg.NewShareRoundThree([][]*ring.Poly{keySwitched[i]}))})
+			for j := range keySwitched {
+				expected[i] = append(expected[i], expectedEntry{step("ComputeEVK"), AuditReceived, hash(ekg.NewShareRoundThree([][]*ring.Poly{keySwitched[j]}))})
+			}
+		}
+	}
+
+	for i := range ekgs {
+
+		entries := loggers[i].Entries()
+
+		if len(entries) != len(expected[i]) {
+			t.Fatalf("error : party %d, the log has %d entries, want %d", i, len(entries), len(expected[i]))
+		}
+
+		for k, entry := range entries {
+
+			if entry.Step != expected[i][k].step || entry.Direction != expected[i][k].direction {
+				t.Errorf("error : party %d, entry %d is (%s, %d), want (%s, %d)", i, k, entry.Step, entry.Direction, expected[i][k].step, expected[i][k].direction)
+			}
+
+			if entry.Hash != expected[i][k].hash {
+				t.Errorf("error : party %d, entry %d (%s) has a wrong hash", i, k, entry.Step)
+			}
+		}
+	}
+
+	// The share sent by a party is the one recorded as received by the others
+	if loggers[0].Entries()[0].Hash != loggers[1].Entries()[1].Hash {
+		t.Errorf("error : the hash of the share sent by party 0 does not match the hash received by party 1")
+	}
+}
+
+func Test_CkgEkgProtocol(t *testing.T) {
+
+	bfvContext, err := bfv.NewBfvContextWithParam(&bfv.DefaultParams[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	context := bfvContext.ContextQ()
+	contextT := bfvContext.ContextT()
+	kgen := bfvContext.NewKeyGenerator()
+
+	parties := 3
+	bitDecomp := uint64(60)
+
+	sk := make([]*ring.Poly, parties)
+	u := make([]*ring.Poly, parties)
+	protocols := make([]*CkgEkgProtocol, parties)
+
+	skCollective := context.NewPoly()
+
+	for i := 0; i < parties; i++ {
+
+		sk[i] = kgen.NewSecretKey().Get()
+		context.Add(skCollective, sk[i], skCollective)
+
+		crpGenerator, _ := NewCRPGenerator(nil, context)
+		crpGenerator.Seed([]byte{})
+
+		if protocols[i], err = NewCkgEkgProtocol(context, bitDecomp, crpGenerator); err != nil {
+			t.Fatal(err)
+		}
+
+		u[i], _ = protocols[i].EkgProtocol().NewEphemeralKey(1.0 / 3)
+	}
+
+	if _, _, err := protocols[0].Finalize(nil); err == nil {
+		t.Errorf("error : CkgEkgProtocol finalized before the last round")
+	}
+
+	// Round 1
+	ckgShares := make([]*ring.Poly, parties)
 	samples := make([][][]*ring.Poly, parties)
 	for i := 0; i < parties; i++ {
-		samples[i] = ekgProtocols[i].GenSamples(ephemeralKeys[i], sk[i].Get(), crp[i])
+		ckgShares[i], samples[i] = protocols[i].GenShares(u[i], sk[i])
+	}
+
+	// Round 2
+	aggregated := make([][][][2]*ring.Poly, parties)
+	for i := 0; i < parties; i++ {
+		aggregated[i] = protocols[i].Aggregate(sk[i], ckgShares, samples)
+	}
+
+	// Round 3
+	keySwitched := make([][][]*ring.Poly, parties)
+	for i := 0; i < parties; i++ {
+		keySwitched[i] = protocols[i].KeySwitch(u[i], sk[i], aggregated)
+	}
+
+	pk := make([]*bfv.PublicKey, parties)
+	rlk := make([]*bfv.EvaluationKey, parties)
+	for i := 0; i < parties; i++ {
+
+		// Each party finalizes on its own copy of the broadcast shares, which are wiped
+		received := make([][][]*ring.Poly, parties)
+		for j := range keySwitched {
+			received[j] = make([][]*ring.Poly, len(keySwitched[j]))
+			for k := range keySwitched[j] {
+				received[j][k] = make([]*ring.Poly, len(keySwitched[j][k]))
+				for w := range keySwitched[j][k] {
+					received[j][k][w] = keySwitched[j][k][w].CopyNew()
+				}
+			}
+		}
+
+		if pk[i], rlk[i], err = protocols[i].Finalize(received); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	for i := 1; i < parties; i++ {
+		if !context.Equal(pk[0].Get()[0], pk[i].Get()[0]) || !context.Equal(pk[0].Get()[1], pk[i].Get()[1]) {
+			t.Errorf("error : parties %d and 0 hold different collective public-keys", i)
+		}
+	}
+
+	skTest := new(bfv.SecretKey)
+	skTest.Set(skCollective)
+
+	encoder, err := bfvContext.NewBatchEncoder()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	encryptor, err := bfvContext.NewEncryptorFromPk(pk[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	decryptor, err := bfvContext.NewDecryptor(skTest)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	evaluator := bfvContext.NewEvaluator()
+
+	coeffs := contextT.NewUniformPoly()
+	plaintext := bfvContext.NewPlaintext()
+	encoder.EncodeUint(coeffs.Coeffs[0], plaintext)
+
+	ciphertext, err := encryptor.EncryptNew(plaintext)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if equalslice(coeffs.Coeffs[0], encoder.DecodeUint(decryptor.DecryptNew(ciphertext))) != true {
+		t.Errorf("error : collective public-key bad decrypt")
+	}
+
+	coeffsMul := contextT.NewPoly()
+	contextT.MulCoeffs(coeffs, coeffs, coeffsMul)
+
+	ciphertextMul, err := evaluator.MulNew(ciphertext, ciphertext)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for i := 0; i < parties; i++ {
+
+		ciphertextRelin := bfvContext.NewCiphertext(1)
+
+		if err := evaluator.Relinearize(ciphertextMul, rlk[i], ciphertextRelin); err != nil {
+			t.Fatal(err)
+		}
+
+		if equalslice(coeffsMul.Coeffs[0], encoder.DecodeUint(decryptor.DecryptNew(ciphertextRelin))) != true {
+			t.Errorf("error : collective evaluation-key of party %d bad decrypt", i)
+		}
+	}
+}
+
+func Test_RefreshProtocol(t *testing.T) {
+
+	bfvContext, err := bfv.NewBfvContextWithParam(&bfv.DefaultParams[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	context := bfvContext.ContextQ()
+	kgen := bfvContext.NewKeyGenerator()
+
+	parties := 2
+
+	sk := make([]*ring.Poly, parties)
+	skCollective := context.NewPoly()
+	for i := range sk {
+		sk[i] = kgen.NewSecretKey().Get()
+		context.Add(skCollective, sk[i], skCollective)
+	}
+
+	skTest := new(bfv.SecretKey)
+	skTest.Set(skCollective)
+
+	pk := kgen.NewPublicKey(skTest)
+
+	encoder, err := bfvContext.NewBatchEncoder()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	encryptor, err := bfvContext.NewEncryptorFromPk(pk)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	decryptor, err := bfvContext.NewDecryptor(skTest)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	coeffs := bfvContext.ContextT().NewUniformPoly()
+	plaintext := bfvContext.NewPlaintext()
+	encoder.EncodeUint(coeffs.Coeffs[0], plaintext)
+
+	ciphertext, err := encryptor.EncryptNew(plaintext)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The flooding noise is as large as the correctness allows, 2^floodBits * parties < Q/(2t), and must hide the residual noise of the
+	// ciphertext, which is brought 40 bits below it
+	floodBits := bfvContext.LogQ() - uint64(bits.Len64(bfvContext.T())) - 1 - 8
+
+	if _, err := NewRefreshProtocol(bfvContext, floodBits+8); err == nil {
+		t.Errorf("error : NewRefreshProtocol accepted a flooding noise as large as Q/(2t)")
+	}
+
+	bfvContext.AddNoise(ciphertext, decryptor.NoiseBudget(ciphertext)-48, skTest)
+
+	budgetBefore := decryptor.NoiseBudget(ciphertext)
+
+	if equalslice(coeffs.Coeffs[0], encoder.DecodeUint(decryptor.DecryptNew(ciphertext))) != true {
+		t.Fatalf("error : high-noise ciphertext bad decrypt before the refresh")
+	}
+
+	crpGenerator, _ := NewCRPGenerator(nil, context)
+	crpGenerator.Seed([]byte{})
+	crp := crpGenerator.Clock()
+
+	refresh := make([]*RefreshProtocol, parties)
+	shares := make([]*RefreshShare, parties)
+	for i := range refresh {
+		if refresh[i], err = NewRefreshProtocol(bfvContext, floodBits); err != nil {
+			t.Fatal(err)
+		}
+		shares[i] = refresh[i].AllocateShares()
+		if err := refresh[i].GenShare(sk[i], ciphertext, crp, shares[i]); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	aggregated := refresh[0].AllocateShares()
+	for i := range shares {
+		refresh[0].AggregateShares(aggregated, shares[i], aggregated)
+	}
+
+	ciphertextRefreshed := bfvContext.NewCiphertext(1)
+	if err := refresh[0].Finalize(ciphertext, crp, aggregated, ciphertextRefreshed); err != nil {
+		t.Fatal(err)
+	}
+
+	budgetAfter := decryptor.NoiseBudget(ciphertextRefreshed)
+
+	if budgetAfter <= budgetBefore {
+		t.Errorf("error : noise budget of the refreshed ciphertext %d not larger than before the refresh %d", budgetAfter, budgetBefore)
+	}
+
+	if equalslice(coeffs.Coeffs[0], encoder.DecodeUint(decryptor.DecryptNew(ciphertextRefreshed))) != true {
+		t.Errorf("error : refreshed ciphertext bad decrypt")
+	}
+
+	// The refresh can be done in place
+	if err := refresh[0].Finalize(ciphertext, crp, aggregated, ciphertext); err != nil {
+		t.Fatal(err)
+	}
+
+	if equalslice(coeffs.Coeffs[0], encoder.DecodeUint(decryptor.DecryptNew(ciphertext))) != true {
+		t.Errorf("error : ciphertext refreshed in place bad decrypt")
+	}
+
+	t.Logf("flooding noise : 2^%d, noise budget before the refresh : %d, after : %d", floodBits, budgetBefore, budgetAfter)
+}
+
+func Test_PCKSAccumulator(t *testing.T) {
+
+	bfvContext, err := bfv.NewBfvContextWithParam(&bfv.DefaultParams[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	context := bfvContext.ContextQ()
+	kgen := bfvContext.NewKeyGenerator()
+
+	parties := 3
+
+	sk := make([]*ring.Poly, parties)
+	skCollective := context.NewPoly()
+	for i := range sk {
+		sk[i] = kgen.NewSecretKey().Get()
+		context.Add(skCollective, sk[i], skCollective)
+	}
+
+	skInput := new(bfv.SecretKey)
+	skInput.Set(skCollective)
+
+	// The receiver's fresh key-pair
+	skOutput, pkOutput := kgen.NewKeyPair()
+
+	encoder, err := bfvContext.NewBatchEncoder()
+	if err != nil {
+		t.Fatal(err)
 	}
 
-	//ROUND 2
-	aggregatedSamples := make([][][][2]*ring.Poly, parties)
-	for i := 0; i < parties; i++ {
-		aggregatedSamples[i] = ekgProtocols[i].Aggregate(sk[i].Get(), samples, crp[i])
+	encryptor, err := bfvContext.NewEncryptorFromPk(kgen.NewPublicKey(skInput))
+	if err != nil {
+		t.Fatal(err)
 	}
 
-	// ROUND 3
-	keySwitched := make([][][]*ring.Poly, parties)
-	sum := make([][][][2]*ring.Poly, parties)
+	decryptor, err := bfvContext.NewDecryptor(skOutput)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	coeffs := bfvContext.ContextT().NewUniformPoly()
+	plaintext := bfvContext.NewPlaintext()
+	encoder.EncodeUint(coeffs.Coeffs[0], plaintext)
+
+	ciphertext, err := encryptor.EncryptNew(plaintext)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The coordinator folds the serialized shares one at a time, as they arrive
+	coordinator := NewPCKS(nil, [2]*ring.Poly{}, context, 6.36)
+	aggregator := NewAggregator(coordinator.NewShareEmpty())
+	accumulated := coordinator.NewShareEmpty()
+
 	for i := 0; i < parties; i++ {
-		sum[i] = ekgProtocols[i].Sum(aggregatedSamples)
-		keySwitched[i] = ekgProtocols[i].KeySwitch(ephemeralKeys[i], sk[i].Get(), sum[i])
+
+		pcks := NewPCKS(nil, [2]*ring.Poly{}, context, 6.36)
+
+		share := pcks.NewShareEmpty()
+		if err := pcks.GenShare(sk[i], pkOutput, ciphertext, share); err != nil {
+			t.Fatal(err)
+		}
+
+		data, err := share.MarshalBinary()
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		received := coordinator.NewShareEmpty()
+		if err := received.UnMarshalBinary(data); err != nil {
+			t.Fatal(err)
+		}
+
+		coordinator.AggregateShares(accumulated, received, accumulated)
+
+		if err := aggregator.Fold(uint32(i), received); err != nil {
+			t.Fatal(err)
+		}
 	}
 
-	// ROUND 4
-	collectiveEvaluationKey := make([][][][2]*ring.Poly, parties)
+	folded := aggregator.Aggregate().(*PCKSShare)
+
+	if !context.Equal(folded.Value[0], accumulated.Value[0]) || !context.Equal(folded.Value[1], accumulated.Value[1]) {
+		t.Errorf("error : PCKS shares folded by the Aggregator and by AggregateShares differ")
+	}
+
+	ciphertextOut := bfvContext.NewCiphertext(1)
+	if err := coordinator.Finalize(ciphertext, accumulated, ciphertextOut); err != nil {
+		t.Fatal(err)
+	}
+
+	if equalslice(coeffs.Coeffs[0], encoder.DecodeUint(decryptor.DecryptNew(ciphertextOut))) != true {
+		t.Errorf("error : PCKS accumulated shares bad decrypt under the receiver's key")
+	}
+}
+
+func Test_EstimateProtocolMemory(t *testing.T) {
+
+	bfvContext, err := bfv.NewBfvContextWithParam(&bfv.DefaultParams[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	context := bfvContext.ContextQ()
+	kgen := bfvContext.NewKeyGenerator()
+
+	for _, test := range []struct {
+		bitDecomp uint64
+		parties   int
+	}{
+		{60, 3},
+		{20, 5},
+	} {
+
+		t.Run(fmt.Sprintf("bitDecomp=%d/parties=%d", test.bitDecomp, test.parties), func(t *testing.T) {
+
+			bitDecomp, parties := test.bitDecomp, test.parties
+
+			sk := make([]*ring.Poly, parties)
+			for i := range sk {
+				sk[i] = kgen.NewSecretKey().Get()
+			}
+
+			var before, after runtime.MemStats
+			runtime.GC()
+			runtime.ReadMemStats(&before)
+
+			crpGenerator, _ := NewCRPGenerator(nil, context)
+			crpGenerator.Seed([]byte{})
+			crp := crpGenerator.ClockNew(NewEkgProtocol(context, bitDecomp))
+
+			ekg := NewEkgProtocol(context, bitDecomp)
+
+			u := make([]*ring.Poly, parties)
+			samples := make([][][]*ring.Poly, parties)
+			for i := 0; i < parties; i++ {
+				u[i], _ = ekg.NewEphemeralKey(1.0 / 3)
+				samples[i] = ekg.GenSamples(u[i], sk[i], crp)
+			}
+
+			aggregated := make([][][][2]*ring.Poly, parties)
+			for i := 0; i < parties; i++ {
+				aggregated[i] = ekg.Aggregate(sk[i], samples, crp)
+			}
+
+			sum := ekg.Sum(aggregated)
+
+			keySwitched := make([][][]*ring.Poly, parties)
+			for i := 0; i < parties; i++ {
+				keySwitched[i] = ekg.KeySwitch(u[i], sk[i], sum)
+			}
+
+			evk := ekg.ComputeEVK(keySwitched, sum)
+
+			runtime.ReadMemStats(&after)
+
+			measured := int64(after.TotalAlloc - before.TotalAlloc)
+			estimate := EstimateProtocolMemory(bfvContext, bitDecomp, parties)
+
+			// The shares account for the bulk of the estimate
+			shares := ekg.NewShareRoundOne(samples[0]).MemoryBytes() + ekg.NewShareRoundTwo(aggregated[0]).MemoryBytes() + ekg.NewShareRoundThree(keySwitched[0]).MemoryBytes()
+			if shares*int64(parties) > estimate {
+				t.Errorf("error : estimate %d smaller than the size of the shares %d", estimate, shares*int64(parties))
+			}
+
+			// The measured allocations include the temporaries of the samplers and of the NTT
+			if float64(estimate) < float64(measured)/2 || float64(estimate) > float64(measured)*2 {
+				t.Errorf("error : estimate %d not within a factor 2 of the measured allocations %d", estimate, measured)
+			}
+
+			t.Logf("estimate : %d bytes, measured : %d bytes", estimate, measured)
+
+			runtime.KeepAlive(evk)
+		})
+	}
+}
+
+func Test_EphemeralKeyReuseDetection(t *testing.T) {
+
+	bfvContext, err := bfv.NewBfvContextWithParam(&bfv.DefaultParams[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	context := bfvContext.ContextQ()
+	sk := bfvContext.NewKeyGenerator().NewSecretKey().Get()
+
+	bitDecomp := uint64(60)
+
+	crpGenerator, err := NewCRPGenerator(nil, context)
+	if err != nil {
+		t.Fatal(err)
+	}
+	crp := crpGenerator.ClockNew(NewEkgProtocol(context, bitDecomp))
+
+	ekg := NewEkgProtocol(context, bitDecomp)
+
+	u, err := ekg.NewEphemeralKey(1.0 / 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Disabled by default
+	for i := 0; i < 2; i++ {
+		if _, err := ekg.GenSamplesChecked(u, sk, crp); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	ekg.SetEphemeralKeyReuseDetection(true)
+
+	samples, err := ekg.GenSamplesChecked(u, sk, crp)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(samples) != len(context.Modulus) {
+		t.Errorf("error : GenSamplesChecked returned the samples of %d moduli, want %d", len(samples), len(context.Modulus))
+	}
+
+	if _, err := ekg.GenSamplesChecked(u, sk, crp); err == nil {
+		t.Errorf("error : GenSamplesChecked accepted an ephemeral key used in a previous run")
+	}
+
+	// A fresh ephemeral key is accepted
+	v, err := ekg.NewEphemeralKey(1.0 / 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := ekg.GenSamplesChecked(v, sk, crp); err != nil {
+		t.Errorf("error : GenSamplesChecked rejected a fresh ephemeral key : %v", err)
+	}
+
+	ekg.ResetEphemeralKeyHistory()
+
+	if _, err := ekg.GenSamplesChecked(u, sk, crp); err != nil {
+		t.Errorf("error : GenSamplesChecked rejected an ephemeral key after the reset of the history : %v", err)
+	}
+}
+
+// newSmallNContext returns a ring context of degree N=16 with two NTT-friendly moduli of 60 bits, to run the EkgProtocol on a toy ring.
+func newSmallNContext(t *testing.T) *ring.Context {
+
+	N := uint64(16)
+
+	moduli, err := ring.GenerateNTTPrimes(N, (1<<59)+1, 2, 60, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	context, err := ring.NewContextWithParameters(N, moduli)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return context
+}
+
+func Test_EkgProtocolSmallN(t *testing.T) {
+
+	// The size of the ring is not validated, only the NTT-friendliness of the moduli
+	context := newSmallNContext(t)
+	N := context.N
+
+	parties := 3
+	bitDecomp := uint64(60)
+
+	ternarySampler := context.NewTernarySampler()
+
+	ekg := make([]*EkgProtocol, parties)
+	sk := make([]*bfv.SecretKey, parties)
+	ephemeralKeys := make([]*ring.Poly, parties)
+	crp := make([][][]*ring.Poly, parties)
+
+	skIdeal := context.NewPoly()
+
 	for i := 0; i < parties; i++ {
-		collectiveEvaluationKey[i] = ekgProtocols[i].ComputeEVK(keySwitched, sum[i])
+
+		sk[i] = new(bfv.SecretKey)
+		s, _ := ternarySampler.SampleMontgomeryNTTNew(1.0 / 3)
+		sk[i].Set(s)
+		context.Add(skIdeal, s, skIdeal)
+
+		// Zero noise
+		ekg[i] = NewEkgProtocol(context, bitDecomp)
+		ekg[i].SetGaussianSampler(newTestMockSampler(t, context.NewPoly()), DefaultBound)
+
+		ephemeralKeys[i], _ = ekg[i].NewEphemeralKey(1.0 / 3)
 	}
 
-	return collectiveEvaluationKey
+	crpGenerator, _ := NewCRPGenerator(nil, context)
+	crpGenerator.Seed([]byte{})
+	crp[0] = make([][]*ring.Poly, len(context.Modulus))
+	for j := range context.Modulus {
+		crp[0][j] = []*ring.Poly{crpGenerator.Clock()}
+	}
+	for i := 1; i < parties; i++ {
+		crp[i] = crp[0]
+	}
+
+	evk := test_EKG_Protocol(parties, ekg, sk, ephemeralKeys, crp)
+
+	// Without noise, evk[i][0] + evk[i][1]*s = s^2 * w_i exactly, with w_i the i-th element of the CRT basis
+	have := context.NewPoly()
+	want := context.NewPoly()
+
+	for p := 0; p < parties; p++ {
+		for i := range context.Modulus {
+
+			context.MulCoeffsMontgomery(evk[p][i][0][1], skIdeal, have)
+			context.Add(have, evk[p][i][0][0], have)
+
+			want.Zero()
+			copy(want.Coeffs[i], skIdeal.Coeffs[i])
+			context.MulCoeffsMontgomery(want, skIdeal, want)
+
+			if context.Equal(have, want) != true {
+				t.Errorf("error : party %d, the noiseless ekg key relation does not hold at N=%d for limb %d", p, N, i)
+			}
+		}
+	}
+}
+
+func Test_EkgProtocolSeededSamplers(t *testing.T) {
+
+	context := newSmallNContext(t)
+
+	parties := 3
+	bitDecomp := uint64(20)
+
+	// run executes the protocol with samplers seeded from seed and returns the encoding of the shares of the first round
+	// followed by the encoding of the collective evaluation key
+	run := func(seed int64) []byte {
+
+		ternarySampler := context.NewTernarySampler()
+		ternarySampler.SetSource(rand.New(rand.NewSource(seed)))
+
+		ekg := make([]*EkgProtocol, parties)
+		sk := make([]*bfv.SecretKey, parties)
+		ephemeralKeys := make([]*ring.Poly, parties)
+		crp := make([][][]*ring.Poly, parties)
+
+		for i := 0; i < parties; i++ {
+
+			sk[i] = new(bfv.SecretKey)
+			s, _ := ternarySampler.SampleMontgomeryNTTNew(1.0 / 3)
+			sk[i].Set(s)
+
+			partyTernarySampler := context.NewTernarySampler()
+			partyTernarySampler.SetSource(rand.New(rand.NewSource(seed + int64(2*i) + 1)))
+
+			// An ErrorSampler, whose lazily built KYSampler must also read from the source
+			partyGaussianSampler, err := context.NewErrorSampler(ring.DiscreteGaussian, DefaultSigma)
+			if err != nil {
+				t.Fatal(err)
+			}
+			partyGaussianSampler.SetSource(rand.New(rand.NewSource(seed + int64(2*i) + 2)))
+
+			ekg[i] = NewEkgProtocol(context, bitDecomp)
+			ekg[i].SetTernarySampler(partyTernarySampler)
+			ekg[i].SetGaussianSampler(partyGaussianSampler, DefaultBound)
+
+			ephemeralKeys[i], _ = ekg[i].NewEphemeralKey(1.0 / 3)
+		}
+
+		crpGenerator, _ := NewCRPGenerator(nil, context)
+		crpGenerator.Seed([]byte{})
+		crp[0] = make([][]*ring.Poly, len(context.Modulus))
+		for j := range context.Modulus {
+			crp[0][j] = make([]*ring.Poly, ekg[0].bitLog)
+			for u := range crp[0][j] {
+				crp[0][j][u] = crpGenerator.Clock()
+			}
+		}
+		for i := 1; i < parties; i++ {
+			crp[i] = crp[0]
+		}
+
+		var buffer bytes.Buffer
+
+		write := func(pol *ring.Poly) {
+			data, err := pol.MarshalBinary()
+			if err != nil {
+				t.Fatal(err)
+			}
+			buffer.Write(data)
+		}
+
+		for i := 0; i < parties; i++ {
+			for _, samples := range ekg[i].GenSamples(ephemeralKeys[i], sk[i].Get(), crp[i]) {
+				for _, sample := range samples {
+					write(sample)
+				}
+			}
+		}
+
+		for _, evk := range test_EKG_Protocol(parties, ekg, sk, ephemeralKeys, crp)[0] {
+			for _, sample := range evk {
+				write(sample[0])
+				write(sample[1])
+			}
+		}
+
+		return buffer.Bytes()
+	}
+
+	if !bytes.Equal(run(42), run(42)) {
+		t.Errorf("error : two runs of the EkgProtocol with identically seeded samplers produce different shares")
+	}
+
+	if bytes.Equal(run(42), run(43)) {
+		t.Errorf("error : two runs of the EkgProtocol with differently seeded samplers produce the same shares")
+	}
+}
+
+func Test_CollectiveDecryptionWithSigma(t *testing.T) {
+
+	bfvContext, err := bfv.NewBfvContextWithParam(&bfv.DefaultParams[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	context := bfvContext.ContextQ()
+	kgen := bfvContext.NewKeyGenerator()
+
+	parties := 3
+
+	// The secret-shares of the parties sum to the collective secret-key
+	sk := make([]*ring.Poly, parties)
+	skCollective := context.NewPoly()
+	for i := range sk {
+		sk[i] = kgen.NewSecretKey().Get()
+		context.Add(skCollective, sk[i], skCollective)
+	}
+
+	skIdeal := new(bfv.SecretKey)
+	skIdeal.Set(skCollective)
+
+	encoder, err := bfvContext.NewBatchEncoder()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	encryptor, err := bfvContext.NewEncryptorFromPk(kgen.NewPublicKey(skIdeal))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	decryptor, err := bfvContext.NewDecryptor(skIdeal)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	coeffs := bfvContext.ContextT().NewUniformPoly()
+
+	plaintext := bfvContext.NewPlaintext()
+	if err := encoder.EncodeUint(coeffs.Coeffs[0], plaintext); err != nil {
+		t.Fatal(err)
+	}
+
+	ciphertext, err := encryptor.EncryptNew(plaintext)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := NewCollectiveDecryptionWithSigma(context, 0); err == nil {
+		t.Errorf("error : NewCollectiveDecryptionWithSigma accepted a sigma of 0")
+	}
+
+	if NewCollectiveDecryption(context).GenShare(sk[0], ciphertext, context.NewPoly()) == nil {
+		t.Errorf("error : GenShare accepted a CollectiveDecryption without sigmaSmudging")
+	}
+
+	for _, sigmaSmudging := range []float64{3.19, 1 << 40} {
+
+		t.Run(fmt.Sprintf("sigmaSmudging=%.2f", sigmaSmudging), func(t *testing.T) {
+
+			shares := make([]*ring.Poly, parties)
+
+			for i := range shares {
+
+				cd, err := NewCollectiveDecryptionWithSigma(context, sigmaSmudging)
+				if err != nil {
+					t.Fatal(err)
+				}
+
+				if cd.SigmaSmudging() != sigmaSmudging {
+					t.Errorf("error : SigmaSmudging is %f, want %f", cd.SigmaSmudging(), sigmaSmudging)
+				}
+
+				shares[i] = context.NewPoly()
+				if err := cd.GenShare(sk[i], ciphertext, shares[i]); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			// The smudging noise e_0 = share_0 - sk_0 * ct[1] reaches the order of sigmaSmudging
+			noise := context.NewPoly()
+			context.NTT(ciphertext.Value()[1], noise)
+			context.MulCoeffsMontgomery(noise, sk[0], noise)
+			context.InvNTT(noise, noise)
+			context.Sub(shares[0], noise, noise)
+
+			q0 := context.Modulus[0]
+			maxNoise := uint64(0)
+			for _, c := range noise.Coeffs[0] {
+				if c > q0>>1 {
+					c = q0 - c
+				}
+				if c > maxNoise {
+					maxNoise = c
+				}
+			}
+
+			if float64(maxNoise) < sigmaSmudging || float64(maxNoise) > 10*sigmaSmudging {
+				t.Errorf("error : largest smudging noise %d not of the order of sigmaSmudging %f", maxNoise, sigmaSmudging)
+			}
+
+			plaintextJoint := bfvContext.NewPlaintext()
+			if err := NewCollectiveDecryption(context).AggregateShares(ciphertext, shares, plaintextJoint); err != nil {
+				t.Fatal(err)
+			}
+
+			have := encoder.DecodeUint(plaintextJoint)
+			want := encoder.DecodeUint(decryptor.DecryptNew(ciphertext))
+
+			if equalslice(have, want) != true || equalslice(want, coeffs.Coeffs[0]) != true {
+				t.Errorf("error : the joint decryption does not match the central decryption")
+			}
+		})
+	}
+}
+
+func Test_EkgVerifyRoundOne(t *testing.T) {
+
+	bfvContext, err := bfv.NewBfvContextWithParam(&bfv.DefaultParams[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	context := bfvContext.ContextQ()
+	kgen := bfvContext.NewKeyGenerator()
+
+	sk := kgen.NewSecretKey().Get()
+
+	ekg := NewEkgProtocol(context, 20)
+
+	u, err := ekg.NewEphemeralKey(1.0 / 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	crpGenerator, _ := NewCRPGenerator(nil, context)
+	crpGenerator.Seed([]byte{})
+
+	crp := make([][]*ring.Poly, len(context.Modulus))
+	for i := range crp {
+		crp[i] = make([]*ring.Poly, ekg.bitLog)
+		for w := range crp[i] {
+			crp[i][w] = crpGenerator.Clock()
+		}
+	}
+
+	share := ekg.NewShareRoundOne(ekg.GenSamples(u, sk, crp))
+
+	t.Run("Valid", func(t *testing.T) {
+		if err := ekg.VerifyRoundOne(share, u, sk, crp); err != nil {
+			t.Error(err)
+		}
+	})
+
+	t.Run("Corrupted", func(t *testing.T) {
+
+		corrupted := ekg.NewShareRoundOneEmpty()
+		if err := corrupted.Aggregate(share); err != nil {
+			t.Fatal(err)
+		}
+
+		if err := ekg.VerifyRoundOne(corrupted, u, sk, crp); err != nil {
+			t.Fatal(err)
+		}
+
+		qi := context.Modulus[1]
+		corrupted.Value[1][2].Coeffs[1][5] = (corrupted.Value[1][2].Coeffs[1][5] + 1) % qi
+
+		if ekg.VerifyRoundOne(corrupted, u, sk, crp) == nil {
+			t.Errorf("error : VerifyRoundOne accepted a corrupted share")
+		}
+	})
+
+	t.Run("WrongSecret", func(t *testing.T) {
+
+		if ekg.VerifyRoundOne(share, u, kgen.NewSecretKey().Get(), crp) == nil {
+			t.Errorf("error : VerifyRoundOne accepted a share generated under another secret share")
+		}
+
+		if ekg.VerifyRoundOne(share, sk, sk, crp) == nil {
+			t.Errorf("error : VerifyRoundOne accepted a share generated under another ephemeral key")
+		}
+	})
+
+	t.Run("InvalidShape", func(t *testing.T) {
+		if ekg.VerifyRoundOne(share, u, sk, crp[1:]) == nil {
+			t.Errorf("error : VerifyRoundOne accepted a crp with missing limbs")
+		}
+	})
+
+	t.Run("CustomSampler", func(t *testing.T) {
+
+		// A wider sampler whose noise exceeds DefaultBound, the bound given with the sampler must be used by VerifyRoundOne
+		ekgWide := NewEkgProtocol(context, 20)
+		ekgWide.SetGaussianSampler(context.NewKYSampler(64*DefaultSigma, 64*DefaultBound), 64*DefaultBound)
+
+		shareWide := ekgWide.NewShareRoundOne(ekgWide.GenSamples(u, sk, crp))
+
+		if err := ekgWide.VerifyRoundOne(shareWide, u, sk, crp); err != nil {
+			t.Error(err)
+		}
+
+		if ekg.VerifyRoundOne(shareWide, u, sk, crp) == nil {
+			t.Errorf("error : VerifyRoundOne accepted a noise above DefaultBound")
+		}
+	})
 }
@@ -0,0 +1,126 @@
+package dbfv
+
+import (
+	"errors"
+	"fmt"
+	"github.com/ldsec/lattigo/bfv"
+	"github.com/ldsec/lattigo/ring"
+)
+
+// HealthCheck is a self-check of the crypto stack of the target bfvcontext (context, samplers and NTT). It runs locally a minimal
+// instance of the EkgProtocol between two parties, and uses the resulting collective evaluation-key to relinearize the square of
+// an encrypted random plaintext. Returns an error if any step fails or if the result does not decrypt to the expected value.
+// It is meant to back the readiness probe of a deployment.
+func HealthCheck(bfvcontext *bfv.BfvContext) (err error) {
+
+	// A corrupted context can also make the arithmetic panic, which is reported as a failure
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("health check failed -> %v", r)
+		}
+	}()
+
+	parties := 2
+	bitDecomp := uint64(60)
+
+	context := bfvcontext.ContextQ()
+	contextT := bfvcontext.ContextT()
+
+	kgen := bfvcontext.NewKeyGenerator()
+
+	crpGenerator, err := NewCRPGenerator(nil, context)
+	if err != nil {
+		return err
+	}
+	crpGenerator.Seed([]byte{})
+
+	bitLog := (60 + bitDecomp - 1) / bitDecomp
+
+	crp := make([][]*ring.Poly, len(context.Modulus))
+	for i := range context.Modulus {
+		crp[i] = make([]*ring.Poly, bitLog)
+		for j := uint64(0); j < bitLog; j++ {
+			crp[i][j] = crpGenerator.Clock()
+		}
+	}
+
+	ekg := NewEkgProtocol(context, bitDecomp)
+
+	// The collective secret-key is the sum of the secret-keys of the parties
+	sk := kgen.NewSecretKeyEmpty()
+	skShares := make([]*ring.Poly, parties)
+	ephemeralKeys := make([]*ring.Poly, parties)
+
+	for i := 0; i < parties; i++ {
+
+		skShares[i] = kgen.NewSecretKey().Get()
+		context.Add(sk.Get(), skShares[i], sk.Get())
+
+		if ephemeralKeys[i], err = ekg.NewEphemeralKey(1.0 / 3); err != nil {
+			return err
+		}
+	}
+
+	samples := make([][][]*ring.Poly, parties)
+	for i := 0; i < parties; i++ {
+		samples[i] = ekg.GenSamples(ephemeralKeys[i], skShares[i], crp)
+	}
+
+	aggregatedSamples := make([][][][2]*ring.Poly, parties)
+	for i := 0; i < parties; i++ {
+		aggregatedSamples[i] = ekg.Aggregate(skShares[i], samples, crp)
+	}
+
+	sum := ekg.Sum(aggregatedSamples)
+
+	keySwitched := make([][][]*ring.Poly, parties)
+	for i := 0; i < parties; i++ {
+		keySwitched[i] = ekg.KeySwitch(ephemeralKeys[i], skShares[i], sum)
+	}
+
+	rlk := new(bfv.EvaluationKey)
+	rlk.SetRelinKeys([][][][2]*ring.Poly{ekg.ComputeEVK(keySwitched, sum)}, bitDecomp)
+
+	// Relinearized square of an encrypted random plaintext
+	encoder, err := bfvcontext.NewBatchEncoder()
+	if err != nil {
+		return err
+	}
+
+	encryptor, err := bfvcontext.NewEncryptorFromSk(sk)
+	if err != nil {
+		return err
+	}
+
+	decryptor, err := bfvcontext.NewDecryptor(sk)
+	if err != nil {
+		return err
+	}
+
+	evaluator := bfvcontext.NewEvaluator()
+
+	coeffs := contextT.NewUniformPoly()
+	plaintext := bfvcontext.NewPlaintext()
+	encoder.EncodeUint(coeffs.Coeffs[0], plaintext)
+
+	ciphertext, err := encryptor.EncryptNew(plaintext)
+	if err != nil {
+		return err
+	}
+
+	if ciphertext, err = evaluator.MulNew(ciphertext, ciphertext); err != nil {
+		return err
+	}
+
+	if err = evaluator.Relinearize(ciphertext, rlk, ciphertext); err != nil {
+		return err
+	}
+
+	contextT.MulCoeffs(coeffs, coeffs, coeffs)
+
+	if equalslice(coeffs.Coeffs[0], encoder.DecodeUint(decryptor.DecryptNew(ciphertext))) != true {
+		return errors.New("health check failed -> the relinearized square does not decrypt to the expected value")
+	}
+
+	return nil
+}
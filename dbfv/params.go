@@ -0,0 +1,144 @@
+package dbfv
+
+import (
+	"errors"
+	"fmt"
+	"github.com/ldsec/lattigo/bfv"
+	"github.com/ldsec/lattigo/ring"
+	"math"
+	"math/big"
+)
+
+// NegotiateParameters returns the parameter set on which parties with the given parameter preferences can agree. The
+// most conservative set is selected : the preference with the largest ring degree N, with the largest standard deviation
+// of the error among the preferences of that degree. The preferences are incompatible, and an error is returned, if they
+// do not share the same plaintext modulus, or if the preferences of the largest degree do not use the same moduli.
+func NegotiateParameters(prefs []*bfv.Parameters) (*bfv.Parameters, error) {
+
+	if len(prefs) == 0 {
+		return nil, errors.New("cannot negotiate parameters -> no preferences")
+	}
+
+	var negotiated *bfv.Parameters
+
+	for _, pref := range prefs {
+
+		if pref == nil {
+			return nil, errors.New("cannot negotiate parameters -> nil preference")
+		}
+
+		if pref.T != prefs[0].T {
+			return nil, errors.New("cannot negotiate parameters -> preferences do not share the same plaintext modulus")
+		}
+
+		if negotiated == nil || pref.N > negotiated.N {
+			negotiated = pref
+		}
+	}
+
+	sigma := negotiated.Sigma
+
+	for _, pref := range prefs {
+
+		if pref.N != negotiated.N {
+			continue
+		}
+
+		if !equalslice(pref.Qi, negotiated.Qi) || !equalslice(pref.Pi, negotiated.Pi) {
+			return nil, errors.New("cannot negotiate parameters -> preferences of the largest degree do not use the same moduli")
+		}
+
+		if pref.Sigma > sigma {
+			sigma = pref.Sigma
+		}
+	}
+
+	params := &bfv.Parameters{
+		N:     negotiated.N,
+		T:     negotiated.T,
+		Qi:    append([]uint64{}, negotiated.Qi...),
+		Pi:    append([]uint64{}, negotiated.Pi...),
+		Sigma: sigma,
+	}
+
+	return params, nil
+}
+
+// TradeoffPoint is a point of the tradeoff between the size of a relinearization key and the noise of the relinearization for a
+// given bit-decomposition (see BitDecompTradeoff).
+type TradeoffPoint struct {
+	BitDecomp uint64
+	// KeyBytes is the size in bytes of the binary encoding of the relinearization key (see RelinKeySize).
+	KeyBytes int
+	// Noise is the log2 of the infinity norm of the noise added by a relinearization.
+	Noise float64
+}
+
+// BitDecompTradeoff reports, for each of the given bit-decompositions (in [1, 60]) and for the parameters of the bfvcontext, the size
+// of the relinearization key and the noise added by a relinearization. The security being given by the parameters, which are fixed,
+// it quantifies the tradeoff between the key size, which decreases with the bit-decomposition, and the noise, which increases with it.
+// The noise is measured on the relinearization of a degree 2 ciphertext (-c2*s^2, 0, c2) with c2 uniform, which decrypts to 0 without
+// noise, under a key generated for the purpose.
+func BitDecompTradeoff(bfvcontext *bfv.BfvContext, values []uint64) ([]TradeoffPoint, error) {
+
+	context := bfvcontext.ContextQ()
+
+	kgen := bfvcontext.NewKeyGenerator()
+	sk := kgen.NewSecretKey()
+
+	decryptor, err := bfvcontext.NewDecryptor(sk)
+	if err != nil {
+		return nil, err
+	}
+
+	evaluator := bfvcontext.NewEvaluator()
+
+	points := make([]TradeoffPoint, len(values))
+
+	for i, bitDecomp := range values {
+
+		if bitDecomp == 0 || bitDecomp > 60 {
+			return nil, fmt.Errorf("cannot measure the tradeoff -> bitDecomp %d is not in the valid range [1, 60]", bitDecomp)
+		}
+
+		ciphertext := bfvcontext.NewCiphertext(2)
+		context.Copy(context.NewUniformPoly(), ciphertext.Value()[2])
+
+		// c0 = -c2*s^2, so that the ciphertext decrypts to 0 without noise
+		phase := decryptor.DecryptNew(ciphertext)
+		context.Neg(phase.Value()[0], ciphertext.Value()[0])
+
+		rlk := kgen.NewRelinKey(sk, 1, bitDecomp)
+
+		relinearized := bfvcontext.NewCiphertext(1)
+		if err = evaluator.Relinearize(ciphertext, rlk, relinearized); err != nil {
+			return nil, err
+		}
+
+		points[i] = TradeoffPoint{
+			BitDecomp: bitDecomp,
+			KeyBytes:  RelinKeySize(bfvcontext, bitDecomp),
+			Noise:     phaseNorm(context, decryptor.DecryptNew(relinearized).Value()[0]),
+		}
+	}
+
+	return points, nil
+}
+
+// phaseNorm returns the log2 of the infinity norm of the centered coefficients of the phase.
+func phaseNorm(context *ring.Context, phase *ring.Poly) float64 {
+
+	coeffsBigint := make([]*ring.Int, context.N)
+
+	context.PolyToBigint(phase, coeffsBigint)
+
+	norm := math.Inf(-1)
+	for _, coeff := range coeffsBigint {
+		coeff.Center(context.ModulusBigint)
+		coeff.Value.Abs(&coeff.Value)
+		value, _ := new(big.Float).SetInt(&coeff.Value).Float64()
+		norm = math.Max(norm, math.Log2(value))
+	}
+
+	return norm
+}
@@ -0,0 +1,7 @@
+package dbfv
+
+// Protocol is the interface implemented by the dbfv protocols, so that an orchestrator can drive any of them generically.
+type Protocol interface {
+	// RoundCount returns the number of rounds of communication among the parties required by the protocol.
+	RoundCount() int
+}
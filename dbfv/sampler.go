@@ -0,0 +1,70 @@
+package dbfv
+
+import (
+	"errors"
+	"github.com/ldsec/lattigo/ring"
+)
+
+// GaussianSampler is the interface of the samplers of the error polynomials of the EkgProtocol protocol. It is implemented by
+// ring.KYSampler, ring.ErrorSampler (to sample the errors within another family of distributions) and MockSampler.
+type GaussianSampler interface {
+	// SampleNTTNew returns a new error polynomial in the NTT domain.
+	SampleNTTNew() *ring.Poly
+	// SampleNTT samples an error polynomial in the NTT domain on the input polynomial.
+	SampleNTT(pol *ring.Poly)
+}
+
+// TernarySampler is the interface of the samplers of the ephemeral keys of the EkgProtocol protocol. It is implemented by
+// ring.TernarySampler and MockSampler.
+type TernarySampler interface {
+	// SampleMontgomeryNTTNew returns a new ternary polynomial in the Montgomery and NTT domain, with coefficients
+	// sampled in {-1, 0, 1} with probabilities [(1-p)/2, p, (1-p)/2].
+	SampleMontgomeryNTTNew(p float64) (*ring.Poly, error)
+}
+
+// MockSampler is a GaussianSampler and TernarySampler returning caller-scripted polynomials instead of random ones. It allows
+// to make the protocols fully deterministic, e.g. to test them with zero noise.
+//
+// Each call returns a copy of the next polynomial of the script, cycling back to the first one once the script is exhausted.
+// The scripted polynomials are returned as they are, it is up to the caller to provide them in the domain expected by the protocol.
+type MockSampler struct {
+	polys []*ring.Poly
+	next  int
+}
+
+// NewMockSampler creates a new MockSampler returning, in a round-robin fashion, copies of the given polynomials.
+// Returns an error if no polynomial is given.
+func NewMockSampler(polys ...*ring.Poly) (*MockSampler, error) {
+
+	if len(polys) == 0 {
+		return nil, errors.New("cannot create MockSampler -> no polynomial to return")
+	}
+
+	return &MockSampler{polys: polys}, nil
+}
+
+// Calls returns the number of polynomials returned so far by the MockSampler.
+func (sampler *MockSampler) Calls() int {
+	return sampler.next
+}
+
+func (sampler *MockSampler) nextPoly() *ring.Poly {
+	pol := sampler.polys[sampler.next%len(sampler.polys)]
+	sampler.next++
+	return pol
+}
+
+// SampleNTTNew returns a copy of the next scripted polynomial.
+func (sampler *MockSampler) SampleNTTNew() *ring.Poly {
+	return sampler.nextPoly().CopyNew()
+}
+
+// SampleNTT copies the next scripted polynomial on the input polynomial.
+func (sampler *MockSampler) SampleNTT(pol *ring.Poly) {
+	pol.Copy(sampler.nextPoly())
+}
+
+// SampleMontgomeryNTTNew returns a copy of the next scripted polynomial, p is ignored.
+func (sampler *MockSampler) SampleMontgomeryNTTNew(p float64) (*ring.Poly, error) {
+	return sampler.nextPoly().CopyNew(), nil
+}
@@ -0,0 +1,521 @@
+package dbfv
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"github.com/ldsec/lattigo/ring"
+	"math/bits"
+)
+
+// Share is the interface implemented by the shares exchanged among the parties during the rounds of the dbfv protocols.
+// It allows to aggregate, copy and serialize the shares independently of the protocol they belong to.
+type Share interface {
+	// Aggregate adds the other share to the target share.
+	Aggregate(other Share) error
+	// Copy returns a deep copy of the target share.
+	Copy() Share
+	// MarshalBinary encodes the target share on a byte slice.
+	MarshalBinary() ([]byte, error)
+	// UnMarshalBinary decodes a previously marshaled share on the target share, which must be of the appropriate format.
+	UnMarshalBinary(data []byte) error
+}
+
+// AggregateShares returns a new share equal to the aggregation of all the given shares, which must be of the same type.
+func AggregateShares(shares []Share) (Share, error) {
+
+	if len(shares) == 0 {
+		return nil, errors.New("cannot aggregate shares -> no shares")
+	}
+
+	aggregated := shares[0].Copy()
+
+	for i := 1; i < len(shares); i++ {
+		if err := aggregated.Aggregate(shares[i]); err != nil {
+			return nil, err
+		}
+	}
+
+	return aggregated, nil
+}
+
+// FoldShares aggregates the given shares, which must be of the same type, on a new share. It is a variant of AggregateShares
+// for which an empty list of shares is not an error: the fold then returns a copy of identity, the empty share of the expected
+// type (e.g. ekg.NewShareRoundOneEmpty()), which is also the starting point of the aggregation.
+func FoldShares(identity Share, shares ...Share) (Share, error) {
+
+	folded := identity.Copy()
+
+	for _, share := range shares {
+		if err := folded.Aggregate(share); err != nil {
+			return nil, err
+		}
+	}
+
+	return folded, nil
+}
+
+// polyShare implements the operations of the Share interface over a flat list of polynomials, it is embedded
+// in each share type which exposes a structured view of the same polynomials. bitDecomp is the bit-decomposition
+// of the protocol of the share (0 for the protocols without decomposition), which is the header of its encoding.
+type polyShare struct {
+	context   *ring.Context
+	bitDecomp uint64
+	polys     []*ring.Poly
+}
+
+// shareHeaderSize is the size in bytes of the header of the binary encoding of a share, i.e. its bit-decomposition.
+const shareHeaderSize = 1
+
+func (share *polyShare) aggregate(other *polyShare) error {
+
+	if len(share.polys) != len(other.polys) {
+		return errors.New("cannot aggregate shares -> shares dimensions do not match")
+	}
+
+	for i := range share.polys {
+		share.context.Add(share.polys[i], other.polys[i], share.polys[i])
+	}
+
+	return nil
+}
+
+// MemoryBytes returns the size in bytes of the coefficients of the polynomials of the target share held in memory, i.e. 8 bytes
+// per coefficient (see EstimateProtocolMemory).
+func (share *polyShare) MemoryBytes() (size int64) {
+	for _, pol := range share.polys {
+		size += int64(pol.GetLenModuli()) * int64(pol.GetDegree()) * 8
+	}
+	return
+}
+
+// MarshalBinary encodes the target share on a byte slice, i.e. its bit-decomposition followed by the encoding of its polynomials.
+func (share *polyShare) MarshalBinary() ([]byte, error) {
+
+	polySize := 2 + ((share.context.N * uint64(len(share.context.Modulus))) << 3)
+
+	data := make([]byte, 0, shareHeaderSize+uint64(len(share.polys))*polySize)
+
+	data = append(data, uint8(share.bitDecomp))
+
+	for _, pol := range share.polys {
+
+		polyData, err := pol.MarshalBinary()
+		if err != nil {
+			return nil, err
+		}
+
+		data = append(data, polyData...)
+	}
+
+	return data, nil
+}
+
+// UnMarshalBinary decodes a previously marshaled share on the target share, which must be of the appropriate format. The bit-decomposition
+// of the encoding, and the ring degree and the number of moduli embedded in the encoding of each polynomial, are checked against the share,
+// so that a share encoded by a protocol instantiated with different parameters is rejected instead of being decoded as garbage, even if
+// its encoding has the expected length (e.g. the bit-decompositions 30 and 59 giving the same number of digits for 60-bit moduli).
+func (share *polyShare) UnMarshalBinary(data []byte) error {
+
+	polySize := 2 + ((share.context.N * uint64(len(share.context.Modulus))) << 3)
+
+	if uint64(len(data)) != shareHeaderSize+uint64(len(share.polys))*polySize {
+		return errors.New("cannot unmarshal share -> invalid share encoding (unexpected data length)")
+	}
+
+	if err := share.checkHeader(data[:shareHeaderSize]); err != nil {
+		return err
+	}
+
+	data = data[shareHeaderSize:]
+
+	for i := range share.polys {
+		if err := share.unmarshalPoly(i, data[uint64(i)*polySize:uint64(i+1)*polySize]); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// checkHeader checks that the header of a share encoding matches the bit-decomposition of the share.
+func (share *polyShare) checkHeader(header []byte) error {
+
+	if uint64(header[0]) != share.bitDecomp {
+		return fmt.Errorf("cannot unmarshal share -> invalid share encoding (bit-decomposition %d does not match %d)", header[0], share.bitDecomp)
+	}
+
+	return nil
+}
+
+// unmarshalPoly decodes the binary encoding of a polynomial on the i-th polynomial of the share, checking that the ring degree and
+// the number of moduli embedded in the encoding match the context of the share, and that the decoded coefficients are reduced.
+func (share *polyShare) unmarshalPoly(i int, data []byte) error {
+
+	if uint64(data[0]) != uint64(bits.Len64(share.context.N)-1) || uint64(data[1]) != uint64(len(share.context.Modulus)) {
+		return errors.New("cannot unmarshal share -> invalid share encoding (dimensions do not match the context)")
+	}
+
+	if _, err := share.polys[i].UnMarshalBinary(data); err != nil {
+		return err
+	}
+
+	if !share.context.IsReduced(share.polys[i]) {
+		return errors.New("cannot unmarshal share -> invalid share encoding (coefficients are not reduced)")
+	}
+
+	return nil
+}
+
+// aggregateSerialized returns the binary encoding (see polyShare.MarshalBinary) of the aggregation of the two shares of count polynomials
+// and of the given bit-decomposition given by their binary encoding, adding their coefficients modulo each qi directly on the byte buffers.
+// Returns an error if a coefficient of the encodings is not reduced modulo its qi.
+func aggregateSerialized(context *ring.Context, bitDecomp, count uint64, a, b []byte) ([]byte, error) {
+
+	N := context.N
+	moduli := uint64(len(context.Modulus))
+	polySize := 2 + ((N * moduli) << 3)
+
+	if uint64(len(a)) != shareHeaderSize+count*polySize || uint64(len(b)) != shareHeaderSize+count*polySize {
+		return nil, errors.New("cannot aggregate serialized shares -> invalid share encoding (unexpected data length)")
+	}
+
+	if uint64(a[0]) != bitDecomp || uint64(b[0]) != bitDecomp {
+		return nil, errors.New("cannot aggregate serialized shares -> invalid share encoding (bit-decomposition does not match)")
+	}
+
+	out := make([]byte, len(a))
+
+	out[0] = a[0]
+
+	for p := uint64(0); p < count; p++ {
+
+		pointer := shareHeaderSize + p*polySize
+
+		if a[pointer] != b[pointer] || a[pointer+1] != b[pointer+1] || uint64(a[pointer]) != uint64(bits.Len64(N)-1) || uint64(a[pointer+1]) != moduli {
+			return nil, errors.New("cannot aggregate serialized shares -> invalid share encoding (polynomial header does not match)")
+		}
+
+		out[pointer], out[pointer+1] = a[pointer], a[pointer+1]
+
+		pointer += 2
+
+		for _, qi := range context.Modulus {
+			for j := uint64(0); j < N; j++ {
+				coeffA := binary.BigEndian.Uint64(a[pointer : pointer+8])
+				coeffB := binary.BigEndian.Uint64(b[pointer : pointer+8])
+
+				// As when decoding a share, the coefficients must be reduced, CRed being only correct for a sum smaller than 2*qi
+				if coeffA >= qi || coeffB >= qi {
+					return nil, errors.New("cannot aggregate serialized shares -> invalid share encoding (coefficients are not reduced)")
+				}
+
+				binary.BigEndian.PutUint64(out[pointer:pointer+8], ring.CRed(coeffA+coeffB, qi))
+				pointer += 8
+			}
+		}
+	}
+
+	return out, nil
+}
+
+// EkgShareRoundOne is the share broadcast during the first round of the EkgProtocol protocol (see EkgProtocol.GenSamples).
+type EkgShareRoundOne struct {
+	polyShare
+	Value [][]*ring.Poly
+}
+
+// NewShareRoundOne wraps the samples returned by GenSamples in an EkgShareRoundOne.
+func (ekg *EkgProtocol) NewShareRoundOne(h [][]*ring.Poly) *EkgShareRoundOne {
+
+	share := &EkgShareRoundOne{polyShare{context: ekg.context, bitDecomp: ekg.bitDecomp}, h}
+
+	for i := range h {
+		share.polys = append(share.polys, h[i]...)
+	}
+
+	return share
+}
+
+// NewShareRoundOneEmpty allocates a new EkgShareRoundOne with all its coefficients set to 0.
+func (ekg *EkgProtocol) NewShareRoundOneEmpty() *EkgShareRoundOne {
+
+	h := make([][]*ring.Poly, len(ekg.context.Modulus))
+
+	for i := range h {
+		h[i] = make([]*ring.Poly, ekg.bitLog)
+		for w := range h[i] {
+			h[i][w] = ekg.context.NewPoly()
+		}
+	}
+
+	return ekg.NewShareRoundOne(h)
+}
+
+// Aggregate adds the other share to the target share.
+func (share *EkgShareRoundOne) Aggregate(other Share) error {
+
+	otherShare, ok := other.(*EkgShareRoundOne)
+	if !ok {
+		return errors.New("cannot aggregate shares -> share types do not match")
+	}
+
+	return share.aggregate(&otherShare.polyShare)
+}
+
+// Copy returns a deep copy of the target share.
+func (share *EkgShareRoundOne) Copy() Share {
+
+	h := make([][]*ring.Poly, len(share.Value))
+
+	for i := range h {
+		h[i] = make([]*ring.Poly, len(share.Value[i]))
+		for w := range h[i] {
+			h[i][w] = share.Value[i][w].CopyNew()
+		}
+	}
+
+	return (&EkgProtocol{context: share.context, bitDecomp: share.bitDecomp}).NewShareRoundOne(h)
+}
+
+// AggregateSerializedRoundOne returns the binary encoding of the aggregation of the two round one shares given by their binary encoding
+// (see EkgShareRoundOne.MarshalBinary), without decoding them : the coefficients are added modulo each qi directly on the byte buffers.
+// It allows a party collecting the serialized shares of many parties to sum them without the cost of their full deserialization.
+func (ekg *EkgProtocol) AggregateSerializedRoundOne(a, b []byte) ([]byte, error) {
+	return aggregateSerialized(ekg.context, ekg.bitDecomp, uint64(len(ekg.context.Modulus))*ekg.bitLog, a, b)
+}
+
+// EkgShareRoundTwo is the share broadcast during the second round of the EkgProtocol protocol (see EkgProtocol.Aggregate).
+type EkgShareRoundTwo struct {
+	polyShare
+	Value [][][2]*ring.Poly
+}
+
+// NewShareRoundTwo wraps the aggregated samples returned by Aggregate in an EkgShareRoundTwo.
+func (ekg *EkgProtocol) NewShareRoundTwo(h [][][2]*ring.Poly) *EkgShareRoundTwo {
+
+	share := &EkgShareRoundTwo{polyShare{context: ekg.context, bitDecomp: ekg.bitDecomp}, h}
+
+	for i := range h {
+		for w := range h[i] {
+			share.polys = append(share.polys, h[i][w][0], h[i][w][1])
+		}
+	}
+
+	return share
+}
+
+// NewShareRoundTwoEmpty allocates a new EkgShareRoundTwo with all its coefficients set to 0.
+func (ekg *EkgProtocol) NewShareRoundTwoEmpty() *EkgShareRoundTwo {
+
+	h := make([][][2]*ring.Poly, len(ekg.context.Modulus))
+
+	for i := range h {
+		h[i] = make([][2]*ring.Poly, ekg.bitLog)
+		for w := range h[i] {
+			h[i][w][0] = ekg.context.NewPoly()
+			h[i][w][1] = ekg.context.NewPoly()
+		}
+	}
+
+	return ekg.NewShareRoundTwo(h)
+}
+
+// Aggregate adds the other share to the target share.
+func (share *EkgShareRoundTwo) Aggregate(other Share) error {
+
+	otherShare, ok := other.(*EkgShareRoundTwo)
+	if !ok {
+		return errors.New("cannot aggregate shares -> share types do not match")
+	}
+
+	return share.aggregate(&otherShare.polyShare)
+}
+
+// Copy returns a deep copy of the target share.
+func (share *EkgShareRoundTwo) Copy() Share {
+
+	h := make([][][2]*ring.Poly, len(share.Value))
+
+	for i := range h {
+		h[i] = make([][2]*ring.Poly, len(share.Value[i]))
+		for w := range h[i] {
+			h[i][w][0] = share.Value[i][w][0].CopyNew()
+			h[i][w][1] = share.Value[i][w][1].CopyNew()
+		}
+	}
+
+	return (&EkgProtocol{context: share.context, bitDecomp: share.bitDecomp}).NewShareRoundTwo(h)
+}
+
+// EkgShareRoundThree is the share broadcast during the third round of the EkgProtocol protocol (see EkgProtocol.KeySwitch).
+type EkgShareRoundThree struct {
+	polyShare
+	Value [][]*ring.Poly
+}
+
+// NewShareRoundThree wraps the key-switched samples returned by KeySwitch in an EkgShareRoundThree.
+func (ekg *EkgProtocol) NewShareRoundThree(h1 [][]*ring.Poly) *EkgShareRoundThree {
+
+	share := &EkgShareRoundThree{polyShare{context: ekg.context, bitDecomp: ekg.bitDecomp}, h1}
+
+	for i := range h1 {
+		share.polys = append(share.polys, h1[i]...)
+	}
+
+	return share
+}
+
+// NewShareRoundThreeEmpty allocates a new EkgShareRoundThree with all its coefficients set to 0.
+func (ekg *EkgProtocol) NewShareRoundThreeEmpty() *EkgShareRoundThree {
+	return ekg.NewShareRoundThree(ekg.NewShareRoundOneEmpty().Value)
+}
+
+// Aggregate adds the other share to the target share.
+func (share *EkgShareRoundThree) Aggregate(other Share) error {
+
+	otherShare, ok := other.(*EkgShareRoundThree)
+	if !ok {
+		return errors.New("cannot aggregate shares -> share types do not match")
+	}
+
+	return share.aggregate(&otherShare.polyShare)
+}
+
+// Copy returns a deep copy of the target share.
+func (share *EkgShareRoundThree) Copy() Share {
+
+	h1 := make([][]*ring.Poly, len(share.Value))
+
+	for i := range h1 {
+		h1[i] = make([]*ring.Poly, len(share.Value[i]))
+		for w := range h1[i] {
+			h1[i][w] = share.Value[i][w].CopyNew()
+		}
+	}
+
+	return (&EkgProtocol{context: share.context, bitDecomp: share.bitDecomp}).NewShareRoundThree(h1)
+}
+
+// CKGShare is the share broadcast during the unique round of the CKG protocol (see CKG.GenShare).
+type CKGShare struct {
+	polyShare
+	Value *ring.Poly
+}
+
+// NewShare wraps the share returned by GetShare in a CKGShare.
+func (ckg *CKG) NewShare(share *ring.Poly) *CKGShare {
+	return &CKGShare{polyShare{context: ckg.context, polys: []*ring.Poly{share}}, share}
+}
+
+// NewShareEmpty allocates a new CKGShare with all its coefficients set to 0.
+func (ckg *CKG) NewShareEmpty() *CKGShare {
+	return ckg.NewShare(ckg.context.NewPoly())
+}
+
+// Aggregate adds the other share to the target share.
+func (share *CKGShare) Aggregate(other Share) error {
+
+	otherShare, ok := other.(*CKGShare)
+	if !ok {
+		return errors.New("cannot aggregate shares -> share types do not match")
+	}
+
+	return share.aggregate(&otherShare.polyShare)
+}
+
+// Copy returns a deep copy of the target share.
+func (share *CKGShare) Copy() Share {
+	return (&CKG{context: share.context}).NewShare(share.Value.CopyNew())
+}
+
+// CKSShare is the share broadcast during the unique round of the CKS protocol (see CKS.KeySwitch).
+type CKSShare struct {
+	polyShare
+	Value *ring.Poly
+}
+
+// NewShare wraps the share returned by KeySwitch in a CKSShare.
+func (cks *CKS) NewShare(share *ring.Poly) *CKSShare {
+	return &CKSShare{polyShare{context: cks.context, polys: []*ring.Poly{share}}, share}
+}
+
+// NewShareEmpty allocates a new CKSShare with all its coefficients set to 0.
+func (cks *CKS) NewShareEmpty() *CKSShare {
+	return cks.NewShare(cks.context.NewPoly())
+}
+
+// Aggregate adds the other share to the target share.
+func (share *CKSShare) Aggregate(other Share) error {
+
+	otherShare, ok := other.(*CKSShare)
+	if !ok {
+		return errors.New("cannot aggregate shares -> share types do not match")
+	}
+
+	return share.aggregate(&otherShare.polyShare)
+}
+
+// Copy returns a deep copy of the target share.
+func (share *CKSShare) Copy() Share {
+	return (&CKS{context: share.context}).NewShare(share.Value.CopyNew())
+}
+
+// PCKSShare is the share broadcast during the unique round of the PCKS protocol (see PCKS.KeySwitch).
+type PCKSShare struct {
+	polyShare
+	Value [2]*ring.Poly
+}
+
+// NewShare wraps the share returned by KeySwitch in a PCKSShare.
+func (pcks *PCKS) NewShare(share [2]*ring.Poly) *PCKSShare {
+	return &PCKSShare{polyShare{context: pcks.context, polys: []*ring.Poly{share[0], share[1]}}, share}
+}
+
+// NewShareEmpty allocates a new PCKSShare with all its coefficients set to 0.
+func (pcks *PCKS) NewShareEmpty() *PCKSShare {
+	return pcks.NewShare([2]*ring.Poly{pcks.context.NewPoly(), pcks.context.NewPoly()})
+}
+
+// Aggregate adds the other share to the target share.
+func (share *PCKSShare) Aggregate(other Share) error {
+
+	otherShare, ok := other.(*PCKSShare)
+	if !ok {
+		return errors.New("cannot aggregate shares -> share types do not match")
+	}
+
+	return share.aggregate(&otherShare.polyShare)
+}
+
+// Copy returns a deep copy of the target share.
+func (share *PCKSShare) Copy() Share {
+	return (&PCKS{context: share.context}).NewShare([2]*ring.Poly{share.Value[0].CopyNew(), share.Value[1].CopyNew()})
+}
+
+// RefreshShare is the share broadcast during the unique round of the refresh protocol (see RefreshProtocol.GenShare).
+type RefreshShare struct {
+	polyShare
+	Value [2]*ring.Poly
+}
+
+// NewShare wraps the two polynomials of a refresh share in a RefreshShare.
+func (refresh *RefreshProtocol) NewShare(share [2]*ring.Poly) *RefreshShare {
+	return &RefreshShare{polyShare{context: refresh.context, polys: []*ring.Poly{share[0], share[1]}}, share}
+}
+
+// Aggregate adds the other share to the target share.
+func (share *RefreshShare) Aggregate(other Share) error {
+
+	otherShare, ok := other.(*RefreshShare)
+	if !ok {
+		return errors.New("cannot aggregate shares -> share types do not match")
+	}
+
+	return share.aggregate(&otherShare.polyShare)
+}
+
+// Copy returns a deep copy of the target share.
+func (share *RefreshShare) Copy() Share {
+	return (&RefreshProtocol{context: share.context}).NewShare([2]*ring.Poly{share.Value[0].CopyNew(), share.Value[1].CopyNew()})
+}
@@ -0,0 +1,206 @@
+package dbfv
+
+import (
+	"errors"
+	"fmt"
+	"io"
+)
+
+// chunked is implemented by the shares whose binary encoding is the header of the share followed by the concatenation of the binary
+// encodings of their polynomials, which are the chunks written and read by ShareWriter and ShareReader.
+type chunked interface {
+	chunks() *polyShare
+}
+
+func (share *polyShare) chunks() *polyShare {
+	return share
+}
+
+// chunkSize returns the size in bytes of the binary encoding of a polynomial of the share.
+func (share *polyShare) chunkSize() uint64 {
+	return 2 + ((share.context.N * uint64(len(share.context.Modulus))) << 3)
+}
+
+// checkpoint returns the number of bytes of the encoding of the share before its next-th chunk, the chunk 0 being the header of the
+// share and the chunk i > 0 its (i-1)-th polynomial.
+func (share *polyShare) checkpoint(next int) uint64 {
+	if next == 0 {
+		return 0
+	}
+	return shareHeaderSize + uint64(next-1)*share.chunkSize()
+}
+
+// resumeChunks returns the polynomials of the share and the index of the chunk at the given checkpoint.
+func resumeChunks(share Share, checkpoint uint64) (*polyShare, int, error) {
+
+	c, ok := share.(chunked)
+	if !ok {
+		return nil, 0, errors.New("cannot resume share -> the share type is not chunked")
+	}
+
+	polys := c.chunks()
+
+	if checkpoint == 0 {
+		return polys, 0, nil
+	}
+
+	if checkpoint < shareHeaderSize || (checkpoint-shareHeaderSize)%polys.chunkSize() != 0 || (checkpoint-shareHeaderSize)/polys.chunkSize() > uint64(len(polys.polys)) {
+		return nil, 0, fmt.Errorf("cannot resume share -> checkpoint %d is not a chunk boundary of the share encoding", checkpoint)
+	}
+
+	return polys, 1 + int((checkpoint-shareHeaderSize)/polys.chunkSize()), nil
+}
+
+// ShareWriter writes the binary encoding of a share (see Share.MarshalBinary) on an io.Writer one chunk at a time, a chunk being the
+// header of the share or one of its polynomials (e.g. the sample of a modulus and a digit for the shares of the EkgProtocol), and flushes the writer after
+// each chunk if it implements Flush() error. Its checkpoint is the number of bytes of the encoding written so far, which is always at a
+// chunk boundary: if the write is interrupted, the output can be truncated to the checkpoint and the write resumed from it.
+type ShareWriter struct {
+	share *polyShare
+	w     io.Writer
+	next  int
+}
+
+// NewShareWriter creates a new ShareWriter writing the given share on w, starting at the given checkpoint (0 for a new write, or the
+// checkpoint of an interrupted ShareWriter to resume its write). Returns an error if the checkpoint is not a chunk boundary.
+func NewShareWriter(share Share, w io.Writer, checkpoint uint64) (*ShareWriter, error) {
+
+	polys, next, err := resumeChunks(share, checkpoint)
+	if err != nil {
+		return nil, err
+	}
+
+	return &ShareWriter{share: polys, w: w, next: next}, nil
+}
+
+// WriteChunk writes and flushes the next chunk of the share. The checkpoint is advanced only once the chunk is written and flushed.
+// Returns io.EOF if the share has been fully written.
+func (writer *ShareWriter) WriteChunk() error {
+
+	if writer.Done() {
+		return io.EOF
+	}
+
+	var data []byte
+	var err error
+
+	if writer.next == 0 {
+		data = []byte{uint8(writer.share.bitDecomp)}
+	} else if data, err = writer.share.polys[writer.next-1].MarshalBinary(); err != nil {
+		return err
+	}
+
+	if _, err = writer.w.Write(data); err != nil {
+		return err
+	}
+
+	if flusher, ok := writer.w.(interface{ Flush() error }); ok {
+		if err = flusher.Flush(); err != nil {
+			return err
+		}
+	}
+
+	writer.next++
+
+	return nil
+}
+
+// WriteAll writes the remaining chunks of the share.
+func (writer *ShareWriter) WriteAll() error {
+
+	for !writer.Done() {
+		if err := writer.WriteChunk(); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Done returns true if the share has been fully written.
+func (writer *ShareWriter) Done() bool {
+	return writer.next == len(writer.share.polys)+1
+}
+
+// Checkpoint returns the number of bytes of the encoding of the share written so far.
+func (writer *ShareWriter) Checkpoint() uint64 {
+	return writer.share.checkpoint(writer.next)
+}
+
+// ShareReader reads the binary encoding of a share written by a ShareWriter (or by Share.MarshalBinary) from an io.Reader one chunk at a
+// time, decoding each chunk on the target share. Its checkpoint is the number of bytes of the encoding decoded so far: if the read is
+// interrupted, it can be resumed from the checkpoint on the same target share.
+type ShareReader struct {
+	share *polyShare
+	r     io.Reader
+	next  int
+	chunk []byte
+}
+
+// NewShareReader creates a new ShareReader decoding the share read from r on the target share, which must be of the appropriate format,
+// r being positioned at the given checkpoint of the encoding (0 for a new read, or the checkpoint of an interrupted ShareReader decoding
+// on the same target share to resume its read). Returns an error if the checkpoint is not a chunk boundary.
+func NewShareReader(share Share, r io.Reader, checkpoint uint64) (*ShareReader, error) {
+
+	polys, next, err := resumeChunks(share, checkpoint)
+	if err != nil {
+		return nil, err
+	}
+
+	return &ShareReader{share: polys, r: r, next: next, chunk: make([]byte, polys.chunkSize())}, nil
+}
+
+// ReadChunk reads and decodes the next chunk of the share. A partially read chunk is discarded and the checkpoint is not advanced.
+// Returns io.EOF if the share has been fully read.
+func (reader *ShareReader) ReadChunk() error {
+
+	if reader.Done() {
+		return io.EOF
+	}
+
+	chunk := reader.chunk
+	if reader.next == 0 {
+		chunk = chunk[:shareHeaderSize]
+	}
+
+	if _, err := io.ReadFull(reader.r, chunk); err != nil {
+		if err == io.EOF {
+			return io.ErrUnexpectedEOF
+		}
+		return err
+	}
+
+	if reader.next == 0 {
+		if err := reader.share.checkHeader(chunk); err != nil {
+			return err
+		}
+	} else if err := reader.share.unmarshalPoly(reader.next-1, chunk); err != nil {
+		return err
+	}
+
+	reader.next++
+
+	return nil
+}
+
+// ReadAll reads the remaining chunks of the share.
+func (reader *ShareReader) ReadAll() error {
+
+	for !reader.Done() {
+		if err := reader.ReadChunk(); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Done returns true if the share has been fully read.
+func (reader *ShareReader) Done() bool {
+	return reader.next == len(reader.share.polys)+1
+}
+
+// Checkpoint returns the number of bytes of the encoding of the share decoded so far.
+func (reader *ShareReader) Checkpoint() uint64 {
+	return reader.share.checkpoint(reader.next)
+}
@@ -0,0 +1,70 @@
+package dbfv
+
+import (
+	"errors"
+	"github.com/ldsec/lattigo/ring"
+)
+
+// ThresholdShare converts the Shamir share skShamir = f(xi) of the collective secret-key s = f(0), f being a polynomial of degree t-1,
+// into the additive share lambda_i * f(xi) of s among the t parties whose points are given by active, where
+// lambda_i = prod_{xj != xi} xj / (xj - xi) is the Lagrange coefficient of xi for the evaluation of f in 0. The t active parties then
+// run the protocols of this package with their additive share, e.g. all the rounds of the EkgProtocol including Aggregate, as in the
+// n-out-of-n case.
+//
+// The residues of the Lagrange coefficient modulo each qi being different, it is folded limb by limb with ring.Context.AddScaled.
+// skShamir can be in any domain, the result being in the same domain. Returns an error if xi is not in active, or if the points of
+// active are not distinct and non-zero modulo each qi.
+func ThresholdShare(context *ring.Context, skShamir *ring.Poly, xi uint64, active []uint64) (skOut *ring.Poly, err error) {
+
+	found := false
+	for _, xj := range active {
+		if xj == xi {
+			found = true
+		}
+	}
+
+	if !found {
+		return nil, errors.New("cannot generate threshold share -> the point of the party is not in the active set")
+	}
+
+	skOut = context.NewPoly()
+
+	for i, qi := range context.Modulus {
+
+		limbContext := ring.NewContext()
+		if err = limbContext.SetParameters(context.N, []uint64{qi}); err != nil {
+			return nil, err
+		}
+
+		bredParams := limbContext.GetBredParams()[0]
+
+		// lambda_i mod qi = prod xj / prod (xj - xi)
+		num, den := uint64(1), uint64(1)
+
+		for k, xj := range active {
+
+			for _, xl := range active[k+1:] {
+				if xj%qi == xl%qi {
+					return nil, errors.New("cannot generate threshold share -> the points of the active set are not distinct")
+				}
+			}
+
+			if xj%qi == 0 {
+				return nil, errors.New("cannot generate threshold share -> the points of the active set must be non-zero")
+			}
+
+			if xj == xi {
+				continue
+			}
+
+			num = ring.BRed(num, xj%qi, qi, bredParams)
+			den = ring.BRed(den, (xj%qi+qi-xi%qi)%qi, qi, bredParams)
+		}
+
+		lambda := ring.BRed(num, ring.ModExp(den, qi-2, qi), qi, bredParams)
+
+		limbContext.AddScaled(&ring.Poly{Coeffs: skShamir.Coeffs[i : i+1]}, lambda, &ring.Poly{Coeffs: skOut.Coeffs[i : i+1]})
+	}
+
+	return skOut, nil
+}
@@ -0,0 +1,103 @@
+package dbgv
+
+import (
+	"github.com/ldsec/lattigo/bgv"
+	"github.com/ldsec/lattigo/dbfv"
+	"github.com/ldsec/lattigo/ring"
+)
+
+// EkgProtocol is a structure storing the parameters for the collective evaluation-key generation of the BGV scheme.
+// It runs the same three rounds as the dbfv.EkgProtocol protocol, to which it delegates, except that all the error
+// polynomials are sampled as multiples of the plaintext modulus t. The noise of the resulting collective evaluation-key
+// is thus a multiple of t, as required by the relinearization of the BGV scheme.
+type EkgProtocol struct {
+	ekg       *dbfv.EkgProtocol
+	bitDecomp uint64
+}
+
+// tGaussianSampler is a dbfv.GaussianSampler returning gaussian error polynomials multiplied by the plaintext modulus.
+type tGaussianSampler struct {
+	context *ring.Context
+	sampler *ring.KYSampler
+	t       uint64
+}
+
+// SampleNTTNew returns a new error polynomial multiplied by t in the NTT domain.
+func (sampler *tGaussianSampler) SampleNTTNew() (pol *ring.Poly) {
+	pol = sampler.sampler.SampleNTTNew()
+	sampler.context.MulScalar(pol, sampler.t, pol)
+	return
+}
+
+// SampleNTT samples an error polynomial multiplied by t in the NTT domain on the input polynomial.
+func (sampler *tGaussianSampler) SampleNTT(pol *ring.Poly) {
+	sampler.sampler.SampleNTT(pol)
+	sampler.context.MulScalar(pol, sampler.t, pol)
+}
+
+// NewEkgProtocol creates a new EkgProtocol object that will be used to generate a collective evaluation-key
+// on the full modulus chain of the target bgvcontext, with the given bit-decomposition.
+func NewEkgProtocol(bgvcontext *bgv.BgvContext, bitDecomp uint64) *EkgProtocol {
+
+	context := bgvcontext.ContextKeys()
+
+	ekg := new(EkgProtocol)
+	ekg.bitDecomp = bitDecomp
+	ekg.ekg = dbfv.NewEkgProtocol(context, bitDecomp)
+	bound := uint64(6 * bgvcontext.Sigma())
+
+	ekg.ekg.SetGaussianSampler(&tGaussianSampler{context, context.NewKYSampler(bgvcontext.Sigma(), int(bound)), bgvcontext.T()}, bound*bgvcontext.T())
+
+	return ekg
+}
+
+// NewEphemeralKey generates a new Ephemeral Key u_i (needs to be stored for the 3 first round).
+// Each party is required to pre-compute a secret additional ephemeral key in addition to its share
+// of the collective secret-key.
+func (ekg *EkgProtocol) NewEphemeralKey(p float64) (ephemeralKey *ring.Poly, err error) {
+	return ekg.ekg.NewEphemeralKey(p)
+}
+
+// GenSamples is the first of three rounds of the EkgProtocol protocol. Each party generates a pseudo encryption of
+// its secret share of the key s_i under its ephemeral key u_i : [-u_i*a + s_i*w + t*e_i] and broadcasts it to the other
+// j-1 parties.
+func (ekg *EkgProtocol) GenSamples(u, sk *ring.Poly, crp [][]*ring.Poly) (h [][]*ring.Poly) {
+	return ekg.ekg.GenSamples(u, sk, crp)
+}
+
+// Aggregate is the second of three rounds of the EkgProtocol protocol. Uppon received the j-1 shares, each party computes :
+//
+// [s_i * sum([-u_j*a + s_j*w + t*e_j]) + t*e_i1, s_i*a + t*e_i2]
+//
+// and broadcasts both values to the other j-1 parties.
+func (ekg *EkgProtocol) Aggregate(sk *ring.Poly, samples [][][]*ring.Poly, crp [][]*ring.Poly) (h [][][2]*ring.Poly) {
+	return ekg.ekg.Aggregate(sk, samples, crp)
+}
+
+// Sum is the first part of the third and last round of the EkgProtocol protocol. Uppon receiving the j-1 elements, each party
+// computues :
+//
+// [s * (-u*a + s*w + t*e) + t*e_1, s*a + t*e_2].
+func (ekg *EkgProtocol) Sum(samples [][][][2]*ring.Poly) (h [][][2]*ring.Poly) {
+	return ekg.ekg.Sum(samples)
+}
+
+// KeySwitch is the second part of the third and last round of the EkgProtocol protocol. Each party operates a key-switch on [s*a + t*e_2],
+// by computing :
+//
+// [(u_i - s_i)*(s*a + t*e_2) + t*e_i3]
+//
+// and broadcasts the result the other j-1 parties.
+func (ekg *EkgProtocol) KeySwitch(u, sk *ring.Poly, samples [][][2]*ring.Poly) (h1 [][]*ring.Poly) {
+	return ekg.ekg.KeySwitch(u, sk, samples)
+}
+
+// ComputeEVK is third part ot the third and last round of the EkgProtocol protocol. Uppon receiving the other j-1 elements, each party
+// computes the collective evaluation-key [-s^2*a + s^2*w + t*e, s*a] (see dbfv.EkgProtocol.ComputeEVK).
+func (ekg *EkgProtocol) ComputeEVK(h1 [][][]*ring.Poly, h [][][2]*ring.Poly) (evakey *bgv.EvaluationKey) {
+
+	evakey = new(bgv.EvaluationKey)
+	evakey.SetRelinKeys(ekg.ekg.ComputeEVK(h1, h), ekg.bitDecomp)
+
+	return evakey
+}
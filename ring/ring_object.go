@@ -102,6 +102,13 @@ func (Pol *Poly) GetCoefficients() [][]uint64 {
 	return coeffs
 }
 
+// Slice returns a view on the coefficients start to end (excluded) of the given limb of the polynomial, without copying them.
+// The view shares the memory of the polynomial, and its capacity is bounded to end, so that appending to the view never
+// overwrites the coefficients that follow it. Like a slice expression, it panics if the limb or the range is out of bounds.
+func (Pol *Poly) Slice(limb, start, end int) []uint64 {
+	return Pol.Coeffs[limb][start:end:end]
+}
+
 // WriteCoeffsTo converts a matrix of coefficients to a byte array.
 func WriteCoeffsTo(pointer, N, numberModuli uint64, coeffs [][]uint64, data []byte) (uint64, error) {
 	tmp := N << 3
@@ -142,17 +149,50 @@ func DecodeCoeffsNew(pointer, N, numberModuli uint64, coeffs [][]uint64, data []
 	return pointer, nil
 }
 
+// GetDataLen returns the length in bytes of the binary encoding of the polynomial (see MarshalBinary). With metadata, it includes the
+// 2 bytes header storing the ring degree and the number of moduli, otherwise only the 8 * N * numberModuli bytes of the coefficients
+// (as written by WriteCoeffsTo), e.g. to pre-size a buffer embedding several polynomials of known dimensions.
+func (Pol *Poly) GetDataLen(WithMetadata bool) (dataLen uint64) {
+
+	for i := range Pol.Coeffs {
+		dataLen += uint64(len(Pol.Coeffs[i])) << 3
+	}
+
+	if WithMetadata {
+		dataLen += 2
+	}
+
+	return
+}
+
+// MarshalBinary encodes the polynomial on a byte slice of length GetDataLen(true). The first byte stores log2 of the ring degree N,
+// the second byte the number of moduli, followed by the coefficients of each modulus as fixed-width big-endian uint64 (see WriteCoeffsTo).
+// Returns an error if the polynomial has no coefficients, has more than 255 moduli or if its degree is not a power of two.
 func (Pol *Poly) MarshalBinary() ([]byte, error) {
 
+	if len(Pol.Coeffs) == 0 || len(Pol.Coeffs[0]) == 0 {
+		return nil, errors.New("cannot marshal poly -> empty polynomial")
+	}
+
 	N := uint64(len(Pol.Coeffs[0]))
 	numberModulies := uint64(len(Pol.Coeffs))
 
-	data := make([]byte, 2+((N*numberModulies)<<3))
-
 	if numberModulies > 0xFF {
-		return nil, errors.New("error : poly max modulies uint16 overflow")
+		return nil, errors.New("cannot marshal poly -> numberModuli overflows uint8")
+	}
+
+	if N&(N-1) != 0 {
+		return nil, errors.New("cannot marshal poly -> N is not a power of two")
 	}
 
+	for i := range Pol.Coeffs {
+		if uint64(len(Pol.Coeffs[i])) != N {
+			return nil, errors.New("cannot marshal poly -> the moduli have different numbers of coefficients")
+		}
+	}
+
+	data := make([]byte, Pol.GetDataLen(true))
+
 	data[0] = uint8(bits.Len64(uint64(N)) - 1)
 	data[1] = uint8(numberModulies)
 
@@ -167,17 +207,38 @@ func (Pol *Poly) MarshalBinary() ([]byte, error) {
 	return data, nil
 }
 
+// UnMarshalBinary decodes a previously marshaled polynomial (see MarshalBinary) on the target polynomial and returns it. Returns an error
+// if the data is truncated or of unexpected length, or if the ring degree and the number of moduli of the encoding do not match the
+// dimensions of the target polynomial, which is then left unchanged.
 func (Pol *Poly) UnMarshalBinary(data []byte) (*Poly, error) {
 
-	N := uint64(int(1 << data[0]))
-	numberModulies := uint64(int(data[1]))
+	if len(data) < 2 {
+		return nil, errors.New("cannot unmarshal poly -> invalid polynomial encoding (data too short)")
+	}
+
+	if data[0] > 32 {
+		return nil, errors.New("cannot unmarshal poly -> invalid polynomial encoding (invalid degree)")
+	}
+
+	N := uint64(1) << data[0]
+	numberModulies := uint64(data[1])
 
 	var pointer uint64
 
 	pointer = 2
 
-	if ((uint64(len(data)) - pointer) >> 3) != N*numberModulies {
-		return nil, errors.New("error : invalid polynomial encoding")
+	if uint64(len(data)) != pointer+((N*numberModulies)<<3) {
+		return nil, errors.New("cannot unmarshal poly -> invalid polynomial encoding (unexpected data length)")
+	}
+
+	if uint64(len(Pol.Coeffs)) != numberModulies {
+		return nil, errors.New("cannot unmarshal poly -> invalid polynomial encoding (unexpected number of moduli)")
+	}
+
+	for i := range Pol.Coeffs {
+		if uint64(len(Pol.Coeffs[i])) != N {
+			return nil, errors.New("cannot unmarshal poly -> invalid polynomial encoding (unexpected degree)")
+		}
 	}
 
 	if _, err := DecodeCoeffs(pointer, N, numberModulies, Pol.Coeffs, data); err != nil {
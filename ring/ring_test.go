@@ -4,11 +4,15 @@ import (
 	"bufio"
 	"fmt"
 	"log"
+	"math"
+	"math/big"
 	"math/bits"
 	"math/rand"
 	"os"
+	"runtime"
 	"strconv"
 	"strings"
+	"sync"
 	"testing"
 	"time"
 )
@@ -71,6 +75,10 @@ func Test_Polynomial(t *testing.T) {
 		// ok!
 		test_GenerateNTTPrimes(N, Qi[0], t)
 
+		test_NewContextWithParameters(N, Qi, t)
+
+		test_EqualContext(N, Qi, t)
+
 		// ok!
 		test_ImportExportPolyString(contextQ, t)
 
@@ -98,6 +106,8 @@ func Test_Polynomial(t *testing.T) {
 		// ok!
 		test_MulPoly(contextQ, t)
 
+		test_MulPolySchoolbook(contextQ, t)
+
 		// ok!
 		test_MulPoly_Montgomery(contextQ, t)
 
@@ -111,6 +121,41 @@ func Test_Polynomial(t *testing.T) {
 		test_ComplexScaling(T, contextQ, contextP, contextQP, t)
 
 		test_MultByMonomial(contextQ, t)
+
+		test_NTTTablesCache(contextQ, contextP, t)
+		test_NTTTablesCacheSize(contextQ, t)
+
+		test_NTTSingle(contextQ, t)
+
+		test_IsReduced(contextQ, t)
+
+		test_ModulusProduct(contextQ, contextQP, t)
+
+		test_DecomposePoly(contextQ, t)
+
+		test_PolySlice(contextQ, t)
+
+		test_AddScaled(contextQ, t)
+
+		test_ErrorSampler(sigma, contextQ, t)
+
+		test_SampleSecretWithNorm(contextQ, t)
+
+		test_SampleHammingWeight(contextQ, t)
+
+		test_CoeffToBigint(contextQ, t)
+
+		test_MarshalPoly(t)
+
+		test_ConstantTimeReduction(contextQ, t)
+
+		test_NTTTable(contextQ, t)
+
+		test_SamplerSource(sigma, contextQ, t)
+
+		test_NTTParallel(contextQ, t)
+
+		test_SampleFlooding(contextQ, t)
 	}
 }
 
@@ -239,6 +284,104 @@ func test_GenerateNTTPrimes(N, Qi uint64, t *testing.T) {
 	})
 }
 
+func test_NewContextWithParameters(N uint64, Qi []uint64, t *testing.T) {
+
+	t.Run(fmt.Sprintf("N=%d/NewContextWithParameters", N), func(t *testing.T) {
+
+		for _, qi := range Qi {
+			if !IsNTTFriendly(qi, N) {
+				t.Errorf("error : IsNTTFriendly rejects the NTT-friendly modulus %d", qi)
+			}
+		}
+
+		context, err := NewContextWithParameters(N, Qi)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if !context.AllowsNTT() {
+			t.Errorf("error : NewContextWithParameters did not generate the NTT parameters")
+		}
+
+		// A prime not congruent to 1 mod 2N, an odd composite congruent to 1 mod 2N and a modulus congruent to 3 mod 2N
+		for _, qi := range []uint64{12289, (N << 1) + 1, Qi[0] + 2} {
+
+			if IsNTTFriendly(qi, N) {
+				t.Errorf("error : IsNTTFriendly accepts the modulus %d", qi)
+			}
+
+			_, err := NewContextWithParameters(N, append([]uint64{qi}, Qi...))
+			if err == nil {
+				t.Errorf("error : NewContextWithParameters accepts the modulus %d", qi)
+			} else if !strings.Contains(err.Error(), fmt.Sprintf("%d", qi)) {
+				t.Errorf("error : NewContextWithParameters error does not report the offending modulus %d : %s", qi, err)
+			}
+		}
+	})
+}
+
+func test_EqualContext(N uint64, Qi []uint64, t *testing.T) {
+
+	t.Run(fmt.Sprintf("N=%d/EqualContext", N), func(t *testing.T) {
+
+		context0, err := NewContextWithParameters(N, Qi)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		context1 := NewContext()
+		context1.SetParameters(N, append([]uint64{}, Qi...))
+		context1.GenNTTParams()
+
+		if !context0.EqualContext(context1) || !context1.EqualContext(context0) {
+			t.Errorf("error : independently built contexts with the same parameters are not equal")
+		}
+
+		if context0.FingerprintUint64() != context1.FingerprintUint64() {
+			t.Errorf("error : independently built contexts with the same parameters have different fingerprints")
+		}
+
+		// Differing only in the last modulus, replaced by an NTT-friendly prime which is not in Qi
+		candidates, err := GenerateNTTPrimes(N, Qi[len(Qi)-1], uint64(len(Qi)+1), 60, false)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		moduli := append([]uint64{}, Qi...)
+		for _, qi := range candidates {
+			inQi := false
+			for _, qj := range Qi {
+				inQi = inQi || qi == qj
+			}
+			if !inQi {
+				moduli[len(moduli)-1] = qi
+				break
+			}
+		}
+
+		context2, err := NewContextWithParameters(N, moduli)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if context0.EqualContext(context2) || context2.EqualContext(context0) {
+			t.Errorf("error : contexts differing in one modulus are equal")
+		}
+
+		if context0.FingerprintUint64() == context2.FingerprintUint64() {
+			t.Errorf("error : contexts differing in one modulus have the same fingerprint")
+		}
+
+		// Without the NTT parameters
+		context3 := NewContext()
+		context3.SetParameters(N, Qi)
+
+		if context0.EqualContext(context3) {
+			t.Errorf("error : a context with the NTT parameters is equal to a context without")
+		}
+	})
+}
+
 func test_ImportExportPolyString(context *Context, t *testing.T) {
 
 	t.Run(fmt.Sprintf("N=%d/limbs=%d/ImportExportPolyString", context.N, len(context.Modulus)), func(t *testing.T) {
@@ -274,6 +417,16 @@ func test_Marshaler(context *Context, t *testing.T) {
 		}
 	})
 
+	t.Run(fmt.Sprintf("N=%d/limbs=%d/MarshalPolyNTT", context.N, len(context.Modulus)), func(t *testing.T) {
+
+		// Uniform and small polynomials, the latter having centered coefficients close to the moduli
+		for _, p := range []*Poly{context.NewUniformPoly(), context.NewKYSampler(3.19, 19).SampleNew()} {
+			if err := assertNTTPreservedThroughSerialization(context, p); err != nil {
+				t.Error(err)
+			}
+		}
+	})
+
 	t.Run(fmt.Sprintf("N=%d/limbs=%d/MarshalPoly", context.N, len(context.Modulus)), func(t *testing.T) {
 
 		p := context.NewUniformPoly()
@@ -480,6 +633,79 @@ func test_MulPoly(context *Context, t *testing.T) {
 	})
 }
 
+func test_MulPolySchoolbook(context *Context, t *testing.T) {
+
+	// The schoolbook multiplication is quadratic, the NTT-based multiplications are checked on a smaller ring degree with the same moduli
+	contextSmall, err := NewContextWithParameters(256, context.Modulus)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	t.Run(fmt.Sprintf("N=%d/limbs=%d/MulPolySchoolbook", contextSmall.N, len(contextSmall.Modulus)), func(t *testing.T) {
+
+		p1 := contextSmall.NewUniformPoly()
+		p2 := contextSmall.NewUniformPoly()
+		p3Want := contextSmall.NewPoly()
+		p3Test := contextSmall.NewPoly()
+
+		contextSmall.MulPolySchoolbook(p1, p2, p3Want)
+
+		// X * X^(N-1) = -1
+		xPow := [2]*Poly{contextSmall.NewPoly(), contextSmall.NewPoly()}
+		for x := range contextSmall.Modulus {
+			xPow[0].Coeffs[x][1] = 1
+			xPow[1].Coeffs[x][contextSmall.N-1] = 1
+		}
+
+		contextSmall.MulPolySchoolbook(xPow[0], xPow[1], p3Test)
+
+		for x, qi := range contextSmall.Modulus {
+			if p3Test.Coeffs[x][0] != qi-1 {
+				t.Errorf("error : MulPolySchoolbook, X * X^(N-1) != -1 mod %d", qi)
+			}
+		}
+
+		contextSmall.MulPoly(p1, p2, p3Test)
+
+		if !equalPolys(contextSmall, p3Want, p3Test) {
+			t.Errorf("error : MulPoly differs from MulPolySchoolbook")
+		}
+
+		contextSmall.MulPolyNaive(p1, p2, p3Test)
+
+		if !equalPolys(contextSmall, p3Want, p3Test) {
+			t.Errorf("error : MulPolyNaive differs from MulPolySchoolbook")
+		}
+
+		p1Mont := contextSmall.NewPoly()
+		contextSmall.MForm(p1, p1Mont)
+		contextSmall.MulPolyMontgomery(p1Mont, p2, p3Test)
+
+		if !equalPolys(contextSmall, p3Want, p3Test) {
+			t.Errorf("error : MulPolyMontgomery differs from MulPolySchoolbook")
+		}
+
+		// In place
+		contextSmall.MulPolySchoolbook(p1, p2, p1)
+
+		if !equalPolys(contextSmall, p3Want, p1) {
+			t.Errorf("error : MulPolySchoolbook in place")
+		}
+	})
+}
+
+// equalPolys returns true if the coefficients of p1 and p2 are equal on all the limbs of the context, without reducing them.
+func equalPolys(context *Context, p1, p2 *Poly) bool {
+	for x := range context.Modulus {
+		for j := uint64(0); j < context.N; j++ {
+			if p1.Coeffs[x][j] != p2.Coeffs[x][j] {
+				return false
+			}
+		}
+	}
+	return true
+}
+
 func test_MulPoly_Montgomery(context *Context, t *testing.T) {
 
 	t.Run(fmt.Sprintf("N=%d/limbs=%d/MulPoly_Montgomery", context.N, len(context.Modulus)), func(t *testing.T) {
@@ -643,3 +869,1018 @@ func test_MultByMonomial(context *Context, t *testing.T) {
 		}
 	})
 }
+
+func test_NTTSingle(context *Context, t *testing.T) {
+
+	t.Run(fmt.Sprintf("N=%d/limbs=%d/NTTSingle", context.N, len(context.Modulus)), func(t *testing.T) {
+
+		p := context.NewUniformPoly()
+		pNTT := context.NewPoly()
+
+		context.NTT(p, pNTT)
+
+		for level, qi := range context.Modulus {
+
+			coeffs := make([]uint64, context.N)
+			copy(coeffs, p.Coeffs[level])
+
+			context.NTTSingle(coeffs, level)
+
+			for j := range coeffs {
+				if coeffs[j] != pNTT.Coeffs[level][j] {
+					t.Errorf("error : NTTSingle differs from NTT on the level %d", level)
+					break
+				}
+			}
+
+			context.InvNTTSingle(coeffs, level)
+
+			for j := range coeffs {
+				if coeffs[j]%qi != p.Coeffs[level][j]%qi {
+					t.Errorf("error : InvNTTSingle(NTTSingle) is not the identity modulo %d", qi)
+					break
+				}
+			}
+		}
+	})
+}
+
+func test_NTTTablesCache(contextQ, contextP *Context, t *testing.T) {
+
+	t.Run(fmt.Sprintf("N=%d/limbs=%d/NTTTablesCache", contextQ.N, len(contextQ.Modulus)), func(t *testing.T) {
+
+		// Builds concurrently several contexts over a moduli chain overlapping with both Q and P
+		moduli := append([]uint64{contextP.Modulus[0]}, contextQ.Modulus[1:]...)
+
+		contexts := make([]*Context, 4)
+
+		var wg sync.WaitGroup
+		for i := range contexts {
+			wg.Add(1)
+			go func(i int) {
+				defer wg.Done()
+				contexts[i] = NewContext()
+				contexts[i].SetParameters(contextQ.N, moduli)
+				contexts[i].GenNTTParams()
+			}(i)
+		}
+		wg.Wait()
+
+		for _, context := range contexts {
+
+			// The tables must be shared with the previously created contexts
+			if &context.nttPsi[0][0] != &contextP.nttPsi[0][0] || &context.nttPsiInv[0][0] != &contextP.nttPsiInv[0][0] {
+				t.Errorf("error : NTT tables not shared")
+			}
+
+			for i := 1; i < len(moduli); i++ {
+				if &context.nttPsi[i][0] != &contextQ.nttPsi[i][0] || &context.nttPsiInv[i][0] != &contextQ.nttPsiInv[i][0] {
+					t.Errorf("error : NTT tables not shared")
+				}
+			}
+
+			// And must match freshly computed tables
+			for i, qi := range context.Modulus {
+
+				tables := genNTTTables(qi, context.N, context.bredParams[i], context.mredParams[i])
+
+				if tables.psiMont != context.psiMont[i] || tables.psiInvMont != context.psiInvMont[i] || tables.nttNInv != context.nttNInv[i] {
+					t.Errorf("error : NTT tables do not match")
+				}
+
+				for j := uint64(0); j < context.N; j++ {
+					if tables.nttPsi[j] != context.nttPsi[i][j] || tables.nttPsiInv[j] != context.nttPsiInv[i][j] {
+						t.Errorf("error : NTT tables do not match")
+						break
+					}
+				}
+			}
+		}
+
+		p0 := contexts[0].NewUniformPoly()
+		p1 := p0.CopyNew()
+
+		contexts[0].NTT(p1, p1)
+		contexts[0].InvNTT(p1, p1)
+
+		if contexts[0].Equal(p0, p1) != true {
+			t.Errorf("error : NTT/InvNTT with shared tables")
+		}
+	})
+}
+
+func test_NTTTablesCacheSize(contextQ *Context, t *testing.T) {
+
+	t.Run(fmt.Sprintf("N=%d/limbs=%d/NTTTablesCacheSize", contextQ.N, len(contextQ.Modulus)), func(t *testing.T) {
+
+		defer SetNTTTablesCacheSize(DefaultNTTTablesCacheSize)
+
+		newContext := func(moduli []uint64) *Context {
+			context := NewContext()
+			context.SetParameters(contextQ.N, moduli)
+			context.GenNTTParams()
+			return context
+		}
+
+		last := len(contextQ.Modulus) - 1
+
+		// The cache never holds more than its size
+		SetNTTTablesCacheSize(1)
+
+		context0 := newContext(contextQ.Modulus)
+
+		if len(nttTablesCache.tables) > 1 || len(nttTablesCache.order) > 1 {
+			t.Errorf("error : NTT tables cache holds %d entries, want at most 1", len(nttTablesCache.tables))
+		}
+
+		// Only the most recently used modulus is still shared
+		context1 := newContext(contextQ.Modulus[last:])
+
+		if &context1.nttPsi[0][0] != &context0.nttPsi[last][0] {
+			t.Errorf("error : most recently used NTT tables not shared")
+		}
+
+		if last > 0 {
+			if context := newContext(contextQ.Modulus[:1]); &context.nttPsi[0][0] == &context0.nttPsi[0][0] {
+				t.Errorf("error : evicted NTT tables still shared")
+			}
+		}
+
+		// A size of 0 disables the cache
+		SetNTTTablesCacheSize(0)
+
+		if len(nttTablesCache.tables) != 0 {
+			t.Errorf("error : disabled NTT tables cache holds %d entries", len(nttTablesCache.tables))
+		}
+
+		context2 := newContext(contextQ.Modulus)
+
+		if &context2.nttPsi[last][0] == &context0.nttPsi[last][0] {
+			t.Errorf("error : NTT tables shared with the cache disabled")
+		}
+
+		// Evicted or uncached tables remain correct
+		p0 := context2.NewUniformPoly()
+		p1 := p0.CopyNew()
+
+		context2.NTT(p1, p1)
+		context0.InvNTT(p1, p1)
+
+		if context2.Equal(p0, p1) != true {
+			t.Errorf("error : NTT/InvNTT with uncached tables")
+		}
+	})
+}
+
+func test_IsReduced(context *Context, t *testing.T) {
+
+	t.Run(fmt.Sprintf("N=%d/limbs=%d/IsReduced", context.N, len(context.Modulus)), func(t *testing.T) {
+
+		p := context.NewUniformPoly()
+
+		if context.IsReduced(p) != true {
+			t.Errorf("error : reduced polynomial reported as unreduced")
+		}
+
+		// A polynomial with an extra limb is not reduced for the context
+		if context.IsReduced(&Poly{Coeffs: append(p.CopyNew().Coeffs, make([]uint64, context.N))}) != false {
+			t.Errorf("error : polynomial with an extra limb reported as reduced")
+		}
+
+		for i, qi := range context.Modulus {
+
+			pTest := p.CopyNew()
+			pTest.Coeffs[i][context.N-1] += qi
+
+			if context.IsReduced(pTest) != false {
+				t.Errorf("error : unreduced polynomial reported as reduced")
+			}
+
+			context.ForceReduce(pTest)
+
+			if context.IsReduced(pTest) != true || pTest.Coeffs[i][context.N-1] != p.Coeffs[i][context.N-1] {
+				t.Errorf("error : ForceReduce")
+			}
+		}
+	})
+}
+
+func test_ModulusProduct(contextQ, contextQP *Context, t *testing.T) {
+
+	t.Run(fmt.Sprintf("N=%d/limbs=%d/ModulusProduct", contextQ.N, len(contextQ.Modulus)), func(t *testing.T) {
+
+		for _, context := range []*Context{contextQ, contextQP} {
+
+			Q := new(big.Int).SetUint64(context.Modulus[0])
+			for _, qi := range context.Modulus[1:] {
+				Q.Mul(Q, new(big.Int).SetUint64(qi))
+			}
+
+			if context.ModulusProduct().Cmp(Q) != 0 {
+				t.Errorf("error : ModulusProduct, want %v have %v", Q, context.ModulusProduct())
+			}
+
+			if context.ModulusProduct().Cmp(&context.ModulusBigint.Value) != 0 {
+				t.Errorf("error : ModulusProduct does not match ModulusBigint")
+			}
+		}
+	})
+}
+
+func test_DecomposePoly(context *Context, t *testing.T) {
+
+	for _, bitDecomp := range []uint64{16, 60} {
+
+		t.Run(fmt.Sprintf("N=%d/limbs=%d/bitDecomp=%d/DecomposePoly", context.N, len(context.Modulus), bitDecomp), func(t *testing.T) {
+
+			p := context.NewUniformPoly()
+
+			decomposed := context.DecomposePoly(p, bitDecomp)
+
+			if len(decomposed) != len(context.Modulus) {
+				t.Fatalf("error : DecomposePoly returned %d limbs", len(decomposed))
+			}
+
+			for i, qi := range context.Modulus {
+
+				if uint64(len(decomposed[i])) != (60+bitDecomp-1)/bitDecomp {
+					t.Fatalf("error : DecomposePoly returned %d digits for the limb %d", len(decomposed[i]), i)
+				}
+
+				for w := range decomposed[i] {
+					for k := range context.Modulus {
+						for j := uint64(0); j < context.N; j++ {
+
+							want := uint64(0)
+							if k == i {
+								want = PowerOf2(p.Coeffs[i][j], bitDecomp*uint64(w), qi, context.mredParams[i])
+							}
+
+							if decomposed[i][w].Coeffs[k][j] != want {
+								t.Fatalf("error : DecomposePoly digit %d of the limb %d differs from the inline decomposition", w, i)
+							}
+						}
+					}
+				}
+			}
+		})
+	}
+}
+
+func test_PolySlice(context *Context, t *testing.T) {
+
+	t.Run(fmt.Sprintf("N=%d/limbs=%d/PolySlice", context.N, len(context.Modulus)), func(t *testing.T) {
+
+		p := context.NewUniformPoly()
+		limb := len(context.Modulus) - 1
+		start, end := int(context.N>>2), int(context.N>>1)
+
+		view := p.Slice(limb, start, end)
+
+		if len(view) != end-start || cap(view) != end-start {
+			t.Fatalf("error : Slice, want len = cap = %d have len %d cap %d", end-start, len(view), cap(view))
+		}
+
+		// The view aliases the coefficients of the polynomial in both directions
+		view[0] = 1
+		if p.Coeffs[limb][start] != 1 {
+			t.Errorf("error : Slice, writing on the view does not modify the polynomial")
+		}
+
+		p.Coeffs[limb][end-1] = 2
+		if view[len(view)-1] != 2 {
+			t.Errorf("error : Slice, writing on the polynomial does not modify the view")
+		}
+
+		// Appending to the view must reallocate instead of overwriting the next coefficient
+		next := p.Coeffs[limb][end]
+		view = append(view, next+1)
+		if p.Coeffs[limb][end] != next {
+			t.Errorf("error : Slice, appending to the view overwrote the polynomial")
+		}
+
+		// Out of bounds ranges panic
+		for _, bounds := range [][3]int{{limb + 1, 0, 1}, {limb, start, int(context.N) + 1}, {limb, end, start}} {
+			func() {
+				defer func() {
+					if recover() == nil {
+						t.Errorf("error : Slice%v did not panic", bounds)
+					}
+				}()
+				p.Slice(bounds[0], bounds[1], bounds[2])
+			}()
+		}
+	})
+}
+
+func test_AddScaled(context *Context, t *testing.T) {
+
+	t.Run(fmt.Sprintf("N=%d/limbs=%d/AddScaled", context.N, len(context.Modulus)), func(t *testing.T) {
+
+		// Weighted sum of shares, with weights smaller and larger than the moduli
+		weights := []uint64{0, 1, 3, context.Modulus[0] - 1, 0xFFFFFFFFFFFFFFFF}
+
+		shares := make([]*Poly, len(weights))
+		for i := range shares {
+			shares[i] = context.NewUniformPoly()
+		}
+
+		have := context.NewPoly()
+		want := context.NewPoly()
+		tmp := context.NewPoly()
+
+		for i := range shares {
+			context.AddScaled(shares[i], weights[i], have)
+
+			context.MulScalarBigint(shares[i], NewUint(weights[i]), tmp)
+			context.Add(want, tmp, want)
+		}
+
+		if context.Equal(want, have) != true {
+			t.Errorf("error : AddScaled")
+		}
+	})
+}
+
+func test_ErrorSampler(sigma float64, context *Context, t *testing.T) {
+
+	distributions := []struct {
+		name     string
+		dist     DistributionType
+		param    float64
+		variance float64
+	}{
+		{"DiscreteGaussian", DiscreteGaussian, sigma, sigma * sigma},
+		{"CenteredBinomial", CenteredBinomial, 8, 4},
+		{"RoundedGaussian", RoundedGaussian, sigma, sigma*sigma + 1.0/12},
+	}
+
+	for _, distribution := range distributions {
+
+		t.Run(fmt.Sprintf("N=%d/limbs=%d/ErrorSampler/%s", context.N, len(context.Modulus), distribution.name), func(t *testing.T) {
+
+			sampler, err := context.NewErrorSampler(distribution.dist, distribution.param)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			pol := context.NewPoly()
+
+			var sum, sumSquares, count float64
+
+			for k := 0; k < 4; k++ {
+
+				sampler.SampleNTT(pol)
+				context.InvNTT(pol, pol)
+
+				for i, qi := range context.Modulus {
+					for j := uint64(0); j < context.N; j++ {
+
+						c := float64(pol.Coeffs[i][j])
+						if pol.Coeffs[i][j] > qi>>1 {
+							c = -float64(qi - pol.Coeffs[i][j])
+						}
+
+						// All the limbs must represent the same small integer
+						if i == 0 {
+							sum += c
+							sumSquares += c * c
+							count++
+						} else if pol.Coeffs[i][j] != pol.Coeffs[0][j] && qi-pol.Coeffs[i][j] != context.Modulus[0]-pol.Coeffs[0][j] {
+							t.Fatalf("error : ErrorSampler, the limbs of the coefficient %d do not match", j)
+						}
+					}
+				}
+			}
+
+			mean := sum / count
+			variance := sumSquares/count - mean*mean
+
+			if math.Abs(variance-distribution.variance) > 0.1*distribution.variance {
+				t.Errorf("error : ErrorSampler, empirical variance %f, want %f", variance, distribution.variance)
+			}
+
+			if math.Abs(mean) > 0.1 {
+				t.Errorf("error : ErrorSampler, empirical mean %f, want 0", mean)
+			}
+		})
+	}
+
+	t.Run(fmt.Sprintf("N=%d/limbs=%d/ErrorSampler/InvalidParameters", context.N, len(context.Modulus)), func(t *testing.T) {
+
+		for _, invalid := range []struct {
+			dist  DistributionType
+			param float64
+		}{{DiscreteGaussian, 0}, {RoundedGaussian, -1}, {CenteredBinomial, 0}, {CenteredBinomial, 2.5}, {CenteredBinomial, 65}, {DistributionType(-1), 1}} {
+			if _, err := context.NewErrorSampler(invalid.dist, invalid.param); err == nil {
+				t.Errorf("error : NewErrorSampler accepted the distribution %d with parameter %f", invalid.dist, invalid.param)
+			}
+		}
+	})
+}
+
+func test_SampleSecretWithNorm(context *Context, t *testing.T) {
+
+	for _, target := range []float64{10, 45.3, math.Sqrt(float64(context.N)), 500, 3000} {
+
+		t.Run(fmt.Sprintf("N=%d/limbs=%d/SampleSecretWithNorm/target=%.1f", context.N, len(context.Modulus), target), func(t *testing.T) {
+
+			secret := context.NewPoly()
+			context.SampleSecretWithNorm(target, secret)
+
+			coeffs := make([]*Int, context.N)
+			context.PolyToBigint(secret, coeffs)
+
+			var square float64
+			for i := range coeffs {
+				coeff := coeffs[i].Center(context.ModulusBigint).Float64()
+				square += coeff * coeff
+			}
+
+			if norm := math.Sqrt(square); math.Abs(norm-target) > 0.01*target {
+				t.Errorf("error : SampleSecretWithNorm, target norm %f, measured norm %f", target, norm)
+			}
+		})
+	}
+
+	t.Run(fmt.Sprintf("N=%d/limbs=%d/SampleSecretWithNorm/target=0", context.N, len(context.Modulus)), func(t *testing.T) {
+
+		secret := context.NewUniformPoly()
+		context.SampleSecretWithNorm(0, secret)
+
+		if context.Equal(secret, context.NewPoly()) != true {
+			t.Errorf("error : SampleSecretWithNorm with a zero target is not zero")
+		}
+	})
+}
+
+func test_SampleHammingWeight(context *Context, t *testing.T) {
+
+	sampler := context.NewTernarySampler()
+
+	// Returns the number of 1 and -1 coefficients of pol, checking that all its coefficients are in [-1, 1] on each limb
+	count := func(pol *Poly, t *testing.T) (plus, minus uint64) {
+		for j := uint64(0); j < context.N; j++ {
+			switch pol.Coeffs[0][j] {
+			case 0:
+			case 1:
+				plus++
+			case context.Modulus[0] - 1:
+				minus++
+			default:
+				t.Fatalf("error : SampleHammingWeight, coefficient %d is not ternary", j)
+			}
+			for i, qi := range context.Modulus {
+				if pol.Coeffs[i][j] != 0 && pol.Coeffs[i][j] != 1 && pol.Coeffs[i][j] != qi-1 || (pol.Coeffs[i][j] == 1) != (pol.Coeffs[0][j] == 1) {
+					t.Fatalf("error : SampleHammingWeight, coefficient %d differs between the limbs", j)
+				}
+			}
+		}
+		return
+	}
+
+	for _, h := range []uint64{0, 1, 64, context.N >> 1, context.N} {
+
+		t.Run(fmt.Sprintf("N=%d/limbs=%d/SampleHammingWeight/h=%d", context.N, len(context.Modulus), h), func(t *testing.T) {
+
+			pol, err := sampler.SampleHammingWeightNew(h)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if plus, minus := count(pol, t); plus+minus != h {
+				t.Errorf("error : SampleHammingWeight, %d nonzero coefficients, want %d", plus+minus, h)
+			}
+
+			// Montgomery and NTT variant
+			if pol, err = sampler.SampleHammingWeightMontgomeryNTTNew(h); err != nil {
+				t.Fatal(err)
+			}
+
+			context.InvNTT(pol, pol)
+			context.InvMForm(pol, pol)
+
+			if plus, minus := count(pol, t); plus+minus != h {
+				t.Errorf("error : SampleHammingWeightMontgomeryNTT, %d nonzero coefficients, want %d", plus+minus, h)
+			}
+		})
+	}
+
+	t.Run(fmt.Sprintf("N=%d/limbs=%d/SampleHammingWeight/Distribution", context.N, len(context.Modulus)), func(t *testing.T) {
+
+		h, draws := uint64(64), 100
+
+		pol := context.NewPoly()
+
+		var plus, minus, lowerHalf uint64
+
+		for k := 0; k < draws; k++ {
+
+			if err := sampler.SampleHammingWeight(h, pol); err != nil {
+				t.Fatal(err)
+			}
+
+			p, m := count(pol, t)
+			plus, minus = plus+p, minus+m
+
+			for j := uint64(0); j < context.N>>1; j++ {
+				if pol.Coeffs[0][j] != 0 {
+					lowerHalf++
+				}
+			}
+		}
+
+		// Both counts are binomial of mean h*draws/2 and standard deviation sqrt(h*draws)/2, 5 standard deviations are tolerated
+		tolerance := 5 * math.Sqrt(float64(h*uint64(draws))) / 2
+
+		if math.Abs(float64(plus)-float64(minus))/2 > tolerance {
+			t.Errorf("error : SampleHammingWeight, unbalanced signs (%d ones, %d minus ones)", plus, minus)
+		}
+
+		if math.Abs(float64(lowerHalf)-float64(h*uint64(draws))/2) > tolerance {
+			t.Errorf("error : SampleHammingWeight, %d of %d nonzero coefficients in the lower half", lowerHalf, h*uint64(draws))
+		}
+
+		if sampler.SampleHammingWeight(context.N+1, pol) == nil {
+			t.Errorf("error : SampleHammingWeight accepted a weight larger than N")
+		}
+	})
+}
+
+func test_CoeffToBigint(context *Context, t *testing.T) {
+
+	t.Run(fmt.Sprintf("N=%d/limbs=%d/CoeffToBigint", context.N, len(context.Modulus)), func(t *testing.T) {
+
+		ternary, err := context.NewTernarySampler().SampleNew(1.0 / 3)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		for _, pol := range []*Poly{context.NewUniformPoly(), ternary} {
+
+			coeffs := make([]*Int, context.N)
+			context.PolyToBigint(pol, coeffs)
+
+			for _, index := range []uint64{0, 1, context.N >> 1, context.N - 1, RandUniform(context.N, context.N-1)} {
+
+				want := coeffs[index].Center(context.ModulusBigint)
+
+				if have := context.CoeffToBigint(pol, index); have.Value.Cmp(&want.Value) != 0 {
+					t.Errorf("error : CoeffToBigint at index %d, want %s have %s", index, want.Value.String(), have.Value.String())
+				}
+			}
+		}
+
+		// The coefficients of a ternary polynomial are reconstructed as -1, 0 or 1
+		for index := uint64(0); index < context.N; index++ {
+			if value := context.CoeffToBigint(ternary, index); !value.Value.IsInt64() || value.Value.Int64() < -1 || value.Value.Int64() > 1 {
+				t.Fatalf("error : CoeffToBigint at index %d of a ternary polynomial is %s", index, value.Value.String())
+			}
+		}
+	})
+}
+
+func test_MarshalPoly(t *testing.T) {
+
+	for _, N := range []uint64{16, 1024, 8192} {
+
+		for _, moduli := range []int{1, 2, 5} {
+
+			context := NewContext()
+			if err := context.SetParameters(N, Qi60[:moduli]); err != nil {
+				t.Fatal(err)
+			}
+
+			t.Run(fmt.Sprintf("N=%d/limbs=%d/MarshalPolyRoundTrip", N, moduli), func(t *testing.T) {
+
+				for k := 0; k < 4; k++ {
+
+					p := context.NewUniformPoly()
+
+					data, err := p.MarshalBinary()
+					if err != nil {
+						t.Fatal(err)
+					}
+
+					if uint64(len(data)) != p.GetDataLen(true) || p.GetDataLen(true) != p.GetDataLen(false)+2 || p.GetDataLen(false) != 8*N*uint64(moduli) {
+						t.Errorf("error : data length %d, GetDataLen(true) %d, GetDataLen(false) %d", len(data), p.GetDataLen(true), p.GetDataLen(false))
+					}
+
+					pTest, err := context.NewPoly().UnMarshalBinary(data)
+					if err != nil {
+						t.Fatal(err)
+					}
+
+					if context.Equal(p, pTest) != true {
+						t.Errorf("error : polynomial round trip")
+					}
+				}
+			})
+
+			t.Run(fmt.Sprintf("N=%d/limbs=%d/MarshalPolyMalformed", N, moduli), func(t *testing.T) {
+
+				p := context.NewUniformPoly()
+				data, _ := p.MarshalBinary()
+
+				for _, length := range []int{0, 1, 2, 9, len(data) / 2, len(data) - 8, len(data) - 1} {
+					if _, err := context.NewPoly().UnMarshalBinary(data[:length]); err == nil {
+						t.Errorf("error : polynomial truncated to %d bytes unmarshaled without error", length)
+					}
+				}
+
+				if _, err := context.NewPoly().UnMarshalBinary(append(data, 0)); err == nil {
+					t.Errorf("error : polynomial with trailing data unmarshaled without error")
+				}
+
+				// Encodings whose dimensions do not match the target polynomial
+				if _, err := (&Poly{Coeffs: p.Coeffs[:moduli-1]}).UnMarshalBinary(data); err == nil {
+					t.Errorf("error : polynomial unmarshaled on a polynomial with fewer moduli")
+				}
+
+				corrupted := append([]byte{}, data...)
+				corrupted[0]++
+				if _, err := context.NewPoly().UnMarshalBinary(corrupted); err == nil {
+					t.Errorf("error : polynomial with a corrupted degree unmarshaled without error")
+				}
+
+				corrupted[0] = 0xFF
+				if _, err := context.NewPoly().UnMarshalBinary(corrupted); err == nil {
+					t.Errorf("error : polynomial with an invalid degree unmarshaled without error")
+				}
+
+				if _, err := new(Poly).MarshalBinary(); err == nil {
+					t.Errorf("error : empty polynomial marshaled without error")
+				}
+			})
+		}
+	}
+}
+
+func test_ConstantTimeReduction(context *Context, t *testing.T) {
+
+	t.Run(fmt.Sprintf("N=%d/limbs=%d/ConstantTimeReduction", context.N, len(context.Modulus)), func(t *testing.T) {
+		for j, q := range context.Modulus {
+
+			u := context.bredParams[j]
+			qInv := context.mredParams[j]
+
+			// The edges of the ranges of the inputs, followed by random inputs
+			edges := []uint64{0, 1, 2, q>>1 - 1, q >> 1, q>>1 + 1, q - 2, q - 1}
+
+			for i := 0; i < 65536+len(edges); i++ {
+
+				var x, y uint64
+				if i < len(edges) {
+					x, y = edges[i], edges[len(edges)-1-i]
+				} else {
+					x, y = rand.Uint64()%q, rand.Uint64()%q
+				}
+
+				// a in [0, 2q-1]
+				a := x + y
+
+				if CRedCT(a, q) != CRed(a, q) {
+					t.Errorf("error : CRedCT(%d) = %d, want %d (q = %d)", a, CRedCT(a, q), CRed(a, q), q)
+				}
+
+				if MFormCT(x, q, u) != MForm(x, q, u) {
+					t.Errorf("error : MFormCT(%d) = %d, want %d (q = %d)", x, MFormCT(x, q, u), MForm(x, q, u), q)
+				}
+
+				if InvMFormCT(x, q, qInv) != InvMForm(x, q, qInv) {
+					t.Errorf("error : InvMFormCT(%d) = %d, want %d (q = %d)", x, InvMFormCT(x, q, qInv), InvMForm(x, q, qInv), q)
+				}
+
+				if MRedCT(x, y, q, qInv) != MRed(x, y, q, qInv) {
+					t.Errorf("error : MRedCT(%d, %d) = %d, want %d (q = %d)", x, y, MRedCT(x, y, q, qInv), MRed(x, y, q, qInv), q)
+				}
+
+				if BRedCT(x, y, q, u) != BRed(x, y, q, u) {
+					t.Errorf("error : BRedCT(%d, %d) = %d, want %d (q = %d)", x, y, BRedCT(x, y, q, u), BRed(x, y, q, u), q)
+				}
+
+				// BRedAdd reduces any 64 bit integer
+				z := x*q + y
+				if BRedAddCT(z, q, u) != BRedAdd(z, q, u) {
+					t.Errorf("error : BRedAddCT(%d) = %d, want %d (q = %d)", z, BRedAddCT(z, q, u), BRedAdd(z, q, u), q)
+				}
+
+				n := uint64(i & 63)
+				if PowerOf2CT(x, n, q, qInv) != PowerOf2(x, n, q, qInv) {
+					t.Errorf("error : PowerOf2CT(%d, %d) = %d, want %d (q = %d)", x, n, PowerOf2CT(x, n, q, qInv), PowerOf2(x, n, q, qInv), q)
+				}
+
+				if t.Failed() {
+					return
+				}
+			}
+		}
+	})
+}
+
+func test_NTTTable(context *Context, t *testing.T) {
+
+	t.Run(fmt.Sprintf("N=%d/limbs=%d/NTTTable", context.N, len(context.Modulus)), func(t *testing.T) {
+
+		bitLenofN := uint64(bits.Len64(context.N) - 1)
+
+		for j, qi := range context.Modulus {
+
+			table := context.NTTTable(j)
+
+			if uint64(len(table)) != context.N {
+				t.Fatalf("error : the NTT table of limb %d has %d elements, want %d", j, len(table), context.N)
+			}
+
+			if table[0] != 1 {
+				t.Errorf("error : the NTT table of limb %d does not start with 1", j)
+			}
+
+			psi := table[bitReverse64(1, bitLenofN)]
+
+			// psi^(i+1) = psi^i * psi, and psi^N = -1 since psi is a 2Nth primitive root
+			for i := uint64(0); i < context.N-1; i++ {
+				if table[bitReverse64(i+1, bitLenofN)] != BRed(table[bitReverse64(i, bitLenofN)], psi, qi, context.bredParams[j]) {
+					t.Fatalf("error : the NTT table of limb %d does not satisfy the recurrence at index %d", j, i+1)
+				}
+			}
+
+			if BRed(table[bitReverse64(context.N-1, bitLenofN)], psi, qi, context.bredParams[j]) != qi-1 {
+				t.Errorf("error : the root of the NTT table of limb %d is not a 2Nth primitive root", j)
+			}
+
+			// The table is a copy
+			table[0] = 0
+			if context.NTTTable(j)[0] != 1 {
+				t.Errorf("error : NTTTable does not return a copy of the table of limb %d", j)
+			}
+		}
+	})
+}
+
+func test_SamplerSource(sigma float64, context *Context, t *testing.T) {
+
+	bound := int(sigma * 6)
+
+	t.Run(fmt.Sprintf("N=%d/limbs=%d/SamplerSource/KYSampler", context.N, len(context.Modulus)), func(t *testing.T) {
+
+		kys0 := context.NewKYSampler(sigma, bound)
+		kys1 := context.NewKYSampler(sigma, bound)
+
+		kys0.SetSource(rand.New(rand.NewSource(42)))
+		kys1.SetSource(rand.New(rand.NewSource(42)))
+
+		for i := 0; i < 2; i++ {
+			if context.Equal(kys0.SampleNTTNew(), kys1.SampleNTTNew()) != true {
+				t.Errorf("error : identically seeded KYSamplers sample different polynomials")
+			}
+		}
+
+		kys1.SetSource(rand.New(rand.NewSource(43)))
+
+		if context.Equal(kys0.SampleNTTNew(), kys1.SampleNTTNew()) {
+			t.Errorf("error : differently seeded KYSamplers sample the same polynomial")
+		}
+	})
+
+	t.Run(fmt.Sprintf("N=%d/limbs=%d/SamplerSource/TernarySampler", context.N, len(context.Modulus)), func(t *testing.T) {
+
+		ternary0 := context.NewTernarySampler()
+		ternary1 := context.NewTernarySampler()
+
+		ternary0.SetSource(rand.New(rand.NewSource(42)))
+		ternary1.SetSource(rand.New(rand.NewSource(42)))
+
+		for _, p := range []float64{1.0 / 3, 0.5} {
+
+			pol0, _ := ternary0.SampleMontgomeryNTTNew(p)
+			pol1, _ := ternary1.SampleMontgomeryNTTNew(p)
+
+			if context.Equal(pol0, pol1) != true {
+				t.Errorf("error : identically seeded TernarySamplers sample different polynomials for p=%f", p)
+			}
+		}
+
+		pol0, _ := ternary0.SampleHammingWeightNew(64)
+		pol1, _ := ternary1.SampleHammingWeightNew(64)
+
+		if context.Equal(pol0, pol1) != true {
+			t.Errorf("error : identically seeded TernarySamplers sample different polynomials of fixed hamming weight")
+		}
+
+		// The default source is crypto/rand
+		ternary0.SetSource(nil)
+		ternary1.SetSource(nil)
+
+		pol0, _ = ternary0.SampleNew(1.0 / 3)
+		pol1, _ = ternary1.SampleNew(1.0 / 3)
+
+		if context.Equal(pol0, pol1) {
+			t.Errorf("error : TernarySamplers without a source sample the same polynomial")
+		}
+	})
+
+	t.Run(fmt.Sprintf("N=%d/limbs=%d/SamplerSource/ErrorSampler", context.N, len(context.Modulus)), func(t *testing.T) {
+
+		errorSampler0, _ := context.NewErrorSampler(DiscreteGaussian, sigma)
+		errorSampler1, _ := context.NewErrorSampler(DiscreteGaussian, sigma)
+
+		// The KYSampler built before the source is set must also read from it
+		errorSampler0.SampleNTTNew()
+		errorSampler0.SetSource(rand.New(rand.NewSource(42)))
+		errorSampler1.SetSource(rand.New(rand.NewSource(42)))
+
+		for _, dist := range []struct {
+			dist  DistributionType
+			param float64
+		}{{DiscreteGaussian, sigma}, {DiscreteGaussian, 2 * sigma}, {CenteredBinomial, 21}, {RoundedGaussian, sigma}} {
+
+			pol0, pol1 := context.NewPoly(), context.NewPoly()
+
+			if err := errorSampler0.SampleError(dist.dist, dist.param, pol0); err != nil {
+				t.Fatal(err)
+			}
+
+			if err := errorSampler1.SampleError(dist.dist, dist.param, pol1); err != nil {
+				t.Fatal(err)
+			}
+
+			if context.Equal(pol0, pol1) != true {
+				t.Errorf("error : identically seeded ErrorSamplers sample different polynomials for the distribution %d", dist.dist)
+			}
+		}
+	})
+
+	t.Run(fmt.Sprintf("N=%d/limbs=%d/SamplerSource/SampleSecretWithNorm", context.N, len(context.Modulus)), func(t *testing.T) {
+
+		pol0, pol1 := context.NewPoly(), context.NewPoly()
+
+		targetNorm := math.Sqrt(float64(context.N)) / 2
+
+		context.SampleSecretWithNormFromSource(rand.New(rand.NewSource(42)), targetNorm, pol0)
+		context.SampleSecretWithNormFromSource(rand.New(rand.NewSource(42)), targetNorm, pol1)
+
+		if context.Equal(pol0, pol1) != true {
+			t.Errorf("error : identically seeded SampleSecretWithNormFromSource sample different secrets")
+		}
+
+		context.SampleSecretWithNormFromSource(rand.New(rand.NewSource(43)), targetNorm, pol1)
+
+		if context.Equal(pol0, pol1) {
+			t.Errorf("error : differently seeded SampleSecretWithNormFromSource sample the same secret")
+		}
+	})
+}
+
+func test_NTTParallel(context *Context, t *testing.T) {
+
+	moduli, err := GenerateNTTPrimes(context.N, (1<<59)+1, 2*DefaultNTTParallelThreshold, 60, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	t.Run(fmt.Sprintf("N=%d/limbs=%d/NTTParallel/Threshold", context.N, DefaultNTTParallelThreshold-1), func(t *testing.T) {
+
+		contextSmall, err := NewContextWithParameters(context.N, moduli[:DefaultNTTParallelThreshold-1])
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if workers := contextSmall.nttWorkers(8); workers != 1 {
+			t.Errorf("error : below the threshold, NTTParallel uses %d goroutines instead of the serial path", workers)
+		}
+	})
+
+	contextLarge, err := NewContextWithParameters(context.N, moduli)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	t.Run(fmt.Sprintf("N=%d/limbs=%d/NTTParallel", contextLarge.N, len(contextLarge.Modulus)), func(t *testing.T) {
+
+		workers := contextLarge.nttWorkers(4)
+
+		if workers < 1 || workers > 4 || workers > runtime.GOMAXPROCS(0) {
+			t.Errorf("error : NTTParallel uses %d goroutines for 4 workers and GOMAXPROCS=%d", workers, runtime.GOMAXPROCS(0))
+		}
+
+		p := contextLarge.NewUniformPoly()
+
+		want := contextLarge.NewPoly()
+		have := contextLarge.NewPoly()
+
+		contextLarge.NTT(p, want)
+		contextLarge.NTTParallel(p, have, 4)
+
+		if contextLarge.Equal(want, have) != true {
+			t.Errorf("error : NTTParallel does not match NTT")
+		}
+
+		contextLarge.InvNTT(want, want)
+		contextLarge.InvNTTParallel(have, have, 4)
+
+		if contextLarge.Equal(want, have) != true || contextLarge.Equal(p, have) != true {
+			t.Errorf("error : InvNTTParallel does not match InvNTT")
+		}
+
+		// The parallel path regardless of the available CPUs
+		limbWorkers := make([]int, len(contextLarge.Modulus))
+
+		contextLarge.ForEachLimb(3, func(x, worker int) {
+			limbWorkers[x] = worker
+			NTT(have.Coeffs[x], have.Coeffs[x], contextLarge.N, contextLarge.nttPsi[x], contextLarge.Modulus[x], contextLarge.mredParams[x], contextLarge.bredParams[x])
+		})
+
+		contextLarge.NTT(p, want)
+
+		if contextLarge.Equal(want, have) != true {
+			t.Errorf("error : the NTT of the limbs distributed across goroutines does not match NTT")
+		}
+
+		for x, worker := range limbWorkers {
+			if worker < 0 || worker >= 3 {
+				t.Errorf("error : limb %d processed by worker %d, not in [0, 3)", x, worker)
+			}
+		}
+	})
+}
+
+func test_SampleFlooding(context *Context, t *testing.T) {
+
+	for _, logBound := range []uint64{10, 63, 100} {
+
+		if logBound+2 > uint64(context.ModulusBigint.Value.BitLen()) {
+			continue
+		}
+
+		t.Run(fmt.Sprintf("N=%d/limbs=%d/logBound=%d/SampleFlooding", context.N, len(context.Modulus), logBound), func(t *testing.T) {
+
+			pol := context.NewPoly()
+			context.SampleFlooding(logBound, pol)
+
+			coeffsBigint := make([]*Int, context.N)
+			context.PolyToBigint(pol, coeffsBigint)
+
+			bound := NewUint(1)
+			bound.Lsh(bound, logBound)
+
+			negBound := NewUint(0)
+			negBound.Sub(context.ModulusBigint, bound)
+
+			// At least one coefficient is expected above 2^(logBound-2) in absolute value
+			quarter := NewUint(1)
+			quarter.Lsh(quarter, logBound-2)
+
+			negQuarter := NewUint(0)
+			negQuarter.Sub(context.ModulusBigint, quarter)
+
+			large := false
+
+			for _, c := range coeffsBigint {
+
+				if c.Compare(bound) != -1 && c.Compare(negBound) == -1 {
+					t.Fatalf("error : coefficient %s out of [-2^%d, 2^%d)", c.String(), logBound, logBound)
+				}
+
+				if c.Compare(quarter) == 1 && c.Compare(negQuarter) == -1 {
+					large = true
+				}
+			}
+
+			if !large {
+				t.Errorf("error : no coefficient above 2^%d in absolute value", logBound-2)
+			}
+		})
+	}
+}
+
+// assertNTTPreservedThroughSerialization guards the handling of the NTT domain by the serializers. It transforms the polynomial p in
+// the NTT domain, marshals it, unmarshals it, transforms it back and compares it with p modulo the moduli, returning an error
+// describing the first mismatch.
+func assertNTTPreservedThroughSerialization(context *Context, p *Poly) error {
+
+	pNTT := context.NewPoly()
+	context.NTT(p, pNTT)
+
+	data, err := pNTT.MarshalBinary()
+	if err != nil {
+		return err
+	}
+
+	pTest, err := context.NewPoly().UnMarshalBinary(data)
+	if err != nil {
+		return err
+	}
+
+	context.InvNTT(pTest, pTest)
+
+	for i, qi := range context.Modulus {
+		for j := uint64(0); j < context.N; j++ {
+			if pTest.Coeffs[i][j] != p.Coeffs[i][j]%qi {
+				return fmt.Errorf("NTT not preserved through serialization -> coefficient %d of limb %d, want %d have %d", j, i, p.Coeffs[i][j]%qi, pTest.Coeffs[i][j])
+			}
+		}
+	}
+
+	return nil
+}
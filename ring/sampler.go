@@ -4,7 +4,9 @@ import (
 	"crypto/rand"
 	"encoding/binary"
 	"errors"
+	"io"
 	"math"
+	"math/bits"
 )
 
 // KYSampler is the structure holding the parameters for the gaussian sampling.
@@ -13,6 +15,7 @@ type KYSampler struct {
 	sigma   float64
 	bound   int
 	Matrix  [][]uint8
+	source  io.Reader
 }
 
 // NewKYSampler creates a new KYSampler with sigma and bound that will be used to sample polynomial within the provided discret gaussian distribution.
@@ -25,6 +28,27 @@ func (context *Context) NewKYSampler(sigma float64, bound int) *KYSampler {
 	return kysampler
 }
 
+// SetSource sets the source of randomness of the KYSampler, e.g. a seeded PRNG to reproduce the sampled polynomials in tests.
+// A nil source, the default, samples from crypto/rand. The sampler panics if the source cannot provide enough bytes.
+func (kys *KYSampler) SetSource(source io.Reader) {
+	kys.source = source
+}
+
+// readRandom fills b with bytes read from source, or from crypto/rand if source is nil.
+func readRandom(source io.Reader, b []byte) {
+
+	if source == nil {
+		if _, err := rand.Read(b); err != nil {
+			panic("crypto rand error")
+		}
+		return
+	}
+
+	if _, err := io.ReadFull(source, b); err != nil {
+		panic("cannot sample -> the source of randomness returned " + err.Error())
+	}
+}
+
 //gaussian computes (1/variange*sqrt(pi)) * exp((x^2) / (2*variance^2)),  2.50662827463100050241576528481104525300698674060993831662992357 = sqrt(2*pi)
 func gaussian(x, sigma float64) float64 {
 	return (1 / (sigma * 2.5066282746310007)) * math.Exp(-((math.Pow(x, 2)) / (2 * sigma * sigma)))
@@ -73,7 +97,7 @@ func computeMatrix(sigma float64, bound int) [][]uint8 {
 	return M
 }
 
-func kysampling(M [][]uint8, randomBytes []byte, pointer uint8) (uint64, uint64, []byte, uint8) {
+func kysampling(source io.Reader, M [][]uint8, randomBytes []byte, pointer uint8) (uint64, uint64, []byte, uint8) {
 
 	var sign uint8
 
@@ -91,7 +115,7 @@ func kysampling(M [][]uint8, randomBytes []byte, pointer uint8) (uint64, uint64,
 			// There is small probability that it will get out of the bound, then
 			// rerun until it gets a proper output
 			if d > colLen-1 {
-				return kysampling(M, randomBytes, i)
+				return kysampling(source, M, randomBytes, i)
 			}
 
 			for row := colLen - 1; row >= 0; row-- {
@@ -108,9 +132,7 @@ func kysampling(M [][]uint8, randomBytes []byte, pointer uint8) (uint64, uint64,
 
 						if len(randomBytes) == 0 {
 							randomBytes = make([]byte, 8)
-							if _, err := rand.Read(randomBytes); err != nil {
-								panic("crypto rand error")
-							}
+							readRandom(source, randomBytes)
 						}
 
 						sign = uint8(randomBytes[0]) & 1
@@ -135,9 +157,7 @@ func kysampling(M [][]uint8, randomBytes []byte, pointer uint8) (uint64, uint64,
 		// Sample 8 new bytes if the last byte was discarded
 		if len(randomBytes) == 0 {
 			randomBytes = make([]byte, 8)
-			if _, err := rand.Read(randomBytes); err != nil {
-				panic("crypto rand error")
-			}
+			readRandom(source, randomBytes)
 		}
 
 	}
@@ -159,13 +179,11 @@ func (kys *KYSampler) Sample(Pol *Poly) {
 	randomBytes := make([]byte, 8)
 	pointer := uint8(0)
 
-	if _, err := rand.Read(randomBytes); err != nil {
-		panic("crypto rand error")
-	}
+	readRandom(kys.source, randomBytes)
 
 	for i := uint64(0); i < kys.context.N; i++ {
 
-		coeff, sign, randomBytes, pointer = kysampling(kys.Matrix, randomBytes, pointer)
+		coeff, sign, randomBytes, pointer = kysampling(kys.source, kys.Matrix, randomBytes, pointer)
 
 		for j, qi := range kys.context.Modulus {
 			Pol.Coeffs[j][i] = (coeff & (sign * 0xFFFFFFFFFFFFFFFF)) | ((qi - coeff) & ((sign ^ 1) * 0xFFFFFFFFFFFFFFFF))
@@ -194,6 +212,8 @@ type TernarySampler struct {
 	MatrixMontgomery [][]uint64
 
 	KYMatrix [][]uint8
+
+	source io.Reader
 }
 
 // NewTernarySampler creates a new TernarySampler from the target context.
@@ -221,6 +241,12 @@ func (context *Context) NewTernarySampler() *TernarySampler {
 	return sampler
 }
 
+// SetSource sets the source of randomness of the TernarySampler, e.g. a seeded PRNG to reproduce the sampled polynomials in tests.
+// A nil source, the default, samples from crypto/rand. The sampler panics if the source cannot provide enough bytes.
+func (sampler *TernarySampler) SetSource(source io.Reader) {
+	sampler.source = source
+}
+
 func computeMatrixTernary(p float64) (M [][]uint8) {
 	var g float64
 	var x uint64
@@ -266,13 +292,8 @@ func (sampler *TernarySampler) sample(samplerMatrix [][]uint64, p float64, pol *
 		randomBytesCoeffs := make([]byte, sampler.context.N>>3)
 		randomBytesSign := make([]byte, sampler.context.N>>3)
 
-		if _, err := rand.Read(randomBytesCoeffs); err != nil {
-			panic("crypto rand error")
-		}
-
-		if _, err := rand.Read(randomBytesSign); err != nil {
-			panic("crypto rand error")
-		}
+		readRandom(sampler.source, randomBytesCoeffs)
+		readRandom(sampler.source, randomBytesSign)
 
 		for i := uint64(0); i < sampler.context.N; i++ {
 			coeff = uint64(uint8(randomBytesCoeffs[i>>3])>>(i&7)) & 1
@@ -293,13 +314,11 @@ func (sampler *TernarySampler) sample(samplerMatrix [][]uint64, p float64, pol *
 
 		pointer := uint8(0)
 
-		if _, err := rand.Read(randomBytes); err != nil {
-			panic("crypto rand error")
-		}
+		readRandom(sampler.source, randomBytes)
 
 		for i := uint64(0); i < sampler.context.N; i++ {
 
-			coeff, sign, randomBytes, pointer = kysampling(matrix, randomBytes, pointer)
+			coeff, sign, randomBytes, pointer = kysampling(sampler.source, matrix, randomBytes, pointer)
 
 			index = (coeff & (sign ^ 1)) | ((sign & coeff) << 1)
 
@@ -386,6 +405,359 @@ func (sampler *TernarySampler) SampleMontgomeryNTT(p float64, pol *Poly) (err er
 	return nil
 }
 
+// sampleHammingWeight samples on pol a polynomial with exactly h nonzero coefficients, placed uniformly at random, each of them
+// being 1 or -1 with probability 1/2. The coefficients are taken from the samplerMatrix.
+func (sampler *TernarySampler) sampleHammingWeight(samplerMatrix [][]uint64, h uint64, pol *Poly) (err error) {
+
+	N := sampler.context.N
+
+	if h > N {
+		return errors.New("cannot sample -> hamming weight larger than the ring degree")
+	}
+
+	// Partial Fisher-Yates shuffle : the first h indexes are a uniform subset of size h
+	index := make([]uint64, N)
+	for i := range index {
+		index[i] = uint64(i)
+	}
+
+	for i := uint64(0); i < h; i++ {
+		j := i + randUniformFrom(sampler.source, N-i, (1<<uint64(bits.Len64(N-i)))-1)
+		index[i], index[j] = index[j], index[i]
+	}
+
+	pol.Zero()
+
+	randomBytesSign := make([]byte, (h+7)>>3)
+
+	readRandom(sampler.source, randomBytesSign)
+
+	for i := uint64(0); i < h; i++ {
+
+		sign := uint64(uint8(randomBytesSign[i>>3])>>(i&7)) & 1
+
+		for j := range sampler.context.Modulus {
+			pol.Coeffs[j][index[i]] = samplerMatrix[j][1+sign]
+		}
+	}
+
+	return nil
+}
+
+// SampleHammingWeight samples on pol a polynomial with exactly h nonzero coefficients in [-1, 1], placed uniformly at random.
+// Returns an error if h is larger than N.
+func (sampler *TernarySampler) SampleHammingWeight(h uint64, pol *Poly) (err error) {
+	return sampler.sampleHammingWeight(sampler.Matrix, h, pol)
+}
+
+// SampleHammingWeightNew samples a new polynomial with exactly h nonzero coefficients in [-1, 1], placed uniformly at random.
+func (sampler *TernarySampler) SampleHammingWeightNew(h uint64) (pol *Poly, err error) {
+	pol = sampler.context.NewPoly()
+	if err = sampler.SampleHammingWeight(h, pol); err != nil {
+		return nil, err
+	}
+	return pol, nil
+}
+
+// SampleHammingWeightMontgomery samples on pol a polynomial with exactly h nonzero coefficients in [-1, 1], in montgomery form.
+func (sampler *TernarySampler) SampleHammingWeightMontgomery(h uint64, pol *Poly) (err error) {
+	return sampler.sampleHammingWeight(sampler.MatrixMontgomery, h, pol)
+}
+
+// SampleHammingWeightMontgomeryNew samples a new polynomial with exactly h nonzero coefficients in [-1, 1], in montgomery form.
+func (sampler *TernarySampler) SampleHammingWeightMontgomeryNew(h uint64) (pol *Poly, err error) {
+	pol = sampler.context.NewPoly()
+	if err = sampler.SampleHammingWeightMontgomery(h, pol); err != nil {
+		return nil, err
+	}
+	return pol, nil
+}
+
+// SampleHammingWeightNTT samples on pol a polynomial with exactly h nonzero coefficients in [-1, 1], in the NTT domain.
+func (sampler *TernarySampler) SampleHammingWeightNTT(h uint64, pol *Poly) (err error) {
+	if err = sampler.SampleHammingWeight(h, pol); err != nil {
+		return err
+	}
+	sampler.context.NTT(pol, pol)
+	return nil
+}
+
+// SampleHammingWeightNTTNew samples a new polynomial with exactly h nonzero coefficients in [-1, 1], in the NTT domain.
+func (sampler *TernarySampler) SampleHammingWeightNTTNew(h uint64) (pol *Poly, err error) {
+	if pol, err = sampler.SampleHammingWeightNew(h); err != nil {
+		return nil, err
+	}
+	sampler.context.NTT(pol, pol)
+	return pol, nil
+}
+
+// SampleHammingWeightMontgomeryNTT samples on pol a polynomial with exactly h nonzero coefficients in [-1, 1], in the NTT domain
+// and in montgomery form.
+func (sampler *TernarySampler) SampleHammingWeightMontgomeryNTT(h uint64, pol *Poly) (err error) {
+	if err = sampler.SampleHammingWeightMontgomery(h, pol); err != nil {
+		return err
+	}
+	sampler.context.NTT(pol, pol)
+	return nil
+}
+
+// SampleHammingWeightMontgomeryNTTNew samples a new polynomial with exactly h nonzero coefficients in [-1, 1], in the NTT domain
+// and in montgomery form.
+func (sampler *TernarySampler) SampleHammingWeightMontgomeryNTTNew(h uint64) (pol *Poly, err error) {
+	if pol, err = sampler.SampleHammingWeightMontgomeryNew(h); err != nil {
+		return nil, err
+	}
+	sampler.context.NTT(pol, pol)
+	return pol, nil
+}
+
+// DistributionType is the family of the distribution of the error polynomials sampled by an ErrorSampler.
+type DistributionType int
+
+const (
+	// DiscreteGaussian is the discrete gaussian distribution of standard deviation param, truncated at 6*param (see KYSampler).
+	DiscreteGaussian DistributionType = iota
+	// CenteredBinomial is the centered binomial distribution sum(a_i - b_i), for 0 <= i < param and a_i, b_i uniform bits.
+	// Its variance is param/2, and param must be an integer in [1, 64].
+	CenteredBinomial
+	// RoundedGaussian is the continuous gaussian distribution of standard deviation param, rounded to the nearest integer.
+	// Its variance is about param^2 + 1/12.
+	RoundedGaussian
+)
+
+// ErrorSampler is the structure holding the parameters for sampling error polynomials within a configurable family of distributions.
+// Its methods SampleNTT and SampleNTTNew sample with its configured distribution, so that it can replace a KYSampler wherever
+// a gaussian sampler is expected.
+type ErrorSampler struct {
+	context    *Context
+	dist       DistributionType
+	param      float64
+	kysamplers map[float64]*KYSampler
+	source     io.Reader
+}
+
+// NewErrorSampler creates a new ErrorSampler sampling by default within the given distribution, with the given parameter.
+func (context *Context) NewErrorSampler(dist DistributionType, param float64) (*ErrorSampler, error) {
+
+	if err := checkErrorDistribution(dist, param); err != nil {
+		return nil, err
+	}
+
+	return &ErrorSampler{context, dist, param, make(map[float64]*KYSampler), nil}, nil
+}
+
+// SetSource sets the source of randomness of the ErrorSampler, e.g. a seeded PRNG to reproduce the sampled polynomials in tests,
+// for all the distributions, including the KYSamplers of the discrete gaussian distribution. A nil source, the default, samples
+// from crypto/rand. The sampler panics if the source cannot provide enough bytes.
+func (sampler *ErrorSampler) SetSource(source io.Reader) {
+	sampler.source = source
+	for _, kysampler := range sampler.kysamplers {
+		kysampler.SetSource(source)
+	}
+}
+
+func checkErrorDistribution(dist DistributionType, param float64) error {
+
+	switch dist {
+	case DiscreteGaussian, RoundedGaussian:
+		if param <= 0 {
+			return errors.New("cannot sample error -> the standard deviation must be positive")
+		}
+	case CenteredBinomial:
+		if param < 1 || param > 64 || param != math.Floor(param) {
+			return errors.New("cannot sample error -> the parameter of the centered binomial distribution must be an integer in [1, 64]")
+		}
+	default:
+		return errors.New("cannot sample error -> unknown distribution type")
+	}
+
+	return nil
+}
+
+// SampleError samples on the target polynomial an error polynomial within the given distribution, with the given parameter.
+func (sampler *ErrorSampler) SampleError(dist DistributionType, param float64, pol *Poly) (err error) {
+
+	if err = checkErrorDistribution(dist, param); err != nil {
+		return err
+	}
+
+	context := sampler.context
+
+	switch dist {
+
+	case DiscreteGaussian:
+
+		kysampler, ok := sampler.kysamplers[param]
+		if !ok {
+			kysampler = context.NewKYSampler(param, int(6*param))
+			kysampler.SetSource(sampler.source)
+			sampler.kysamplers[param] = kysampler
+		}
+
+		kysampler.Sample(pol)
+
+		return nil
+
+	case CenteredBinomial:
+
+		mask := uint64(0xFFFFFFFFFFFFFFFF) >> (64 - uint64(param))
+
+		randomBytes := make([]byte, context.N<<4)
+		readRandom(sampler.source, randomBytes)
+
+		for i := uint64(0); i < context.N; i++ {
+			a := binary.BigEndian.Uint64(randomBytes[i<<4:]) & mask
+			b := binary.BigEndian.Uint64(randomBytes[(i<<4)+8:]) & mask
+			setSignedCoefficient(context, pol, i, int64(bits.OnesCount64(a))-int64(bits.OnesCount64(b)))
+		}
+
+	case RoundedGaussian:
+
+		randomBytes := make([]byte, context.N<<4)
+		readRandom(sampler.source, randomBytes)
+
+		// Box-Muller transform of two uniform variables in (0, 1]
+		for i := uint64(0); i < context.N; i++ {
+			u1 := float64((binary.BigEndian.Uint64(randomBytes[i<<4:])>>11)+1) / (1 << 53)
+			u2 := float64(binary.BigEndian.Uint64(randomBytes[(i<<4)+8:])>>11) / (1 << 53)
+			setSignedCoefficient(context, pol, i, int64(math.Round(param*math.Sqrt(-2*math.Log(u1))*math.Cos(2*math.Pi*u2))))
+		}
+	}
+
+	return nil
+}
+
+// SampleErrorNTT samples on the target polynomial an error polynomial within the given distribution, with the given parameter,
+// and applies the NTT.
+func (sampler *ErrorSampler) SampleErrorNTT(dist DistributionType, param float64, pol *Poly) (err error) {
+
+	if err = sampler.SampleError(dist, param, pol); err != nil {
+		return err
+	}
+
+	sampler.context.NTT(pol, pol)
+
+	return nil
+}
+
+// SampleNTT samples on the target polynomial an error polynomial within the configured distribution of the sampler, and applies the NTT.
+func (sampler *ErrorSampler) SampleNTT(pol *Poly) {
+	// The configuration has been checked by NewErrorSampler
+	sampler.SampleErrorNTT(sampler.dist, sampler.param, pol)
+}
+
+// SampleNTTNew samples a new error polynomial within the configured distribution of the sampler, and applies the NTT.
+func (sampler *ErrorSampler) SampleNTTNew() (pol *Poly) {
+	pol = sampler.context.NewPoly()
+	sampler.SampleNTT(pol)
+	return
+}
+
+// SampleSecretWithNorm samples on out a secret polynomial (in the coefficient domain) whose L2 norm approximates targetNorm, for
+// parameter exploration such as security estimations. With S = round(targetNorm^2) and k = floor(sqrt(S/N)), all the coefficients
+// have a magnitude of k, except round((S - N*k^2)/(2k+1)) of them, at uniformly random positions, which have a magnitude of k+1. The
+// signs are uniformly random. The norm is therefore within (2k+1)/(2*targetNorm) of the target, e.g. the secret is ternary of hamming
+// weight round(targetNorm^2) for targetNorm <= sqrt(N). out is set to zero if targetNorm is not positive.
+func (context *Context) SampleSecretWithNorm(targetNorm float64, out *Poly) {
+	context.SampleSecretWithNormFromSource(nil, targetNorm, out)
+}
+
+// SampleSecretWithNormFromSource is a variant of SampleSecretWithNorm reading the randomness from source, e.g. a seeded PRNG to
+// reproduce the secret, instead of crypto/rand if source is nil. Panics if the source cannot provide enough bytes.
+func (context *Context) SampleSecretWithNormFromSource(source io.Reader, targetNorm float64, out *Poly) {
+
+	out.Zero()
+
+	if targetNorm <= 0 {
+		return
+	}
+
+	N := context.N
+
+	square := uint64(math.Round(targetNorm * targetNorm))
+
+	k := uint64(math.Sqrt(float64(square) / float64(N)))
+	for (k+1)*(k+1)*N <= square {
+		k++
+	}
+	for k*k*N > square {
+		k--
+	}
+
+	upgraded := uint64(math.Round(float64(square-N*k*k) / float64(2*k+1)))
+	if upgraded > N {
+		upgraded = N
+	}
+
+	// Random positions : the first upgraded indexes of a uniformly random permutation
+	index := make([]uint64, N)
+	for i := range index {
+		index[i] = uint64(i)
+	}
+
+	for i := uint64(0); i < upgraded; i++ {
+		j := i + randUniformFrom(source, N-i, (1<<uint64(bits.Len64(N-i)))-1)
+		index[i], index[j] = index[j], index[i]
+	}
+
+	for i, j := range index {
+
+		magnitude := int64(k)
+		if uint64(i) < upgraded {
+			magnitude++
+		}
+
+		if randUniformFrom(source, 2, 1) == 1 {
+			magnitude = -magnitude
+		}
+
+		setSignedCoefficient(context, out, j, magnitude)
+	}
+}
+
+// SampleFlooding samples on pol a polynomial whose coefficients are uniform in [-2^logBound, 2^logBound), e.g. to flood the residual
+// error of a decryption share. logBound is not limited to 64 bits, the coefficients being read as words of 64 bits reduced modulo
+// each qi, so that the cost of the sampling only grows with logBound/64.
+func (context *Context) SampleFlooding(logBound uint64, pol *Poly) {
+
+	// x uniform in [0, 2^(logBound+1)), on words of 64 bits whose first one is masked
+	words := (logBound >> 6) + 1
+	mask := uint64(0xFFFFFFFFFFFFFFFF) >> (63 - (logBound & 63))
+
+	randomBytes := make([]byte, (context.N*words)<<3)
+	readRandom(nil, randomBytes)
+
+	for i, qi := range context.Modulus {
+
+		// 2^logBound mod qi
+		bound := ModExp(2, logBound, qi)
+
+		for j := uint64(0); j < context.N; j++ {
+
+			x := randomBytes[(j*words)<<3:]
+
+			// x mod qi, then e = x - 2^logBound
+			xModQi := (binary.BigEndian.Uint64(x) & mask) % qi
+			for k := uint64(1); k < words; k++ {
+				_, xModQi = bits.Div64(xModQi, binary.BigEndian.Uint64(x[k<<3:]), qi)
+			}
+
+			pol.Coeffs[i][j] = CRed(xModQi+qi-bound, qi)
+		}
+	}
+}
+
+// setSignedCoefficient sets the coefficient i of the polynomial to the signed value c, on all the moduli of the context.
+func setSignedCoefficient(context *Context, pol *Poly, i uint64, c int64) {
+	for j, qi := range context.Modulus {
+		if c < 0 {
+			pol.Coeffs[j][i] = qi - uint64(-c)
+		} else {
+			pol.Coeffs[j][i] = uint64(c)
+		}
+	}
+}
+
 // RandUniform samples a uniform randomInt variable in the range [0, mask] until randomInt is in the range [0, v-1].
 // mask needs to be of the form 2^n -1.
 func RandUniform(v uint64, mask uint64) (randomInt uint64) {
@@ -397,6 +769,18 @@ func RandUniform(v uint64, mask uint64) (randomInt uint64) {
 	}
 }
 
+// randUniformFrom is a variant of RandUniform reading from source, or from crypto/rand if source is nil.
+func randUniformFrom(source io.Reader, v uint64, mask uint64) (randomInt uint64) {
+	randomBytes := make([]byte, 8)
+	for {
+		readRandom(source, randomBytes)
+		randomInt = mask & binary.BigEndian.Uint64(randomBytes)
+		if randomInt < v {
+			return randomInt
+		}
+	}
+}
+
 // randInt3 samples a bit and a sign with rejection sampling (25% chance of failure), with probabilities :
 // Pr[int = 0 : 1/3 ; int = 1 : 2/3]
 // Pr[sign = 1 : 1/2; sign = 0 : 1/2]
@@ -17,6 +17,14 @@ func PowerOf2(x, n, q, qInv uint64) (r uint64) {
 	return
 }
 
+// PowerOf2CT is identical to PowerOf2, except that it runs in constant time (see CRedCT).
+func PowerOf2CT(x, n, q, qInv uint64) (r uint64) {
+	ahi, alo := x>>(64-n), x<<n
+	R := alo * qInv
+	H, _ := bits.Mul64(R, q)
+	return CRedCT(ahi-H+q, q)
+}
+
 //==============================
 //=== MODULAR EXPONENTIATION ===
 //==============================